@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"genie/internal/accounts"
+	"genie/internal/activity"
+	"genie/internal/admin"
 	"genie/internal/api"
 	"genie/internal/auth"
 	"genie/internal/config"
@@ -18,7 +20,14 @@ import (
 	"genie/internal/events"
 	"genie/internal/messaging"
 	"genie/internal/middleware"
+	"genie/internal/models"
+	"genie/internal/pricetracking"
+	"genie/internal/privacy"
+	"genie/internal/ratelimit"
+	"genie/internal/reminders"
+	"genie/internal/scraper"
 	"genie/internal/stories"
+	"genie/internal/unfurl"
 	"genie/internal/utils"
 	"genie/internal/wishlist"
 
@@ -66,13 +75,16 @@ func main() {
 	corsConfig.AllowOrigins = cfg.Server.CorsOrigins
 	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
 	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	corsConfig.ExposeHeaders = []string{"Content-Length"}
+	corsConfig.ExposeHeaders = []string{"Content-Length", "X-Next-Cursor"}
 	corsConfig.AllowCredentials = true
 	corsConfig.MaxAge = 12 * time.Hour
 	router.Use(cors.New(corsConfig))
 
 	// Initialiser le service JWT
-	jwtService := middleware.NewJWTService(cfg.JWT)
+	jwtService, err := middleware.NewJWTService(cfg.JWT)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Impossible de charger les clés de signature JWT")
+	}
 
 	// Initialiser les services d'email et SMS
 	emailService := utils.NewEmailService(cfg.Email)
@@ -82,25 +94,36 @@ func main() {
 	authService := auth.NewService(database, jwtService, emailService, smsService, cfg)
 	accountsService := accounts.NewService(database)
 	messagingService := messaging.NewService(database)
-	wishlistService := wishlist.NewService(database, cfg)
+	canopyClient := scraper.NewCanopyClient(cfg.Scraper.CanopyAPIKey)
+	unfurlService := unfurl.NewService(cfg.Unfurl, canopyClient)
 	storiesService := stories.NewService(database.DB) // Initialiser le service de stories
-	eventsService := events.NewService(database.DB)   // Initialiser le service d'événements
 
 	// Middleware d'authentification
 	// Passer l'instance unique jwtService au middleware
 	router.Use(middleware.SetJWTService(jwtService))
+	// Permet à AuthRequired de refuser les comptes suspendus depuis l'émission du token
+	router.Use(middleware.SetAccountChecker(authService))
+
+	// Limites anti-abus (force brute, énumération des comptes), partagées entre instances avec MongoDB
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Store == "mongo" {
+		rateLimitStore = ratelimit.NewMongoStore(database.DB)
+	}
+	authService.SetRateLimitStore(rateLimitStore)
 
 	// Initialiser et enregistrer les handlers
-	authHandler := api.NewAuthHandler(authService)
+	authHandler := api.NewAuthHandler(authService, rateLimitStore, ratelimit.Rule{
+		Limit:  cfg.RateLimit.AuthPerIP,
+		Window: cfg.RateLimit.AuthPerIPWindow,
+	})
 	accountsHandler := api.NewAccountsHandler(accountsService)
 	friendsHandler := api.NewFriendsHandler(database)
 	messagingHandler := api.NewMessagingHandler(messagingService)
-	wishlistHandler := api.NewWishlistHandler(wishlistService)
 	storiesHandler := api.NewStoriesHandler(storiesService) // Initialiser le handler de stories
-	eventsHandler := events.NewHandler(eventsService)       // Initialiser le handler d'événements
 
-	// Initialiser le handler du scraper avec mise à jour quotidienne
-	scraperHandler := api.NewScraperHandler()
+	// Initialiser le scraper avec mise à jour quotidienne ; ses inspirations servent aussi de modèles de wishlist
+	scraperManager := scraper.NewScraperManager()
+	scraperHandler := api.NewScraperHandler(scraperManager)
 
 	// Commenté pour éviter une double mise à jour du cache
 	// La mise à jour est déjà déclenchée dans ScraperManager.NewScraperManager()
@@ -126,16 +149,85 @@ func main() {
 	// Routes nécessitant le middleware comme argument
 	friendsHandler.RegisterRoutes(apiRoutes, authMiddleware)
 	messagingHandler.RegisterRoutes(apiRoutes, authMiddleware)
-	messaging.SetupWebsocketHandler(messagingService, apiRoutes, authMiddleware)
+	websocketHub := messaging.SetupWebsocketHandler(messagingService, apiRoutes, authMiddleware)
+
+	// Fil d'activité des wishlists, poussé en temps réel aux collaborateurs
+	activityService := activity.NewService(database.DB, websocketHub)
+	activityHandler := activity.NewHandler(activityService)
+
+	// Le service wishlist notifie en temps réel (remerciements), il a besoin du hub websocket
+	wishlistService := wishlist.NewService(database, cfg, unfurlService, emailService, websocketHub, activityService, scraperManager)
+	wishlistHandler := api.NewWishlistHandler(wishlistService)
+
+	// Les droits sur le fil d'activité sont ceux des wishlists
+	activityService.SetPermissions(wishlistService)
+
+	// Les registres de cadeaux des événements passent par le service wishlist (droits, mode surprise, réservations)
+	eventsService := events.NewService(database.DB, wishlistService, websocketHub) // Initialiser le service d'événements
+	eventsHandler := events.NewHandler(eventsService)                              // Initialiser le handler d'événements
+
+	// Pages publiques de wishlist, consultables et réservables sans compte
+	wishlistService.SetRateLimitStore(rateLimitStore)
+	publicWishlistHandler := api.NewPublicWishlistHandler(wishlistService, rateLimitStore, ratelimit.Rule{
+		Limit:  cfg.RateLimit.PublicPerIP,
+		Window: cfg.RateLimit.PublicPerIPWindow,
+	})
+	publicWishlistHandler.RegisterRoutes(apiRoutes)
+
+	// Initialiser le planificateur de rappels (invitations, événements, anniversaires)
+	remindersService := reminders.NewService(database.DB, cfg.Reminders, emailService, smsService, websocketHub)
+	remindersHandler := reminders.NewHandler(remindersService)
+	remindersHandler.RegisterRoutes(apiRoutes.Group("/reminders", authMiddleware))
+
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	if cfg.Reminders.Enabled {
+		remindersService.Start(schedulerCtx)
+	}
+
+	// Initialiser le suivi des prix des items de wishlist (via l'API Canopy), avec les droits des wishlists
+	priceSource := pricetracking.NewCanopySource(canopyClient)
+	priceTrackingService := pricetracking.NewService(database.DB, cfg.PriceTracking, priceSource, wishlistService, emailService, websocketHub)
+	priceTrackingHandler := pricetracking.NewHandler(priceTrackingService)
+	priceTrackingHandler.RegisterRoutes(apiRoutes.Group("/price-tracking", authMiddleware))
+
+	if cfg.PriceTracking.Enabled && cfg.Scraper.CanopyAPIKey != "" {
+		priceTrackingService.Start(schedulerCtx)
+	} else if cfg.PriceTracking.Enabled {
+		log.Warn().Msg("CANOPY_API_KEY non défini, suivi des prix désactivé")
+	}
+
+	// Export des données (RGPD) et suppression de compte après un délai de grâce
+	mediaStore, err := privacy.NewS3MediaStore(cfg.Storage)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Impossible d'initialiser le stockage des médias")
+	}
+	privacyService := privacy.NewService(database.DB, cfg.Privacy, mediaStore, emailService)
+	privacyHandler := privacy.NewHandler(privacyService)
+	privacyHandler.RegisterRoutes(apiRoutes.Group("/account", authMiddleware, middleware.NotImpersonated()))
+	privacyService.Start(schedulerCtx)
+
+	// Back-office : chaque route exige un scope accordé par les rôles, chaque action est journalisée
+	adminService := admin.NewService(database.DB, cfg.Security, authService, jwtService, mediaStore)
+	adminHandler := admin.NewHandler(adminService)
+	adminHandler.RegisterRoutes(apiRoutes.Group("/admin", authMiddleware, middleware.NotImpersonated()), authService)
+
+	// Certaines actions (transferts, partages...) sont réservées aux comptes vérifiés
+	verifiedMiddleware := middleware.VerifiedRequired(authService, cfg.Security.UnverifiedRestrictions)
 
 	// Enregistrer d'abord le groupe /events spécifique
-	eventsHandler.RegisterRoutes(apiRoutes.Group("/events", authMiddleware))
+	eventsHandler.RegisterRoutes(apiRoutes.Group("/events", authMiddleware, verifiedMiddleware))
+
+	// Gestion du catalogue d'événements prédéfinis (back-office)
+	catalogMiddleware := middleware.ScopeRequired(authService, models.ScopeCatalog)
+	eventsHandler.RegisterAdminRoutes(apiRoutes.Group("/admin/events/predefined", authMiddleware, catalogMiddleware))
 
 	// Ensuite, enregistrer les autres handlers sur le groupe /api authentifié de base
-	authenticatedAPIRoutes := apiRoutes.Group("", authMiddleware)
+	authenticatedAPIRoutes := apiRoutes.Group("", authMiddleware, verifiedMiddleware)
 	{ // Utiliser un bloc pour la clarté, même si pas strictement nécessaire
-		wishlistHandler.RegisterRoutes(authenticatedAPIRoutes)                 // Le handler ajoute /wishlists
-		api.RegisterTransactionRoutes(authenticatedAPIRoutes, accountsService) // Le handler ajoute /transactions
+		wishlistHandler.RegisterRoutes(authenticatedAPIRoutes)                     // Le handler ajoute /wishlists
+		activityHandler.RegisterRoutes(authenticatedAPIRoutes.Group("/wishlists")) // Fil d'activité sous /wishlists/:id/activity
+		api.RegisterTransactionRoutes(authenticatedAPIRoutes, accountsService)     // Le handler ajoute /transactions
 	}
 	// Supprimer les accolades superflues
 	// Routes de stories (enregistrées sur le routeur principal)
@@ -150,6 +242,9 @@ func main() {
 
 	// Les routes de transaction et websocket sont enregistrées ci-dessus avec leur middleware respectif
 
+	// Clés publiques des tokens d'accès, pour les services qui les vérifient
+	router.GET("/.well-known/jwks.json", middleware.JWKSHandler(jwtService))
+
 	// Route de base pour vérifier que l'API fonctionne
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -180,6 +275,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Info().Msg("Arrêt du serveur...")
+	stopScheduler()
 
 	// Fermeture gracieuse du serveur
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -17,6 +17,7 @@ require (
 	github.com/twilio/twilio-go v1.24.1
 	go.mongodb.org/mongo-driver v1.14.0
 	golang.org/x/crypto v0.36.0
+	golang.org/x/net v0.37.0
 )
 
 require (
@@ -52,7 +53,6 @@ require (
 	github.com/xdg-go/stringprep v1.0.4 // indirect
 	github.com/youmark/pkcs8 v0.0.0-20181117223130-1be2e3e5546d // indirect
 	golang.org/x/arch v0.12.0 // indirect
-	golang.org/x/net v0.37.0 // indirect
 	golang.org/x/sync v0.12.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
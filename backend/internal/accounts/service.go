@@ -331,6 +331,16 @@ func (s *Service) DeleteManagedAccount(ctx context.Context, userID, accountID st
 		return err
 	}
 
+	// Les wishlists tenues pour ce compte reviennent au parent
+	_, err = s.db.DB.Collection("wishlists").UpdateMany(
+		ctx,
+		bson.M{"userId": ownerID, "managedAccountId": accID},
+		bson.M{"$unset": bson.M{"managedAccountId": ""}},
+	)
+	if err != nil {
+		log.Warn().Err(err).Str("accountID", accountID).Msg("Impossible de détacher les wishlists du compte géré supprimé")
+	}
+
 	// Retirer le compte géré de la liste de l'utilisateur
 	_, err = s.db.Users.UpdateOne(
 		ctx,
@@ -0,0 +1,65 @@
+package activity
+
+import (
+	"net/http"
+	"strconv"
+
+	"genie/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Handler is the wishlist activity API handler
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new activity handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the activity routes on the wishlists group
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/:id/activity", h.GetFeed)
+}
+
+// GetFeed returns a page of the activity feed of a wishlist
+func (h *Handler) GetFeed(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = value
+	}
+
+	page, err := h.service.Feed(c.Request.Context(), c.Param("id"), userIDValue.(string), c.Query("cursor"), limit)
+	if err != nil {
+		switch err {
+		case ErrInvalidID, ErrInvalidCursor:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case ErrWishlistNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case ErrAccessDenied:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Msg("Failed to get wishlist activity")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get wishlist activity"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,271 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"genie/internal/messaging"
+	"genie/internal/models"
+)
+
+const (
+	activitiesCollection = "wishlistActivities"
+	// Collection owned by the auth service
+	usersCollection = "users"
+
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var (
+	ErrInvalidID        = errors.New("invalid wishlist ID")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrWishlistNotFound = errors.New("wishlist not found")
+	ErrAccessDenied     = errors.New("access denied")
+)
+
+// Permissions is the part of the wishlist service that decides who follows the activity of a
+// wishlist. Its permission matrix and surprise mode are the only access rules applied here.
+type Permissions interface {
+	// ActivityAccess reports whether a user may view a wishlist and whether they see its
+	// reservations. It returns mongo.ErrNoDocuments when the wishlist does not exist.
+	ActivityAccess(ctx context.Context, wishlistID, userID primitive.ObjectID) (bool, bool, error)
+	// ActivityRecipients returns the members of a wishlist to notify live of an activity,
+	// only those who see reservations when the activity is private
+	ActivityRecipients(ctx context.Context, wishlistID primitive.ObjectID, private bool) ([]primitive.ObjectID, error)
+}
+
+// Service records what happens on wishlists and serves it to their collaborators
+type Service struct {
+	db          *mongo.Database
+	pusher      messaging.Pusher
+	permissions Permissions
+}
+
+// NewService creates a new activity service. pusher may be nil, in which case
+// activities are only recorded.
+func NewService(db *mongo.Database, pusher messaging.Pusher) *Service {
+	ctx := context.Background()
+
+	feedIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "wishlistId", Value: 1}, {Key: "_id", Value: -1}},
+		Options: options.Index().SetBackground(true),
+	}
+	if _, err := db.Collection(activitiesCollection).Indexes().CreateOne(ctx, feedIndex); err != nil {
+		log.Warn().Err(err).Msg("Failed to create indexes on wishlistActivities collection")
+	}
+
+	return &Service{
+		db:     db,
+		pusher: pusher,
+	}
+}
+
+// SetPermissions sets the access rules of the feeds. The wishlist service provides them, but it
+// records activities itself and is therefore created after this service.
+func (s *Service) SetPermissions(permissions Permissions) {
+	s.permissions = permissions
+}
+
+// Record stores an activity and pushes it to the collaborators of the wishlist.
+// Failures are only logged: the change the activity describes has already happened.
+func (s *Service) Record(ctx context.Context, entry models.WishlistActivity) {
+	if s == nil {
+		return
+	}
+
+	entry.ID = primitive.NewObjectID()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	if _, err := s.db.Collection(activitiesCollection).InsertOne(ctx, entry); err != nil {
+		log.Warn().Err(err).Str("wishlistID", entry.WishlistID.Hex()).Str("type", string(entry.Type)).Msg("Failed to record wishlist activity")
+		return
+	}
+
+	s.push(ctx, &entry)
+}
+
+// DeleteForWishlist removes the activity log of a deleted wishlist
+func (s *Service) DeleteForWishlist(ctx context.Context, wishlistID primitive.ObjectID) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Collection(activitiesCollection).DeleteMany(ctx, bson.M{"wishlistId": wishlistID})
+	return err
+}
+
+// Feed returns a page of the activity log of a wishlist, newest first.
+// Reservation activities are only shown to those who see the reservations of the wishlist.
+func (s *Service) Feed(ctx context.Context, wishlistID string, userID string, cursor string, limit int) (*models.WishlistActivityPage, error) {
+	wid, err := primitive.ObjectIDFromHex(wishlistID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrAccessDenied
+	}
+
+	if s.permissions == nil {
+		return nil, ErrAccessDenied
+	}
+	canView, seesReservations, err := s.permissions.ActivityAccess(ctx, wid, uid)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrWishlistNotFound
+		}
+		return nil, err
+	}
+	if !canView {
+		return nil, ErrAccessDenied
+	}
+
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	filter := bson.M{"wishlistId": wid}
+	if cursor != "" {
+		before, err := primitive.ObjectIDFromHex(cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		filter["_id"] = bson.M{"$lt": before}
+	}
+	if !seesReservations {
+		filter["private"] = bson.M{"$ne": true}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetLimit(int64(limit + 1))
+	cur, err := s.db.Collection(activitiesCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	activities := []models.WishlistActivity{}
+	if err := cur.All(ctx, &activities); err != nil {
+		return nil, err
+	}
+
+	page := &models.WishlistActivityPage{}
+	if len(activities) > limit {
+		activities = activities[:limit]
+		page.NextCursor = activities[limit-1].ID.Hex()
+	}
+
+	if err := s.resolveActorNames(ctx, activities); err != nil {
+		return nil, err
+	}
+	page.Activities = activities
+
+	return page, nil
+}
+
+// resolveActorNames fills the names of the users behind activities
+func (s *Service) resolveActorNames(ctx context.Context, activities []models.WishlistActivity) error {
+	ids := []primitive.ObjectID{}
+	seen := make(map[primitive.ObjectID]bool)
+	for _, entry := range activities {
+		if entry.ActorID != nil && entry.ActorName == "" && !seen[*entry.ActorID] {
+			seen[*entry.ActorID] = true
+			ids = append(ids, *entry.ActorID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	opts := options.Find().SetProjection(bson.M{"firstName": 1, "lastName": 1})
+	cur, err := s.db.Collection(usersCollection).Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return err
+	}
+	var users []models.User
+	if err := cur.All(ctx, &users); err != nil {
+		return err
+	}
+
+	names := make(map[primitive.ObjectID]string, len(users))
+	for _, user := range users {
+		names[user.ID] = user.FirstName + " " + user.LastName
+	}
+	for i := range activities {
+		if activities[i].ActorID != nil && activities[i].ActorName == "" {
+			activities[i].ActorName = names[*activities[i].ActorID]
+		}
+	}
+	return nil
+}
+
+// push sends an activity to the connected collaborators of its wishlist, except its author
+func (s *Service) push(ctx context.Context, entry *models.WishlistActivity) {
+	if s.pusher == nil || s.permissions == nil {
+		return
+	}
+
+	recipients, err := s.permissions.ActivityRecipients(ctx, entry.WishlistID, entry.Private)
+	if err != nil {
+		log.Warn().Err(err).Str("wishlistID", entry.WishlistID.Hex()).Msg("Failed to load recipients of activity push")
+		return
+	}
+
+	payload := map[string]interface{}{
+		"id":         entry.ID.Hex(),
+		"wishlistId": entry.WishlistID.Hex(),
+		"type":       entry.Type,
+		"createdAt":  entry.CreatedAt,
+	}
+	if entry.ActorID != nil {
+		payload["actorId"] = entry.ActorID.Hex()
+	}
+	if entry.ActorName != "" {
+		payload["actorName"] = entry.ActorName
+	}
+	if entry.ItemID != nil {
+		payload["itemId"] = entry.ItemID.Hex()
+		payload["itemName"] = entry.ItemName
+	}
+	if entry.TargetUserID != nil {
+		payload["targetUserId"] = entry.TargetUserID.Hex()
+	}
+	if entry.EventID != nil {
+		payload["eventId"] = entry.EventID.Hex()
+	}
+	if entry.Quantity > 0 {
+		payload["quantity"] = entry.Quantity
+	}
+	if len(entry.Changes) > 0 {
+		payload["changes"] = entry.Changes
+	}
+
+	data, err := json.Marshal(messaging.WebsocketMessage{
+		Type:    "wishlist_activity",
+		Payload: payload,
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode wishlist activity")
+		return
+	}
+
+	for _, recipient := range recipients {
+		if entry.ActorID != nil && recipient == *entry.ActorID {
+			continue
+		}
+		s.pusher.SendToUser(recipient.Hex(), data)
+	}
+}
@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"genie/internal/models"
+)
+
+// Audited actions
+const (
+	ActionSuspend         = "user.suspend"
+	ActionUnsuspend       = "user.unsuspend"
+	ActionPasswordReset   = "user.password_reset"
+	ActionUpdateRoles     = "user.roles"
+	ActionImpersonate     = "user.impersonate"
+	ActionReverse         = "transaction.reverse"
+	ActionTakeDownStory   = "story.takedown"
+	ActionTakeDownMessage = "message.takedown"
+)
+
+// Actor is the staff member performing a back-office action
+type Actor struct {
+	ID        primitive.ObjectID
+	IP        string
+	UserAgent string
+}
+
+// AuditSearch filters the audit log
+type AuditSearch struct {
+	ActorID  string
+	TargetID string
+	Action   string
+	Limit    int64
+	Offset   int64
+}
+
+// record writes a back-office action to the audit log
+func (s *Service) record(ctx context.Context, actor Actor, action, targetType string, targetID primitive.ObjectID, reason string, details map[string]interface{}) error {
+	entry := models.AuditLogEntry{
+		ID:         primitive.NewObjectID(),
+		ActorID:    actor.ID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+		Details:    details,
+		IP:         actor.IP,
+		UserAgent:  actor.UserAgent,
+		CreatedAt:  time.Now(),
+	}
+	_, err := s.db.Collection(auditLogCollection).InsertOne(ctx, entry)
+	return err
+}
+
+// ListAuditLog returns audit log entries, most recent first
+func (s *Service) ListAuditLog(ctx context.Context, search AuditSearch) (*models.AuditLogListResponse, error) {
+	filter := bson.M{}
+	if search.ActorID != "" {
+		id, err := primitive.ObjectIDFromHex(search.ActorID)
+		if err != nil {
+			return nil, ErrInvalidID
+		}
+		filter["actorId"] = id
+	}
+	if search.TargetID != "" {
+		id, err := primitive.ObjectIDFromHex(search.TargetID)
+		if err != nil {
+			return nil, ErrInvalidID
+		}
+		filter["targetId"] = id
+	}
+	if search.Action != "" {
+		filter["action"] = search.Action
+	}
+
+	collection := s.db.Collection(auditLogCollection)
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(search.Offset).
+		SetLimit(search.Limit)
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	entries := []models.AuditLogEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return &models.AuditLogListResponse{
+		Entries: entries,
+		Total:   total,
+		Limit:   search.Limit,
+		Offset:  search.Offset,
+	}, nil
+}
@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"genie/internal/models"
+)
+
+// removedMessageContent replaces the content of a message taken down by moderation
+const removedMessageContent = "Ce message a été retiré par la modération"
+
+// TakeDownStory deletes a story, its media and its views
+func (s *Service) TakeDownStory(ctx context.Context, actor Actor, storyID, reason string) error {
+	id, err := primitive.ObjectIDFromHex(storyID)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	var story models.Story
+	if err := s.db.Collection(storiesCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&story); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrStoryNotFound
+		}
+		return err
+	}
+
+	if _, err := s.db.Collection(storiesCollection).DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return err
+	}
+	if _, err := s.db.Collection(storyViewsCollection).DeleteMany(ctx, bson.M{"storyId": id}); err != nil {
+		log.Warn().Err(err).Str("storyID", storyID).Msg("Failed to delete views of taken down story")
+	}
+	for _, media := range story.Media {
+		s.deleteMedia(ctx, media.URL)
+	}
+
+	log.Info().Str("storyID", storyID).Str("actorID", actor.ID.Hex()).Msg("Story taken down")
+	details := map[string]interface{}{"userId": story.UserID.Hex(), "media": len(story.Media)}
+	return s.record(ctx, actor, ActionTakeDownStory, "story", id, reason, details)
+}
+
+// TakeDownMessage replaces the content of a message with a moderation notice and deletes its media.
+// The message is kept so the conversation stays readable.
+func (s *Service) TakeDownMessage(ctx context.Context, actor Actor, messageID, reason string) error {
+	id, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	var message models.Message
+	if err := s.db.Collection(messagesCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&message); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrMessageNotFound
+		}
+		return err
+	}
+
+	_, err = s.db.Collection(messagesCollection).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set": bson.M{
+				"content":   removedMessageContent,
+				"type":      models.MessageTypeSystem,
+				"updatedAt": time.Now(),
+			},
+			"$unset": bson.M{"mediaUrl": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	s.deleteMedia(ctx, message.MediaURL)
+
+	log.Info().Str("messageID", messageID).Str("actorID", actor.ID.Hex()).Msg("Message taken down")
+	details := map[string]interface{}{
+		"chatId":   message.ChatID.Hex(),
+		"senderId": message.SenderID.Hex(),
+		"type":     message.Type,
+	}
+	return s.record(ctx, actor, ActionTakeDownMessage, "message", id, reason, details)
+}
+
+// deleteMedia removes uploaded media. Failures are logged: the content is already gone.
+func (s *Service) deleteMedia(ctx context.Context, urls ...string) {
+	if s.media == nil {
+		return
+	}
+	for _, url := range urls {
+		if url == "" {
+			continue
+		}
+		if err := s.media.Delete(ctx, url); err != nil {
+			log.Warn().Err(err).Str("url", url).Msg("Failed to delete media")
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"genie/internal/middleware"
+	"genie/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Handler is the back-office API handler
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new back-office handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the back-office routes, each behind the scope it requires
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, checker middleware.AccountChecker) {
+	scope := func(scope string) gin.HandlerFunc {
+		return middleware.ScopeRequired(checker, scope)
+	}
+
+	users := router.Group("/users")
+	{
+		users.GET("", scope(models.ScopeUsersRead), h.SearchUsers)
+		users.GET("/:id", scope(models.ScopeUsersRead), h.GetUser)
+		users.POST("/:id/suspend", scope(models.ScopeUsersWrite), h.SuspendUser)
+		users.POST("/:id/unsuspend", scope(models.ScopeUsersWrite), h.UnsuspendUser)
+		users.POST("/:id/password-reset", scope(models.ScopeUsersWrite), h.ForcePasswordReset)
+		users.PUT("/:id/roles", scope(models.ScopeRoles), h.UpdateRoles)
+		users.POST("/:id/impersonate", scope(models.ScopeImpersonate), h.Impersonate)
+	}
+
+	router.GET("/transactions", scope(models.ScopeTransactionsRead), h.ListTransactions)
+	router.POST("/transactions/:id/reverse", scope(models.ScopeTransactionsWrite), h.ReverseTransaction)
+
+	router.DELETE("/stories/:id", scope(models.ScopeContent), h.TakeDownStory)
+	router.DELETE("/messages/:id", scope(models.ScopeContent), h.TakeDownMessage)
+
+	router.GET("/audit-log", scope(models.ScopeAudit), h.ListAuditLog)
+}
+
+// SearchUsers finds accounts by ID, email, phone or name
+func (h *Handler) SearchUsers(c *gin.Context) {
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+	search := UserSearch{
+		Query:  c.Query("q"),
+		Role:   c.Query("role"),
+		Limit:  limit,
+		Offset: offset,
+	}
+	if raw := c.Query("suspended"); raw != "" {
+		suspended, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid suspended filter"})
+			return
+		}
+		search.Suspended = &suspended
+	}
+	if search.Role != "" && !models.IsValidRole(search.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRole.Error()})
+		return
+	}
+
+	users, err := h.service.SearchUsers(c.Request.Context(), search)
+	if err != nil {
+		respondError(c, err, "Failed to search users")
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// GetUser returns an account with its back-office details
+func (h *Handler) GetUser(c *gin.Context) {
+	user, err := h.service.GetUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err, "Failed to get user")
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// SuspendUser suspends an account
+func (h *Handler) SuspendUser(c *gin.Context) {
+	actor, req, ok := actionRequest(c)
+	if !ok {
+		return
+	}
+	if err := h.service.SuspendUser(c.Request.Context(), actor, c.Param("id"), req.Reason); err != nil {
+		respondError(c, err, "Failed to suspend user")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Account suspended"})
+}
+
+// UnsuspendUser restores a suspended account
+func (h *Handler) UnsuspendUser(c *gin.Context) {
+	actor, req, ok := actionRequest(c)
+	if !ok {
+		return
+	}
+	if err := h.service.UnsuspendUser(c.Request.Context(), actor, c.Param("id"), req.Reason); err != nil {
+		respondError(c, err, "Failed to unsuspend user")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Account unsuspended"})
+}
+
+// ForcePasswordReset signs a user out and requires a new password
+func (h *Handler) ForcePasswordReset(c *gin.Context) {
+	actor, req, ok := actionRequest(c)
+	if !ok {
+		return
+	}
+	if err := h.service.ForcePasswordReset(c.Request.Context(), actor, c.Param("id"), req.Reason); err != nil {
+		respondError(c, err, "Failed to force password reset")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset required"})
+}
+
+// UpdateRoles replaces the roles of a user
+func (h *Handler) UpdateRoles(c *gin.Context) {
+	actor, ok := actorFrom(c)
+	if !ok {
+		return
+	}
+	var req models.UpdateRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.service.UpdateRoles(c.Request.Context(), actor, c.Param("id"), req.Roles)
+	if err != nil {
+		respondError(c, err, "Failed to update roles")
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// Impersonate issues a short-lived access token to act as a user
+func (h *Handler) Impersonate(c *gin.Context) {
+	actor, req, ok := actionRequest(c)
+	if !ok {
+		return
+	}
+	response, err := h.service.Impersonate(c.Request.Context(), actor, c.Param("id"), req.Reason)
+	if err != nil {
+		respondError(c, err, "Failed to impersonate user")
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListTransactions returns transactions across all accounts
+func (h *Handler) ListTransactions(c *gin.Context) {
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+	search := TransactionSearch{
+		UserID: c.Query("userId"),
+		Type:   c.Query("type"),
+		Limit:  limit,
+		Offset: offset,
+	}
+	if search.Type != "" && search.Type != "CREDIT" && search.Type != "DEBIT" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be CREDIT or DEBIT"})
+		return
+	}
+	if raw := c.Query("minAmount"); raw != "" {
+		amount, err := strconv.ParseFloat(raw, 64)
+		if err != nil || amount < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid minAmount"})
+			return
+		}
+		search.MinAmount = amount
+	}
+	if search.From, ok = dateQuery(c, "from"); !ok {
+		return
+	}
+	if search.To, ok = dateQuery(c, "to"); !ok {
+		return
+	}
+	if raw := c.Query("reversed"); raw != "" {
+		reversed, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reversed filter"})
+			return
+		}
+		search.Reversed = &reversed
+	}
+
+	transactions, err := h.service.ListTransactions(c.Request.Context(), search)
+	if err != nil {
+		respondError(c, err, "Failed to list transactions")
+		return
+	}
+	c.JSON(http.StatusOK, transactions)
+}
+
+// ReverseTransaction cancels a transaction and restores the balances
+func (h *Handler) ReverseTransaction(c *gin.Context) {
+	actor, req, ok := actionRequest(c)
+	if !ok {
+		return
+	}
+	reversals, err := h.service.ReverseTransaction(c.Request.Context(), actor, c.Param("id"), req.Reason)
+	if err != nil {
+		respondError(c, err, "Failed to reverse transaction")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"reversals": reversals})
+}
+
+// TakeDownStory removes a story
+func (h *Handler) TakeDownStory(c *gin.Context) {
+	actor, req, ok := actionRequest(c)
+	if !ok {
+		return
+	}
+	if err := h.service.TakeDownStory(c.Request.Context(), actor, c.Param("id"), req.Reason); err != nil {
+		respondError(c, err, "Failed to take down story")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Story taken down"})
+}
+
+// TakeDownMessage removes the content of a message
+func (h *Handler) TakeDownMessage(c *gin.Context) {
+	actor, req, ok := actionRequest(c)
+	if !ok {
+		return
+	}
+	if err := h.service.TakeDownMessage(c.Request.Context(), actor, c.Param("id"), req.Reason); err != nil {
+		respondError(c, err, "Failed to take down message")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Message taken down"})
+}
+
+// ListAuditLog returns the back-office audit log
+func (h *Handler) ListAuditLog(c *gin.Context) {
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+	entries, err := h.service.ListAuditLog(c.Request.Context(), AuditSearch{
+		ActorID:  c.Query("actorId"),
+		TargetID: c.Query("targetId"),
+		Action:   c.Query("action"),
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		respondError(c, err, "Failed to list audit log")
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// actorFrom returns the staff member making the request, for the audit log
+func actorFrom(c *gin.Context) (Actor, bool) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return Actor{}, false
+	}
+	id, err := primitive.ObjectIDFromHex(userIDValue.(string))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return Actor{}, false
+	}
+	return Actor{ID: id, IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}, true
+}
+
+// actionRequest returns the actor and the reason of an audited action
+func actionRequest(c *gin.Context) (Actor, models.AdminActionRequest, bool) {
+	var req models.AdminActionRequest
+	actor, ok := actorFrom(c)
+	if !ok {
+		return actor, req, false
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required"})
+		return actor, req, false
+	}
+	return actor, req, true
+}
+
+// pagination reads the limit and offset query parameters
+func pagination(c *gin.Context) (int64, int64, bool) {
+	limit, offset := int64(defaultPageSize), int64(0)
+	if raw := c.Query("limit"); raw != "" {
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || value < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return 0, 0, false
+		}
+		limit = value
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	if raw := c.Query("offset"); raw != "" {
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || value < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+			return 0, 0, false
+		}
+		offset = value
+	}
+	return limit, offset, true
+}
+
+// dateQuery reads an optional RFC 3339 date query parameter
+func dateQuery(c *gin.Context, param string) (*time.Time, bool) {
+	raw := c.Query(param)
+	if raw == "" {
+		return nil, true
+	}
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " date, expected RFC 3339"})
+		return nil, false
+	}
+	return &value, true
+}
+
+// respondError maps service errors to HTTP responses
+func respondError(c *gin.Context, err error, message string) {
+	switch err {
+	case ErrInvalidID, ErrInvalidRole:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case ErrUserNotFound, ErrTransactionNotFound, ErrStoryNotFound, ErrMessageNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case ErrSelfAction, ErrCannotImpersonateStaff:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case ErrAlreadySuspended, ErrNotSuspended, ErrAlreadyReversed, ErrNotReversible:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,311 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"genie/internal/auth"
+	"genie/internal/config"
+	"genie/internal/middleware"
+	"genie/internal/models"
+	"genie/internal/privacy"
+	"genie/internal/utils"
+)
+
+const (
+	// Collection names
+	usersCollection        = "users"
+	transactionsCollection = "transactions"
+	storiesCollection      = "stories"
+	storyViewsCollection   = "storyViews"
+	messagesCollection     = "messages"
+	auditLogCollection     = "admin_audit_log"
+)
+
+var (
+	ErrInvalidID              = errors.New("invalid ID")
+	ErrUserNotFound           = errors.New("user not found")
+	ErrSelfAction             = errors.New("cannot perform this action on your own account")
+	ErrAlreadySuspended       = errors.New("account already suspended")
+	ErrNotSuspended           = errors.New("account not suspended")
+	ErrInvalidRole            = errors.New("unknown role")
+	ErrCannotImpersonateStaff = errors.New("cannot impersonate a staff member or a suspended account")
+	ErrTransactionNotFound    = errors.New("transaction not found")
+	ErrAlreadyReversed        = errors.New("transaction already reversed")
+	ErrNotReversible          = errors.New("transaction cannot be reversed")
+	ErrStoryNotFound          = errors.New("story not found")
+	ErrMessageNotFound        = errors.New("message not found")
+)
+
+// Service implements the back-office operations. Every change it makes is recorded in the audit log.
+type Service struct {
+	db                    *mongo.Database
+	auth                  *auth.Service
+	jwt                   *middleware.JWTService
+	media                 privacy.MediaStore
+	impersonationLifetime time.Duration
+}
+
+// UserSearch filters the user search
+type UserSearch struct {
+	Query     string // ID, or part of an email, phone number or name
+	Role      string
+	Suspended *bool
+	Limit     int64
+	Offset    int64
+}
+
+// NewService creates a new back-office service
+func NewService(db *mongo.Database, cfg config.SecurityConfig, authService *auth.Service, jwtService *middleware.JWTService, media privacy.MediaStore) *Service {
+	auditIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "targetId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "actorId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "createdAt", Value: -1}}},
+	}
+	if _, err := db.Collection(auditLogCollection).Indexes().CreateMany(context.Background(), auditIndexes); err != nil {
+		log.Warn().Err(err).Msg("Failed to create indexes on admin audit log collection")
+	}
+
+	return &Service{
+		db:                    db,
+		auth:                  authService,
+		jwt:                   jwtService,
+		media:                 media,
+		impersonationLifetime: cfg.ImpersonationLifetime,
+	}
+}
+
+// SearchUsers finds accounts by ID, email, phone or name
+func (s *Service) SearchUsers(ctx context.Context, search UserSearch) (*models.AdminUserListResponse, error) {
+	filter := bson.M{}
+	if query := strings.TrimSpace(search.Query); query != "" {
+		if id, err := primitive.ObjectIDFromHex(query); err == nil {
+			filter["_id"] = id
+		} else {
+			pattern := primitive.Regex{Pattern: regexp.QuoteMeta(query), Options: "i"}
+			filter["$or"] = []bson.M{
+				{"email": pattern},
+				{"phone": pattern},
+				{"firstName": pattern},
+				{"lastName": pattern},
+			}
+		}
+	}
+	if search.Role != "" {
+		filter["roles"] = search.Role
+	}
+	if search.Suspended != nil {
+		filter["suspendedAt"] = bson.M{"$exists": *search.Suspended}
+	}
+
+	collection := s.db.Collection(usersCollection)
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(search.Offset).
+		SetLimit(search.Limit)
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	response := &models.AdminUserListResponse{
+		Users:  make([]models.AdminUserResponse, 0, len(users)),
+		Total:  total,
+		Limit:  search.Limit,
+		Offset: search.Offset,
+	}
+	for i := range users {
+		response.Users = append(response.Users, users[i].ToAdminResponse())
+	}
+	return response, nil
+}
+
+// GetUser returns an account as seen by the back-office
+func (s *Service) GetUser(ctx context.Context, userID string) (*models.AdminUserResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	response := user.ToAdminResponse()
+	return &response, nil
+}
+
+// SuspendUser blocks an account: its sessions are revoked and its access tokens refused
+func (s *Service) SuspendUser(ctx context.Context, actor Actor, userID, reason string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.ID == actor.ID {
+		return ErrSelfAction
+	}
+
+	result, err := s.db.Collection(usersCollection).UpdateOne(ctx,
+		bson.M{"_id": user.ID, "suspendedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{
+			"suspendedAt":      time.Now(),
+			"suspensionReason": reason,
+			"refreshTokens":    []models.RefreshToken{},
+			"updatedAt":        time.Now(),
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return ErrAlreadySuspended
+	}
+
+	log.Info().Str("userID", userID).Str("actorID", actor.ID.Hex()).Msg("Account suspended")
+	return s.record(ctx, actor, ActionSuspend, "user", user.ID, reason, nil)
+}
+
+// UnsuspendUser restores access to a suspended account
+func (s *Service) UnsuspendUser(ctx context.Context, actor Actor, userID, reason string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.Collection(usersCollection).UpdateOne(ctx,
+		bson.M{"_id": user.ID, "suspendedAt": bson.M{"$exists": true}},
+		bson.M{
+			"$unset": bson.M{"suspendedAt": "", "suspensionReason": ""},
+			"$set":   bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return ErrNotSuspended
+	}
+
+	log.Info().Str("userID", userID).Str("actorID", actor.ID.Hex()).Msg("Account unsuspended")
+	return s.record(ctx, actor, ActionUnsuspend, "user", user.ID, reason, nil)
+}
+
+// ForcePasswordReset signs a user out everywhere and requires a new password before the next sign-in
+func (s *Service) ForcePasswordReset(ctx context.Context, actor Actor, userID, reason string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.auth.ForcePasswordReset(ctx, userID); err != nil {
+		return err
+	}
+
+	log.Info().Str("userID", userID).Str("actorID", actor.ID.Hex()).Msg("Password reset forced")
+	return s.record(ctx, actor, ActionPasswordReset, "user", user.ID, reason, nil)
+}
+
+// UpdateRoles replaces the back-office roles of a user. Staff cannot change their own roles.
+func (s *Service) UpdateRoles(ctx context.Context, actor Actor, userID string, roles []string) (*models.AdminUserResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == actor.ID {
+		return nil, ErrSelfAction
+	}
+
+	unique := []string{}
+	seen := make(map[string]bool)
+	for _, role := range roles {
+		if !models.IsValidRole(role) {
+			return nil, ErrInvalidRole
+		}
+		if !seen[role] {
+			seen[role] = true
+			unique = append(unique, role)
+		}
+	}
+
+	update := bson.M{"$set": bson.M{"roles": unique, "updatedAt": time.Now()}}
+	if len(unique) == 0 {
+		update = bson.M{"$unset": bson.M{"roles": ""}, "$set": bson.M{"updatedAt": time.Now()}}
+	}
+	if _, err := s.db.Collection(usersCollection).UpdateOne(ctx, bson.M{"_id": user.ID}, update); err != nil {
+		return nil, err
+	}
+
+	details := map[string]interface{}{"before": user.Roles, "after": unique}
+	if err := s.record(ctx, actor, ActionUpdateRoles, "user", user.ID, "", details); err != nil {
+		return nil, err
+	}
+
+	user.Roles = unique
+	response := user.ToAdminResponse()
+	return &response, nil
+}
+
+// Impersonate issues a short-lived access token to act as a user for support. The audit entry is
+// written first: without it, no token is issued. Staff accounts cannot be impersonated.
+func (s *Service) Impersonate(ctx context.Context, actor Actor, userID, reason string) (*models.ImpersonationResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == actor.ID {
+		return nil, ErrSelfAction
+	}
+	scopes, err := s.auth.Scopes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(scopes) > 0 || user.SuspendedAt != nil {
+		return nil, ErrCannotImpersonateStaff
+	}
+
+	details := map[string]interface{}{"expiresIn": s.impersonationLifetime.String()}
+	if err := s.record(ctx, actor, ActionImpersonate, "user", user.ID, reason, details); err != nil {
+		return nil, err
+	}
+
+	token, err := s.jwt.GenerateImpersonationToken(userID, actor.ID.Hex(), s.impersonationLifetime)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Warn().Str("userID", userID).Str("actorID", actor.ID.Hex()).Msg("Impersonation token issued")
+	return &models.ImpersonationResponse{
+		AccessToken: token,
+		ExpiresIn:   utils.DurationToMilliseconds(s.impersonationLifetime),
+		UserID:      userID,
+	}, nil
+}
+
+// getUser loads a user by ID
+func (s *Service) getUser(ctx context.Context, userID string) (*models.User, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var user models.User
+	if err := s.db.Collection(usersCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
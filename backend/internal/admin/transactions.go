@@ -0,0 +1,240 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"genie/internal/models"
+)
+
+// TransactionSearch filters the transaction review
+type TransactionSearch struct {
+	UserID    string
+	Type      string // CREDIT or DEBIT
+	MinAmount float64
+	From      *time.Time
+	To        *time.Time
+	Reversed  *bool
+	Limit     int64
+	Offset    int64
+}
+
+// ListTransactions returns transactions across all accounts, most recent first
+func (s *Service) ListTransactions(ctx context.Context, search TransactionSearch) (*models.AdminTransactionListResponse, error) {
+	filter := bson.M{}
+	if search.UserID != "" {
+		id, err := primitive.ObjectIDFromHex(search.UserID)
+		if err != nil {
+			return nil, ErrInvalidID
+		}
+		filter["userId"] = id
+	}
+	if search.Type != "" {
+		filter["type"] = search.Type
+	}
+	if search.MinAmount > 0 {
+		filter["amount"] = bson.M{"$gte": search.MinAmount}
+	}
+	if search.From != nil || search.To != nil {
+		createdAt := bson.M{}
+		if search.From != nil {
+			createdAt["$gte"] = *search.From
+		}
+		if search.To != nil {
+			createdAt["$lt"] = *search.To
+		}
+		filter["createdAt"] = createdAt
+	}
+	if search.Reversed != nil {
+		filter["reversedAt"] = bson.M{"$exists": *search.Reversed}
+	}
+
+	collection := s.db.Collection(transactionsCollection)
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(search.Offset).
+		SetLimit(search.Limit)
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var transactions []models.Transaction
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+
+	response := &models.AdminTransactionListResponse{
+		Transactions: make([]models.AdminTransactionResponse, 0, len(transactions)),
+		Total:        total,
+		Limit:        search.Limit,
+		Offset:       search.Offset,
+	}
+	for i := range transactions {
+		response.Transactions = append(response.Transactions, toAdminTransaction(&transactions[i]))
+	}
+	return response, nil
+}
+
+// ReverseTransaction cancels a transaction by recording the opposite movement and restoring the balance.
+// For a transfer between two users, both sides are reversed. Returns the reversal transactions.
+func (s *Service) ReverseTransaction(ctx context.Context, actor Actor, transactionID, reason string) ([]models.AdminTransactionResponse, error) {
+	id, err := primitive.ObjectIDFromHex(transactionID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var transaction models.Transaction
+	if err := s.db.Collection(transactionsCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&transaction); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	if transaction.ReversalOf != nil || transaction.UserID.IsZero() {
+		return nil, ErrNotReversible
+	}
+	if transaction.ReversedAt != nil {
+		return nil, ErrAlreadyReversed
+	}
+
+	reversal, err := s.reverse(ctx, &transaction, reason)
+	if err != nil {
+		return nil, err
+	}
+	reversals := []models.AdminTransactionResponse{toAdminTransaction(reversal)}
+	details := map[string]interface{}{
+		"amount":     transaction.Amount,
+		"type":       transaction.Type,
+		"userId":     transaction.UserID.Hex(),
+		"reversalId": reversal.ID.Hex(),
+	}
+
+	// The other side of a transfer between two users is in the other user's ledger
+	if !transaction.RecipientID.IsZero() && !transaction.IsManagedAccount {
+		counterpart, err := s.findCounterpart(ctx, &transaction)
+		if err != nil {
+			return nil, err
+		}
+		if counterpart != nil {
+			counterReversal, err := s.reverse(ctx, counterpart, reason)
+			if err != nil && err != ErrAlreadyReversed {
+				return nil, err
+			}
+			if counterReversal != nil {
+				reversals = append(reversals, toAdminTransaction(counterReversal))
+				details["counterpartId"] = counterpart.ID.Hex()
+				details["counterpartReversalId"] = counterReversal.ID.Hex()
+			}
+		} else {
+			log.Warn().Str("transactionID", transactionID).Msg("Counterpart of reversed transfer not found")
+		}
+	}
+
+	log.Info().Str("transactionID", transactionID).Str("actorID", actor.ID.Hex()).Msg("Transaction reversed")
+	if err := s.record(ctx, actor, ActionReverse, "transaction", transaction.ID, reason, details); err != nil {
+		return nil, err
+	}
+	return reversals, nil
+}
+
+// reverse marks a transaction as reversed, restores the balance of its owner and records the
+// opposite movement. Marking first guarantees a transaction is reversed only once.
+func (s *Service) reverse(ctx context.Context, transaction *models.Transaction, reason string) (*models.Transaction, error) {
+	now := time.Now()
+	result, err := s.db.Collection(transactionsCollection).UpdateOne(ctx,
+		bson.M{"_id": transaction.ID, "reversedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"reversedAt": now, "updatedAt": now}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.ModifiedCount == 0 {
+		return nil, ErrAlreadyReversed
+	}
+
+	delta, reversalType := -transaction.Amount, "DEBIT"
+	if transaction.Type == "DEBIT" {
+		delta, reversalType = transaction.Amount, "CREDIT"
+	}
+
+	// The balance may become negative if the credited amount has already been spent
+	if _, err := s.db.Collection(usersCollection).UpdateOne(ctx,
+		bson.M{"_id": transaction.UserID},
+		bson.M{
+			"$inc": bson.M{"balance": delta},
+			"$set": bson.M{"updatedAt": now},
+		},
+	); err != nil {
+		log.Error().Err(err).Str("transactionID", transaction.ID.Hex()).Msg("Failed to restore balance of reversed transaction")
+		return nil, err
+	}
+
+	description := "Annulation : " + transaction.Description
+	if reason != "" {
+		description += " (" + reason + ")"
+	}
+	reversal := &models.Transaction{
+		ID:               primitive.NewObjectID(),
+		UserID:           transaction.UserID,
+		Amount:           transaction.Amount,
+		Type:             reversalType,
+		Description:      description,
+		RecipientID:      transaction.RecipientID,
+		RecipientName:    transaction.RecipientName,
+		RecipientAvatar:  transaction.RecipientAvatar,
+		IsManagedAccount: transaction.IsManagedAccount,
+		ReversalOf:       &transaction.ID,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if _, err := s.db.Collection(transactionsCollection).InsertOne(ctx, reversal); err != nil {
+		log.Error().Err(err).Str("transactionID", transaction.ID.Hex()).Msg("Failed to record reversal transaction")
+		return nil, err
+	}
+	return reversal, nil
+}
+
+// findCounterpart returns the other side of a transfer: both are recorded at the same time,
+// for the same amount, each pointing to the other user
+func (s *Service) findCounterpart(ctx context.Context, transaction *models.Transaction) (*models.Transaction, error) {
+	counterType := "CREDIT"
+	if transaction.Type == "CREDIT" {
+		counterType = "DEBIT"
+	}
+
+	var counterpart models.Transaction
+	err := s.db.Collection(transactionsCollection).FindOne(ctx, bson.M{
+		"userId":      transaction.RecipientID,
+		"recipientId": transaction.UserID,
+		"type":        counterType,
+		"amount":      transaction.Amount,
+		"createdAt":   transaction.CreatedAt,
+		"reversalOf":  bson.M{"$exists": false},
+	}).Decode(&counterpart)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &counterpart, nil
+}
+
+// toAdminTransaction converts a transaction for the back-office
+func toAdminTransaction(transaction *models.Transaction) models.AdminTransactionResponse {
+	return models.AdminTransactionResponse{
+		TransactionResponse: transaction.ToResponse(),
+		UserID:              transaction.UserID.Hex(),
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -8,6 +9,7 @@ import (
 	"genie/internal/auth"
 	"genie/internal/middleware"
 	"genie/internal/models"
+	"genie/internal/ratelimit"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
@@ -15,38 +17,68 @@ import (
 // AuthHandler gère les routes d'authentification
 type AuthHandler struct {
 	authService *auth.Service
+	ipLimit     gin.HandlerFunc // limite par adresse IP des routes publiques sensibles
 }
 
-// NewAuthHandler crée une nouvelle instance du gestionnaire d'authentification
-func NewAuthHandler(authService *auth.Service) *AuthHandler {
+// NewAuthHandler crée une nouvelle instance du gestionnaire d'authentification.
+// Les routes publiques sensibles sont limitées par adresse IP selon ipRule, chacune avec son propre compteur.
+func NewAuthHandler(authService *auth.Service, limits ratelimit.Store, ipRule ratelimit.Rule) *AuthHandler {
+	limiter := ratelimit.NewLimiter(limits, "auth:ip", ipRule)
 	return &AuthHandler{
 		authService: authService,
+		ipLimit: ratelimit.Middleware(limiter, func(c *gin.Context) string {
+			return c.FullPath() + ":" + c.ClientIP()
+		}),
 	}
 }
 
 // RegisterRoutes enregistre les routes d'authentification
 func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup) {
 	authRoutes := router.Group("/auth")
+	authRoutes.Use(withDeviceInfo) // Appareil enregistré avec les sessions
 	{
 		// Routes publiques (sans authentification)
-		authRoutes.POST("/check", h.CheckUser)               // Vérifier si un utilisateur existe
-		authRoutes.POST("/signin", h.SignIn)                 // Connexion
-		authRoutes.POST("/signup", h.SignUp)                 // Inscription
-		authRoutes.POST("/social", h.SocialLogin)            // Connexion sociale
-		authRoutes.POST("/reset", h.RequestPasswordReset)    // Demande de réinitialisation du mot de passe
-		authRoutes.POST("/verify-code", h.VerifyResetCode)   // Vérification du code de réinitialisation
-		authRoutes.POST("/reset-password", h.ResetPassword)  // Réinitialisation du mot de passe
+		// Les routes exposées à la force brute ou à l'énumération des comptes sont limitées par adresse IP
+		authRoutes.POST("/check", h.ipLimit, h.CheckUser)               // Vérifier si un utilisateur existe
+		authRoutes.POST("/signin", h.ipLimit, h.SignIn)                 // Connexion
+		authRoutes.POST("/signup", h.ipLimit, h.SignUp)                 // Inscription
+		authRoutes.POST("/social", h.ipLimit, h.SocialLogin)            // Connexion sociale
+		authRoutes.POST("/reset", h.ipLimit, h.RequestPasswordReset)    // Demande de réinitialisation du mot de passe
+		authRoutes.POST("/verify-code", h.ipLimit, h.VerifyResetCode)   // Vérification du code de réinitialisation
+		authRoutes.POST("/reset-password", h.ipLimit, h.ResetPassword)  // Réinitialisation du mot de passe
 		authRoutes.POST("/refresh", h.RefreshToken)          // Rafraîchissement du token
+		authRoutes.POST("/2fa/verify", h.ipLimit, h.VerifyMFA)          // Code de double authentification après connexion
+		authRoutes.POST("/passkeys/login/options", h.ipLimit, h.BeginPasskeyLogin) // Défi de connexion par passkey
+		authRoutes.POST("/passkeys/login", h.ipLimit, h.PasskeyLogin)              // Connexion par passkey
+		authRoutes.POST("/passwordless/start", h.ipLimit, h.StartPasswordless)     // Code par SMS ou lien de connexion par email
+		authRoutes.POST("/passwordless/verify", h.ipLimit, h.CompletePasswordless) // Connexion avec le code ou le lien
 
 		// Routes sécurisées (nécessitent une authentification)
 		secured := authRoutes.Group("/")
 		secured.Use(middleware.AuthRequired())
+		// La sécurité et l'identité du compte (profil, photos, email, téléphone) restent réservées
+		// à son titulaire, même pendant une session du support
+		noImpersonation := middleware.NotImpersonated()
 		{
 			secured.POST("/signout", h.SignOut)              // Déconnexion
-			secured.PUT("/profile", h.UpdateProfile)         // Mise à jour du profil
-			secured.POST("/avatar", h.SetAvatar)             // Mise à jour de l'avatar
-			secured.POST("/profile-picture", h.SetProfilePicture) // Mise à jour de la photo de profil
-			secured.POST("/upload", h.UploadImage)           // Upload d'image (pour avatar ou photo de profil)
+			secured.PUT("/profile", noImpersonation, h.UpdateProfile)                  // Mise à jour du profil
+			secured.POST("/avatar", noImpersonation, h.SetAvatar)                      // Mise à jour de l'avatar
+			secured.POST("/profile-picture", noImpersonation, h.SetProfilePicture)     // Mise à jour de la photo de profil
+			secured.POST("/upload", noImpersonation, h.UploadImage)                    // Upload d'image (pour avatar ou photo de profil)
+			secured.GET("/sessions", noImpersonation, h.ListSessions)          // Sessions et appareils connectés
+			secured.DELETE("/sessions", noImpersonation, h.RevokeAllSessions)  // Révocation de toutes les sessions
+			secured.DELETE("/sessions/:id", noImpersonation, h.RevokeSession)  // Révocation d'une session
+			secured.POST("/verification/send", noImpersonation, h.SendVerification)       // Envoi d'un code de vérification (email ou téléphone)
+			secured.POST("/verification/confirm", noImpersonation, h.ConfirmVerification) // Vérification de l'email ou du téléphone
+			secured.POST("/2fa/setup", noImpersonation, h.SetupTwoFactor)     // Génération du secret TOTP
+			secured.POST("/2fa/confirm", noImpersonation, h.ConfirmTwoFactor) // Activation avec un premier code
+			secured.POST("/2fa/disable", noImpersonation, h.DisableTwoFactor) // Désactivation
+			secured.POST("/2fa/recovery-codes", noImpersonation, h.RegenerateRecoveryCodes) // Nouveaux codes de secours
+			secured.POST("/passkeys/register/options", noImpersonation, h.BeginPasskeyRegistration) // Défi d'enregistrement d'une passkey
+			secured.POST("/passkeys/register", noImpersonation, h.FinishPasskeyRegistration)        // Enregistrement d'une passkey
+			secured.GET("/passkeys", noImpersonation, h.ListPasskeys)                               // Passkeys enregistrées
+			secured.PATCH("/passkeys/:id", noImpersonation, h.RenamePasskey)                        // Renommage d'une passkey
+			secured.DELETE("/passkeys/:id", noImpersonation, h.DeletePasskey)                       // Suppression d'une passkey
 		}
 	}
 }
@@ -80,6 +112,13 @@ func (h *AuthHandler) SignIn(c *gin.Context) {
 	response, err := h.authService.SignIn(c.Request.Context(), req)
 	if err != nil {
 		log.Error().Err(err).Msg("Erreur lors de la connexion")
+		if respondRateLimited(c, err) || respondAccountBlocked(c, err) {
+			return
+		}
+		if errors.Is(err, auth.ErrTooManyMFAAttempts) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
@@ -116,6 +155,17 @@ func (h *AuthHandler) SocialLogin(c *gin.Context) {
 	response, err := h.authService.SocialLogin(c.Request.Context(), req)
 	if err != nil {
 		log.Error().Err(err).Msg("Erreur lors de la connexion sociale")
+		if respondAccountBlocked(c, err) {
+			return
+		}
+		if errors.Is(err, auth.ErrInvalidSocialToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, auth.ErrTooManyMFAAttempts) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -134,6 +184,9 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	response, err := h.authService.RefreshToken(c.Request.Context(), req.RefreshToken)
 	if err != nil {
 		log.Error().Err(err).Msg("Erreur lors du rafraîchissement du token")
+		if respondAccountBlocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
@@ -184,6 +237,9 @@ func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
 	}
 
 	err := h.authService.RequestPasswordReset(c.Request.Context(), req.EmailOrPhone)
+	if respondRateLimited(c, err) {
+		return
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("Erreur lors de la demande de réinitialisation de mot de passe")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur lors de l'envoi du code de réinitialisation"})
@@ -203,6 +259,9 @@ func (h *AuthHandler) VerifyResetCode(c *gin.Context) {
 	}
 
 	valid, err := h.authService.VerifyResetCode(c.Request.Context(), req)
+	if respondRateLimited(c, err) {
+		return
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("Erreur lors de la vérification du code")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur lors de la vérification du code"})
@@ -228,6 +287,9 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	err := h.authService.ResetPassword(c.Request.Context(), req)
 	if err != nil {
 		log.Error().Err(err).Msg("Erreur lors de la réinitialisation du mot de passe")
+		if respondRateLimited(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -365,4 +427,27 @@ func isValidImageType(filename string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+// respondAccountBlocked répond 403 si err est un refus lié à l'état du compte (suspendu,
+// réinitialisation du mot de passe imposée), avec un code pour que l'application affiche le bon écran
+func respondAccountBlocked(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, auth.ErrAccountSuspended):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "account_suspended"})
+	case errors.Is(err, auth.ErrPasswordResetRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "password_reset_required"})
+	default:
+		return false
+	}
+	return true
+}
+
+// respondRateLimited répond 429 si err est un refus d'un limiteur anti-abus
+func respondRateLimited(c *gin.Context, err error) bool {
+	var limitErr *ratelimit.LimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+	ratelimit.Abort(c, limitErr)
+	return true
+}
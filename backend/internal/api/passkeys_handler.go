@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"genie/internal/auth"
+	"genie/internal/models"
+)
+
+// BeginPasskeyLogin renvoie les options de connexion par passkey
+func (h *AuthHandler) BeginPasskeyLogin(c *gin.Context) {
+	options, err := h.authService.BeginPasskeyLogin(c.Request.Context())
+	if err != nil {
+		respondPasskeyError(c, err, "Erreur lors de la préparation de la connexion par passkey")
+		return
+	}
+
+	c.JSON(http.StatusOK, options)
+}
+
+// PasskeyLogin connecte un utilisateur avec la réponse de son authentificateur
+func (h *AuthHandler) PasskeyLogin(c *gin.Context) {
+	var req models.PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return
+	}
+
+	response, err := h.authService.PasskeyLogin(c.Request.Context(), req)
+	if err != nil {
+		respondPasskeyError(c, err, "Erreur lors de la connexion par passkey")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// BeginPasskeyRegistration renvoie les options d'enregistrement d'une passkey
+func (h *AuthHandler) BeginPasskeyRegistration(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Non authentifié"})
+		return
+	}
+
+	options, err := h.authService.BeginPasskeyRegistration(c.Request.Context(), userID)
+	if err != nil {
+		respondPasskeyError(c, err, "Erreur lors de la préparation de l'enregistrement de la passkey")
+		return
+	}
+
+	c.JSON(http.StatusOK, options)
+}
+
+// FinishPasskeyRegistration enregistre une passkey avec la réponse de l'authentificateur
+func (h *AuthHandler) FinishPasskeyRegistration(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Non authentifié"})
+		return
+	}
+
+	var req models.PasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return
+	}
+
+	passkey, err := h.authService.FinishPasskeyRegistration(c.Request.Context(), userID, req)
+	if err != nil {
+		respondPasskeyError(c, err, "Erreur lors de l'enregistrement de la passkey")
+		return
+	}
+
+	c.JSON(http.StatusCreated, passkey)
+}
+
+// ListPasskeys liste les passkeys de l'utilisateur connecté
+func (h *AuthHandler) ListPasskeys(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Non authentifié"})
+		return
+	}
+
+	passkeys, err := h.authService.ListPasskeys(c.Request.Context(), userID)
+	if err != nil {
+		respondPasskeyError(c, err, "Erreur lors de la récupération des passkeys")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"passkeys": passkeys})
+}
+
+// RenamePasskey renomme une passkey
+func (h *AuthHandler) RenamePasskey(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Non authentifié"})
+		return
+	}
+
+	var req models.RenamePasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nom invalide: " + err.Error()})
+		return
+	}
+
+	if err := h.authService.RenamePasskey(c.Request.Context(), userID, c.Param("id"), req.Name); err != nil {
+		respondPasskeyError(c, err, "Erreur lors du renommage de la passkey")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Passkey renommée"})
+}
+
+// DeletePasskey supprime une passkey
+func (h *AuthHandler) DeletePasskey(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Non authentifié"})
+		return
+	}
+
+	if err := h.authService.DeletePasskey(c.Request.Context(), userID, c.Param("id")); err != nil {
+		respondPasskeyError(c, err, "Erreur lors de la suppression de la passkey")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Passkey supprimée"})
+}
+
+// respondPasskeyError traduit une erreur de passkey en réponse HTTP
+func respondPasskeyError(c *gin.Context, err error, message string) {
+	if respondAccountBlocked(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, auth.ErrInvalidPasskey), errors.Is(err, auth.ErrPasskeyChallengeExpired),
+		errors.Is(err, auth.ErrPasskeyCloned):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrPasskeyNotFound), err.Error() == "utilisateur non trouvé":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrPasskeysDisabled):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+	case err.Error() == "passkey déjà enregistrée",
+		err.Error() == "impossible de supprimer le dernier moyen de connexion":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"genie/internal/auth"
+	"genie/internal/models"
+)
+
+// StartPasswordless envoie un code de connexion par SMS ou un lien de connexion par email
+func (h *AuthHandler) StartPasswordless(c *gin.Context) {
+	var req models.PasswordlessStartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email ou téléphone requis"})
+		return
+	}
+
+	response, err := h.authService.StartPasswordless(c.Request.Context(), req)
+	if err != nil {
+		respondPasswordlessError(c, err, "Erreur lors de l'envoi du code de connexion")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CompletePasswordless connecte l'utilisateur avec le code reçu ou le jeton du lien
+func (h *AuthHandler) CompletePasswordless(c *gin.Context) {
+	var req models.PasswordlessVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return
+	}
+
+	response, err := h.authService.CompletePasswordless(c.Request.Context(), req)
+	if err != nil {
+		respondPasswordlessError(c, err, "Erreur lors de la connexion sans mot de passe")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// respondPasswordlessError traduit une erreur de connexion sans mot de passe en réponse HTTP
+func respondPasswordlessError(c *gin.Context, err error, message string) {
+	if respondRateLimited(c, err) || respondAccountBlocked(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, auth.ErrInvalidPasswordlessCode), errors.Is(err, auth.ErrPasswordlessExpired),
+		errors.Is(err, auth.ErrMFAChallengeExpired):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrTooManyPasswordlessAttempts), errors.Is(err, auth.ErrTooManyMFAAttempts):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case err.Error() == "format de téléphone invalide":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"genie/internal/models"
+	"genie/internal/ratelimit"
+	"genie/internal/wishlist"
+)
+
+// sharePasswordHeader porte le mot de passe d'un lien public protégé
+const sharePasswordHeader = "X-Wishlist-Password"
+
+// PublicWishlistHandler gère les pages publiques de wishlist, accessibles sans compte
+type PublicWishlistHandler struct {
+	wishlistSvc *wishlist.Service
+	ipLimit     gin.HandlerFunc // limite par adresse IP des routes publiques
+}
+
+// NewPublicWishlistHandler crée un nouveau gestionnaire pour les pages publiques de wishlist.
+// Les routes sont limitées par adresse IP selon ipRule, chacune avec son propre compteur.
+func NewPublicWishlistHandler(wishlistSvc *wishlist.Service, limits ratelimit.Store, ipRule ratelimit.Rule) *PublicWishlistHandler {
+	limiter := ratelimit.NewLimiter(limits, "public:ip", ipRule)
+	return &PublicWishlistHandler{
+		wishlistSvc: wishlistSvc,
+		ipLimit: ratelimit.Middleware(limiter, func(c *gin.Context) string {
+			return c.FullPath() + ":" + c.ClientIP()
+		}),
+	}
+}
+
+// RegisterRoutes enregistre les routes publiques, sans authentification
+func (h *PublicWishlistHandler) RegisterRoutes(router *gin.RouterGroup) {
+	publicRoutes := router.Group("/public", h.ipLimit)
+	{
+		publicRoutes.GET("/wishlists/:slug", h.getPublicWishlist)
+		publicRoutes.POST("/wishlists/:slug/items/:itemId/reserve", h.requestGuestReservation)
+		publicRoutes.POST("/reservations/confirm", h.confirmGuestReservation)
+		publicRoutes.POST("/reservations/cancel", h.cancelGuestReservation)
+	}
+}
+
+// getPublicWishlist renvoie une wishlist en lecture seule à partir de son lien public
+func (h *PublicWishlistHandler) getPublicWishlist(c *gin.Context) {
+	slug := c.Param("slug")
+
+	response, err := h.wishlistSvc.GetPublicWishlist(c, slug, c.GetHeader(sharePasswordHeader))
+	if err != nil {
+		respondPublicError(c, err, "Impossible de récupérer la wishlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// requestGuestReservation enregistre la réservation d'un invité et lui envoie un email de confirmation
+func (h *PublicWishlistHandler) requestGuestReservation(c *gin.Context) {
+	slug := c.Param("slug")
+	itemID := c.Param("itemId")
+
+	var req models.GuestReserveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return
+	}
+
+	err := h.wishlistSvc.RequestGuestReservation(c, slug, c.GetHeader(sharePasswordHeader), itemID, req)
+	if err != nil {
+		respondPublicError(c, err, "Impossible d'enregistrer la réservation")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Un email de confirmation vous a été envoyé"})
+}
+
+// confirmGuestReservation confirme la réservation d'un invité depuis le lien reçu par email
+func (h *PublicWishlistHandler) confirmGuestReservation(c *gin.Context) {
+	var req models.GuestTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return
+	}
+
+	if err := h.wishlistSvc.ConfirmGuestReservation(c, req.Token); err != nil {
+		respondPublicError(c, err, "Impossible de confirmer la réservation")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Réservation confirmée"})
+}
+
+// cancelGuestReservation annule la réservation d'un invité depuis le lien reçu par email
+func (h *PublicWishlistHandler) cancelGuestReservation(c *gin.Context) {
+	var req models.GuestTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return
+	}
+
+	if err := h.wishlistSvc.CancelGuestReservation(c, req.Token); err != nil {
+		respondPublicError(c, err, "Impossible d'annuler la réservation")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Réservation annulée"})
+}
+
+// respondPublicError traduit les erreurs des pages publiques en réponses HTTP
+func respondPublicError(c *gin.Context, err error, message string) {
+	if respondRateLimited(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, wishlist.ErrShareLinkNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cette wishlist n'existe pas ou n'est plus partagée"})
+	case errors.Is(err, wishlist.ErrShareLinkExpired):
+		c.JSON(http.StatusGone, gin.H{"error": "Ce lien de partage a expiré"})
+	case errors.Is(err, wishlist.ErrInvalidSharePassword):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Mot de passe requis ou incorrect"})
+	case errors.Is(err, wishlist.ErrItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item non trouvé"})
+	case errors.Is(err, wishlist.ErrInvalidGuestName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Le nom doit faire entre 1 et 80 caractères"})
+	case errors.Is(err, wishlist.ErrInvalidQuantity):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quantité invalide"})
+	case errors.Is(err, wishlist.ErrAlreadyReserved), errors.Is(err, wishlist.ErrInsufficientQuantity):
+		c.JSON(http.StatusConflict, gin.H{"error": "Cet item n'est plus disponible"})
+	case errors.Is(err, wishlist.ErrCannotCancel):
+		c.JSON(http.StatusConflict, gin.H{"error": "Cette réservation ne peut plus être annulée"})
+	case errors.Is(err, wishlist.ErrInvalidGuestToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ce lien est invalide ou a expiré"})
+	case errors.Is(err, wishlist.ErrTooManyPending):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Trop de réservations en attente de confirmation pour cette adresse"})
+	default:
+		log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
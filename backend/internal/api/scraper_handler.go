@@ -40,9 +40,9 @@ func (h *ScraperHandler) UpdateCache() {
 }
 
 // NewScraperHandler crée un nouveau gestionnaire de scraper
-func NewScraperHandler() *ScraperHandler {
+func NewScraperHandler(scraperManager *scraper.ScraperManager) *ScraperHandler {
 	return &ScraperHandler{
-		scraperManager: scraper.NewScraperManager(),
+		scraperManager: scraperManager,
 	}
 }
 
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"genie/internal/auth"
+	"genie/internal/middleware"
+)
+
+// maxDeviceNameLen est la longueur maximale du nom d'appareil transmis par l'application
+const maxDeviceNameLen = 100
+
+// withDeviceInfo attache à la requête l'appareil qui l'envoie (IP, user agent et nom transmis
+// dans l'en-tête X-Device-Name), enregistré avec les sessions ouvertes ou rafraîchies
+func withDeviceInfo(c *gin.Context) {
+	deviceName := strings.TrimSpace(c.GetHeader("X-Device-Name"))
+	if len(deviceName) > maxDeviceNameLen {
+		deviceName = deviceName[:maxDeviceNameLen]
+	}
+
+	c.Request = c.Request.WithContext(auth.WithDevice(c.Request.Context(), auth.DeviceInfo{
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		DeviceName: deviceName,
+	}))
+	c.Next()
+}
+
+// ListSessions liste les sessions actives de l'utilisateur
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Non authentifié"})
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(c.Request.Context(), userID, c.GetString(middleware.SessionIDKey))
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la récupération des sessions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur lors de la récupération des sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession révoque une session
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Non authentifié"})
+		return
+	}
+
+	if err := h.authService.RevokeSession(c.Request.Context(), userID, c.Param("id")); err != nil {
+		if err.Error() == "session introuvable" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error().Err(err).Msg("Erreur lors de la révocation de la session")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur lors de la révocation de la session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session révoquée"})
+}
+
+// RevokeAllSessions révoque toutes les sessions, ou toutes les autres avec ?exceptCurrent=true
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Non authentifié"})
+		return
+	}
+
+	except := ""
+	if c.Query("exceptCurrent") == "true" {
+		except = c.GetString(middleware.SessionIDKey)
+		if except == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Session courante inconnue, reconnectez-vous"})
+			return
+		}
+	}
+
+	if err := h.authService.RevokeAllSessions(c.Request.Context(), userID, except); err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la révocation des sessions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur lors de la révocation des sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sessions révoquées"})
+}
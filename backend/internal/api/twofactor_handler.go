@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"genie/internal/auth"
+	"genie/internal/models"
+)
+
+// VerifyMFA termine une connexion avec le code de double authentification
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req models.VerifyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return
+	}
+
+	response, err := h.authService.VerifyMFA(c.Request.Context(), req)
+	if err != nil {
+		respondTwoFactorError(c, err, "Erreur lors de la vérification du code de double authentification")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetupTwoFactor génère le secret TOTP à enregistrer dans l'application d'authentification
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Non authentifié"})
+		return
+	}
+
+	response, err := h.authService.SetupTwoFactor(c.Request.Context(), userID)
+	if err != nil {
+		respondTwoFactorError(c, err, "Erreur lors de l'activation de la double authentification")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ConfirmTwoFactor active la double authentification avec un premier code
+func (h *AuthHandler) ConfirmTwoFactor(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Non authentifié"})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Code requis"})
+		return
+	}
+
+	response, err := h.authService.ConfirmTwoFactor(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "Erreur lors de l'activation de la double authentification")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DisableTwoFactor désactive la double authentification
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Non authentifié"})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Code requis"})
+		return
+	}
+
+	if err := h.authService.DisableTwoFactor(c.Request.Context(), userID, req.Code); err != nil {
+		respondTwoFactorError(c, err, "Erreur lors de la désactivation de la double authentification")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Double authentification désactivée"})
+}
+
+// RegenerateRecoveryCodes remplace les codes de secours
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Non authentifié"})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Code requis"})
+		return
+	}
+
+	response, err := h.authService.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "Erreur lors de la régénération des codes de secours")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// respondTwoFactorError traduit une erreur de double authentification en réponse HTTP
+func respondTwoFactorError(c *gin.Context, err error, message string) {
+	if respondAccountBlocked(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, auth.ErrInvalidTwoFactorCode), errors.Is(err, auth.ErrMFAChallengeExpired):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrTooManyMFAAttempts):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case err.Error() == "utilisateur non trouvé":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "double authentification déjà activée",
+		err.Error() == "double authentification non activée",
+		err.Error() == "aucune activation de double authentification en cours":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"genie/internal/auth"
+	"genie/internal/models"
+)
+
+// SendVerification envoie un code de vérification de l'email ou du téléphone
+func (h *AuthHandler) SendVerification(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Non authentifié"})
+		return
+	}
+
+	var req models.SendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Canal invalide (email ou phone)"})
+		return
+	}
+
+	if err := h.authService.SendVerification(c.Request.Context(), userID, req.Channel); err != nil {
+		respondVerificationError(c, err, "Erreur lors de l'envoi du code de vérification")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Code de vérification envoyé"})
+}
+
+// ConfirmVerification vérifie l'email ou le téléphone avec le code reçu
+func (h *AuthHandler) ConfirmVerification(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Non authentifié"})
+		return
+	}
+
+	var req models.ConfirmVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return
+	}
+
+	user, err := h.authService.ConfirmVerification(c.Request.Context(), userID, req.Channel, req.Code)
+	if err != nil {
+		respondVerificationError(c, err, "Erreur lors de la vérification du code")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// respondVerificationError traduit une erreur de vérification des coordonnées en réponse HTTP
+func respondVerificationError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidVerificationCode), errors.Is(err, auth.ErrVerificationExpired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrTooManyVerificationAttempts), errors.Is(err, auth.ErrVerificationThrottled):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case err.Error() == "utilisateur non trouvé":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "compte déjà vérifié", err.Error() == "aucune vérification en attente":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err.Error() == "aucun email à vérifier", err.Error() == "aucun numéro de téléphone à vérifier":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -1,6 +1,11 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -8,8 +13,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	"genie/internal/models"
+	"genie/internal/unfurl"
 	"genie/internal/wishlist"
 )
 
@@ -33,6 +40,11 @@ func (h *WishlistHandler) RegisterRoutes(router *gin.RouterGroup) {
 		wishlistRoutes.POST("", h.createWishlist)
 		wishlistRoutes.GET("/invitations", h.getWishlistInvitations)
 		wishlistRoutes.GET("/search", h.searchWishlists)
+		wishlistRoutes.POST("/import", h.importWishlist)
+		wishlistRoutes.GET("/users/:userId/managed", h.getManagedWishlists)
+		wishlistRoutes.GET("/templates", h.getTemplates)
+		wishlistRoutes.DELETE("/templates/:templateId", h.deleteTemplate)
+		wishlistRoutes.POST("/templates/:templateId/use", h.useTemplate)
 		wishlistRoutes.GET("/:id", h.getWishlist)
 		wishlistRoutes.PUT("/:id", h.updateWishlist)
 		wishlistRoutes.DELETE("/:id", h.deleteWishlist)
@@ -40,19 +52,69 @@ func (h *WishlistHandler) RegisterRoutes(router *gin.RouterGroup) {
 		wishlistRoutes.POST("/:id/respond", h.respondToInvitation)
 		wishlistRoutes.DELETE("/:id/share/:userId", h.removeSharing)
 		wishlistRoutes.POST("/:id/upload-cover", h.uploadWishlistCover)
+		wishlistRoutes.POST("/:id/share-link", h.createShareLink)
+		wishlistRoutes.PUT("/:id/share-link", h.updateShareLink)
+		wishlistRoutes.DELETE("/:id/share-link", h.revokeShareLink)
+		wishlistRoutes.PUT("/:id/order", h.reorderWishlist)
+		wishlistRoutes.POST("/:id/sections", h.createSection)
+		wishlistRoutes.PUT("/:id/sections/:sectionId", h.renameSection)
+		wishlistRoutes.DELETE("/:id/sections/:sectionId", h.deleteSection)
+		wishlistRoutes.POST("/:id/clone", h.cloneWishlist)
+		wishlistRoutes.POST("/:id/template", h.saveAsTemplate)
+		wishlistRoutes.POST("/:id/merge", h.mergeWishlists)
+		wishlistRoutes.GET("/:id/export", h.exportWishlist)
+		wishlistRoutes.POST("/:id/import", h.importIntoWishlist)
 
 		wishlistRoutes.GET("/:id/items", h.getWishlistItems)
 		wishlistRoutes.POST("/items", h.createWishItem)
+		wishlistRoutes.POST("/items/unfurl", h.unfurlLink)
 		wishlistRoutes.GET("/items/all", h.getUserWishItems)
 		wishlistRoutes.GET("/items/search", h.searchWishItems)
 		wishlistRoutes.GET("/items/:itemId", h.getWishItem)
 		wishlistRoutes.PUT("/items/:itemId", h.updateWishItem)
 		wishlistRoutes.DELETE("/items/:itemId", h.deleteWishItem)
 		wishlistRoutes.POST("/items/:itemId/reserve", h.reserveWishItem)
+		wishlistRoutes.POST("/items/:itemId/purchase", h.markPurchased)
+		wishlistRoutes.POST("/items/:itemId/received", h.markReceived)
 		wishlistRoutes.POST("/items/:itemId/upload-image", h.uploadWishItemImage)
 	}
 }
 
+// getManagedWishlists récupère les wishlists des comptes gérés d'un utilisateur ;
+// elles sont visibles par ses amis
+func (h *WishlistHandler) getManagedWishlists(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	guardianID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	wishlists, err := h.wishlistSvc.GetManagedWishlists(c, guardianID, uid)
+	if err != nil {
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Seuls les amis de cet utilisateur peuvent voir les wishlists de ses comptes gérés"})
+			return
+		}
+		log.Error().Err(err).Msg("Erreur lors de la récupération des wishlists des comptes gérés")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Impossible de récupérer les wishlists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, wishlists)
+}
+
 // getUserWishlists récupère toutes les wishlists de l'utilisateur
 func (h *WishlistHandler) getUserWishlists(c *gin.Context) {
 	userID, exists := c.Get("userID")
@@ -100,6 +162,14 @@ func (h *WishlistHandler) createWishlist(c *gin.Context) {
 	wishlist, err := h.wishlistSvc.CreateWishlist(c, uid, req)
 	if err != nil {
 		log.Error().Err(err).Msg("Erreur lors de la création de la wishlist")
+		if err.Error() == "mode surprise invalide" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Mode surprise invalide"})
+			return
+		}
+		if err.Error() == "compte géré introuvable" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Compte géré non trouvé"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Impossible de créer la wishlist"})
 		return
 	}
@@ -182,6 +252,10 @@ func (h *WishlistHandler) updateWishlist(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Accès refusé à cette wishlist"})
 			return
 		}
+		if err.Error() == "mode surprise invalide" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Mode surprise invalide"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Impossible de mettre à jour la wishlist"})
 		return
 	}
@@ -268,6 +342,14 @@ func (h *WishlistHandler) shareWishlist(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Accès refusé à cette wishlist"})
 			return
 		}
+		if err.Error() == "permission invalide" || err.Error() == "impossible de partager une wishlist avec son propriétaire" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "wishlist déjà partagée avec cet utilisateur" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Impossible de partager la wishlist"})
 		return
 	}
@@ -423,6 +505,26 @@ func (h *WishlistHandler) createWishItem(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Accès refusé à cette wishlist"})
 			return
 		}
+		if err.Error() == "nom requis" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Un nom ou un lien est requis"})
+			return
+		}
+		if err.Error() == "priorité invalide" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Priorité invalide (low, medium ou high)"})
+			return
+		}
+		if err.Error() == "quantité invalide" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Quantité invalide"})
+			return
+		}
+		if err.Error() == "section not found" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Section inconnue dans cette wishlist"})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "impossible de récupérer les informations du lien") {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Impossible de créer le wish item"})
 		return
 	}
@@ -430,6 +532,38 @@ func (h *WishlistHandler) createWishItem(c *gin.Context) {
 	c.JSON(http.StatusCreated, item)
 }
 
+// unfurlLink renvoie l'aperçu d'un produit (nom, image, prix) à partir de son URL
+func (h *WishlistHandler) unfurlLink(c *gin.Context) {
+	if _, exists := c.Get("userID"); !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	var req models.UnfurlLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return
+	}
+
+	preview, err := h.wishlistSvc.UnfurlLink(c.Request.Context(), req.URL)
+	if err != nil {
+		switch {
+		case errors.Is(err, unfurl.ErrInvalidURL), errors.Is(err, unfurl.ErrBlockedHost):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "URL invalide ou non autorisée"})
+		case errors.Is(err, unfurl.ErrRateLimited):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Trop de requêtes vers ce site, réessayez plus tard"})
+		case errors.Is(err, unfurl.ErrNoMetadata), errors.Is(err, unfurl.ErrUnsupportedContent):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Aucune information produit trouvée à cette adresse"})
+		default:
+			log.Warn().Err(err).Str("url", req.URL).Msg("Erreur lors de la récupération de l'aperçu du lien")
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Impossible de récupérer la page"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
 // getWishItem récupère un wish item par son ID
 func (h *WishlistHandler) getWishItem(c *gin.Context) {
 	userID, exists := c.Get("userID")
@@ -505,6 +639,18 @@ func (h *WishlistHandler) updateWishItem(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Accès refusé à cet item"})
 			return
 		}
+		if err.Error() == "priorité invalide" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Priorité invalide (low, medium ou high)"})
+			return
+		}
+		if err.Error() == "quantité invalide" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Quantité invalide"})
+			return
+		}
+		if err.Error() == "la quantité ne peut pas être inférieure aux réservations" {
+			c.JSON(http.StatusConflict, gin.H{"error": "La quantité ne peut pas être inférieure aux réservations en cours"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Impossible de mettre à jour le wish item"})
 		return
 	}
@@ -595,6 +741,30 @@ func (h *WishlistHandler) reserveWishItem(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Seul le propriétaire peut annuler la réservation de quelqu'un d'autre"})
 			return
 		}
+		if err.Error() == "vous ne pouvez pas réserver votre propre item" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Vous ne pouvez pas réserver votre propre item"})
+			return
+		}
+		if err.Error() == "cet item est déjà réservé" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cet item est déjà réservé"})
+			return
+		}
+		if err.Error() == "vous ne pouvez pas annuler cette réservation" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Vous ne pouvez pas annuler cette réservation"})
+			return
+		}
+		if err.Error() == "vous avez déjà réservé cet item" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Vous avez déjà réservé cet item"})
+			return
+		}
+		if err.Error() == "quantité invalide" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Quantité invalide"})
+			return
+		}
+		if err.Error() == "quantité disponible insuffisante" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Il ne reste pas assez d'exemplaires à réserver"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Impossible de réserver le wish item"})
 		return
 	}
@@ -607,6 +777,95 @@ func (h *WishlistHandler) reserveWishItem(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": message})
 }
 
+// markPurchased indique que la personne qui a réservé un wish item l'a acheté
+func (h *WishlistHandler) markPurchased(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	itemID := c.Param("itemId")
+	if itemID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de wish item manquant"})
+		return
+	}
+
+	err = h.wishlistSvc.MarkPurchased(c, itemID, uid)
+	if err != nil {
+		log.Error().Err(err).Str("itemID", itemID).Msg("Erreur lors de la confirmation d'achat du wish item")
+		if err.Error() == "aucune réservation à confirmer" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Aucune réservation à confirmer pour cet item"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Impossible de confirmer l'achat"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Achat confirmé avec succès"})
+}
+
+// markReceived indique que le propriétaire a reçu un wish item réservé
+func (h *WishlistHandler) markReceived(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	itemID := c.Param("itemId")
+	if itemID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de wish item manquant"})
+		return
+	}
+
+	// Le corps est optionnel : sans remerciement, rien à envoyer
+	var req models.MarkReceivedRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+			return
+		}
+	}
+
+	err = h.wishlistSvc.MarkReceived(c, itemID, uid, req)
+	if err != nil {
+		log.Error().Err(err).Str("itemID", itemID).Msg("Erreur lors de la confirmation de réception du wish item")
+		if err.Error() == "wish item not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Wish item non trouvé"})
+			return
+		}
+		if err.Error() == "access denied" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Seul le propriétaire de la wishlist peut confirmer la réception"})
+			return
+		}
+		if err.Error() == "message trop long" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Le message de remerciement est trop long"})
+			return
+		}
+		if err.Error() == "cet item n'est pas en attente de réception" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cet item n'est pas en attente de réception"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Impossible de confirmer la réception"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Réception confirmée avec succès"})
+}
+
 // getUserWishItems récupère tous les wish items d'un utilisateur
 func (h *WishlistHandler) getUserWishItems(c *gin.Context) {
 	userID, exists := c.Get("userID")
@@ -854,3 +1113,686 @@ func validateImageType(filename string) bool {
 	}
 	return false
 }
+
+// reorderWishlist réorganise d'un bloc les items et sections d'une wishlist
+func (h *WishlistHandler) reorderWishlist(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	wishlistID := c.Param("id")
+	if wishlistID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de wishlist manquant"})
+		return
+	}
+
+	var req models.ReorderWishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return
+	}
+
+	wishlist, err := h.wishlistSvc.ReorderWishlist(c, wishlistID, uid, req)
+	if err != nil {
+		log.Error().Err(err).Str("wishlistID", wishlistID).Msg("Erreur lors de la réorganisation de la wishlist")
+		respondLayoutError(c, err, "Impossible de réorganiser la wishlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, wishlist)
+}
+
+// createSection ajoute une section à une wishlist
+func (h *WishlistHandler) createSection(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	wishlistID := c.Param("id")
+	if wishlistID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de wishlist manquant"})
+		return
+	}
+
+	var req models.SectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return
+	}
+
+	section, err := h.wishlistSvc.CreateSection(c, wishlistID, uid, req)
+	if err != nil {
+		log.Error().Err(err).Str("wishlistID", wishlistID).Msg("Erreur lors de la création de la section")
+		respondLayoutError(c, err, "Impossible de créer la section")
+		return
+	}
+
+	c.JSON(http.StatusCreated, section)
+}
+
+// renameSection renomme une section de wishlist
+func (h *WishlistHandler) renameSection(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	wishlistID := c.Param("id")
+	sectionID := c.Param("sectionId")
+	if wishlistID == "" || sectionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de wishlist ou de section manquant"})
+		return
+	}
+
+	var req models.SectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return
+	}
+
+	section, err := h.wishlistSvc.RenameSection(c, wishlistID, sectionID, uid, req)
+	if err != nil {
+		log.Error().Err(err).Str("wishlistID", wishlistID).Str("sectionID", sectionID).Msg("Erreur lors du renommage de la section")
+		respondLayoutError(c, err, "Impossible de renommer la section")
+		return
+	}
+
+	c.JSON(http.StatusOK, section)
+}
+
+// deleteSection supprime une section de wishlist, ses items restent dans la wishlist
+func (h *WishlistHandler) deleteSection(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	wishlistID := c.Param("id")
+	sectionID := c.Param("sectionId")
+	if wishlistID == "" || sectionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de wishlist ou de section manquant"})
+		return
+	}
+
+	err = h.wishlistSvc.DeleteSection(c, wishlistID, sectionID, uid)
+	if err != nil {
+		log.Error().Err(err).Str("wishlistID", wishlistID).Str("sectionID", sectionID).Msg("Erreur lors de la suppression de la section")
+		respondLayoutError(c, err, "Impossible de supprimer la section")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Section supprimée avec succès"})
+}
+
+// respondLayoutError traduit les erreurs d'organisation d'une wishlist en réponses HTTP
+func respondLayoutError(c *gin.Context, err error, message string) {
+	switch err.Error() {
+	case "wishlist not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wishlist non trouvée"})
+	case "section not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Section non trouvée"})
+	case "access denied":
+		c.JSON(http.StatusForbidden, gin.H{"error": "Accès refusé à cette wishlist"})
+	case "nom de section invalide":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Le nom de section doit faire entre 1 et 50 caractères"})
+	case "ordre des sections invalide", "ordre des items invalide":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ordre invalide: chaque élément doit apparaître une seule fois"})
+	case "nombre maximum de sections atteint":
+		c.JSON(http.StatusConflict, gin.H{"error": "Nombre maximum de sections atteint"})
+	case "la wishlist a été modifiée entre-temps":
+		c.JSON(http.StatusConflict, gin.H{"error": "La wishlist a été modifiée entre-temps, rechargez-la puis réessayez"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// createShareLink crée ou renouvelle le lien public d'une wishlist
+func (h *WishlistHandler) createShareLink(c *gin.Context) {
+	h.saveShareLink(c, true)
+}
+
+// updateShareLink modifie le mot de passe ou l'expiration du lien public d'une wishlist
+func (h *WishlistHandler) updateShareLink(c *gin.Context) {
+	h.saveShareLink(c, false)
+}
+
+// saveShareLink crée (rotate) ou modifie le lien public d'une wishlist
+func (h *WishlistHandler) saveShareLink(c *gin.Context, rotate bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	wishlistID := c.Param("id")
+	if wishlistID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de wishlist manquant"})
+		return
+	}
+
+	// Le corps est optionnel : sans paramètres, le lien est permanent et sans mot de passe
+	var req models.ShareLinkRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+			return
+		}
+	}
+
+	var link *models.ShareLinkResponse
+	if rotate {
+		link, err = h.wishlistSvc.CreateShareLink(c, wishlistID, uid, req)
+	} else {
+		link, err = h.wishlistSvc.UpdateShareLink(c, wishlistID, uid, req)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("wishlistID", wishlistID).Msg("Erreur lors de l'enregistrement du lien public")
+		respondShareLinkError(c, err, "Impossible d'enregistrer le lien public")
+		return
+	}
+
+	status := http.StatusOK
+	if rotate {
+		status = http.StatusCreated
+	}
+	c.JSON(status, link)
+}
+
+// revokeShareLink supprime le lien public d'une wishlist
+func (h *WishlistHandler) revokeShareLink(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	wishlistID := c.Param("id")
+	if wishlistID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de wishlist manquant"})
+		return
+	}
+
+	err = h.wishlistSvc.RevokeShareLink(c, wishlistID, uid)
+	if err != nil {
+		log.Error().Err(err).Str("wishlistID", wishlistID).Msg("Erreur lors de la suppression du lien public")
+		respondShareLinkError(c, err, "Impossible de supprimer le lien public")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Lien public supprimé avec succès"})
+}
+
+// respondShareLinkError traduit les erreurs de gestion du lien public en réponses HTTP
+func respondShareLinkError(c *gin.Context, err error, message string) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wishlist non trouvée"})
+		return
+	}
+
+	switch err.Error() {
+	case "wishlist not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wishlist non trouvée"})
+	case "lien public introuvable":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cette wishlist n'a pas de lien public"})
+	case "access denied":
+		c.JSON(http.StatusForbidden, gin.H{"error": "Vous n'avez pas le droit de gérer le lien public de cette wishlist"})
+	case "date d'expiration invalide":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La date d'expiration doit être dans le futur"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// cloneWishlist duplique une wishlist et ses items dans une nouvelle wishlist de l'utilisateur
+func (h *WishlistHandler) cloneWishlist(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	wishlistID := c.Param("id")
+	if wishlistID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de wishlist manquant"})
+		return
+	}
+
+	// Le corps est facultatif : sans lui, la copie reprend le titre d'origine sans les images
+	var req models.CloneWishlistRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+			return
+		}
+	}
+
+	wishlist, err := h.wishlistSvc.CloneWishlist(c, wishlistID, uid, req)
+	if err != nil {
+		log.Error().Err(err).Str("wishlistID", wishlistID).Msg("Erreur lors de la duplication de la wishlist")
+		respondTemplateError(c, err, "Impossible de dupliquer la wishlist")
+		return
+	}
+
+	c.JSON(http.StatusCreated, wishlist)
+}
+
+// saveAsTemplate enregistre une wishlist comme modèle réutilisable
+func (h *WishlistHandler) saveAsTemplate(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	wishlistID := c.Param("id")
+	if wishlistID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de wishlist manquant"})
+		return
+	}
+
+	var req models.SaveTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return
+	}
+
+	template, err := h.wishlistSvc.SaveAsTemplate(c, wishlistID, uid, req)
+	if err != nil {
+		log.Error().Err(err).Str("wishlistID", wishlistID).Msg("Erreur lors de l'enregistrement du modèle")
+		respondTemplateError(c, err, "Impossible d'enregistrer le modèle")
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// getTemplates récupère les modèles de l'utilisateur et ceux proposés par Genie
+func (h *WishlistHandler) getTemplates(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	templates, err := h.wishlistSvc.GetTemplates(c, uid)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la récupération des modèles")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Impossible de récupérer les modèles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// deleteTemplate supprime un modèle enregistré par l'utilisateur
+func (h *WishlistHandler) deleteTemplate(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	templateID := c.Param("templateId")
+	err = h.wishlistSvc.DeleteTemplate(c, templateID, uid)
+	if err != nil {
+		log.Error().Err(err).Str("templateID", templateID).Msg("Erreur lors de la suppression du modèle")
+		respondTemplateError(c, err, "Impossible de supprimer le modèle")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Modèle supprimé avec succès"})
+}
+
+// useTemplate crée une wishlist à partir d'un modèle
+func (h *WishlistHandler) useTemplate(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	var req models.UseTemplateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+			return
+		}
+	}
+
+	templateID := c.Param("templateId")
+	wishlist, err := h.wishlistSvc.UseTemplate(c, templateID, uid, req)
+	if err != nil {
+		log.Error().Err(err).Str("templateID", templateID).Msg("Erreur lors de la création depuis un modèle")
+		respondTemplateError(c, err, "Impossible de créer la wishlist depuis ce modèle")
+		return
+	}
+
+	c.JSON(http.StatusCreated, wishlist)
+}
+
+// mergeWishlists fusionne une wishlist source dans la wishlist de l'URL
+func (h *WishlistHandler) mergeWishlists(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	wishlistID := c.Param("id")
+	if wishlistID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de wishlist manquant"})
+		return
+	}
+
+	var req models.MergeWishlistsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return
+	}
+
+	result, err := h.wishlistSvc.MergeWishlists(c, wishlistID, uid, req)
+	if err != nil {
+		log.Error().Err(err).Str("wishlistID", wishlistID).Str("sourceID", req.SourceID).Msg("Erreur lors de la fusion des wishlists")
+		respondTemplateError(c, err, "Impossible de fusionner les wishlists")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// maxImportFileSize est la taille maximale d'un fichier importé
+const maxImportFileSize = 5 << 20
+
+// exportWishlist exporte une wishlist et ses items en JSON (par défaut) ou en CSV
+func (h *WishlistHandler) exportWishlist(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	wishlistID := c.Param("id")
+	if wishlistID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de wishlist manquant"})
+		return
+	}
+
+	format := c.DefaultQuery("format", wishlist.FormatJSON)
+	if !wishlist.IsValidExportFormat(format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format d'export invalide. Utilisez json ou csv"})
+		return
+	}
+
+	export, err := h.wishlistSvc.ExportWishlist(c, wishlistID, uid)
+	if err != nil {
+		log.Error().Err(err).Str("wishlistID", wishlistID).Msg("Erreur lors de l'export de la wishlist")
+		respondTemplateError(c, err, "Impossible d'exporter la wishlist")
+		return
+	}
+
+	filename := exportFilename(export.Title) + "." + format
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if format == wishlist.FormatJSON {
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := wishlist.WriteExportCSV(&buf, export); err != nil {
+		log.Error().Err(err).Str("wishlistID", wishlistID).Msg("Erreur lors de l'écriture du CSV")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Impossible d'exporter la wishlist"})
+		return
+	}
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// importWishlist crée une wishlist à partir d'un fichier importé
+func (h *WishlistHandler) importWishlist(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	req, data, ok := readImportRequest(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.wishlistSvc.ImportNewWishlist(c, uid, req, data)
+	if err != nil {
+		log.Error().Err(err).Str("format", req.Format).Msg("Erreur lors de l'import de la wishlist")
+		respondImportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// importIntoWishlist ajoute à une wishlist les items d'un fichier importé
+func (h *WishlistHandler) importIntoWishlist(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Utilisateur non authentifié"})
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID utilisateur invalide"})
+		return
+	}
+
+	wishlistID := c.Param("id")
+	if wishlistID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de wishlist manquant"})
+		return
+	}
+
+	req, data, ok := readImportRequest(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.wishlistSvc.ImportIntoWishlist(c, wishlistID, uid, req, data)
+	if err != nil {
+		log.Error().Err(err).Str("wishlistID", wishlistID).Str("format", req.Format).Msg("Erreur lors de l'import dans la wishlist")
+		respondImportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// readImportRequest lit le formulaire d'import : le fichier, son format et, pour un CSV
+// quelconque, la correspondance des colonnes en JSON. Répond à la requête en cas d'erreur.
+func readImportRequest(c *gin.Context) (models.ImportWishlistRequest, []byte, bool) {
+	var req models.ImportWishlistRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Données invalides: " + err.Error()})
+		return req, nil, false
+	}
+	if !wishlist.IsValidImportFormat(req.Format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format d'import invalide. Utilisez json, csv, amazon ou mapped_csv"})
+		return req, nil, false
+	}
+	if mapping := c.PostForm("mapping"); mapping != "" {
+		req.Mapping = &models.CSVColumnMapping{}
+		if err := json.Unmarshal([]byte(mapping), req.Mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Correspondance des colonnes invalide"})
+			return req, nil, false
+		}
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fichier à importer requis"})
+		return req, nil, false
+	}
+	defer file.Close()
+
+	if header.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Le fichier ne doit pas dépasser 5 Mo"})
+		return req, nil, false
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxImportFileSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fichier illisible"})
+		return req, nil, false
+	}
+	return req, data, true
+}
+
+// respondImportError traduit les erreurs d'import en réponses HTTP
+func respondImportError(c *gin.Context, err error) {
+	message := err.Error()
+	switch {
+	case message == "fichier illisible":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Fichier illisible dans ce format"})
+	case message == "correspondance des colonnes invalide":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La correspondance des colonnes doit au moins indiquer la colonne du nom"})
+	case message == "aucun article trouvé dans la liste Amazon":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Aucun article trouvé dans la liste Amazon"})
+	case strings.HasPrefix(message, "colonne introuvable"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Colonne introuvable dans le fichier : " + strings.TrimSpace(strings.TrimPrefix(message, "colonne introuvable :"))})
+	default:
+		respondTemplateError(c, err, "Impossible d'importer la wishlist")
+	}
+}
+
+// exportFilename construit un nom de fichier à partir du titre d'une wishlist
+func exportFilename(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteByte('-')
+		}
+	}
+	name := strings.Trim(b.String(), "-")
+	if name == "" {
+		return "wishlist"
+	}
+	return name
+}
+
+// respondTemplateError traduit les erreurs de duplication, de modèles et de fusion en réponses HTTP
+func respondTemplateError(c *gin.Context, err error, message string) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wishlist non trouvée"})
+		return
+	}
+	if errors.Is(err, primitive.ErrInvalidHex) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de wishlist invalide"})
+		return
+	}
+
+	switch err.Error() {
+	case "wishlist not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wishlist non trouvée"})
+	case "template not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Modèle non trouvé"})
+	case "compte géré introuvable":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Compte géré non trouvé"})
+	case "access denied":
+		c.JSON(http.StatusForbidden, gin.H{"error": "Accès refusé à cette wishlist"})
+	case "nom de modèle invalide":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Le nom du modèle doit faire entre 1 et 80 caractères"})
+	case "impossible de fusionner une wishlist avec elle-même":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Impossible de fusionner une wishlist avec elle-même"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"genie/internal/models"
+	"genie/internal/ratelimit"
+)
+
+// SetRateLimitStore remplace le stockage des compteurs anti-abus (en mémoire par défaut).
+// Avec plusieurs instances du serveur, un stockage partagé (MongoDB) est nécessaire.
+func (s *Service) SetRateLimitStore(store ratelimit.Store) {
+	cfg := s.config.RateLimit
+	s.identifierLimit = ratelimit.NewLimiter(store, "auth:identifier", ratelimit.Rule{
+		Limit:  cfg.PerIdentifier,
+		Window: cfg.PerIdentifierWindow,
+	})
+	s.loginGuard = ratelimit.NewGuard(store, "auth:signin", ratelimit.FailurePolicy{
+		FreeAttempts:     cfg.FreeFailures,
+		BaseDelay:        cfg.FailureBaseDelay,
+		MaxDelay:         cfg.FailureMaxDelay,
+		LockoutThreshold: cfg.LockoutThreshold,
+		LockoutDuration:  cfg.LockoutDuration,
+	})
+}
+
+// limitIdentifier compte une requête de l'action pour un email ou un téléphone.
+// Seul un dépassement de limite est renvoyé : si le stockage est indisponible, la requête passe.
+func (s *Service) limitIdentifier(ctx context.Context, action string, emailOrPhone string) error {
+	_, err := s.identifierLimit.Allow(ctx, action+":"+identifierKey(emailOrPhone))
+	return limitOnly(err)
+}
+
+// checkLogin refuse une connexion pendant le délai imposé après des échecs, ou si le compte est verrouillé
+func (s *Service) checkLogin(ctx context.Context, emailOrPhone string) error {
+	return limitOnly(s.loginGuard.Check(ctx, identifierKey(emailOrPhone)))
+}
+
+// recordLogin enregistre le résultat d'une tentative de connexion par mot de passe
+func (s *Service) recordLogin(ctx context.Context, emailOrPhone string, success bool) {
+	key := identifierKey(emailOrPhone)
+	var err error
+	if success {
+		err = s.loginGuard.Succeed(ctx, key)
+	} else {
+		err = s.loginGuard.Fail(ctx, key)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de l'enregistrement d'une tentative de connexion")
+	}
+}
+
+// checkResetCode vérifie un code de réinitialisation. Au-delà de ResetCodeMaxAttempts erreurs,
+// le code est invalidé et un nouveau code doit être demandé.
+func (s *Service) checkResetCode(ctx context.Context, user *models.User, code string) bool {
+	if user.ResetToken == "" || user.ResetTokenExpires.Before(time.Now()) ||
+		user.ResetAttempts >= s.config.RateLimit.ResetCodeMaxAttempts {
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(user.ResetToken), []byte(strings.TrimSpace(code))) == 1 {
+		return true
+	}
+
+	update := bson.M{"$inc": bson.M{"resetAttempts": 1}}
+	if user.ResetAttempts+1 >= s.config.RateLimit.ResetCodeMaxAttempts {
+		update = bson.M{"$set": bson.M{"resetToken": "", "resetTokenExpires": time.Time{}, "resetAttempts": 0}}
+	}
+	if _, err := s.db.Users.UpdateOne(ctx, bson.M{"_id": user.ID, "resetToken": user.ResetToken}, update); err != nil {
+		log.Error().Err(err).Msg("Erreur lors de l'enregistrement d'un code de réinitialisation erroné")
+	}
+	return false
+}
+
+// identifierKey normalise un email ou un téléphone pour les compteurs
+func identifierKey(emailOrPhone string) string {
+	if isEmail(emailOrPhone) {
+		return strings.ToLower(strings.TrimSpace(emailOrPhone))
+	}
+	return normalizePhone(emailOrPhone)
+}
+
+// limitOnly ne garde que les dépassements de limite ; les autres erreurs sont journalisées
+func limitOnly(err error) error {
+	var limitErr *ratelimit.LimitError
+	if errors.As(err, &limitErr) {
+		return err
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Limiteur anti-abus indisponible")
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwksMinRefreshInterval limite les rechargements provoqués par des identifiants de clé inconnus
+const jwksMinRefreshInterval = time.Minute
+
+// jwksMaxBodyBytes est la taille maximale d'un document JWKS
+const jwksMaxBodyBytes = 1 << 20
+
+// rsaMinModulusBits est la taille minimale acceptée pour une clé RSA publiée
+const rsaMinModulusBits = 2048
+
+// jwksCache garde en mémoire les clés publiques publiées par un fournisseur.
+// Les clés sont rechargées à l'expiration du cache, ou plus tôt lorsqu'un jeton
+// est signé par une clé inconnue (rotation des clés du fournisseur).
+type jwksCache struct {
+	url    string
+	client *http.Client
+	ttl    time.Duration
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	expiresAt   time.Time
+	refreshedAt time.Time
+}
+
+// newJWKSCache crée un cache pour le document JWKS publié à l'URL donnée
+func newJWKSCache(url string, client *http.Client, ttl time.Duration) *jwksCache {
+	return &jwksCache{url: url, client: client, ttl: ttl}
+}
+
+// key renvoie la clé publique d'identifiant kid
+func (c *jwksCache) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if key, found := c.keys[kid]; found && now.Before(c.expiresAt) {
+		return key, nil
+	}
+
+	// Clé inconnue ou cache expiré : recharger, sans solliciter le fournisseur à chaque jeton invalide
+	if c.keys == nil || now.After(c.expiresAt) || now.Sub(c.refreshedAt) >= jwksMinRefreshInterval {
+		if err := c.refresh(ctx, now); err != nil {
+			// Les clés déjà connues restent utilisables si le fournisseur est indisponible
+			if key, found := c.keys[kid]; found {
+				return key, nil
+			}
+			return nil, err
+		}
+	}
+
+	key, found := c.keys[kid]
+	if !found {
+		return nil, fmt.Errorf("clé de signature inconnue: %s", kid)
+	}
+	return key, nil
+}
+
+// refresh recharge le document JWKS. Appelé verrou pris.
+func (c *jwksCache) refresh(ctx context.Context, now time.Time) error {
+	c.refreshedAt = now
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("récupération des clés du fournisseur: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("récupération des clés du fournisseur: statut %d", resp.StatusCode)
+	}
+
+	var document struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, jwksMaxBodyBytes)).Decode(&document); err != nil {
+		return fmt.Errorf("document JWKS illisible: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Kty != "RSA" || jwk.Kid == "" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := rsaPublicKey(jwk.N, jwk.E)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return errors.New("aucune clé de signature dans le document JWKS")
+	}
+
+	c.keys = keys
+	c.expiresAt = now.Add(cacheMaxAge(resp.Header.Get("Cache-Control"), c.ttl))
+	return nil
+}
+
+// rsaPublicKey construit une clé RSA à partir de son module et de son exposant en base64url
+func rsaPublicKey(n string, e string) (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, err
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, err
+	}
+	if len(modulus) == 0 || len(exponent) == 0 || len(exponent) > 4 {
+		return nil, errors.New("clé RSA invalide")
+	}
+
+	key := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}
+	if key.N.BitLen() < rsaMinModulusBits {
+		return nil, fmt.Errorf("clé RSA trop courte: %d bits", key.N.BitLen())
+	}
+	if key.E < 3 || key.E%2 == 0 {
+		return nil, errors.New("exposant RSA invalide")
+	}
+	return key, nil
+}
+
+// cacheMaxAge renvoie la durée de cache annoncée par un en-tête Cache-Control, ou fallback
+func cacheMaxAge(header string, fallback time.Duration) time.Duration {
+	for _, directive := range strings.Split(header, ",") {
+		directive = strings.TrimSpace(directive)
+		if !strings.HasPrefix(directive, "max-age=") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+		if err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,460 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"genie/internal/models"
+)
+
+// Erreurs des passkeys
+var (
+	ErrPasskeysDisabled        = errors.New("passkeys non configurées")
+	ErrPasskeyChallengeExpired = errors.New("demande de passkey expirée, veuillez recommencer")
+	ErrPasskeyNotFound         = errors.New("passkey introuvable")
+	ErrPasskeyCloned           = errors.New("compteur de signatures incohérent, passkey peut-être clonée")
+)
+
+// Cérémonies WebAuthn, telles qu'indiquées dans clientDataJSON
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+)
+
+// passkeyChallengesCollection conserve les cérémonies en cours ; la connexion se fait sans
+// connaître l'utilisateur à l'avance, le défi ne peut donc pas être rangé sur son document
+const passkeyChallengesCollection = "passkey_challenges"
+
+// passkeyChallenge est un défi WebAuthn en attente de la réponse de l'authentificateur
+type passkeyChallenge struct {
+	Hash      string             `bson:"_id"` // condensé du défi
+	Ceremony  string             `bson:"ceremony"`
+	UserID    primitive.ObjectID `bson:"userId,omitempty"` // enregistrement uniquement
+	ExpiresAt time.Time          `bson:"expiresAt"`
+}
+
+// newPasskeyChallenges renvoie la collection des défis en cours et crée son index d'expiration
+func newPasskeyChallenges(database *mongo.Database) *mongo.Collection {
+	collection := database.Collection(passkeyChallengesCollection)
+
+	ttlIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	if _, err := collection.Indexes().CreateOne(context.Background(), ttlIndex); err != nil {
+		log.Warn().Err(err).Msg("Impossible de créer l'index d'expiration des défis de passkey")
+	}
+	return collection
+}
+
+// BeginPasskeyRegistration prépare l'enregistrement d'une passkey pour un utilisateur connecté
+func (s *Service) BeginPasskeyRegistration(ctx context.Context, userID string) (*models.PasskeyCreationOptions, error) {
+	if !s.passkeysEnabled() {
+		return nil, ErrPasskeysDisabled
+	}
+
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.startPasskeyChallenge(ctx, ceremonyCreate, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Un authentificateur ne peut enregistrer qu'une passkey par compte
+	exclude := []models.PasskeyCredentialDescriptor{}
+	for _, passkey := range user.Passkeys {
+		exclude = append(exclude, models.PasskeyCredentialDescriptor{
+			Type:       "public-key",
+			ID:         passkey.CredentialID,
+			Transports: passkey.Transports,
+		})
+	}
+
+	name := user.Email
+	if name == "" {
+		name = user.Phone
+	}
+	security := s.config.Security
+	return &models.PasskeyCreationOptions{
+		Challenge: challenge,
+		RP:        models.PasskeyRelyingParty{ID: security.WebAuthnRPID, Name: security.WebAuthnRPName},
+		User: models.PasskeyUserEntity{
+			ID:          userHandle(user.ID),
+			Name:        name,
+			DisplayName: strings.TrimSpace(user.FirstName + " " + user.LastName),
+		},
+		PubKeyCredParams: []models.PasskeyCredentialParam{
+			{Type: "public-key", Alg: coseAlgES256},
+			{Type: "public-key", Alg: coseAlgEdDSA},
+			{Type: "public-key", Alg: coseAlgRS256},
+		},
+		Timeout:            security.PasskeyChallengeLifetime.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: models.PasskeyAuthenticatorSelection{
+			ResidentKey:        "required",
+			RequireResidentKey: true,
+			UserVerification:   "required",
+		},
+		Attestation: "none",
+	}, nil
+}
+
+// FinishPasskeyRegistration vérifie la réponse de l'authentificateur et enregistre la passkey
+func (s *Service) FinishPasskeyRegistration(ctx context.Context, userID string, req models.PasskeyRegistrationRequest) (*models.PasskeyResponse, error) {
+	if !s.passkeysEnabled() {
+		return nil, ErrPasskeysDisabled
+	}
+
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("ID utilisateur invalide")
+	}
+
+	clientDataJSON, err := decodeBase64URL(req.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.consumePasskeyChallenge(ctx, clientDataJSON, ceremonyCreate, id); err != nil {
+		return nil, err
+	}
+
+	attestationObject, err := decodeBase64URL(req.Response.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+	authData, err := parseAttestationObject(attestationObject)
+	if err != nil {
+		return nil, err
+	}
+	if err := authData.verify(s.config.Security.WebAuthnRPID); err != nil {
+		return nil, err
+	}
+	rawID, err := decodeBase64URL(req.RawID)
+	if err != nil {
+		return nil, err
+	}
+	if authData.CredentialID == nil || !bytes.Equal(authData.CredentialID, rawID) {
+		return nil, ErrInvalidPasskey
+	}
+	_, alg, err := parseCOSEKey(authData.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "Passkey"
+	}
+	now := time.Now()
+	passkey := models.Passkey{
+		ID:             primitive.NewObjectID(),
+		CredentialID:   base64.RawURLEncoding.EncodeToString(rawID),
+		PublicKey:      authData.PublicKey,
+		Algorithm:      alg,
+		SignCount:      authData.SignCount,
+		AAGUID:         hex.EncodeToString(authData.AAGUID),
+		Transports:     req.Response.Transports,
+		BackupEligible: authData.Flags&authFlagBackupEligible != 0,
+		BackedUp:       authData.Flags&authFlagBackedUp != 0,
+		Name:           name,
+		CreatedAt:      now,
+	}
+
+	result, err := s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$push": bson.M{"passkeys": passkey},
+			"$set":  bson.M{"updatedAt": now},
+		},
+	)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("passkey déjà enregistrée")
+		}
+		log.Error().Err(err).Msg("Erreur lors de l'enregistrement de la passkey")
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, errors.New("utilisateur non trouvé")
+	}
+
+	response := passkeyResponse(passkey)
+	return &response, nil
+}
+
+// BeginPasskeyLogin prépare une connexion par passkey. L'utilisateur n'est pas désigné :
+// l'authentificateur propose les passkeys enregistrées pour le domaine.
+func (s *Service) BeginPasskeyLogin(ctx context.Context) (*models.PasskeyRequestOptions, error) {
+	if !s.passkeysEnabled() {
+		return nil, ErrPasskeysDisabled
+	}
+
+	challenge, err := s.startPasskeyChallenge(ctx, ceremonyGet, primitive.NilObjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	security := s.config.Security
+	return &models.PasskeyRequestOptions{
+		Challenge:        challenge,
+		RPID:             security.WebAuthnRPID,
+		Timeout:          security.PasskeyChallengeLifetime.Milliseconds(),
+		UserVerification: "required",
+	}, nil
+}
+
+// PasskeyLogin authentifie un utilisateur par passkey et délivre ses tokens, comme SignIn.
+// La passkey vérifie déjà l'utilisateur (biométrie ou code de l'appareil) : la double authentification n'est pas demandée.
+func (s *Service) PasskeyLogin(ctx context.Context, req models.PasskeyLoginRequest) (*models.AuthResponse, error) {
+	if !s.passkeysEnabled() {
+		return nil, ErrPasskeysDisabled
+	}
+
+	clientDataJSON, err := decodeBase64URL(req.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.consumePasskeyChallenge(ctx, clientDataJSON, ceremonyGet, primitive.NilObjectID); err != nil {
+		return nil, err
+	}
+
+	rawID, err := decodeBase64URL(req.RawID)
+	if err != nil {
+		return nil, err
+	}
+	credentialID := base64.RawURLEncoding.EncodeToString(rawID)
+
+	var user models.User
+	err = s.db.Users.FindOne(ctx, bson.M{"passkeys.credentialId": credentialID}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrPasskeyNotFound
+		}
+		log.Error().Err(err).Msg("Erreur lors de la recherche de la passkey")
+		return nil, err
+	}
+	passkey := findPasskey(user.Passkeys, func(p models.Passkey) bool { return p.CredentialID == credentialID })
+	if passkey == nil {
+		return nil, ErrPasskeyNotFound
+	}
+	if req.Response.UserHandle != "" && trimBase64Padding(req.Response.UserHandle) != userHandle(user.ID) {
+		return nil, ErrInvalidPasskey
+	}
+
+	rawAuthData, err := decodeBase64URL(req.Response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := authData.verify(s.config.Security.WebAuthnRPID); err != nil {
+		return nil, err
+	}
+	signature, err := decodeBase64URL(req.Response.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyAssertionSignature(passkey.PublicKey, rawAuthData, clientDataJSON, signature); err != nil {
+		return nil, err
+	}
+
+	// Le compteur doit croître, sauf pour les authentificateurs qui n'en tiennent pas (toujours 0)
+	if (authData.SignCount != 0 || passkey.SignCount != 0) && authData.SignCount <= passkey.SignCount {
+		log.Warn().Str("userID", user.ID.Hex()).Str("passkeyID", passkey.ID.Hex()).
+			Uint32("stored", passkey.SignCount).Uint32("received", authData.SignCount).
+			Msg("Compteur de signatures de passkey incohérent")
+		return nil, ErrPasskeyCloned
+	}
+
+	// Mise à jour conditionnelle : deux connexions parallèles ne peuvent pas présenter le même compteur
+	result, err := s.db.Users.UpdateOne(ctx,
+		bson.M{
+			"_id":      user.ID,
+			"passkeys": bson.M{"$elemMatch": bson.M{"id": passkey.ID, "signCount": passkey.SignCount}},
+		},
+		bson.M{"$set": bson.M{
+			"passkeys.$.signCount":  authData.SignCount,
+			"passkeys.$.backedUp":   authData.Flags&authFlagBackedUp != 0,
+			"passkeys.$.lastUsedAt": time.Now(),
+		}},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la mise à jour de la passkey")
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrPasskeyCloned
+	}
+
+	return s.issueTokens(ctx, &user)
+}
+
+// ListPasskeys liste les passkeys d'un utilisateur
+func (s *Service) ListPasskeys(ctx context.Context, userID string) ([]models.PasskeyResponse, error) {
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	passkeys := []models.PasskeyResponse{}
+	for _, passkey := range user.Passkeys {
+		passkeys = append(passkeys, passkeyResponse(passkey))
+	}
+	return passkeys, nil
+}
+
+// RenamePasskey renomme une passkey
+func (s *Service) RenamePasskey(ctx context.Context, userID string, passkeyID string, name string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("ID utilisateur invalide")
+	}
+	pid, err := primitive.ObjectIDFromHex(passkeyID)
+	if err != nil {
+		return ErrPasskeyNotFound
+	}
+
+	result, err := s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": id, "passkeys.id": pid},
+		bson.M{"$set": bson.M{"passkeys.$.name": strings.TrimSpace(name), "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors du renommage de la passkey")
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPasskeyNotFound
+	}
+	return nil
+}
+
+// DeletePasskey supprime une passkey. La dernière passkey d'un compte sans mot de passe
+// ni connexion sociale est conservée, pour ne pas priver l'utilisateur de tout moyen de connexion.
+func (s *Service) DeletePasskey(ctx context.Context, userID string, passkeyID string) error {
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	pid, err := primitive.ObjectIDFromHex(passkeyID)
+	if err != nil {
+		return ErrPasskeyNotFound
+	}
+	if findPasskey(user.Passkeys, func(p models.Passkey) bool { return p.ID == pid }) == nil {
+		return ErrPasskeyNotFound
+	}
+	if user.PasswordHash == "" && len(user.SocialAuth) == 0 && len(user.Passkeys) == 1 {
+		return errors.New("impossible de supprimer le dernier moyen de connexion")
+	}
+
+	result, err := s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{
+			"$pull": bson.M{"passkeys": bson.M{"id": pid}},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la suppression de la passkey")
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return ErrPasskeyNotFound
+	}
+	return nil
+}
+
+// passkeysEnabled indique si le domaine et les origines des passkeys sont configurés
+func (s *Service) passkeysEnabled() bool {
+	return s.config.Security.WebAuthnRPID != "" && len(s.config.Security.WebAuthnOrigins) > 0
+}
+
+// startPasskeyChallenge enregistre un nouveau défi pour une cérémonie
+func (s *Service) startPasskeyChallenge(ctx context.Context, ceremony string, userID primitive.ObjectID) (string, error) {
+	challenge, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.passkeyChallenges.InsertOne(ctx, passkeyChallenge{
+		Hash:      hashSecret(challenge),
+		Ceremony:  ceremony,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(s.config.Security.PasskeyChallengeLifetime),
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de l'enregistrement du défi de passkey")
+		return "", err
+	}
+	return challenge, nil
+}
+
+// consumePasskeyChallenge vérifie clientDataJSON et consomme le défi qu'il contient :
+// un défi ne sert qu'une fois, pour la cérémonie et l'utilisateur pour lesquels il a été émis
+func (s *Service) consumePasskeyChallenge(ctx context.Context, clientDataJSON []byte, ceremony string, userID primitive.ObjectID) (*clientData, error) {
+	data, err := parseClientData(clientDataJSON, ceremony, s.config.Security.WebAuthnOrigins)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"_id":       hashSecret(trimBase64Padding(data.Challenge)),
+		"ceremony":  ceremony,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}
+	if !userID.IsZero() {
+		filter["userId"] = userID
+	}
+
+	var challenge passkeyChallenge
+	if err := s.passkeyChallenges.FindOneAndDelete(ctx, filter).Decode(&challenge); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrPasskeyChallengeExpired
+		}
+		log.Error().Err(err).Msg("Erreur lors de la vérification du défi de passkey")
+		return nil, err
+	}
+	return data, nil
+}
+
+// userHandle est l'identifiant de l'utilisateur transmis à l'authentificateur
+func userHandle(id primitive.ObjectID) string {
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
+
+// findPasskey renvoie la première passkey satisfaisant match
+func findPasskey(passkeys []models.Passkey, match func(models.Passkey) bool) *models.Passkey {
+	for i := range passkeys {
+		if match(passkeys[i]) {
+			return &passkeys[i]
+		}
+	}
+	return nil
+}
+
+// passkeyResponse convertit une passkey pour la réponse
+func passkeyResponse(passkey models.Passkey) models.PasskeyResponse {
+	return models.PasskeyResponse{
+		ID:         passkey.ID.Hex(),
+		Name:       passkey.Name,
+		Transports: passkey.Transports,
+		BackedUp:   passkey.BackedUp,
+		CreatedAt:  passkey.CreatedAt,
+		LastUsedAt: passkey.LastUsedAt,
+	}
+}
@@ -0,0 +1,281 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"genie/internal/models"
+	"genie/internal/utils"
+)
+
+// Erreurs de la connexion sans mot de passe
+var (
+	ErrInvalidPasswordlessCode     = errors.New("code de connexion invalide")
+	ErrPasswordlessExpired         = errors.New("demande de connexion expirée, demandez un nouveau code")
+	ErrTooManyPasswordlessAttempts = errors.New("trop de codes erronés, demandez un nouveau code")
+)
+
+// passwordlessCollection conserve les demandes de connexion sans mot de passe ; le compte
+// pouvant être créé à la première connexion, elles ne sont pas rangées sur le document utilisateur
+const passwordlessCollection = "passwordless_logins"
+
+// passwordlessLogin est une demande de connexion sans mot de passe en attente.
+// Le code ou le lien n'est accepté qu'accompagné du jeton remis à l'appareil qui a fait la demande.
+type passwordlessLogin struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	RequestHash string             `bson:"requestHash"` // condensé du jeton remis à l'appareil demandeur
+	SecretHash  string             `bson:"secretHash"`  // condensé du code SMS ou du jeton du lien
+	Channel     string             `bson:"channel"`
+	Target      string             `bson:"target"` // email ou téléphone normalisé
+	Attempts    int                `bson:"attempts"`
+	ExpiresAt   time.Time          `bson:"expiresAt"`
+}
+
+// newPasswordlessLogins renvoie la collection des demandes en cours et crée ses index
+func newPasswordlessLogins(database *mongo.Database) *mongo.Collection {
+	collection := database.Collection(passwordlessCollection)
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{
+			Keys:    bson.D{{Key: "requestHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "target", Value: 1}},
+		},
+	}
+	if _, err := collection.Indexes().CreateMany(context.Background(), indexes); err != nil {
+		log.Warn().Err(err).Msg("Impossible de créer les index des connexions sans mot de passe")
+	}
+	return collection
+}
+
+// StartPasswordless envoie un code de connexion par SMS, ou un lien de connexion par email.
+// La réponse ne révèle pas si le compte existe : sans compte ni création autorisée, rien n'est envoyé.
+func (s *Service) StartPasswordless(ctx context.Context, req models.PasswordlessStartRequest) (*models.PasswordlessStartResponse, error) {
+	if err := s.limitIdentifier(ctx, "passwordless", req.EmailOrPhone); err != nil {
+		return nil, err
+	}
+
+	channel, target := ChannelPhone, normalizePhone(req.EmailOrPhone)
+	if isEmail(req.EmailOrPhone) {
+		channel, target = ChannelEmail, req.EmailOrPhone
+	} else if len(target) < 8 {
+		return nil, errors.New("format de téléphone invalide")
+	}
+
+	requestToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	lifetime := s.config.Security.PasswordlessLifetime
+	response := &models.PasswordlessStartResponse{
+		RequestToken: requestToken,
+		Channel:      channel,
+		ExpiresIn:    utils.DurationToMilliseconds(lifetime),
+	}
+
+	exists, err := s.db.Users.CountDocuments(ctx, bson.M{contactField(channel): target})
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la recherche de l'utilisateur pour connexion sans mot de passe")
+		return nil, err
+	}
+	if exists == 0 && !s.config.Security.PasswordlessSignup {
+		return response, nil
+	}
+
+	// Code à 6 chiffres par SMS, jeton long dans le lien envoyé par email
+	var secret string
+	if channel == ChannelPhone {
+		secret, err = randomDigits(verifyCodeDigits)
+	} else {
+		secret, err = randomToken()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	login := passwordlessLogin{
+		ID:          primitive.NewObjectID(),
+		RequestHash: hashSecret(requestToken),
+		Channel:     channel,
+		Target:      target,
+		ExpiresAt:   time.Now().Add(lifetime),
+	}
+	login.SecretHash = hashSecret(login.ID.Hex() + ":" + secret)
+
+	// Une seule demande en attente par destinataire : la nouvelle remplace les précédentes
+	if _, err := s.passwordlessLogins.DeleteMany(ctx, bson.M{"target": target}); err != nil {
+		log.Error().Err(err).Msg("Erreur lors du remplacement des demandes de connexion sans mot de passe")
+		return nil, err
+	}
+	if _, err := s.passwordlessLogins.InsertOne(ctx, login); err != nil {
+		log.Error().Err(err).Msg("Erreur lors de l'enregistrement de la demande de connexion sans mot de passe")
+		return nil, err
+	}
+
+	if channel == ChannelEmail && s.emailService != nil {
+		err = s.emailService.SendMagicLink(target, s.magicLink(secret), lifetime)
+	} else if channel == ChannelPhone && s.smsService != nil {
+		err = s.smsService.SendLoginCode(target, secret, lifetime)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("channel", channel).Msg("Erreur lors de l'envoi du code de connexion")
+		return nil, errors.New("impossible d'envoyer le code de connexion")
+	}
+
+	return response, nil
+}
+
+// CompletePasswordless échange le code reçu, ou le jeton du lien, contre les tokens, comme SignIn.
+// Chaque demande ne sert qu'une fois ; après PasswordlessMaxAttempts codes erronés, elle est annulée.
+func (s *Service) CompletePasswordless(ctx context.Context, req models.PasswordlessVerifyRequest) (*models.AuthResponse, error) {
+	var login passwordlessLogin
+	err := s.passwordlessLogins.FindOne(ctx, bson.M{
+		"requestHash": hashSecret(req.RequestToken),
+		"expiresAt":   bson.M{"$gt": time.Now()},
+	}).Decode(&login)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrPasswordlessExpired
+		}
+		log.Error().Err(err).Msg("Erreur lors de la recherche de la demande de connexion sans mot de passe")
+		return nil, err
+	}
+
+	secret := strings.TrimSpace(req.Code)
+	if subtle.ConstantTimeCompare([]byte(hashSecret(login.ID.Hex()+":"+secret)), []byte(login.SecretHash)) != 1 {
+		return nil, s.recordFailedPasswordlessAttempt(ctx, &login)
+	}
+
+	// Consommer la demande : deux échanges parallèles du même code ne peuvent pas réussir tous les deux
+	err = s.passwordlessLogins.FindOneAndDelete(ctx, bson.M{
+		"_id":      login.ID,
+		"attempts": bson.M{"$lt": s.config.Security.PasswordlessMaxAttempts},
+	}).Err()
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrPasswordlessExpired
+		}
+		log.Error().Err(err).Msg("Erreur lors de la clôture de la demande de connexion sans mot de passe")
+		return nil, err
+	}
+
+	user, err := s.passwordlessUser(ctx, &login)
+	if err != nil {
+		return nil, err
+	}
+
+	// Le code prouve seulement l'accès à l'email ou au téléphone : la double authentification reste exigée
+	if user.IsTwoFactorEnabled {
+		return s.startMFAChallenge(ctx, user)
+	}
+	return s.issueTokens(ctx, user)
+}
+
+// recordFailedPasswordlessAttempt compte un code erroné et annule la demande au-delà de la limite.
+// Renvoie l'erreur à présenter à l'utilisateur.
+func (s *Service) recordFailedPasswordlessAttempt(ctx context.Context, login *passwordlessLogin) error {
+	var updated passwordlessLogin
+	err := s.passwordlessLogins.FindOneAndUpdate(ctx,
+		bson.M{"_id": login.ID},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Error().Err(err).Msg("Erreur lors de l'enregistrement d'un code de connexion erroné")
+		}
+		return ErrInvalidPasswordlessCode
+	}
+
+	if updated.Attempts >= s.config.Security.PasswordlessMaxAttempts {
+		if _, err := s.passwordlessLogins.DeleteOne(ctx, bson.M{"_id": login.ID}); err != nil {
+			log.Error().Err(err).Msg("Erreur lors de l'annulation de la demande de connexion sans mot de passe")
+		}
+		return ErrTooManyPasswordlessAttempts
+	}
+	return ErrInvalidPasswordlessCode
+}
+
+// passwordlessUser renvoie le compte de l'email ou du téléphone prouvé, en le créant si la configuration l'autorise.
+// Un compte créé ainsi n'a pas de mot de passe ; ses coordonnées sont vérifiées.
+func (s *Service) passwordlessUser(ctx context.Context, login *passwordlessLogin) (*models.User, error) {
+	field := contactField(login.Channel)
+
+	var user models.User
+	err := s.db.Users.FindOne(ctx, bson.M{field: login.Target}).Decode(&user)
+	if err == nil {
+		return &user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		log.Error().Err(err).Msg("Erreur lors de la recherche de l'utilisateur pour connexion sans mot de passe")
+		return nil, err
+	}
+	if !s.config.Security.PasswordlessSignup {
+		return nil, ErrPasswordlessExpired
+	}
+
+	now := time.Now()
+	user = models.User{
+		ID:              primitive.NewObjectID(),
+		IsVerified:      true,
+		ManagedAccounts: []primitive.ObjectID{},
+		RefreshTokens:   []models.RefreshToken{},
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		LastLoginAt:     now,
+	}
+	if login.Channel == ChannelEmail {
+		user.Email = login.Target
+	} else {
+		user.Phone = login.Target
+	}
+
+	if _, err := s.db.Users.InsertOne(ctx, user); err != nil {
+		// Compte créé entre-temps par une autre requête
+		if mongo.IsDuplicateKeyError(err) {
+			if err := s.db.Users.FindOne(ctx, bson.M{field: login.Target}).Decode(&user); err != nil {
+				return nil, err
+			}
+			return &user, nil
+		}
+		log.Error().Err(err).Msg("Erreur lors de la création du compte sans mot de passe")
+		return nil, err
+	}
+
+	log.Info().Str("userID", user.ID.Hex()).Str("channel", login.Channel).Msg("Compte créé par connexion sans mot de passe")
+	return &user, nil
+}
+
+// magicLink construit le lien de connexion envoyé par email
+func (s *Service) magicLink(token string) string {
+	link := s.config.Security.MagicLinkURL
+	separator := "?"
+	if strings.Contains(link, "?") {
+		separator = "&"
+	}
+	return link + separator + "token=" + url.QueryEscape(token)
+}
+
+// contactField renvoie le champ du document utilisateur qui porte l'email ou le téléphone
+func contactField(channel string) string {
+	if channel == ChannelEmail {
+		return "email"
+	}
+	return "phone"
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"genie/internal/models"
+)
+
+// Erreurs d'accès au compte
+var (
+	ErrAccountSuspended      = errors.New("compte suspendu")
+	ErrPasswordResetRequired = errors.New("réinitialisation du mot de passe requise, un code vous a été envoyé")
+)
+
+// checkAccountAccess refuse la délivrance de tokens à un compte suspendu
+func checkAccountAccess(user *models.User) error {
+	if user.SuspendedAt != nil {
+		return ErrAccountSuspended
+	}
+	return nil
+}
+
+// scopesFor renvoie les scopes du back-office accordés par les rôles de l'utilisateur.
+// Les utilisateurs de Security.AdminUserIDs ont le rôle admin sans qu'il soit enregistré,
+// ce qui permet de désigner les premiers administrateurs.
+func (s *Service) scopesFor(user *models.User) []string {
+	if user.SuspendedAt != nil {
+		return nil
+	}
+	roles := user.Roles
+	for _, id := range s.config.Security.AdminUserIDs {
+		if id == user.ID.Hex() {
+			roles = append([]string{models.RoleAdmin}, roles...)
+			break
+		}
+	}
+	return models.ScopesForRoles(roles)
+}
+
+// IsSuspended indique si un compte est suspendu
+func (s *Service) IsSuspended(ctx context.Context, userID string) (bool, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, errors.New("ID utilisateur invalide")
+	}
+
+	var user models.User
+	err = s.db.Users.FindOne(ctx, bson.M{"_id": id}, options.FindOne().SetProjection(bson.M{"suspendedAt": 1})).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, nil
+		}
+		return false, err
+	}
+	return user.SuspendedAt != nil, nil
+}
+
+// Scopes renvoie les scopes du back-office que les rôles actuels de l'utilisateur accordent
+func (s *Service) Scopes(ctx context.Context, userID string) ([]string, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("ID utilisateur invalide")
+	}
+
+	var user models.User
+	projection := bson.M{"roles": 1, "suspendedAt": 1}
+	err = s.db.Users.FindOne(ctx, bson.M{"_id": id}, options.FindOne().SetProjection(projection)).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return s.scopesFor(&user), nil
+}
+
+// ForcePasswordReset oblige un utilisateur à changer de mot de passe : ses sessions sont révoquées,
+// la connexion par mot de passe est refusée et un code de réinitialisation lui est envoyé
+func (s *Service) ForcePasswordReset(ctx context.Context, userID string) error {
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{"$set": bson.M{
+			"passwordResetRequired": true,
+			"refreshTokens":         []models.RefreshToken{},
+			"updatedAt":             time.Now(),
+		}},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la réinitialisation forcée du mot de passe")
+		return err
+	}
+
+	target := user.Email
+	if target == "" {
+		target = user.Phone
+	}
+	if target == "" {
+		return nil
+	}
+	return s.sendResetCode(ctx, user, target)
+}
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"math/rand"
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
@@ -18,6 +17,7 @@ import (
 	"genie/internal/db"
 	"genie/internal/middleware"
 	"genie/internal/models"
+	"genie/internal/ratelimit"
 	"genie/internal/utils"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -39,17 +39,35 @@ type Service struct {
 	emailService *utils.EmailService
 	smsService   *utils.SMSService
 	config       *config.Config
+	verifiers    map[string]TokenVerifier // vérificateurs des jetons de connexion sociale, par fournisseur
+
+	passkeyChallenges  *mongo.Collection // cérémonies WebAuthn en cours
+	passwordlessLogins *mongo.Collection // demandes de connexion sans mot de passe en cours
+
+	identifierLimit *ratelimit.Limiter // requêtes par email ou téléphone
+	loginGuard      *ratelimit.Guard   // délais et verrouillage après des échecs de connexion
 }
 
 // NewService crée une nouvelle instance du service d'authentification
 func NewService(database *db.Database, jwtService *middleware.JWTService, emailService *utils.EmailService, smsService *utils.SMSService, cfg *config.Config) *Service {
-	return &Service{
+	s := &Service{
 		db:           database,
 		jwtService:   jwtService,
 		emailService: emailService,
 		smsService:   smsService,
 		config:       cfg,
+		verifiers:    newSocialVerifiers(cfg.Social),
+
+		passkeyChallenges:  newPasskeyChallenges(database.DB),
+		passwordlessLogins: newPasswordlessLogins(database.DB),
 	}
+	s.SetRateLimitStore(ratelimit.NewMemoryStore())
+	return s
+}
+
+// SetTokenVerifier remplace le vérificateur des jetons d'un fournisseur de connexion sociale
+func (s *Service) SetTokenVerifier(provider string, verifier TokenVerifier) {
+	s.verifiers[provider] = verifier
 }
 
 // CheckUserExists vérifie si un utilisateur existe avec l'email ou le téléphone fourni
@@ -75,6 +93,14 @@ func (s *Service) CheckUserExists(ctx context.Context, emailOrPhone string) (boo
 
 // SignIn authentifie un utilisateur et retourne les tokens d'accès et de rafraîchissement
 func (s *Service) SignIn(ctx context.Context, req models.SignInRequest) (*models.AuthResponse, error) {
+	// Délais croissants puis verrouillage temporaire après des échecs répétés
+	if err := s.checkLogin(ctx, req.EmailOrPhone); err != nil {
+		return nil, err
+	}
+	if err := s.limitIdentifier(ctx, "signin", req.EmailOrPhone); err != nil {
+		return nil, err
+	}
+
 	// Chercher l'utilisateur par email ou téléphone
 	var filter bson.M
 	if isEmail(req.EmailOrPhone) {
@@ -87,6 +113,8 @@ func (s *Service) SignIn(ctx context.Context, req models.SignInRequest) (*models
 	err := s.db.Users.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
+			// Compté comme un échec pour ne pas distinguer un compte inexistant
+			s.recordLogin(ctx, req.EmailOrPhone, false)
 			return nil, errors.New("identifiants invalides")
 		}
 		log.Error().Err(err).Msg("Erreur lors de la recherche de l'utilisateur")
@@ -96,58 +124,25 @@ func (s *Service) SignIn(ctx context.Context, req models.SignInRequest) (*models
 	// Vérifier le mot de passe
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password))
 	if err != nil {
+		s.recordLogin(ctx, req.EmailOrPhone, false)
 		return nil, errors.New("identifiants invalides")
 	}
+	s.recordLogin(ctx, req.EmailOrPhone, true)
 
-	// Générer les tokens
-	accessToken, err := s.jwtService.GenerateAccessToken(user.ID.Hex())
-	if err != nil {
-		log.Error().Err(err).Msg("Erreur lors de la génération du token d'accès")
+	if err := checkAccountAccess(&user); err != nil {
 		return nil, err
 	}
-
-	refreshToken, err := s.jwtService.GenerateRefreshToken(user.ID.Hex())
-	if err != nil {
-		log.Error().Err(err).Msg("Erreur lors de la génération du token de rafraîchissement")
-		return nil, err
+	// Mot de passe possiblement compromis : il doit d'abord être réinitialisé
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
 	}
 
-	// Mettre à jour la dernière connexion et enregistrer le token de rafraîchissement
-	now := time.Now()
-	expiresAt := now.Add(s.config.JWT.RefreshExpiryTime)
-
-	newRefreshToken := models.RefreshToken{
-		Token:     refreshToken,
-		ExpiresAt: expiresAt,
-		IssuedAt:  now,
-	}
-
-	// Mise à jour de l'utilisateur
-	_, err = s.db.Users.UpdateOne(
-		ctx,
-		bson.M{"_id": user.ID},
-		bson.M{
-			"$set": bson.M{
-				"lastLoginAt": now,
-				"updatedAt":   now,
-			},
-			"$push": bson.M{
-				"refreshTokens": newRefreshToken,
-			},
-		},
-	)
-	if err != nil {
-		log.Error().Err(err).Msg("Erreur lors de la mise à jour de l'utilisateur après connexion")
-		return nil, err
+	// Avec la double authentification, les tokens ne sont délivrés qu'après le code (VerifyMFA)
+	if user.IsTwoFactorEnabled {
+		return s.startMFAChallenge(ctx, &user)
 	}
 
-	// Préparer la réponse
-	return &models.AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    utils.DurationToMilliseconds(s.config.JWT.AccessExpiryTime),
-		User:         user.ToResponse(),
-	}, nil
+	return s.issueTokens(ctx, &user)
 }
 
 // SignUp crée un nouvel utilisateur
@@ -238,6 +233,15 @@ func (s *Service) SignUp(ctx context.Context, req models.SignUpRequest) (*models
 		return nil, err
 	}
 
+	// Envoyer les codes de vérification des coordonnées fournies ; le compte reste non vérifié d'ici là
+	for _, channel := range []string{ChannelEmail, ChannelPhone} {
+		if target := contactOf(&newUser, channel); target != "" {
+			if err := s.startVerification(ctx, &newUser, channel, target, true); err != nil {
+				log.Warn().Err(err).Str("channel", channel).Msg("Code de vérification non envoyé à l'inscription")
+			}
+		}
+	}
+
 	// Envoyer un email ou SMS de bienvenue si configuré
 	if req.Email != "" && s.emailService != nil {
 		go s.emailService.SendWelcomeEmail(req.Email, req.FirstName)
@@ -245,41 +249,12 @@ func (s *Service) SignUp(ctx context.Context, req models.SignUpRequest) (*models
 		go s.smsService.SendWelcomeSMS(req.Phone, req.FirstName)
 	}
 
-	// Générer les tokens
-	accessToken, err := s.jwtService.GenerateAccessToken(newUser.ID.Hex())
-	if err != nil {
-		log.Error().Err(err).Msg("Erreur lors de la génération du token d'accès")
-		return nil, err
-	}
-
-	refreshToken, err := s.jwtService.GenerateRefreshToken(newUser.ID.Hex())
+	// Ouvrir la première session
+	response, err := s.issueTokens(ctx, &newUser)
 	if err != nil {
-		log.Error().Err(err).Msg("Erreur lors de la génération du token de rafraîchissement")
 		return nil, err
 	}
 
-	// Enregistrer le token de rafraîchissement
-	expiresAt := now.Add(s.config.JWT.RefreshExpiryTime)
-	newRefreshToken := models.RefreshToken{
-		Token:     refreshToken,
-		ExpiresAt: expiresAt,
-		IssuedAt:  now,
-	}
-
-	_, err = s.db.Users.UpdateOne(
-		ctx,
-		bson.M{"_id": newUser.ID},
-		bson.M{
-			"$push": bson.M{
-				"refreshTokens": newRefreshToken,
-			},
-		},
-	)
-	if err != nil {
-		log.Error().Err(err).Msg("Erreur lors de l'enregistrement du token de rafraîchissement")
-		// On continue quand même car l'utilisateur est créé
-	}
-
 	// Log détaillé de l'inscription utilisateur dans le terminal avec fmt.Println pour plus de visibilité
 	fmt.Print("\n\n")
 	fmt.Println("====================================================")
@@ -563,12 +538,8 @@ func (s *Service) SignUp(ctx context.Context, req models.SignUpRequest) (*models
 		Bool("Vérifié", newUser.IsVerified).Msg("NOUVELLE INSCRIPTION UTILISATEUR")
 
 	// Préparer la réponse
-	return &models.AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    utils.DurationToMilliseconds(s.config.JWT.AccessExpiryTime),
-		User:         newUser.ToResponse(),
-	}, nil
+	response.User = newUser.ToResponse()
+	return response, nil
 }
 
 // SocialLogin gère l'authentification par réseau social
@@ -578,12 +549,34 @@ func (s *Service) SocialLogin(ctx context.Context, req models.SocialLoginRequest
 		return nil, fmt.Errorf("fournisseur social non pris en charge: %s", req.Provider)
 	}
 
-	// Note: Dans une implémentation réelle, il faudrait vérifier le token avec l'API du fournisseur
-	// Pour ce prototype, nous supposons que le token est valide et que les informations fournies sont correctes
+	// Vérifier le jeton d'identité auprès du fournisseur
+	verifier, found := s.verifiers[req.Provider]
+	if !found {
+		return nil, fmt.Errorf("connexion %s non disponible", req.Provider)
+	}
+	identity, err := verifier.Verify(ctx, req.Token, req.Nonce)
+	if err != nil {
+		log.Warn().Err(err).Str("provider", req.Provider).Msg("Jeton de connexion sociale refusé")
+		return nil, ErrInvalidSocialToken
+	}
+
+	// L'identifiant et l'email proviennent uniquement du jeton vérifié ; le nom et l'avatar
+	// envoyés par l'application complètent le profil (Apple ne les met pas dans le jeton)
+	req.SocialID = identity.Subject
+	req.Email = identity.Email
+	if identity.FirstName != "" {
+		req.FirstName = identity.FirstName
+	}
+	if identity.LastName != "" {
+		req.LastName = identity.LastName
+	}
+	if identity.AvatarURL != "" {
+		req.AvatarURL = identity.AvatarURL
+	}
 
 	// Chercher un utilisateur existant avec ce compte social
 	var user models.User
-	err := s.db.Users.FindOne(ctx, bson.M{
+	err = s.db.Users.FindOne(ctx, bson.M{
 		"socialAuth": bson.M{
 			"$elemMatch": bson.M{
 				"provider": req.Provider,
@@ -862,140 +855,40 @@ func (s *Service) SocialLogin(ctx context.Context, req models.SocialLoginRequest
 		}
 	}
 
-	// Générer les tokens
-	accessToken, err := s.jwtService.GenerateAccessToken(user.ID.Hex())
-	if err != nil {
-		log.Error().Err(err).Msg("Erreur lors de la génération du token d'accès")
-		return nil, err
-	}
-
-	refreshToken, err := s.jwtService.GenerateRefreshToken(user.ID.Hex())
-	if err != nil {
-		log.Error().Err(err).Msg("Erreur lors de la génération du token de rafraîchissement")
-		return nil, err
-	}
-
-	// Enregistrer le token de rafraîchissement
-	expiresAt := now.Add(s.config.JWT.RefreshExpiryTime)
-	newRefreshToken := models.RefreshToken{
-		Token:     refreshToken,
-		ExpiresAt: expiresAt,
-		IssuedAt:  now,
-	}
-
-	_, err = s.db.Users.UpdateOne(
-		ctx,
-		bson.M{"_id": user.ID},
-		bson.M{
-			"$push": bson.M{
-				"refreshTokens": newRefreshToken,
-			},
-		},
-	)
-	if err != nil {
-		log.Error().Err(err).Msg("Erreur lors de l'enregistrement du token de rafraîchissement")
-		// Continuer quand même car l'utilisateur est authentifié
+	if user.IsTwoFactorEnabled {
+		return s.startMFAChallenge(ctx, &user)
 	}
 
-	// Préparer la réponse
-	return &models.AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    utils.DurationToMilliseconds(s.config.JWT.AccessExpiryTime),
-		User:         user.ToResponse(),
-	}, nil
+	return s.issueTokens(ctx, &user)
 }
 
-// RefreshToken rafraîchit un token d'authentification
-func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (*models.AuthResponse, error) {
-	// Vérifier le token
-	claims, err := s.jwtService.VerifyRefreshToken(refreshToken)
-	if err != nil {
-		return nil, errors.New("token de rafraîchissement invalide")
-	}
-
-	userID, err := primitive.ObjectIDFromHex(claims.UserID)
-	if err != nil {
-		return nil, errors.New("ID utilisateur invalide dans le token")
-	}
-
-	// Vérifier si le token existe dans la base de données
-	var user models.User
-	err = s.db.Users.FindOne(ctx, bson.M{
-		"_id": userID,
-		"refreshTokens": bson.M{
-			"$elemMatch": bson.M{
-				"token": refreshToken,
-				"expiresAt": bson.M{
-					"$gt": time.Now(),
-				},
-			},
-		},
-	}).Decode(&user)
-
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("token de rafraîchissement invalide ou expiré")
-		}
-		log.Error().Err(err).Msg("Erreur lors de la vérification du token de rafraîchissement")
-		return nil, err
-	}
-
-	// Générer un nouveau token d'accès
-	accessToken, err := s.jwtService.GenerateAccessToken(user.ID.Hex())
-	if err != nil {
-		log.Error().Err(err).Msg("Erreur lors de la génération du nouveau token d'accès")
-		return nil, err
+// SignOut déconnecte un utilisateur en révoquant la session du token de rafraîchissement fourni,
+// ou toutes ses sessions si aucun token n'est fourni
+func (s *Service) SignOut(ctx context.Context, userID string, refreshToken string) error {
+	if refreshToken == "" {
+		log.Info().Str("userID", userID).Msg("Déconnexion de toutes les sessions (refresh token non fourni)")
+		return s.RevokeAllSessions(ctx, userID, "")
 	}
 
-	// Préparer la réponse
-	return &models.AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken, // Conserver le même token de rafraîchissement
-		ExpiresIn:    utils.DurationToMilliseconds(s.config.JWT.AccessExpiryTime),
-		User:         user.ToResponse(),
-	}, nil
-}
-
-// SignOut déconnecte un utilisateur en révoquant son token de rafraîchissement
-func (s *Service) SignOut(ctx context.Context, userID string, refreshToken string) error {
 	id, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return errors.New("ID utilisateur invalide")
 	}
 
-	// Vérifier si un token de rafraîchissement spécifique a été fourni
-	if refreshToken == "" {
-		// Si aucun token spécifique n'est fourni, déconnecter toutes les sessions
-		// en supprimant tous les tokens de rafraîchissement
-		log.Info().Str("userID", userID).Msg("Déconnexion de toutes les sessions (refresh token non fourni)")
-
-		_, err = s.db.Users.UpdateOne(
-			ctx,
-			bson.M{"_id": id},
-			bson.M{
-				"$set": bson.M{
-					"refreshTokens": []models.RefreshToken{},
-				},
-			},
-		)
-	} else {
-		// Supprimer uniquement le token de rafraîchissement spécifié
-		log.Info().Str("userID", userID).Str("token", refreshToken[:10]+"...").Msg("Déconnexion d'une session spécifique")
-
+	// Supprimer uniquement la session du token fourni, ou l'ancien token en clair
+	log.Info().Str("userID", userID).Msg("Déconnexion d'une session spécifique")
+	result, err := s.db.Users.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$pull": bson.M{"refreshTokens": bson.M{"tokenHash": hashSecret(refreshToken)}}},
+	)
+	if err == nil && result.ModifiedCount == 0 {
 		_, err = s.db.Users.UpdateOne(
 			ctx,
 			bson.M{"_id": id},
-			bson.M{
-				"$pull": bson.M{
-					"refreshTokens": bson.M{
-						"token": refreshToken,
-					},
-				},
-			},
+			bson.M{"$pull": bson.M{"refreshTokens": bson.M{"token": refreshToken}}},
 		)
 	}
-
 	if err != nil {
 		log.Error().Err(err).Msg("Erreur lors de la suppression du token de rafraîchissement")
 		return err
@@ -1006,6 +899,10 @@ func (s *Service) SignOut(ctx context.Context, userID string, refreshToken strin
 
 // RequestPasswordReset initie une demande de réinitialisation de mot de passe
 func (s *Service) RequestPasswordReset(ctx context.Context, emailOrPhone string) error {
+	if err := s.limitIdentifier(ctx, "reset", emailOrPhone); err != nil {
+		return err
+	}
+
 	// Trouver l'utilisateur
 	var filter bson.M
 	var user models.User
@@ -1026,8 +923,16 @@ func (s *Service) RequestPasswordReset(ctx context.Context, emailOrPhone string)
 		return err
 	}
 
+	return s.sendResetCode(ctx, &user, emailOrPhone)
+}
+
+// sendResetCode génère un code de réinitialisation et l'envoie à l'email ou au téléphone target
+func (s *Service) sendResetCode(ctx context.Context, user *models.User, target string) error {
 	// Générer un code de réinitialisation (6 chiffres)
-	resetCode := generateRandomCode(6)
+	resetCode, err := randomDigits(6)
+	if err != nil {
+		return err
+	}
 
 	// Calculer l'expiration
 	expiresAt := time.Now().Add(s.config.Security.ResetTokenLifetime)
@@ -1040,6 +945,7 @@ func (s *Service) RequestPasswordReset(ctx context.Context, emailOrPhone string)
 			"$set": bson.M{
 				"resetToken":        resetCode,
 				"resetTokenExpires": expiresAt,
+				"resetAttempts":     0,
 				"updatedAt":         time.Now(),
 			},
 		},
@@ -1050,10 +956,10 @@ func (s *Service) RequestPasswordReset(ctx context.Context, emailOrPhone string)
 	}
 
 	// Envoyer le code par email ou SMS
-	if isEmail(emailOrPhone) && s.emailService != nil {
-		return s.emailService.SendPasswordReset(emailOrPhone, resetCode)
+	if isEmail(target) && s.emailService != nil {
+		return s.emailService.SendPasswordReset(target, resetCode)
 	} else if s.smsService != nil {
-		return s.smsService.SendPasswordReset(normalizePhone(emailOrPhone), resetCode)
+		return s.smsService.SendPasswordReset(normalizePhone(target), resetCode)
 	}
 
 	return nil
@@ -1061,6 +967,10 @@ func (s *Service) RequestPasswordReset(ctx context.Context, emailOrPhone string)
 
 // VerifyResetCode vérifie un code de réinitialisation
 func (s *Service) VerifyResetCode(ctx context.Context, req models.VerifyCodeRequest) (bool, error) {
+	if err := s.limitIdentifier(ctx, "reset-code", req.EmailOrPhone); err != nil {
+		return false, err
+	}
+
 	// Trouver l'utilisateur
 	var filter bson.M
 	if isEmail(req.EmailOrPhone) {
@@ -1079,8 +989,8 @@ func (s *Service) VerifyResetCode(ctx context.Context, req models.VerifyCodeRequ
 		return false, err
 	}
 
-	// Vérifier le code et son expiration
-	if user.ResetToken != req.Code || user.ResetTokenExpires.Before(time.Now()) {
+	// Vérifier le code, son expiration et le nombre d'essais
+	if !s.checkResetCode(ctx, &user, req.Code) {
 		return false, nil
 	}
 
@@ -1089,6 +999,10 @@ func (s *Service) VerifyResetCode(ctx context.Context, req models.VerifyCodeRequ
 
 // ResetPassword réinitialise le mot de passe avec le code fourni
 func (s *Service) ResetPassword(ctx context.Context, req models.NewPasswordRequest) error {
+	if err := s.limitIdentifier(ctx, "reset-code", req.EmailOrPhone); err != nil {
+		return err
+	}
+
 	// Trouver l'utilisateur
 	var filter bson.M
 	if isEmail(req.EmailOrPhone) {
@@ -1107,8 +1021,8 @@ func (s *Service) ResetPassword(ctx context.Context, req models.NewPasswordReque
 		return err
 	}
 
-	// Vérifier le code et son expiration
-	if user.ResetToken != req.Code || user.ResetTokenExpires.Before(time.Now()) {
+	// Vérifier le code, son expiration et le nombre d'essais
+	if !s.checkResetCode(ctx, &user, req.Code) {
 		return errors.New("code de réinitialisation invalide ou expiré")
 	}
 
@@ -1133,10 +1047,12 @@ func (s *Service) ResetPassword(ctx context.Context, req models.NewPasswordReque
 				"passwordHash":      string(hashedPassword),
 				"resetToken":        "",
 				"resetTokenExpires": time.Time{},
+				"resetAttempts":     0,
 				"updatedAt":         time.Now(),
 				// Supprimer tous les tokens de rafraîchissement pour forcer la reconnexion
 				"refreshTokens": []models.RefreshToken{},
 			},
+			"$unset": bson.M{"passwordResetRequired": ""},
 		},
 	)
 	if err != nil {
@@ -1149,10 +1065,11 @@ func (s *Service) ResetPassword(ctx context.Context, req models.NewPasswordReque
 
 // UpdateProfile met à jour le profil d'un utilisateur
 func (s *Service) UpdateProfile(ctx context.Context, userID string, req models.UpdateProfileRequest) (*models.UserResponse, error) {
-	id, err := primitive.ObjectIDFromHex(userID)
+	current, err := s.getUserByID(ctx, userID)
 	if err != nil {
-		return nil, errors.New("ID utilisateur invalide")
+		return nil, err
 	}
+	id := current.ID
 
 	// Préparer les champs à mettre à jour
 	updates := bson.M{
@@ -1196,7 +1113,6 @@ func (s *Service) UpdateProfile(ctx context.Context, userID string, req models.U
 		}
 
 		updates["email"] = req.Email
-		updates["isVerified"] = false // L'email doit être vérifié à nouveau
 	}
 
 	if req.Phone != "" {
@@ -1245,6 +1161,17 @@ func (s *Service) UpdateProfile(ctx context.Context, userID string, req models.U
 		return nil, err
 	}
 
+	// Un nouvel email ou un nouveau téléphone doit être vérifié à nouveau
+	for _, channel := range []string{ChannelEmail, ChannelPhone} {
+		target := contactOf(&updatedUser, channel)
+		if target == "" || target == contactOf(current, channel) {
+			continue
+		}
+		if err := s.startVerification(ctx, &updatedUser, channel, target, true); err != nil {
+			log.Warn().Err(err).Str("channel", channel).Msg("Code de vérification non envoyé après modification du profil")
+		}
+	}
+
 	// Convertir en réponse
 	userResponse := updatedUser.ToResponse()
 	return &userResponse, nil
@@ -1441,17 +1368,6 @@ func normalizePhone(phone string) string {
 	return normalized
 }
 
-// generateRandomCode génère un code numérique aléatoire de longueur spécifiée
-func generateRandomCode(length int) string {
-	rand.Seed(time.Now().UnixNano())
-	digits := "0123456789"
-	code := make([]byte, length)
-	for i := 0; i < length; i++ {
-		code[i] = digits[rand.Intn(len(digits))]
-	}
-	return string(code)
-}
-
 // isValidSocialProvider vérifie si le fournisseur d'authentification sociale est pris en charge
 func isValidSocialProvider(provider string) bool {
 	validProviders := []string{"google", "apple", "facebook", "twitter"}
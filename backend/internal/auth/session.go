@@ -0,0 +1,339 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"genie/internal/models"
+	"genie/internal/utils"
+)
+
+// ErrRefreshTokenReused est renvoyée lorsqu'un token de rafraîchissement déjà remplacé est présenté :
+// le token a pu être volé, la session entière est révoquée
+var ErrRefreshTokenReused = errors.New("token de rafraîchissement déjà utilisé, session révoquée")
+
+// DeviceInfo décrit l'appareil à l'origine d'une requête d'authentification
+type DeviceInfo struct {
+	IP         string
+	UserAgent  string
+	DeviceName string
+}
+
+type deviceKey struct{}
+
+// WithDevice attache au contexte l'appareil à l'origine de la requête, enregistré avec les sessions ouvertes
+func WithDevice(ctx context.Context, device DeviceInfo) context.Context {
+	return context.WithValue(ctx, deviceKey{}, device)
+}
+
+// deviceFrom renvoie l'appareil attaché au contexte
+func deviceFrom(ctx context.Context) DeviceInfo {
+	device, _ := ctx.Value(deviceKey{}).(DeviceInfo)
+	return device
+}
+
+// issueTokens ouvre une session pour un utilisateur authentifié et délivre ses tokens
+func (s *Service) issueTokens(ctx context.Context, user *models.User) (*models.AuthResponse, error) {
+	if err := checkAccountAccess(user); err != nil {
+		return nil, err
+	}
+	sessionID := primitive.NewObjectID()
+
+	// Générer les tokens
+	accessToken, err := s.jwtService.GenerateAccessToken(user.ID.Hex(), sessionID.Hex(), s.scopesFor(user))
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la génération du token d'accès")
+		return nil, err
+	}
+
+	refreshToken, err := s.jwtService.GenerateRefreshToken(user.ID.Hex(), sessionID.Hex())
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la génération du token de rafraîchissement")
+		return nil, err
+	}
+
+	now := time.Now()
+	device := deviceFrom(ctx)
+	session := models.RefreshToken{
+		SessionID:  sessionID,
+		TokenHash:  hashSecret(refreshToken),
+		ExpiresAt:  now.Add(s.config.JWT.RefreshExpiryTime),
+		IP:         device.IP,
+		UserAgent:  device.UserAgent,
+		DeviceName: device.DeviceName,
+		IssuedAt:   now,
+		LastUsedAt: now,
+	}
+
+	// Retirer les sessions expirées avant d'ajouter la nouvelle
+	_, err = s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{"$pull": bson.M{"refreshTokens": bson.M{"expiresAt": bson.M{"$lte": now}}}},
+	)
+	if err != nil {
+		log.Warn().Err(err).Msg("Erreur lors du nettoyage des sessions expirées")
+	}
+
+	// Mettre à jour la dernière connexion et enregistrer la session
+	_, err = s.db.Users.UpdateOne(
+		ctx,
+		bson.M{"_id": user.ID},
+		bson.M{
+			"$set": bson.M{
+				"lastLoginAt": now,
+				"updatedAt":   now,
+			},
+			"$push": bson.M{
+				"refreshTokens": session,
+			},
+		},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la mise à jour de l'utilisateur après connexion")
+		return nil, err
+	}
+
+	// Préparer la réponse
+	return &models.AuthResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    utils.DurationToMilliseconds(s.config.JWT.AccessExpiryTime),
+		User:         user.ToResponse(),
+	}, nil
+}
+
+// RefreshToken rafraîchit un token d'authentification. Le token de rafraîchissement est remplacé
+// à chaque appel ; présenter un token déjà remplacé révoque toute la session.
+func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (*models.AuthResponse, error) {
+	// Vérifier le token
+	claims, err := s.jwtService.VerifyRefreshToken(refreshToken)
+	if err != nil {
+		return nil, errors.New("token de rafraîchissement invalide")
+	}
+
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return nil, errors.New("ID utilisateur invalide dans le token")
+	}
+
+	// Tokens émis avant les sessions : conservés en clair, ils sont échangés contre une session
+	if claims.SessionID == "" {
+		return s.migrateLegacyRefreshToken(ctx, userID, refreshToken)
+	}
+
+	sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
+	if err != nil {
+		return nil, errors.New("token de rafraîchissement invalide")
+	}
+
+	var user models.User
+	err = s.db.Users.FindOne(ctx, bson.M{"_id": userID, "refreshTokens.sessionId": sessionID}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("token de rafraîchissement invalide ou expiré")
+		}
+		log.Error().Err(err).Msg("Erreur lors de la vérification du token de rafraîchissement")
+		return nil, err
+	}
+
+	session := findSession(&user, sessionID)
+	now := time.Now()
+	if session == nil || !now.Before(session.ExpiresAt) {
+		return nil, errors.New("token de rafraîchissement invalide ou expiré")
+	}
+
+	// Un token signé pour cette session mais qui n'est plus le token courant a déjà été remplacé
+	if hashSecret(refreshToken) != session.TokenHash {
+		s.revokeReusedSession(ctx, userID, sessionID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	if err := checkAccountAccess(&user); err != nil {
+		return nil, err
+	}
+
+	// Les scopes suivent les rôles actuels de l'utilisateur
+	accessToken, err := s.jwtService.GenerateAccessToken(user.ID.Hex(), sessionID.Hex(), s.scopesFor(&user))
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la génération du nouveau token d'accès")
+		return nil, err
+	}
+	newRefreshToken, err := s.jwtService.GenerateRefreshToken(user.ID.Hex(), sessionID.Hex())
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la génération du nouveau token de rafraîchissement")
+		return nil, err
+	}
+
+	updates := bson.M{
+		"refreshTokens.$.tokenHash":  hashSecret(newRefreshToken),
+		"refreshTokens.$.expiresAt":  now.Add(s.config.JWT.RefreshExpiryTime),
+		"refreshTokens.$.lastUsedAt": now,
+	}
+	if device := deviceFrom(ctx); device.IP != "" {
+		updates["refreshTokens.$.ip"] = device.IP
+	}
+
+	// Le filtre sur le condensé courant garantit qu'un token n'est échangé qu'une fois,
+	// même si deux rafraîchissements arrivent en même temps
+	result, err := s.db.Users.UpdateOne(ctx,
+		bson.M{
+			"_id": userID,
+			"refreshTokens": bson.M{"$elemMatch": bson.M{
+				"sessionId": sessionID,
+				"tokenHash": session.TokenHash,
+			}},
+		},
+		bson.M{"$set": updates},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors du remplacement du token de rafraîchissement")
+		return nil, err
+	}
+	if result.ModifiedCount == 0 {
+		s.revokeReusedSession(ctx, userID, sessionID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	// Préparer la réponse
+	return &models.AuthResponse{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    utils.DurationToMilliseconds(s.config.JWT.AccessExpiryTime),
+		User:         user.ToResponse(),
+	}, nil
+}
+
+// migrateLegacyRefreshToken échange un token de rafraîchissement en clair contre une nouvelle session
+func (s *Service) migrateLegacyRefreshToken(ctx context.Context, userID primitive.ObjectID, refreshToken string) (*models.AuthResponse, error) {
+	var user models.User
+	err := s.db.Users.FindOneAndUpdate(ctx,
+		bson.M{
+			"_id": userID,
+			"refreshTokens": bson.M{"$elemMatch": bson.M{
+				"token":     refreshToken,
+				"expiresAt": bson.M{"$gt": time.Now()},
+			}},
+		},
+		bson.M{"$pull": bson.M{"refreshTokens": bson.M{"token": refreshToken}}},
+	).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("token de rafraîchissement invalide ou expiré")
+		}
+		log.Error().Err(err).Msg("Erreur lors de la vérification du token de rafraîchissement")
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, &user)
+}
+
+// revokeReusedSession révoque la session d'un token de rafraîchissement réutilisé
+func (s *Service) revokeReusedSession(ctx context.Context, userID primitive.ObjectID, sessionID primitive.ObjectID) {
+	log.Warn().Str("userID", userID.Hex()).Str("sessionID", sessionID.Hex()).
+		Msg("Réutilisation d'un token de rafraîchissement détectée, session révoquée")
+
+	_, err := s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$pull": bson.M{"refreshTokens": bson.M{"sessionId": sessionID}}},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la révocation de la session")
+	}
+}
+
+// ListSessions liste les sessions actives d'un utilisateur, la plus récemment utilisée d'abord.
+// currentSessionID désigne la session de la requête.
+func (s *Service) ListSessions(ctx context.Context, userID string, currentSessionID string) ([]models.SessionResponse, error) {
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sessions := []models.SessionResponse{}
+	for _, session := range user.RefreshTokens {
+		if session.SessionID.IsZero() || !now.Before(session.ExpiresAt) {
+			continue
+		}
+		sessions = append(sessions, models.SessionResponse{
+			ID:         session.SessionID.Hex(),
+			DeviceName: session.DeviceName,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.IssuedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.SessionID.Hex() == currentSessionID,
+		})
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession révoque une session : son token de rafraîchissement n'est plus accepté
+func (s *Service) RevokeSession(ctx context.Context, userID string, sessionID string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("ID utilisateur invalide")
+	}
+	sid, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return errors.New("session introuvable")
+	}
+
+	result, err := s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$pull": bson.M{"refreshTokens": bson.M{"sessionId": sid}}},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la révocation de la session")
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return errors.New("session introuvable")
+	}
+	return nil
+}
+
+// RevokeAllSessions révoque toutes les sessions d'un utilisateur, sauf exceptSessionID s'il est renseigné
+func (s *Service) RevokeAllSessions(ctx context.Context, userID string, exceptSessionID string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("ID utilisateur invalide")
+	}
+
+	update := bson.M{"$set": bson.M{"refreshTokens": []models.RefreshToken{}}}
+	if exceptSessionID != "" {
+		keep, err := primitive.ObjectIDFromHex(exceptSessionID)
+		if err != nil {
+			return errors.New("session introuvable")
+		}
+		update = bson.M{"$pull": bson.M{"refreshTokens": bson.M{"sessionId": bson.M{"$ne": keep}}}}
+	}
+
+	if _, err := s.db.Users.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la révocation des sessions")
+		return err
+	}
+	return nil
+}
+
+// findSession renvoie la session d'identifiant sessionID, ou nil
+func findSession(user *models.User, sessionID primitive.ObjectID) *models.RefreshToken {
+	for i := range user.RefreshTokens {
+		if user.RefreshTokens[i].SessionID == sessionID {
+			return &user.RefreshTokens[i]
+		}
+	}
+	return nil
+}
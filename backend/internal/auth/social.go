@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"genie/internal/config"
+)
+
+// ErrInvalidSocialToken est renvoyée lorsqu'un jeton d'identité social est refusé
+var ErrInvalidSocialToken = errors.New("jeton d'identité invalide")
+
+// Points d'accès publics des fournisseurs de connexion sociale
+const (
+	googleJWKSURL = "https://www.googleapis.com/oauth2/v3/certs"
+	appleJWKSURL  = "https://appleid.apple.com/auth/keys"
+)
+
+// tokenLeeway tolère un léger décalage d'horloge avec le fournisseur
+const tokenLeeway = time.Minute
+
+// SocialIdentity est l'identité extraite d'un jeton vérifié.
+// Email n'est renseigné que si le fournisseur l'a vérifié.
+type SocialIdentity struct {
+	Provider  string
+	Subject   string
+	Email     string
+	FirstName string
+	LastName  string
+	AvatarURL string
+}
+
+// TokenVerifier vérifie le jeton d'identité émis par un fournisseur de connexion sociale
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string, nonce string) (*SocialIdentity, error)
+}
+
+// OIDCVerifier vérifie les jetons d'identité OpenID Connect signés en RS256 :
+// signature par les clés publiées du fournisseur, émetteur, audience, expiration et nonce
+type OIDCVerifier struct {
+	provider     string
+	issuers      []string
+	audiences    []string
+	requireNonce bool
+	keys         *jwksCache
+}
+
+// NewOIDCVerifier crée un vérificateur pour un fournisseur. jwksURL est l'adresse de ses clés publiques.
+func NewOIDCVerifier(provider string, jwksURL string, issuers []string, audiences []string, requireNonce bool, client *http.Client, cacheTTL time.Duration) *OIDCVerifier {
+	return &OIDCVerifier{
+		provider:     provider,
+		issuers:      issuers,
+		audiences:    audiences,
+		requireNonce: requireNonce,
+		keys:         newJWKSCache(jwksURL, client, cacheTTL),
+	}
+}
+
+// newSocialVerifiers crée les vérificateurs des fournisseurs configurés
+func newSocialVerifiers(cfg config.SocialConfig) map[string]TokenVerifier {
+	client := &http.Client{Timeout: cfg.HTTPTimeout}
+	verifiers := make(map[string]TokenVerifier)
+	if len(cfg.GoogleClientIDs) > 0 {
+		verifiers["google"] = NewOIDCVerifier("google", googleJWKSURL,
+			[]string{"https://accounts.google.com", "accounts.google.com"},
+			cfg.GoogleClientIDs, cfg.RequireNonce, client, cfg.JWKSCacheTTL)
+	}
+	if len(cfg.AppleClientIDs) > 0 {
+		verifiers["apple"] = NewOIDCVerifier("apple", appleJWKSURL,
+			[]string{"https://appleid.apple.com"},
+			cfg.AppleClientIDs, cfg.RequireNonce, client, cfg.JWKSCacheTTL)
+	}
+	return verifiers
+}
+
+// identityClaims sont les revendications lues dans un jeton d'identité.
+// Apple transmet email_verified sous forme de chaîne.
+type identityClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string      `json:"nonce"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+	GivenName     string      `json:"given_name"`
+	FamilyName    string      `json:"family_name"`
+	Picture       string      `json:"picture"`
+}
+
+// Verify vérifie un jeton et renvoie l'identité qu'il atteste
+func (v *OIDCVerifier) Verify(ctx context.Context, token string, nonce string) (*SocialIdentity, error) {
+	claims := &identityClaims{}
+	_, err := jwt.ParseWithClaims(token, claims,
+		func(t *jwt.Token) (interface{}, error) {
+			kid, _ := t.Header["kid"].(string)
+			if kid == "" {
+				return nil, errors.New("identifiant de clé manquant")
+			}
+			return v.keys.key(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(tokenLeeway),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSocialToken, err)
+	}
+
+	if !contains(v.issuers, claims.Issuer) {
+		return nil, fmt.Errorf("%w: émetteur inattendu", ErrInvalidSocialToken)
+	}
+	if !audienceAllowed(claims.Audience, v.audiences) {
+		return nil, fmt.Errorf("%w: audience inattendue", ErrInvalidSocialToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: identifiant manquant", ErrInvalidSocialToken)
+	}
+	if err := checkNonce(claims.Nonce, nonce, v.requireNonce); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSocialToken, err)
+	}
+
+	identity := &SocialIdentity{
+		Provider:  v.provider,
+		Subject:   claims.Subject,
+		FirstName: claims.GivenName,
+		LastName:  claims.FamilyName,
+		AvatarURL: claims.Picture,
+	}
+	if claims.Email != "" && isTrue(claims.EmailVerified) {
+		identity.Email = claims.Email
+	}
+	return identity, nil
+}
+
+// checkNonce compare le nonce du jeton à celui présenté par le client.
+// Apple reçoit le condensé SHA-256 du nonce : les deux formes sont acceptées.
+func checkNonce(claimed string, presented string, required bool) error {
+	if presented == "" {
+		if required || claimed != "" {
+			return errors.New("nonce manquant")
+		}
+		return nil
+	}
+	if claimed == "" {
+		return errors.New("nonce absent du jeton")
+	}
+
+	hash := sha256.Sum256([]byte(presented))
+	if subtle.ConstantTimeCompare([]byte(claimed), []byte(presented)) == 1 ||
+		subtle.ConstantTimeCompare([]byte(claimed), []byte(hex.EncodeToString(hash[:]))) == 1 {
+		return nil
+	}
+	return errors.New("nonce invalide")
+}
+
+// audienceAllowed indique si l'une des audiences du jeton est acceptée
+func audienceAllowed(audience jwt.ClaimStrings, allowed []string) bool {
+	for _, aud := range audience {
+		if contains(allowed, aud) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTrue lit un booléen transmis comme booléen ou comme chaîne
+func isTrue(v interface{}) bool {
+	switch value := v.(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+	return false
+}
+
+// contains indique si une liste contient une valeur
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
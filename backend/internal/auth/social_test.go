@@ -0,0 +1,438 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://issuer.example"
+	testAudience = "client-id"
+)
+
+// jwksServer publie un document JWKS modifiable pendant le test
+type jwksServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	keys     map[string]*rsa.PublicKey
+	requests int
+}
+
+func newJWKSServer(t *testing.T, keys map[string]*rsa.PublicKey) *jwksServer {
+	t.Helper()
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+
+		type jwk struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		}
+		document := struct {
+			Keys []jwk `json:"keys"`
+		}{}
+		for kid, key := range s.keys {
+			document.Keys = append(document.Keys, jwk{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				Alg: "RS256",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		json.NewEncoder(w).Encode(document)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// setKeys remplace les clés publiées (rotation chez le fournisseur)
+func (s *jwksServer) setKeys(keys map[string]*rsa.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func generateKey(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("génération de la clé: %v", err)
+	}
+	return key
+}
+
+// signToken signe des revendications avec la méthode, la clé et l'identifiant de clé donnés
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("signature du jeton: %v", err)
+	}
+	return signed
+}
+
+// validClaims renvoie des revendications acceptées, à modifier par chaque cas
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            testIssuer,
+		"aud":            testAudience,
+		"sub":            "user-123",
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          "nonce-value",
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"given_name":     "Jane",
+		"family_name":    "Doe",
+	}
+}
+
+func newTestVerifier(url string, requireNonce bool) *OIDCVerifier {
+	return NewOIDCVerifier("google", url, []string{testIssuer}, []string{testAudience}, requireNonce, http.DefaultClient, time.Hour)
+}
+
+func TestOIDCVerifierVerify(t *testing.T) {
+	signingKey := generateKey(t, 2048)
+	otherKey := generateKey(t, 2048)
+	server := newJWKSServer(t, map[string]*rsa.PublicKey{"key-1": &signingKey.PublicKey})
+
+	hashedNonce := sha256.Sum256([]byte("nonce-value"))
+
+	tests := []struct {
+		name         string
+		method       jwt.SigningMethod
+		key          interface{}
+		kid          string
+		edit         func(jwt.MapClaims)
+		nonce        string
+		requireNonce bool
+		wantErr      bool
+		wantEmail    string
+	}{
+		{
+			name:      "jeton valide",
+			nonce:     "nonce-value",
+			wantEmail: "jane@example.com",
+		},
+		{
+			name:    "signature d'une autre clé",
+			key:     otherKey,
+			nonce:   "nonce-value",
+			wantErr: true,
+		},
+		{
+			name:    "émetteur inattendu",
+			edit:    func(c jwt.MapClaims) { c["iss"] = "https://evil.example" },
+			nonce:   "nonce-value",
+			wantErr: true,
+		},
+		{
+			name:    "audience inattendue",
+			edit:    func(c jwt.MapClaims) { c["aud"] = "other-client" },
+			nonce:   "nonce-value",
+			wantErr: true,
+		},
+		{
+			name:      "une des audiences acceptée",
+			edit:      func(c jwt.MapClaims) { c["aud"] = []string{"other-client", testAudience} },
+			nonce:     "nonce-value",
+			wantEmail: "jane@example.com",
+		},
+		{
+			name:    "jeton expiré",
+			edit:    func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-2 * time.Hour).Unix() },
+			nonce:   "nonce-value",
+			wantErr: true,
+		},
+		{
+			name:    "expiration manquante",
+			edit:    func(c jwt.MapClaims) { delete(c, "exp") },
+			nonce:   "nonce-value",
+			wantErr: true,
+		},
+		{
+			name:    "émis dans le futur",
+			edit:    func(c jwt.MapClaims) { c["iat"] = time.Now().Add(time.Hour).Unix() },
+			nonce:   "nonce-value",
+			wantErr: true,
+		},
+		{
+			name:      "décalage d'horloge toléré",
+			edit:      func(c jwt.MapClaims) { c["iat"] = time.Now().Add(30 * time.Second).Unix() },
+			nonce:     "nonce-value",
+			wantEmail: "jane@example.com",
+		},
+		{
+			name:      "nonce condensé en SHA-256",
+			edit:      func(c jwt.MapClaims) { c["nonce"] = hex.EncodeToString(hashedNonce[:]) },
+			nonce:     "nonce-value",
+			wantEmail: "jane@example.com",
+		},
+		{
+			name:    "nonce différent",
+			nonce:   "other-nonce",
+			wantErr: true,
+		},
+		{
+			name:    "nonce du jeton non présenté",
+			wantErr: true,
+		},
+		{
+			name:    "nonce présenté absent du jeton",
+			edit:    func(c jwt.MapClaims) { delete(c, "nonce") },
+			nonce:   "nonce-value",
+			wantErr: true,
+		},
+		{
+			name:         "nonce requis",
+			edit:         func(c jwt.MapClaims) { delete(c, "nonce") },
+			requireNonce: true,
+			wantErr:      true,
+		},
+		{
+			name: "nonce facultatif",
+			edit: func(c jwt.MapClaims) { delete(c, "nonce") },
+			// sans nonce de part et d'autre
+			wantEmail: "jane@example.com",
+		},
+		{
+			name:  "email non vérifié",
+			edit:  func(c jwt.MapClaims) { c["email_verified"] = false },
+			nonce: "nonce-value",
+		},
+		{
+			name:      "email vérifié transmis en chaîne",
+			edit:      func(c jwt.MapClaims) { c["email_verified"] = "true" },
+			nonce:     "nonce-value",
+			wantEmail: "jane@example.com",
+		},
+		{
+			name:  "email non vérifié transmis en chaîne",
+			edit:  func(c jwt.MapClaims) { c["email_verified"] = "false" },
+			nonce: "nonce-value",
+		},
+		{
+			name:    "identifiant manquant",
+			edit:    func(c jwt.MapClaims) { delete(c, "sub") },
+			nonce:   "nonce-value",
+			wantErr: true,
+		},
+		{
+			name:    "identifiant de clé manquant",
+			kid:     "-",
+			nonce:   "nonce-value",
+			wantErr: true,
+		},
+		{
+			name:    "RS384 refusé",
+			method:  jwt.SigningMethodRS384,
+			nonce:   "nonce-value",
+			wantErr: true,
+		},
+		{
+			name:    "PS256 refusé",
+			method:  jwt.SigningMethodPS256,
+			nonce:   "nonce-value",
+			wantErr: true,
+		},
+		{
+			name:    "HS256 refusé",
+			method:  jwt.SigningMethodHS256,
+			key:     []byte("secret"),
+			nonce:   "nonce-value",
+			wantErr: true,
+		},
+		{
+			name:    "alg none refusé",
+			method:  jwt.SigningMethodNone,
+			key:     jwt.UnsafeAllowNoneSignatureType,
+			nonce:   "nonce-value",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, key, kid := tt.method, tt.key, tt.kid
+			if method == nil {
+				method = jwt.SigningMethodRS256
+			}
+			if key == nil {
+				key = signingKey
+			}
+			switch kid {
+			case "":
+				kid = "key-1"
+			case "-":
+				kid = ""
+			}
+			claims := validClaims()
+			if tt.edit != nil {
+				tt.edit(claims)
+			}
+			token := signToken(t, method, key, kid, claims)
+
+			identity, err := newTestVerifier(server.URL, tt.requireNonce).Verify(context.Background(), token, tt.nonce)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("jeton accepté, erreur attendue")
+				}
+				if !errors.Is(err, ErrInvalidSocialToken) {
+					t.Fatalf("erreur %v, ErrInvalidSocialToken attendue", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("jeton refusé: %v", err)
+			}
+			if identity.Subject != "user-123" || identity.Provider != "google" {
+				t.Errorf("identité %+v inattendue", identity)
+			}
+			if identity.Email != tt.wantEmail {
+				t.Errorf("email %q, %q attendu", identity.Email, tt.wantEmail)
+			}
+		})
+	}
+}
+
+func TestOIDCVerifierKeyRotation(t *testing.T) {
+	oldKey := generateKey(t, 2048)
+	newKey := generateKey(t, 2048)
+	server := newJWKSServer(t, map[string]*rsa.PublicKey{"old": &oldKey.PublicKey})
+	verifier := newTestVerifier(server.URL, false)
+	ctx := context.Background()
+
+	claims := validClaims()
+	delete(claims, "nonce")
+
+	if _, err := verifier.Verify(ctx, signToken(t, jwt.SigningMethodRS256, oldKey, "old", claims), ""); err != nil {
+		t.Fatalf("jeton signé par la clé courante refusé: %v", err)
+	}
+	if got := server.requestCount(); got != 1 {
+		t.Fatalf("%d chargements des clés, 1 attendu", got)
+	}
+
+	// Les clés en cache servent tant qu'elles ne sont pas expirées
+	if _, err := verifier.Verify(ctx, signToken(t, jwt.SigningMethodRS256, oldKey, "old", claims), ""); err != nil {
+		t.Fatalf("jeton refusé: %v", err)
+	}
+	if got := server.requestCount(); got != 1 {
+		t.Fatalf("%d chargements des clés, le cache aurait dû servir", got)
+	}
+
+	// Rotation chez le fournisseur : la nouvelle clé est inconnue du cache
+	server.setKeys(map[string]*rsa.PublicKey{"new": &newKey.PublicKey})
+	newToken := signToken(t, jwt.SigningMethodRS256, newKey, "new", claims)
+
+	// Juste après un chargement, une clé inconnue ne provoque pas de nouvel appel au fournisseur
+	if _, err := verifier.Verify(ctx, newToken, ""); err == nil {
+		t.Fatalf("jeton accepté sans rechargement des clés")
+	}
+	if got := server.requestCount(); got != 1 {
+		t.Fatalf("%d chargements des clés, le rechargement aurait dû être limité", got)
+	}
+
+	// Passé l'intervalle minimal, la clé inconnue force le rechargement
+	verifier.keys.mu.Lock()
+	verifier.keys.refreshedAt = time.Now().Add(-2 * jwksMinRefreshInterval)
+	verifier.keys.mu.Unlock()
+
+	if _, err := verifier.Verify(ctx, newToken, ""); err != nil {
+		t.Fatalf("jeton signé par la nouvelle clé refusé: %v", err)
+	}
+	if got := server.requestCount(); got != 2 {
+		t.Fatalf("%d chargements des clés, 2 attendus", got)
+	}
+
+	// L'ancienne clé n'est plus publiée
+	if _, err := verifier.Verify(ctx, signToken(t, jwt.SigningMethodRS256, oldKey, "old", claims), ""); err == nil {
+		t.Fatalf("jeton signé par une clé retirée accepté")
+	}
+}
+
+func TestRSAPublicKey(t *testing.T) {
+	strong := generateKey(t, 2048)
+	weak := generateKey(t, 1024)
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	exponent := encode(big.NewInt(65537).Bytes())
+
+	tests := []struct {
+		name    string
+		n       string
+		e       string
+		wantErr bool
+	}{
+		{name: "clé de 2048 bits", n: encode(strong.N.Bytes()), e: exponent},
+		{name: "clé de 1024 bits", n: encode(weak.N.Bytes()), e: exponent, wantErr: true},
+		{name: "module vide", n: "", e: exponent, wantErr: true},
+		{name: "exposant pair", n: encode(strong.N.Bytes()), e: encode([]byte{2}), wantErr: true},
+		{name: "exposant trop long", n: encode(strong.N.Bytes()), e: encode([]byte{1, 0, 0, 0, 1}), wantErr: true},
+		{name: "base64 invalide", n: "!!", e: exponent, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := rsaPublicKey(tt.n, tt.e)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("clé acceptée, erreur attendue")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("clé refusée: %v", err)
+			}
+			if key.N.Cmp(strong.N) != 0 || key.E != 65537 {
+				t.Errorf("clé %v inattendue", key)
+			}
+		})
+	}
+}
+
+func TestJWKSCacheIgnoresWeakKeys(t *testing.T) {
+	weak := generateKey(t, 1024)
+	server := newJWKSServer(t, map[string]*rsa.PublicKey{"weak": &weak.PublicKey})
+	verifier := newTestVerifier(server.URL, false)
+
+	claims := validClaims()
+	delete(claims, "nonce")
+	token := signToken(t, jwt.SigningMethodRS256, weak, "weak", claims)
+	if _, err := verifier.Verify(context.Background(), token, ""); err == nil {
+		t.Fatalf("jeton signé par une clé de 1024 bits accepté")
+	}
+}
@@ -0,0 +1,370 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"genie/internal/models"
+	"genie/internal/utils"
+)
+
+// Erreurs de la double authentification
+var (
+	ErrInvalidTwoFactorCode = errors.New("code de vérification invalide")
+	ErrMFAChallengeExpired  = errors.New("session de vérification expirée, veuillez vous reconnecter")
+	ErrTooManyMFAAttempts   = errors.New("trop de codes erronés, veuillez réessayer plus tard")
+)
+
+// Codes de secours : recoveryCodeCount codes de recoveryCodeLength caractères, sans caractères ambigus
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// SetupTwoFactor génère un secret TOTP en attente de confirmation.
+// La double authentification n'est activée qu'après ConfirmTwoFactor.
+func (s *Service) SetupTwoFactor(ctx context.Context, userID string) (*models.TwoFactorSetupResponse, error) {
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsTwoFactorEnabled {
+		return nil, errors.New("double authentification déjà activée")
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{"$set": bson.M{"twoFactorPendingSecret": secret, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de l'enregistrement du secret TOTP")
+		return nil, err
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Phone
+	}
+	return &models.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: totpURI(s.config.Security.TwoFactorIssuer, account, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor active la double authentification après vérification d'un premier code
+// et renvoie les codes de secours
+func (s *Service) ConfirmTwoFactor(ctx context.Context, userID string, code string) (*models.RecoveryCodesResponse, error) {
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsTwoFactorEnabled {
+		return nil, errors.New("double authentification déjà activée")
+	}
+	if user.TwoFactorPendingSecret == "" {
+		return nil, errors.New("aucune activation de double authentification en cours")
+	}
+
+	step, ok := matchTOTP(user.TwoFactorPendingSecret, code, time.Now(), 0)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "twoFactorPendingSecret": user.TwoFactorPendingSecret},
+		bson.M{
+			"$set": bson.M{
+				"isTwoFactorEnabled":     true,
+				"twoFactorSecret":        user.TwoFactorPendingSecret,
+				"twoFactorRecoveryCodes": hashes,
+				"twoFactorLastStep":      step,
+				"updatedAt":              time.Now(),
+			},
+			"$unset": bson.M{"twoFactorPendingSecret": ""},
+		},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de l'activation de la double authentification")
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, errors.New("aucune activation de double authentification en cours")
+	}
+
+	return &models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor désactive la double authentification, sur présentation d'un code TOTP ou d'un code de secours
+func (s *Service) DisableTwoFactor(ctx context.Context, userID string, code string) error {
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsTwoFactorEnabled {
+		return errors.New("double authentification non activée")
+	}
+	if err := s.consumeTwoFactorCode(ctx, user, code, true); err != nil {
+		return err
+	}
+
+	_, err = s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{
+			"$set": bson.M{"isTwoFactorEnabled": false, "updatedAt": time.Now()},
+			"$unset": bson.M{
+				"twoFactorSecret":        "",
+				"twoFactorPendingSecret": "",
+				"twoFactorRecoveryCodes": "",
+				"twoFactorLastStep":      "",
+				"twoFactorChallenge":     "",
+			},
+		},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la désactivation de la double authentification")
+	}
+	return err
+}
+
+// RegenerateRecoveryCodes remplace les codes de secours, sur présentation d'un code TOTP.
+// Les anciens codes ne sont plus valables.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID string, code string) (*models.RecoveryCodesResponse, error) {
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsTwoFactorEnabled {
+		return nil, errors.New("double authentification non activée")
+	}
+	if err := s.consumeTwoFactorCode(ctx, user, code, false); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{"$set": bson.M{"twoFactorRecoveryCodes": hashes, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la régénération des codes de secours")
+		return nil, err
+	}
+
+	return &models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// VerifyMFA termine une connexion en attente du second facteur et délivre les tokens.
+// Après MFAMaxAttempts codes erronés, plus aucun code n'est accepté jusqu'à l'expiration de la connexion.
+func (s *Service) VerifyMFA(ctx context.Context, req models.VerifyMFARequest) (*models.AuthResponse, error) {
+	var user models.User
+	err := s.db.Users.FindOne(ctx, bson.M{
+		"twoFactorChallenge.tokenHash": hashSecret(req.MFAToken),
+		"twoFactorChallenge.expiresAt": bson.M{"$gt": time.Now()},
+	}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrMFAChallengeExpired
+		}
+		log.Error().Err(err).Msg("Erreur lors de la recherche de la connexion en attente")
+		return nil, err
+	}
+	if user.TwoFactorChallenge.Attempts >= s.config.Security.MFAMaxAttempts {
+		return nil, ErrTooManyMFAAttempts
+	}
+
+	if err := s.consumeTwoFactorCode(ctx, &user, req.Code, true); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.recordFailedMFAAttempt(ctx, &user)
+		}
+		return nil, err
+	}
+
+	// Le jeton de connexion ne sert qu'une fois
+	result, err := s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "twoFactorChallenge.tokenHash": user.TwoFactorChallenge.TokenHash},
+		bson.M{"$unset": bson.M{"twoFactorChallenge": ""}},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la clôture de la connexion en attente")
+		return nil, err
+	}
+	if result.ModifiedCount == 0 {
+		return nil, ErrMFAChallengeExpired
+	}
+
+	return s.issueTokens(ctx, &user)
+}
+
+// startMFAChallenge crée la connexion en attente du second facteur d'un utilisateur dont le premier
+// facteur (mot de passe ou connexion sociale) est validé. Elle remplace toute connexion déjà en attente,
+// dont elle reprend le nombre de codes erronés pour qu'une nouvelle connexion ne relance pas le compteur.
+func (s *Service) startMFAChallenge(ctx context.Context, user *models.User) (*models.AuthResponse, error) {
+	now := time.Now()
+	attempts := 0
+	if previous := user.TwoFactorChallenge; previous != nil && now.Before(previous.ExpiresAt) {
+		attempts = previous.Attempts
+	}
+	if attempts >= s.config.Security.MFAMaxAttempts {
+		return nil, ErrTooManyMFAAttempts
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime := s.config.Security.MFAChallengeLifetime
+	_, err = s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{"$set": bson.M{"twoFactorChallenge": models.TwoFactorChallenge{
+			TokenHash: hashSecret(token),
+			Attempts:  attempts,
+			ExpiresAt: now.Add(lifetime),
+		}}},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la création de la connexion en attente")
+		return nil, err
+	}
+
+	return &models.AuthResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresIn:   utils.DurationToMilliseconds(lifetime),
+	}, nil
+}
+
+// recordFailedMFAAttempt compte un code erroné sur la connexion en attente
+func (s *Service) recordFailedMFAAttempt(ctx context.Context, user *models.User) {
+	_, err := s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "twoFactorChallenge.tokenHash": user.TwoFactorChallenge.TokenHash},
+		bson.M{"$inc": bson.M{"twoFactorChallenge.attempts": 1}},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de l'enregistrement d'un code de vérification erroné")
+	}
+}
+
+// consumeTwoFactorCode vérifie un code TOTP, ou un code de secours si allowRecovery, et l'invalide.
+// Les mises à jour conditionnelles empêchent qu'un même code serve deux fois, même en parallèle.
+func (s *Service) consumeTwoFactorCode(ctx context.Context, user *models.User, code string, allowRecovery bool) error {
+	if step, ok := matchTOTP(user.TwoFactorSecret, code, time.Now(), user.TwoFactorLastStep); ok {
+		result, err := s.db.Users.UpdateOne(ctx,
+			bson.M{"_id": user.ID, "twoFactorLastStep": user.TwoFactorLastStep},
+			bson.M{"$set": bson.M{"twoFactorLastStep": step}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	if !allowRecovery {
+		return ErrInvalidTwoFactorCode
+	}
+
+	hash := hashSecret(normalizeRecoveryCode(code))
+	result, err := s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "twoFactorRecoveryCodes": hash},
+		bson.M{"$pull": bson.M{"twoFactorRecoveryCodes": hash}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	log.Info().Str("userID", user.ID.Hex()).Int("remaining", len(user.TwoFactorRecoveryCodes)-1).Msg("Code de secours utilisé")
+	return nil
+}
+
+// getUserByID charge un utilisateur par son identifiant
+func (s *Service) getUserByID(ctx context.Context, userID string) (*models.User, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("ID utilisateur invalide")
+	}
+
+	var user models.User
+	if err := s.db.Users.FindOne(ctx, bson.M{"_id": id}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("utilisateur non trouvé")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// generateRecoveryCodes génère les codes de secours et leurs condensés stockés en base
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	// Les octets au-delà du dernier multiple de la taille de l'alphabet sont écartés pour ne pas biaiser le tirage
+	limit := 256 - 256%len(recoveryCodeAlphabet)
+	buf := make([]byte, 1)
+	for len(codes) < recoveryCodeCount {
+		raw := make([]byte, 0, recoveryCodeLength)
+		for len(raw) < recoveryCodeLength {
+			if _, err := rand.Read(buf); err != nil {
+				return nil, nil, err
+			}
+			if int(buf[0]) < limit {
+				raw = append(raw, recoveryCodeAlphabet[int(buf[0])%len(recoveryCodeAlphabet)])
+			}
+		}
+		code := string(raw[:recoveryCodeLength/2]) + "-" + string(raw[recoveryCodeLength/2:])
+		codes = append(codes, code)
+		hashes = append(hashes, hashSecret(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode rend la saisie d'un code de secours insensible à la casse et aux séparateurs
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// randomToken génère un jeton opaque aléatoire
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashSecret renvoie le condensé SHA-256 d'un jeton ou d'un code, seul conservé en base
+func hashSecret(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
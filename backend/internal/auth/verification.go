@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"genie/internal/models"
+)
+
+// Canaux de vérification des coordonnées
+const (
+	ChannelEmail = "email"
+	ChannelPhone = "phone"
+)
+
+// verifyCodeDigits est la longueur des codes de vérification
+const verifyCodeDigits = 6
+
+// verifySendWindow est la période sur laquelle les envois de codes sont comptés
+const verifySendWindow = time.Hour
+
+// Erreurs de la vérification des coordonnées
+var (
+	ErrInvalidVerificationCode     = errors.New("code de vérification invalide")
+	ErrVerificationExpired         = errors.New("code de vérification expiré, demandez un nouveau code")
+	ErrTooManyVerificationAttempts = errors.New("trop de codes erronés, demandez un nouveau code")
+	ErrVerificationThrottled       = errors.New("trop de demandes de code, veuillez réessayer plus tard")
+)
+
+// SendVerification envoie un nouveau code de vérification de l'email ou du téléphone du compte
+func (s *Service) SendVerification(ctx context.Context, userID string, channel string) error {
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	target := contactOf(user, channel)
+	if target == "" {
+		return fmt.Errorf("aucun %s à vérifier", channelLabel(channel))
+	}
+	if pendingVerification(user, channel) == nil && user.IsVerified {
+		return errors.New("compte déjà vérifié")
+	}
+
+	return s.startVerification(ctx, user, channel, target, false)
+}
+
+// ConfirmVerification vérifie le code reçu. Le compte est vérifié lorsqu'aucune autre vérification n'est en attente.
+func (s *Service) ConfirmVerification(ctx context.Context, userID string, channel string, code string) (*models.UserResponse, error) {
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := pendingVerification(user, channel)
+	if pending == nil {
+		return nil, errors.New("aucune vérification en attente")
+	}
+	if pending.CodeHash == "" || pending.Target != contactOf(user, channel) || time.Now().After(pending.ExpiresAt) {
+		return nil, ErrVerificationExpired
+	}
+	if pending.Attempts >= s.config.Security.VerifyMaxAttempts {
+		return nil, ErrTooManyVerificationAttempts
+	}
+
+	field := verificationField(channel)
+	hash := hashSecret(user.ID.Hex() + ":" + normalizeTOTPCode(code))
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(pending.CodeHash)) != 1 {
+		_, err := s.db.Users.UpdateOne(ctx,
+			bson.M{"_id": user.ID, field + ".codeHash": pending.CodeHash},
+			bson.M{"$inc": bson.M{field + ".attempts": 1}},
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Erreur lors de l'enregistrement d'un code de vérification erroné")
+		}
+		return nil, ErrInvalidVerificationCode
+	}
+
+	// Le filtre sur le code empêche qu'il serve deux fois
+	otherPending := pendingVerification(user, otherChannel(channel)) != nil
+	result, err := s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID, field + ".codeHash": pending.CodeHash},
+		bson.M{
+			"$set":   bson.M{"isVerified": !otherPending, "updatedAt": time.Now()},
+			"$unset": bson.M{field: ""},
+		},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de la confirmation de la vérification")
+		return nil, err
+	}
+	if result.ModifiedCount == 0 {
+		return nil, ErrVerificationExpired
+	}
+
+	user, err = s.getUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// IsVerified indique si le compte a vérifié ses coordonnées
+func (s *Service) IsVerified(ctx context.Context, userID string) (bool, error) {
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.IsVerified, nil
+}
+
+// startVerification enregistre une vérification en attente pour target et envoie le code.
+// Sans force, un nouvel envoi est refusé avant VerifyResendInterval. Au-delà de VerifyMaxSendsPerHour
+// envois, la vérification est enregistrée sans code : un nouveau code pourra être demandé plus tard.
+func (s *Service) startVerification(ctx context.Context, user *models.User, channel string, target string, force bool) error {
+	now := time.Now()
+	previous := pendingVerification(user, channel)
+
+	verification := models.ContactVerification{Target: target, SendWindowStart: now}
+	if previous != nil && now.Sub(previous.SendWindowStart) < verifySendWindow {
+		verification.SentAt = previous.SentAt
+		verification.SendCount = previous.SendCount
+		verification.SendWindowStart = previous.SendWindowStart
+	}
+
+	throttled := verification.SendCount >= s.config.Security.VerifyMaxSendsPerHour ||
+		(!force && previous != nil && now.Sub(previous.SentAt) < s.config.Security.VerifyResendInterval)
+	if throttled && !force {
+		return ErrVerificationThrottled
+	}
+
+	code := ""
+	if !throttled {
+		var err error
+		code, err = randomDigits(verifyCodeDigits)
+		if err != nil {
+			return err
+		}
+		verification.CodeHash = hashSecret(user.ID.Hex() + ":" + code)
+		verification.ExpiresAt = now.Add(s.config.Security.VerifyCodeLifetime)
+		verification.SentAt = now
+		verification.SendCount++
+	}
+
+	_, err := s.db.Users.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{"$set": bson.M{verificationField(channel): verification, "isVerified": false}},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de l'enregistrement du code de vérification")
+		return err
+	}
+	user.IsVerified = false
+	if channel == ChannelEmail {
+		user.EmailVerification = &verification
+	} else {
+		user.PhoneVerification = &verification
+	}
+
+	if throttled {
+		return ErrVerificationThrottled
+	}
+
+	if channel == ChannelEmail && s.emailService != nil {
+		err = s.emailService.SendVerificationCode(target, code, "email_verification")
+	} else if channel == ChannelPhone && s.smsService != nil {
+		err = s.smsService.SendVerificationCode(target, code, "phone_verification")
+	}
+	if err != nil {
+		log.Error().Err(err).Str("channel", channel).Msg("Erreur lors de l'envoi du code de vérification")
+		return fmt.Errorf("impossible d'envoyer le code de vérification: %w", err)
+	}
+	return nil
+}
+
+// contactOf renvoie l'email ou le téléphone du compte selon le canal
+func contactOf(user *models.User, channel string) string {
+	if channel == ChannelEmail {
+		return user.Email
+	}
+	return user.Phone
+}
+
+// pendingVerification renvoie la vérification en attente d'un canal, ou nil
+func pendingVerification(user *models.User, channel string) *models.ContactVerification {
+	if channel == ChannelEmail {
+		return user.EmailVerification
+	}
+	return user.PhoneVerification
+}
+
+// verificationField renvoie le champ du document utilisateur qui porte la vérification d'un canal
+func verificationField(channel string) string {
+	if channel == ChannelEmail {
+		return "emailVerification"
+	}
+	return "phoneVerification"
+}
+
+// otherChannel renvoie l'autre canal de vérification
+func otherChannel(channel string) string {
+	if channel == ChannelEmail {
+		return ChannelPhone
+	}
+	return ChannelEmail
+}
+
+// channelLabel renvoie le nom d'un canal pour les messages d'erreur
+func channelLabel(channel string) string {
+	if channel == ChannelEmail {
+		return "email"
+	}
+	return "numéro de téléphone"
+}
+
+// randomDigits génère un code numérique aléatoire
+func randomDigits(length int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(length)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", length, n), nil
+}
@@ -0,0 +1,343 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Algorithmes COSE acceptés pour les passkeys, par ordre de préférence
+const (
+	coseAlgES256 = -7
+	coseAlgEdDSA = -8
+	coseAlgRS256 = -257
+)
+
+// Drapeaux des données de l'authentificateur (WebAuthn §6.1)
+const (
+	authFlagUserPresent      = 0x01
+	authFlagUserVerified     = 0x04
+	authFlagBackupEligible   = 0x08
+	authFlagBackedUp         = 0x10
+	authFlagAttestedCredData = 0x40
+)
+
+// ErrInvalidPasskey est renvoyée lorsqu'une réponse d'authentificateur ne peut pas être vérifiée
+var ErrInvalidPasskey = errors.New("passkey invalide")
+
+// clientData est le contenu de clientDataJSON signé par l'authentificateur
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// parseClientData décode clientDataJSON et vérifie le type de cérémonie et l'origine
+func parseClientData(raw []byte, ceremony string, origins []string) (*clientData, error) {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	if data.Type != ceremony || data.Challenge == "" {
+		return nil, ErrInvalidPasskey
+	}
+
+	for _, origin := range origins {
+		if data.Origin == origin {
+			return &data, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: origine %q non autorisée", ErrInvalidPasskey, data.Origin)
+}
+
+// authenticatorData est la structure signée par l'authentificateur (WebAuthn §6.1)
+type authenticatorData struct {
+	RPIDHash  []byte
+	Flags     byte
+	SignCount uint32
+
+	// Présents à l'enregistrement uniquement
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte // clé publique au format COSE
+}
+
+// parseAuthenticatorData décode les données de l'authentificateur
+func parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, ErrInvalidPasskey
+	}
+
+	data := &authenticatorData{
+		RPIDHash:  raw[:32],
+		Flags:     raw[32],
+		SignCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	if data.Flags&authFlagAttestedCredData == 0 {
+		return data, nil
+	}
+
+	rest := raw[37:]
+	if len(rest) < 18 {
+		return nil, ErrInvalidPasskey
+	}
+	data.AAGUID = rest[:16]
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLength == 0 || len(rest) < idLength {
+		return nil, ErrInvalidPasskey
+	}
+	data.CredentialID = rest[:idLength]
+	rest = rest[idLength:]
+
+	// La clé COSE est suivie des éventuelles extensions : seule sa longueur est nécessaire ici
+	_, after, err := decodeCBOR(rest, 0)
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	data.PublicKey = rest[:len(rest)-len(after)]
+	return data, nil
+}
+
+// verify contrôle le domaine et la présence puis la vérification de l'utilisateur
+func (d *authenticatorData) verify(rpID string) error {
+	expected := sha256.Sum256([]byte(rpID))
+	if !bytes.Equal(d.RPIDHash, expected[:]) {
+		return fmt.Errorf("%w: domaine inattendu", ErrInvalidPasskey)
+	}
+	if d.Flags&authFlagUserPresent == 0 || d.Flags&authFlagUserVerified == 0 {
+		return fmt.Errorf("%w: utilisateur non vérifié par l'authentificateur", ErrInvalidPasskey)
+	}
+	return nil
+}
+
+// parseAttestationObject renvoie les données de l'authentificateur d'un objet d'attestation.
+// Les options d'enregistrement demandent une attestation "none" : la déclaration d'attestation n'est pas vérifiée.
+func parseAttestationObject(raw []byte) (*authenticatorData, error) {
+	value, _, err := decodeCBOR(raw, 0)
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	object, ok := value.(map[any]any)
+	if !ok {
+		return nil, ErrInvalidPasskey
+	}
+	authData, ok := object["authData"].([]byte)
+	if !ok {
+		return nil, ErrInvalidPasskey
+	}
+	return parseAuthenticatorData(authData)
+}
+
+// parseCOSEKey décode une clé publique COSE et renvoie la clé et son algorithme
+func parseCOSEKey(raw []byte) (crypto.PublicKey, int, error) {
+	value, _, err := decodeCBOR(raw, 0)
+	if err != nil {
+		return nil, 0, ErrInvalidPasskey
+	}
+	key, ok := value.(map[any]any)
+	if !ok {
+		return nil, 0, ErrInvalidPasskey
+	}
+
+	kty, _ := key[int64(1)].(int64)
+	alg, _ := key[int64(3)].(int64)
+	switch {
+	case kty == 2 && alg == coseAlgES256:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, ErrInvalidPasskey
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, 0, ErrInvalidPasskey
+		}
+		return pub, coseAlgES256, nil
+
+	case kty == 1 && alg == coseAlgEdDSA:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		if crv != 6 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, ErrInvalidPasskey
+		}
+		return ed25519.PublicKey(x), coseAlgEdDSA, nil
+
+	case kty == 3 && alg == coseAlgRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, ErrInvalidPasskey
+		}
+		exponent := int(new(big.Int).SetBytes(e).Int64())
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}, coseAlgRS256, nil
+	}
+	return nil, 0, fmt.Errorf("%w: algorithme non pris en charge", ErrInvalidPasskey)
+}
+
+// verifyAssertionSignature vérifie la signature d'une authentification,
+// portant sur les données de l'authentificateur suivies du condensé de clientDataJSON
+func verifyAssertionSignature(coseKey []byte, authData []byte, clientDataJSON []byte, signature []byte) error {
+	pub, alg, err := parseCOSEKey(coseKey)
+	if err != nil {
+		return err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, authData...), clientDataHash[:]...)
+
+	valid := false
+	switch alg {
+	case coseAlgES256:
+		digest := sha256.Sum256(signed)
+		valid = ecdsa.VerifyASN1(pub.(*ecdsa.PublicKey), digest[:], signature)
+	case coseAlgEdDSA:
+		valid = ed25519.Verify(pub.(ed25519.PublicKey), signed, signature)
+	case coseAlgRS256:
+		digest := sha256.Sum256(signed)
+		valid = rsa.VerifyPKCS1v15(pub.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	}
+	if !valid {
+		return fmt.Errorf("%w: signature invalide", ErrInvalidPasskey)
+	}
+	return nil
+}
+
+// decodeBase64URL décode les champs binaires des réponses WebAuthn, avec ou sans remplissage
+func decodeBase64URL(value string) ([]byte, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(trimBase64Padding(value))
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	return decoded, nil
+}
+
+func trimBase64Padding(value string) string {
+	for len(value) > 0 && value[len(value)-1] == '=' {
+		value = value[:len(value)-1]
+	}
+	return value
+}
+
+// cborMaxDepth et cborMaxItems bornent le décodage de données fournies par le client
+const (
+	cborMaxDepth = 8
+	cborMaxItems = 1024
+)
+
+var errCBOR = errors.New("CBOR invalide")
+
+// decodeCBOR décode la première valeur CBOR (RFC 8949) de data et renvoie la suite.
+// Seul le sous-ensemble utilisé par WebAuthn est pris en charge : entiers, chaînes d'octets et de texte,
+// tableaux, maps et valeurs simples, sans longueurs indéfinies. Les entiers sont des int64,
+// les maps des map[any]any indexées par int64 ou string.
+func decodeCBOR(data []byte, depth int) (any, []byte, error) {
+	if depth > cborMaxDepth || len(data) == 0 {
+		return nil, nil, errCBOR
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	var arg uint64
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info == 24 && len(data) >= 1:
+		arg, data = uint64(data[0]), data[1:]
+	case info == 25 && len(data) >= 2:
+		arg, data = uint64(binary.BigEndian.Uint16(data)), data[2:]
+	case info == 26 && len(data) >= 4:
+		arg, data = uint64(binary.BigEndian.Uint32(data)), data[4:]
+	case info == 27 && len(data) >= 8:
+		arg, data = binary.BigEndian.Uint64(data), data[8:]
+	default:
+		return nil, nil, errCBOR
+	}
+
+	switch major {
+	case 0: // entier positif
+		if arg > math.MaxInt64 {
+			return nil, nil, errCBOR
+		}
+		return int64(arg), data, nil
+
+	case 1: // entier négatif
+		if arg > math.MaxInt64 {
+			return nil, nil, errCBOR
+		}
+		return -1 - int64(arg), data, nil
+
+	case 2, 3: // chaîne d'octets, chaîne de texte
+		if arg > uint64(len(data)) {
+			return nil, nil, errCBOR
+		}
+		value := data[:arg]
+		if major == 3 {
+			return string(value), data[arg:], nil
+		}
+		return value, data[arg:], nil
+
+	case 4: // tableau
+		if arg > cborMaxItems {
+			return nil, nil, errCBOR
+		}
+		items := make([]any, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var item any
+			var err error
+			if item, data, err = decodeCBOR(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+
+	case 5: // map
+		if arg > cborMaxItems {
+			return nil, nil, errCBOR
+		}
+		entries := make(map[any]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value any
+			var err error
+			if key, data, err = decodeCBOR(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errCBOR
+			}
+			if value, data, err = decodeCBOR(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			entries[key] = value
+		}
+		return entries, data, nil
+
+	case 7: // valeurs simples
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		}
+	}
+	return nil, nil, errCBOR
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -19,6 +20,14 @@ type Config struct {
 	SMS      SMSConfig
 	Security SecurityConfig
 	Storage  StorageConfig
+	Reminders RemindersConfig
+	PriceTracking PriceTrackingConfig
+	Scraper   ScraperConfig
+	Unfurl    UnfurlConfig
+	Sharing   SharingConfig
+	Social    SocialConfig
+	RateLimit RateLimitConfig
+	Privacy   PrivacyConfig
 }
 
 // ServerConfig contient la configuration du serveur HTTP
@@ -57,6 +66,8 @@ type JWTConfig struct {
 	RefreshCookieName   string
 	RefreshCookieSecure bool
 	RefreshCookieHTTPOnly bool
+	SigningKeys         []string // clés asymétriques des tokens d'accès, "kid=fichier.pem" ; la première signe, les suivantes vérifient seulement
+	AcceptHMACAccessTokens bool  // avec des clés asymétriques, accepter encore les tokens d'accès HS256 émis avant le changement
 }
 
 // EmailConfig contient la configuration pour l'envoi d'emails
@@ -79,6 +90,23 @@ type SecurityConfig struct {
 	PasswordHashCost   int
 	ResetTokenLifetime time.Duration
 	VerifyCodeLifetime time.Duration
+	AdminUserIDs       []string // utilisateurs ayant le rôle admin sans qu'il soit enregistré (premiers administrateurs)
+	TwoFactorIssuer    string        // nom affiché dans les applications d'authentification
+	MFAChallengeLifetime time.Duration // durée pour saisir le code de double authentification
+	MFAMaxAttempts     int           // codes erronés tolérés par connexion
+	VerifyMaxAttempts  int           // codes erronés tolérés par code de vérification
+	VerifyResendInterval time.Duration // délai minimal entre deux envois d'un code de vérification
+	VerifyMaxSendsPerHour int        // envois de codes de vérification par heure et par canal
+	UnverifiedRestrictions []string  // actions interdites aux comptes non vérifiés (transfers, sharing, invitations)
+	WebAuthnRPID       string        // domaine des passkeys ; vide, les passkeys sont désactivées
+	WebAuthnRPName     string        // nom du service affiché par l'authentificateur
+	WebAuthnOrigins    []string      // origines acceptées (https://..., android:apk-key-hash:...)
+	PasskeyChallengeLifetime time.Duration // durée d'une cérémonie d'enregistrement ou de connexion
+	PasswordlessLifetime time.Duration // validité d'un code SMS ou d'un lien de connexion sans mot de passe
+	PasswordlessMaxAttempts int       // codes erronés tolérés par demande de connexion sans mot de passe
+	PasswordlessSignup bool           // création du compte à la première connexion sans mot de passe
+	MagicLinkURL       string        // page ou lien universel qui transmet le jeton du lien de connexion à l'application
+	ImpersonationLifetime time.Duration // validité d'un token d'usurpation délivré au support
 }
 
 // StorageConfig contient la configuration pour le stockage de fichiers
@@ -94,6 +122,82 @@ type StorageConfig struct {
 	MediaBucketPath   string
 }
 
+// RemindersConfig contient la configuration du planificateur de rappels
+type RemindersConfig struct {
+	Enabled              bool
+	PollInterval         time.Duration // fréquence de traitement des rappels échus
+	PlanInterval         time.Duration // fréquence de planification des nouveaux rappels
+	InvitationNudgeAfter time.Duration // délai avant de relancer une invitation sans réponse
+	BirthdayLeadTime     time.Duration // délai d'anticipation des anniversaires d'amis
+	BatchSize            int
+	MaxAttempts          int
+}
+
+// PriceTrackingConfig contient la configuration du suivi des prix des items de wishlist
+type PriceTrackingConfig struct {
+	Enabled        bool
+	PollInterval   time.Duration // fréquence de recherche des items à vérifier
+	CheckInterval  time.Duration // délai entre deux vérifications d'un même item
+	BatchSize      int
+	MinDropPercent float64 // baisse minimale signalée lorsqu'aucun prix cible n'est défini
+}
+
+// ScraperConfig contient la configuration des sources de produits externes
+type ScraperConfig struct {
+	CanopyAPIKey string
+}
+
+// UnfurlConfig contient la configuration de l'extraction des informations produit depuis une URL
+type UnfurlConfig struct {
+	Timeout      time.Duration // durée maximale d'une récupération de page
+	CacheTTL     time.Duration // durée de conservation des aperçus en cache
+	CacheSize    int
+	HostInterval time.Duration // délai minimal entre deux requêtes vers un même hôte
+	MaxHostWait  time.Duration // attente maximale avant de refuser une requête limitée
+	MaxBodyBytes int64
+	UserAgent    string
+}
+
+// SharingConfig contient la configuration des pages publiques de wishlist
+type SharingConfig struct {
+	PublicBaseURL        string        // URL du site qui affiche les pages publiques et les liens de confirmation
+	GuestConfirmationTTL time.Duration // durée de validité d'un lien de confirmation de réservation
+	GuestMaxPending      int           // réservations en attente de confirmation par adresse email
+}
+
+// SocialConfig contient la configuration de la vérification des connexions sociales.
+// Un fournisseur sans identifiant client configuré est refusé.
+type SocialConfig struct {
+	GoogleClientIDs []string      // audiences acceptées des jetons Google (clients web, iOS et Android)
+	AppleClientIDs  []string      // audiences acceptées des jetons Apple (bundle ID et services ID)
+	RequireNonce    bool          // refuser les jetons présentés sans nonce
+	JWKSCacheTTL    time.Duration // durée de conservation des clés publiques sans Cache-Control
+	HTTPTimeout     time.Duration
+}
+
+// RateLimitConfig contient les limites anti-abus des routes d'authentification et des pages publiques
+type RateLimitConfig struct {
+	Store               string        // "mongo" (partagé entre instances) ou "memory"
+	AuthPerIP           int           // requêtes par adresse IP et par route d'authentification sensible
+	AuthPerIPWindow     time.Duration
+	PublicPerIP         int           // requêtes par adresse IP et par route des pages publiques de wishlist
+	PublicPerIPWindow   time.Duration
+	PerIdentifier       int           // requêtes par email ou téléphone (connexion, vérification, réinitialisation)
+	PerIdentifierWindow time.Duration
+	FreeFailures        int           // échecs de connexion, ou de mot de passe d'un lien public, tolérés avant d'imposer un délai
+	FailureBaseDelay    time.Duration // délai imposé ensuite, doublé à chaque échec
+	FailureMaxDelay     time.Duration
+	LockoutThreshold    int           // échecs entraînant le verrouillage temporaire du compte ou du lien public
+	LockoutDuration     time.Duration
+	ResetCodeMaxAttempts int          // essais par code de réinitialisation avant son invalidation
+}
+
+// PrivacyConfig contient la configuration de l'export des données et de la suppression des comptes (RGPD)
+type PrivacyConfig struct {
+	DeletionGracePeriod time.Duration // délai pendant lequel une demande de suppression peut être annulée
+	PurgeInterval       time.Duration // fréquence de suppression des comptes dont le délai est écoulé
+}
+
 // Load charge la configuration à partir des variables d'environnement et des flags CLI
 func Load(cliMongoURI string) (*Config, error) { // Accept CLI flag value
 	// Charger les variables d'environnement depuis .env si le fichier existe
@@ -144,6 +248,8 @@ func Load(cliMongoURI string) (*Config, error) { // Accept CLI flag value
 			RefreshCookieName:   getEnv("JWT_REFRESH_COOKIE_NAME", "refresh_token"),
 			RefreshCookieSecure: getBoolEnv("JWT_REFRESH_COOKIE_SECURE", true),
 			RefreshCookieHTTPOnly: getBoolEnv("JWT_REFRESH_COOKIE_HTTP_ONLY", true),
+			SigningKeys:         getListEnv("JWT_SIGNING_KEYS", []string{}),
+			AcceptHMACAccessTokens: getBoolEnv("JWT_ACCEPT_HMAC_ACCESS_TOKENS", true),
 		},
 		Email: EmailConfig{
 			SendgridAPIKey: getEnv("SENDGRID_API_KEY", ""),
@@ -160,6 +266,23 @@ func Load(cliMongoURI string) (*Config, error) { // Accept CLI flag value
 			PasswordHashCost:   getIntEnv("PASSWORD_HASH_COST", 10),
 			ResetTokenLifetime: getDurationEnv("RESET_TOKEN_LIFETIME", 15*time.Minute),
 			VerifyCodeLifetime: getDurationEnv("VERIFY_CODE_LIFETIME", 15*time.Minute),
+			AdminUserIDs:       getListEnv("ADMIN_USER_IDS", []string{}),
+			TwoFactorIssuer:    getEnv("TWO_FACTOR_ISSUER", "Genie"),
+			MFAChallengeLifetime: getDurationEnv("MFA_CHALLENGE_LIFETIME", 5*time.Minute),
+			MFAMaxAttempts:     getIntEnv("MFA_MAX_ATTEMPTS", 5),
+			VerifyMaxAttempts:  getIntEnv("VERIFY_MAX_ATTEMPTS", 5),
+			VerifyResendInterval: getDurationEnv("VERIFY_RESEND_INTERVAL", time.Minute),
+			VerifyMaxSendsPerHour: getIntEnv("VERIFY_MAX_SENDS_PER_HOUR", 5),
+			UnverifiedRestrictions: getListEnv("UNVERIFIED_RESTRICTIONS", []string{"transfers", "sharing"}),
+			WebAuthnRPID:       getEnv("WEBAUTHN_RP_ID", ""),
+			WebAuthnRPName:     getEnv("WEBAUTHN_RP_NAME", "Genie"),
+			WebAuthnOrigins:    getListEnv("WEBAUTHN_ORIGINS", []string{}),
+			PasskeyChallengeLifetime: getDurationEnv("PASSKEY_CHALLENGE_LIFETIME", 5*time.Minute),
+			PasswordlessLifetime: getDurationEnv("PASSWORDLESS_LIFETIME", 10*time.Minute),
+			PasswordlessMaxAttempts: getIntEnv("PASSWORDLESS_MAX_ATTEMPTS", 5),
+			PasswordlessSignup: getBoolEnv("PASSWORDLESS_SIGNUP", false),
+			MagicLinkURL:       getEnv("MAGIC_LINK_URL", "http://localhost:3000/auth/magic-link"),
+			ImpersonationLifetime: getDurationEnv("IMPERSONATION_TOKEN_LIFETIME", 15*time.Minute),
 		},
 		Storage: StorageConfig{
 			S3Bucket:         getEnv("S3_BUCKET", ""),
@@ -172,6 +295,65 @@ func Load(cliMongoURI string) (*Config, error) { // Accept CLI flag value
 			AvatarBucketPath: getEnv("AVATAR_BUCKET_PATH", "avatars"),
 			MediaBucketPath:  getEnv("MEDIA_BUCKET_PATH", "media"),
 		},
+		Reminders: RemindersConfig{
+			Enabled:              getBoolEnv("REMINDERS_ENABLED", true),
+			PollInterval:         getDurationEnv("REMINDERS_POLL_INTERVAL", time.Minute),
+			PlanInterval:         getDurationEnv("REMINDERS_PLAN_INTERVAL", time.Hour),
+			InvitationNudgeAfter: getDurationEnv("REMINDERS_INVITATION_NUDGE_AFTER", 3*24*time.Hour),
+			BirthdayLeadTime:     getDurationEnv("REMINDERS_BIRTHDAY_LEAD_TIME", 7*24*time.Hour),
+			BatchSize:            getIntEnv("REMINDERS_BATCH_SIZE", 100),
+			MaxAttempts:          getIntEnv("REMINDERS_MAX_ATTEMPTS", 5),
+		},
+		PriceTracking: PriceTrackingConfig{
+			Enabled:        getBoolEnv("PRICE_TRACKING_ENABLED", true),
+			PollInterval:   getDurationEnv("PRICE_TRACKING_POLL_INTERVAL", 15*time.Minute),
+			CheckInterval:  getDurationEnv("PRICE_TRACKING_CHECK_INTERVAL", 12*time.Hour),
+			BatchSize:      getIntEnv("PRICE_TRACKING_BATCH_SIZE", 50),
+			MinDropPercent: float64(getIntEnv("PRICE_TRACKING_MIN_DROP_PERCENT", 5)),
+		},
+		Scraper: ScraperConfig{
+			CanopyAPIKey: getEnv("CANOPY_API_KEY", ""),
+		},
+		Unfurl: UnfurlConfig{
+			Timeout:      getDurationEnv("UNFURL_TIMEOUT", 10*time.Second),
+			CacheTTL:     getDurationEnv("UNFURL_CACHE_TTL", 6*time.Hour),
+			CacheSize:    getIntEnv("UNFURL_CACHE_SIZE", 1000),
+			HostInterval: getDurationEnv("UNFURL_HOST_INTERVAL", time.Second),
+			MaxHostWait:  getDurationEnv("UNFURL_MAX_HOST_WAIT", 3*time.Second),
+			MaxBodyBytes: getInt64Env("UNFURL_MAX_BODY_BYTES", 2*1024*1024),
+			UserAgent:    getEnv("UNFURL_USER_AGENT", "Mozilla/5.0 (compatible; GenieBot/1.0)"),
+		},
+		Sharing: SharingConfig{
+			PublicBaseURL:        strings.TrimRight(getEnv("PUBLIC_BASE_URL", "http://localhost:3000"), "/"),
+			GuestConfirmationTTL: getDurationEnv("GUEST_CONFIRMATION_TTL", 48*time.Hour),
+			GuestMaxPending:      getIntEnv("GUEST_MAX_PENDING", 5),
+		},
+		Social: SocialConfig{
+			GoogleClientIDs: getListEnv("GOOGLE_CLIENT_IDS", []string{}),
+			AppleClientIDs:  getListEnv("APPLE_CLIENT_IDS", []string{}),
+			RequireNonce:    getBoolEnv("SOCIAL_REQUIRE_NONCE", false),
+			JWKSCacheTTL:    getDurationEnv("SOCIAL_JWKS_CACHE_TTL", time.Hour),
+			HTTPTimeout:     getDurationEnv("SOCIAL_HTTP_TIMEOUT", 5*time.Second),
+		},
+		RateLimit: RateLimitConfig{
+			Store:               getEnv("RATE_LIMIT_STORE", "mongo"),
+			AuthPerIP:           getIntEnv("RATE_LIMIT_AUTH_PER_IP", 20),
+			AuthPerIPWindow:     getDurationEnv("RATE_LIMIT_AUTH_PER_IP_WINDOW", time.Minute),
+			PublicPerIP:         getIntEnv("RATE_LIMIT_PUBLIC_PER_IP", 60),
+			PublicPerIPWindow:   getDurationEnv("RATE_LIMIT_PUBLIC_PER_IP_WINDOW", time.Minute),
+			PerIdentifier:       getIntEnv("RATE_LIMIT_PER_IDENTIFIER", 10),
+			PerIdentifierWindow: getDurationEnv("RATE_LIMIT_PER_IDENTIFIER_WINDOW", 15*time.Minute),
+			FreeFailures:        getIntEnv("LOGIN_FREE_FAILURES", 3),
+			FailureBaseDelay:    getDurationEnv("LOGIN_FAILURE_BASE_DELAY", time.Second),
+			FailureMaxDelay:     getDurationEnv("LOGIN_FAILURE_MAX_DELAY", time.Minute),
+			LockoutThreshold:    getIntEnv("LOGIN_LOCKOUT_THRESHOLD", 10),
+			LockoutDuration:     getDurationEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+			ResetCodeMaxAttempts: getIntEnv("RESET_CODE_MAX_ATTEMPTS", 5),
+		},
+		Privacy: PrivacyConfig{
+			DeletionGracePeriod: getDurationEnv("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour),
+			PurgeInterval:       getDurationEnv("ACCOUNT_PURGE_INTERVAL", time.Hour),
+		},
 	}
 
 	// Valider les paramètres critiques
@@ -267,4 +449,19 @@ func getSliceEnv(key string, defaultValue []string) []string {
 		return defaultValue
 	}
 	return []string{value}
-}
\ No newline at end of file
+}
+
+// getListEnv lit une liste de valeurs séparées par des virgules
+func getListEnv(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
@@ -145,6 +145,16 @@ func (d *Database) CreateIndexes(ctx context.Context) error {
 			Keys:    bson.D{{Key: "resetToken", Value: 1}},
 			Options: options.Index().SetSparse(true),
 		},
+		{
+			Keys:    bson.D{{Key: "twoFactorChallenge.tokenHash", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			// Index partiel : un tableau de passkeys vidé ne doit pas entrer en conflit avec les autres
+			Keys: bson.D{{Key: "passkeys.credentialId", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"passkeys.credentialId": bson.M{"$exists": true}}),
+		},
 		{
 			Keys:    bson.D{{Key: "socialAuth.provider", Value: 1}, {Key: "socialAuth.userId", Value: 1}},
 			Options: options.Index().SetUnique(true).SetSparse(true),
@@ -0,0 +1,220 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"genie/internal/messaging"
+	"genie/internal/models"
+)
+
+const (
+	// maxPlusOnes caps the number of extra guests a participant may bring
+	maxPlusOnes = 10
+
+	// maxMutationRetries bounds optimistic concurrency retries
+	maxMutationRetries = 5
+)
+
+var (
+	ErrAlreadyParticipant = errors.New("user is already a participant")
+	ErrParticipantMissing = errors.New("participant not found")
+	ErrConcurrentUpdate   = errors.New("event was modified concurrently")
+	ErrNoRoomForGuests    = errors.New("not enough room left for these guests")
+)
+
+// mutateEvent loads an event, applies mutate and saves it back, retrying when
+// another request modified the event in between (optimistic concurrency on updatedAt)
+func (s *Service) mutateEvent(ctx context.Context, id primitive.ObjectID, mutate func(event *models.Event, now time.Time) error) (*models.Event, error) {
+	collection := s.db.Collection(eventsCollection)
+
+	for attempt := 0; attempt < maxMutationRetries; attempt++ {
+		var event models.Event
+		err := collection.FindOne(ctx, bson.M{"_id": id, "deletedAt": nil}).Decode(&event)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, ErrEventNotFound
+			}
+			return nil, err
+		}
+
+		now := time.Now()
+		previousUpdate := event.UpdatedAt
+		if err := mutate(&event, now); err != nil {
+			return nil, err
+		}
+		event.UpdatedAt = now
+
+		result, err := collection.ReplaceOne(ctx, bson.M{"_id": id, "updatedAt": previousUpdate}, &event)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 1 {
+			return &event, nil
+		}
+	}
+
+	return nil, ErrConcurrentUpdate
+}
+
+// findParticipant returns the index of a user in the participants, or -1
+func findParticipant(event *models.Event, uid primitive.ObjectID) int {
+	for i, p := range event.Participants {
+		if p.UserID == uid {
+			return i
+		}
+	}
+	return -1
+}
+
+// seatsTaken counts confirmed participants and their plus-ones
+func seatsTaken(event *models.Event) int {
+	seats := 0
+	for _, p := range event.Participants {
+		if p.Status == models.EventStatusConfirmed {
+			seats += 1 + p.PlusOnes
+		}
+	}
+	return seats
+}
+
+// hasRoomFor reports whether a party of the given size fits in the event
+func hasRoomFor(event *models.Event, party int) bool {
+	return event.MaxCapacity <= 0 || seatsTaken(event)+party <= event.MaxCapacity
+}
+
+// applyRSVP records a participant's answer, putting them on the waitlist
+// when confirming would exceed the event capacity. A confirmed participant keeps
+// their seat: bringing more guests than there is room for is refused instead.
+func applyRSVP(event *models.Event, index int, status string, plusOnes int, now time.Time) error {
+	p := &event.Participants[index]
+
+	if status == models.EventStatusConfirmed && p.Status == models.EventStatusConfirmed &&
+		plusOnes > p.PlusOnes && !hasRoomFor(event, plusOnes-p.PlusOnes) {
+		return ErrNoRoomForGuests
+	}
+
+	// Free the participant's seats before checking capacity
+	p.Status = models.EventStatusInvited
+	p.PlusOnes = plusOnes
+	p.RespondedAt = &now
+
+	if status == models.EventStatusConfirmed && !hasRoomFor(event, 1+plusOnes) {
+		p.Status = models.EventStatusWaitlisted
+		if p.WaitlistedAt == nil {
+			p.WaitlistedAt = &now
+		}
+		return nil
+	}
+
+	p.Status = status
+	p.WaitlistedAt = nil
+	return nil
+}
+
+// promoteWaitlist confirms waitlisted participants, first come first served,
+// as long as seats are available. It returns the promoted user IDs.
+func promoteWaitlist(event *models.Event, now time.Time) []primitive.ObjectID {
+	var waitlisted []int
+	for i, p := range event.Participants {
+		if p.Status == models.EventStatusWaitlisted {
+			waitlisted = append(waitlisted, i)
+		}
+	}
+
+	sort.SliceStable(waitlisted, func(a, b int) bool {
+		pa, pb := event.Participants[waitlisted[a]], event.Participants[waitlisted[b]]
+		if pa.WaitlistedAt == nil || pb.WaitlistedAt == nil {
+			return pb.WaitlistedAt == nil && pa.WaitlistedAt != nil
+		}
+		return pa.WaitlistedAt.Before(*pb.WaitlistedAt)
+	})
+
+	var promoted []primitive.ObjectID
+	for _, i := range waitlisted {
+		p := &event.Participants[i]
+		if !hasRoomFor(event, 1+p.PlusOnes) {
+			break
+		}
+		p.Status = models.EventStatusConfirmed
+		p.WaitlistedAt = nil
+		p.RespondedAt = &now
+		promoted = append(promoted, p.UserID)
+	}
+
+	if len(promoted) > 0 {
+		log.Info().Str("eventID", event.ID.Hex()).Int("promoted", len(promoted)).Msg("Promoted waitlisted participants")
+	}
+	return promoted
+}
+
+// computeHeadcount summarizes the attendance of an event
+func computeHeadcount(event *models.Event) *models.EventHeadcount {
+	headcount := &models.EventHeadcount{Capacity: event.MaxCapacity}
+
+	for _, p := range event.Participants {
+		switch p.Status {
+		case models.EventStatusConfirmed:
+			headcount.Confirmed++
+			headcount.PlusOnes += p.PlusOnes
+		case models.EventStatusMaybe:
+			headcount.Maybe++
+		case models.EventStatusInvited:
+			headcount.Invited++
+		case models.EventStatusDeclined:
+			headcount.Declined++
+		case models.EventStatusWaitlisted:
+			headcount.Waitlisted++
+		}
+	}
+
+	headcount.Attending = headcount.Confirmed + headcount.PlusOnes
+	if event.MaxCapacity > 0 {
+		headcount.SeatsLeft = event.MaxCapacity - headcount.Attending
+		if headcount.SeatsLeft < 0 {
+			headcount.SeatsLeft = 0
+		}
+	}
+	return headcount
+}
+
+// withHeadcount attaches the headcount summary for users allowed to see it
+func withHeadcount(event *models.Event, uid primitive.ObjectID) *models.Event {
+	if canOnEvent(event, uid, ActionViewHeadcount) {
+		event.Headcount = computeHeadcount(event)
+	}
+	return event
+}
+
+// notifyPromoted tells the participants moved off the waitlist that they now have a seat.
+// Push is best effort: they may simply not be connected.
+func (s *Service) notifyPromoted(event *models.Event, promoted []primitive.ObjectID) {
+	if s.pusher == nil || len(promoted) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(messaging.WebsocketMessage{
+		Type: "event_waitlist_promoted",
+		Payload: map[string]interface{}{
+			"eventId":   event.ID.Hex(),
+			"title":     event.Title,
+			"startDate": event.StartDate,
+		},
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode waitlist promotion")
+		return
+	}
+
+	for _, userID := range promoted {
+		s.pusher.SendToUser(userID.Hex(), data)
+	}
+}
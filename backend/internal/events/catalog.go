@@ -0,0 +1,277 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"genie/internal/models"
+)
+
+const (
+	predefinedEventsCollection = "predefinedEvents"
+)
+
+var (
+	ErrPredefinedEventNotFound = errors.New("predefined event not found")
+	ErrPredefinedEventExists   = errors.New("predefined event already exists")
+	ErrInvalidPredefinedEvent  = errors.New("invalid predefined event data")
+)
+
+// seedPredefinedEvents fills the catalog with the built-in events when it is empty.
+// Seeding only an empty catalog keeps admin edits and deletions across restarts.
+func (s *Service) seedPredefinedEvents(ctx context.Context) error {
+	collection := s.db.Collection(predefinedEventsCollection)
+
+	count, err := collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	seed := builtinPredefinedEvents()
+	docs := make([]interface{}, 0, len(seed))
+	for _, event := range seed {
+		docs = append(docs, event)
+	}
+
+	_, err = collection.InsertMany(ctx, docs)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Int("count", len(docs)).Msg("Seeded predefined events catalog")
+	return nil
+}
+
+// builtinPredefinedEvents returns the built-in catalog in display order
+func builtinPredefinedEvents() []PredefinedEvent {
+	all := append([]PredefinedEvent{}, collectiveEvents...)
+	all = append(all, individualEvents...)
+	all = append(all, specialEvents...)
+
+	for i := range all {
+		all[i].SortOrder = (i + 1) * 10
+		all[i].Translations = seedTranslations[all[i].ID]
+	}
+	return all
+}
+
+// GetAllPredefinedEvents returns the predefined events catalog localized for the given locales
+func (s *Service) GetAllPredefinedEvents(ctx context.Context, locales []string) ([]PredefinedEvent, error) {
+	events, err := s.ListPredefinedEventsForAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range events {
+		events[i] = localizePredefinedEvent(events[i], locales)
+	}
+	return events, nil
+}
+
+// GetPredefinedEvent returns a specific predefined event localized for the given locales
+func (s *Service) GetPredefinedEvent(ctx context.Context, id string, locales []string) (*PredefinedEvent, error) {
+	var event PredefinedEvent
+	err := s.db.Collection(predefinedEventsCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&event)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrPredefinedEventNotFound
+		}
+		return nil, err
+	}
+
+	localized := localizePredefinedEvent(event, locales)
+	return &localized, nil
+}
+
+// ListPredefinedEventsForAdmin returns the raw catalog, translations included
+func (s *Service) ListPredefinedEventsForAdmin(ctx context.Context) ([]PredefinedEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "sortOrder", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.db.Collection(predefinedEventsCollection).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []PredefinedEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// CreatePredefinedEvent adds an occasion to the catalog
+func (s *Service) CreatePredefinedEvent(ctx context.Context, event *PredefinedEvent) (*PredefinedEvent, error) {
+	if err := validatePredefinedEvent(event); err != nil {
+		return nil, err
+	}
+	event.Locale = ""
+
+	_, err := s.db.Collection(predefinedEventsCollection).InsertOne(ctx, event)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrPredefinedEventExists
+		}
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// UpdatePredefinedEvent replaces a catalog entry
+func (s *Service) UpdatePredefinedEvent(ctx context.Context, id string, event *PredefinedEvent) (*PredefinedEvent, error) {
+	event.ID = id // The ID is the key events refer to and cannot change
+	if err := validatePredefinedEvent(event); err != nil {
+		return nil, err
+	}
+	event.Locale = ""
+
+	result, err := s.db.Collection(predefinedEventsCollection).ReplaceOne(ctx, bson.M{"_id": id}, event)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrPredefinedEventNotFound
+	}
+
+	return event, nil
+}
+
+// DeletePredefinedEvent removes a catalog entry. Existing events keep their predefinedType.
+func (s *Service) DeletePredefinedEvent(ctx context.Context, id string) error {
+	result, err := s.db.Collection(predefinedEventsCollection).DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrPredefinedEventNotFound
+	}
+	return nil
+}
+
+// validatePredefinedEvent checks a catalog entry before it is stored
+func validatePredefinedEvent(event *PredefinedEvent) error {
+	event.ID = strings.TrimSpace(event.ID)
+	if event.ID == "" || strings.TrimSpace(event.Name) == "" {
+		return ErrInvalidPredefinedEvent
+	}
+
+	switch event.Type {
+	case string(models.EventTypeCollective), string(models.EventTypeIndividual), string(models.EventTypeSpecial):
+	default:
+		return ErrInvalidPredefinedEvent
+	}
+
+	if event.DefaultDate != "" {
+		if _, err := parsePredefinedDate(event.DefaultDate); err != nil {
+			return ErrInvalidPredefinedEvent
+		}
+	}
+
+	for locale := range event.Translations {
+		if locale == "" || locale != strings.ToLower(locale) {
+			return ErrInvalidPredefinedEvent
+		}
+	}
+
+	return nil
+}
+
+// localizePredefinedEvent resolves the texts of an entry for the first
+// supported locale, falling back to DefaultLocale
+func localizePredefinedEvent(event PredefinedEvent, locales []string) PredefinedEvent {
+	translations := event.Translations
+	event.Translations = nil
+	event.Locale = DefaultLocale
+
+	for _, locale := range locales {
+		candidates := []string{locale}
+		if base, _, found := strings.Cut(locale, "-"); found {
+			candidates = append(candidates, base)
+		}
+
+		for _, candidate := range candidates {
+			if candidate == DefaultLocale {
+				return event
+			}
+			translation, ok := translations[candidate]
+			if !ok {
+				continue
+			}
+
+			event.Locale = candidate
+			if translation.Name != "" {
+				event.Name = translation.Name
+			}
+			if translation.DefaultDate != "" {
+				event.DefaultDate = translation.DefaultDate
+			}
+			if translation.Invitations != "" {
+				event.Invitations = translation.Invitations
+			}
+			if translation.Info != "" {
+				event.Info = translation.Info
+			}
+			return event
+		}
+	}
+
+	return event
+}
+
+// ParseAcceptLanguage returns the locales of an Accept-Language header,
+// lower-cased and ordered by preference
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+
+	var entries []weighted
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		locale, params, _ := strings.Cut(part, ";")
+		locale = strings.ToLower(strings.TrimSpace(locale))
+		if locale == "" || locale == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		entries = append(entries, weighted{locale: locale, q: q})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].q > entries[j].q
+	})
+
+	locales := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		locales = append(locales, entry.locale)
+	}
+	return locales
+}
@@ -1,8 +1,11 @@
 package events
 
+// DefaultLocale is the locale the predefined events catalog is written in
+const DefaultLocale = "fr"
+
 // Constants for invitation types
 const (
-	InvitationEveryone  = "Tous le monde"
+	InvitationEveryone  = "Tout le monde"
 	InvitationOnePerson = "Qté 1 personne de +18 ans"
 )
 
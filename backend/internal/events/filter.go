@@ -0,0 +1,239 @@
+package events
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"genie/internal/models"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 100
+
+	// earthRadiusKm converts a distance into radians for $centerSphere
+	earthRadiusKm = 6378.1
+)
+
+// Listing scopes
+const (
+	// ScopeMine lists events the user takes part in
+	ScopeMine = "mine"
+	// ScopePublic lists public events, whether or not the user takes part in them
+	ScopePublic = "public"
+)
+
+// Listing time windows
+const (
+	WhenUpcoming = "upcoming"
+	WhenPast     = "past"
+)
+
+var (
+	ErrInvalidFilter = errors.New("invalid event filter")
+	ErrInvalidCursor = errors.New("invalid pagination cursor")
+)
+
+// NearFilter restricts a listing to events within a radius of a point
+type NearFilter struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+}
+
+// ListFilter holds the optional criteria of an event listing
+type ListFilter struct {
+	Scope          string
+	When           string // upcoming, past or empty for both
+	From           *time.Time
+	To             *time.Time
+	Type           models.EventType
+	PredefinedType string
+	Role           string // role of the current user in the event
+	Status         string // RSVP status of the current user in the event
+	Search         string // text search on title and description
+	Near           *NearFilter
+	Cursor         string
+	Limit          int
+}
+
+// listCursor identifies the last event of a page
+type listCursor struct {
+	StartDate time.Time
+	ID        primitive.ObjectID
+}
+
+// encodeCursor builds the opaque cursor pointing after an event
+func encodeCursor(event *models.Event) string {
+	raw := fmt.Sprintf("%d:%s", event.StartDate.UnixNano(), event.ID.Hex())
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor parses a cursor produced by encodeCursor
+func decodeCursor(cursor string) (*listCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	id, err := primitive.ObjectIDFromHex(parts[1])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &listCursor{StartDate: time.Unix(0, nanos), ID: id}, nil
+}
+
+// sortDirection returns the startDate sort order of a listing:
+// past events are listed most recent first, everything else chronologically
+func (f *ListFilter) sortDirection() int {
+	if f.When == WhenPast {
+		return -1
+	}
+	return 1
+}
+
+// normalize validates the filter and applies defaults
+func (f *ListFilter) normalize() error {
+	switch f.Scope {
+	case "":
+		f.Scope = ScopeMine
+	case ScopeMine, ScopePublic:
+	default:
+		return ErrInvalidFilter
+	}
+
+	switch f.When {
+	case "", WhenUpcoming, WhenPast:
+	default:
+		return ErrInvalidFilter
+	}
+
+	if f.From != nil && f.To != nil && f.To.Before(*f.From) {
+		return ErrInvalidFilter
+	}
+
+	if f.Near != nil {
+		if f.Near.Latitude < -90 || f.Near.Latitude > 90 ||
+			f.Near.Longitude < -180 || f.Near.Longitude > 180 ||
+			f.Near.RadiusKm <= 0 {
+			return ErrInvalidFilter
+		}
+	}
+
+	if f.Limit <= 0 {
+		f.Limit = defaultListLimit
+	}
+	if f.Limit > maxListLimit {
+		f.Limit = maxListLimit
+	}
+
+	return nil
+}
+
+// buildQuery translates the filter into a MongoDB query for the given user
+func (f *ListFilter) buildQuery(uid primitive.ObjectID, now time.Time) (bson.M, error) {
+	clauses := []bson.M{{"deletedAt": nil}}
+
+	// Visibility
+	if f.Scope == ScopePublic {
+		clauses = append(clauses, bson.M{"isPrivate": false})
+	} else {
+		clauses = append(clauses, bson.M{"$or": []bson.M{
+			{"participants.userId": uid},
+			{"creatorId": uid},
+		}})
+	}
+
+	// Time window
+	dateRange := bson.M{}
+	switch f.When {
+	case WhenUpcoming:
+		dateRange["$gte"] = now
+	case WhenPast:
+		dateRange["$lt"] = now
+	}
+	if f.From != nil {
+		clauses = append(clauses, bson.M{"startDate": bson.M{"$gte": *f.From}})
+	}
+	if f.To != nil {
+		clauses = append(clauses, bson.M{"startDate": bson.M{"$lte": *f.To}})
+	}
+	if len(dateRange) > 0 {
+		clauses = append(clauses, bson.M{"startDate": dateRange})
+	}
+
+	if f.Type != "" {
+		clauses = append(clauses, bson.M{"type": f.Type})
+	}
+	if f.PredefinedType != "" {
+		clauses = append(clauses, bson.M{"predefinedType": f.PredefinedType})
+	}
+
+	// Role and RSVP status apply to the current user's own participation
+	if f.Role != "" || f.Status != "" {
+		match := bson.M{"userId": uid}
+		if f.Role != "" {
+			match["role"] = f.Role
+		}
+		if f.Status != "" {
+			match["status"] = f.Status
+		}
+		clauses = append(clauses, bson.M{"participants": bson.M{"$elemMatch": match}})
+	}
+
+	if search := strings.TrimSpace(f.Search); search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(search), Options: "i"}
+		clauses = append(clauses, bson.M{"$or": []bson.M{
+			{"title": pattern},
+			{"description": pattern},
+		}})
+	}
+
+	if f.Near != nil {
+		clauses = append(clauses, bson.M{"location.geo": bson.M{
+			"$geoWithin": bson.M{
+				"$centerSphere": []interface{}{
+					[]float64{f.Near.Longitude, f.Near.Latitude},
+					f.Near.RadiusKm / earthRadiusKm,
+				},
+			},
+		}})
+	}
+
+	// Keyset pagination on (startDate, _id)
+	if f.Cursor != "" {
+		cursor, err := decodeCursor(f.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		op := "$gt"
+		if f.sortDirection() < 0 {
+			op = "$lt"
+		}
+		clauses = append(clauses, bson.M{"$or": []bson.M{
+			{"startDate": bson.M{op: cursor.StartDate}},
+			{"startDate": cursor.StartDate, "_id": bson.M{op: cursor.ID}},
+		}})
+	}
+
+	return bson.M{"$and": clauses}, nil
+}
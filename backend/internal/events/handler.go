@@ -1,9 +1,12 @@
 package events
 
 import (
+	"fmt"
 	"genie/internal/middleware" // Importer le package middleware
 	"genie/internal/models"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -28,6 +31,7 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	// Event CRUD operations
 	router.POST("", h.CreateEvent)
 	router.GET("/list", h.ListEvents) // Changé de "" à "/list"
+	router.GET("/nearby", h.ListNearbyEvents)
 	router.GET("/:id", h.GetEvent)
 	router.PUT("/:id", h.UpdateEvent)
 	router.DELETE("/:id", h.DeleteEvent)
@@ -36,18 +40,34 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/:id/participants", h.AddParticipant)
 	router.DELETE("/:id/participants/:userId", h.RemoveParticipant)
 	router.PUT("/:id/participants/status", h.UpdateParticipantStatus)
+	router.PUT("/:id/participants/:userId/role", h.UpdateParticipantRole)
 
 	// Gifts management
 	router.POST("/:id/gifts", h.AddGift)
 	router.PUT("/:id/gifts/:giftId", h.UpdateGift)
 	router.DELETE("/:id/gifts/:giftId", h.DeleteGift)
 
+	// Honoree wishlists linked to the event
+	router.POST("/:id/wishlists", h.LinkWishlist)
+	router.DELETE("/:id/wishlists/:wishlistId", h.UnlinkWishlist)
+	router.GET("/:id/registry", h.GetRegistry)
+	router.PUT("/:id/registry/:itemId/reservation", h.ReserveRegistryItem)
+
 	// Predefined events routes
 	router.GET("/predefined", h.ListPredefinedEvents)
 	router.POST("/predefined/:type", h.CreateFromPredefined)
 }
 
-// ListEvents returns all events for the current user
+// RegisterAdminRoutes registers the predefined events catalog administration routes
+func (h *Handler) RegisterAdminRoutes(router *gin.RouterGroup) {
+	router.GET("", h.AdminListPredefinedEvents)
+	router.POST("", h.AdminCreatePredefinedEvent)
+	router.PUT("/:type", h.AdminUpdatePredefinedEvent)
+	router.DELETE("/:type", h.AdminDeletePredefinedEvent)
+}
+
+// ListEvents returns the events of the current user matching the query filters.
+// The cursor of the next page, if any, is returned in the X-Next-Cursor header.
 func (h *Handler) ListEvents(c *gin.Context) {
 	log.Debug().Msg(">>> HANDLER: ListEvents CALLED") // Ajouter log ici
 	// Get user ID from token using the correct key from middleware
@@ -57,17 +77,130 @@ func (h *Handler) ListEvents(c *gin.Context) {
 		return
 	}
 
-	// Get events for the user
-	events, err := h.service.ListUserEvents(c.Request.Context(), userIDValue.(string))
+	filter, err := parseListFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondWithEvents(c, userIDValue.(string), filter)
+}
+
+// ListNearbyEvents returns upcoming public events around a position
+func (h *Handler) ListNearbyEvents(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	filter, err := parseListFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.Near == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng are required"})
+		return
+	}
+	if c.Query("scope") == "" {
+		filter.Scope = ScopePublic
+	}
+	if c.Query("when") == "" {
+		filter.When = WhenUpcoming
+	}
+
+	h.respondWithEvents(c, userIDValue.(string), filter)
+}
+
+// respondWithEvents runs a listing and writes the page
+func (h *Handler) respondWithEvents(c *gin.Context, userID string, filter ListFilter) {
+	events, nextCursor, err := h.service.ListUserEvents(c.Request.Context(), userID, filter)
 	if err != nil {
+		if err == ErrInvalidFilter || err == ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		log.Error().Err(err).Msg("Failed to list events")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list events"})
 		return
 	}
 
+	if nextCursor != "" {
+		c.Header("X-Next-Cursor", nextCursor)
+	}
 	c.JSON(http.StatusOK, events)
 }
 
+// parseListFilter reads the listing filters from the query string
+func parseListFilter(c *gin.Context) (ListFilter, error) {
+	filter := ListFilter{
+		Scope:          c.Query("scope"),
+		When:           c.Query("when"),
+		Type:           models.EventType(c.Query("type")),
+		PredefinedType: c.Query("predefinedType"),
+		Role:           c.Query("role"),
+		Status:         c.Query("status"),
+		Search:         c.Query("q"),
+		Cursor:         c.Query("cursor"),
+	}
+
+	if from := c.Query("from"); from != "" {
+		t, err := parseQueryDate(from)
+		if err != nil {
+			return filter, errInvalidQuery("from")
+		}
+		filter.From = &t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := parseQueryDate(to)
+		if err != nil {
+			return filter, errInvalidQuery("to")
+		}
+		filter.To = &t
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return filter, errInvalidQuery("limit")
+		}
+		filter.Limit = n
+	}
+
+	lat, lng := c.Query("lat"), c.Query("lng")
+	if lat != "" || lng != "" {
+		latitude, err := strconv.ParseFloat(lat, 64)
+		if err != nil {
+			return filter, errInvalidQuery("lat")
+		}
+		longitude, err := strconv.ParseFloat(lng, 64)
+		if err != nil {
+			return filter, errInvalidQuery("lng")
+		}
+		radius, err := strconv.ParseFloat(c.DefaultQuery("radiusKm", "10"), 64)
+		if err != nil {
+			return filter, errInvalidQuery("radiusKm")
+		}
+		filter.Near = &NearFilter{Latitude: latitude, Longitude: longitude, RadiusKm: radius}
+	}
+
+	return filter, nil
+}
+
+// parseQueryDate accepts RFC 3339 timestamps and plain dates
+func parseQueryDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// errInvalidQuery reports an unparsable query parameter
+func errInvalidQuery(param string) error {
+	return fmt.Errorf("invalid query parameter: %s", param)
+}
+
 // GetEvent returns a single event by ID
 func (h *Handler) GetEvent(c *gin.Context) {
 	log.Debug().Str("idParam", c.Param("id")).Msg(">>> HANDLER: GetEvent CALLED") // Ajouter log ici
@@ -239,9 +372,19 @@ func (h *Handler) AddParticipant(c *gin.Context) {
 	// Add participant
 	err := h.service.AddParticipant(c.Request.Context(), eventID, userIDValue.(string), &participant)
 	if err != nil {
-		if err == ErrUnauthorized {
+		switch err {
+		case ErrUnauthorized:
 			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to add participants to this event"})
 			return
+		case ErrInvalidParticipant:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid participant data"})
+			return
+		case ErrAlreadyParticipant:
+			c.JSON(http.StatusConflict, gin.H{"error": "User is already a participant"})
+			return
+		case ErrEventNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+			return
 		}
 		log.Error().Err(err).Msg("Failed to add participant")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add participant"})
@@ -268,15 +411,49 @@ func (h *Handler) RemoveParticipant(c *gin.Context) {
 		return
 	}
 
-	// For future implementation: Check if currentUserID has permission to remove participants
-	log.Info().Str("currentUserID", currentUserIDValue.(string)).Str("targetUserID", targetUserID).Msg("Attempt to remove participant")
-
 	// Remove participant
-	// Note: This endpoint is not implemented in the service yet
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "Not implemented"})
+	err := h.service.RemoveParticipant(c.Request.Context(), eventID, currentUserIDValue.(string), targetUserID)
+	if err != nil {
+		h.respondParticipantError(c, err, "Failed to remove participant")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Participant removed successfully"})
 }
 
-// UpdateParticipantStatus updates a participant's status (confirm/decline)
+// UpdateParticipantRole changes the role of a participant (hosts only)
+func (h *Handler) UpdateParticipantRole(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	eventID := c.Param("id")
+	targetUserID := c.Param("userId")
+	if eventID == "" || targetUserID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Event ID and user ID are required"})
+		return
+	}
+
+	var request struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	err := h.service.UpdateParticipantRole(c.Request.Context(), eventID, userIDValue.(string), targetUserID, request.Role)
+	if err != nil {
+		h.respondParticipantError(c, err, "Failed to update participant role")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated successfully"})
+}
+
+// UpdateParticipantStatus updates a participant's status (confirm/decline) and plus-ones
 func (h *Handler) UpdateParticipantStatus(c *gin.Context) {
 	// Get user ID from token using the correct key from middleware
 	userIDValue, exists := c.Get(middleware.UserIDKey)
@@ -294,7 +471,8 @@ func (h *Handler) UpdateParticipantStatus(c *gin.Context) {
 
 	// Parse request body
 	var request struct {
-		Status string `json:"status"`
+		Status   string `json:"status"`
+		PlusOnes *int   `json:"plusOnes"`
 	}
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
@@ -302,14 +480,36 @@ func (h *Handler) UpdateParticipantStatus(c *gin.Context) {
 	}
 
 	// Update status
-	err := h.service.UpdateParticipantStatus(c.Request.Context(), eventID, userIDValue.(string), request.Status)
+	participant, err := h.service.UpdateParticipantStatus(c.Request.Context(), eventID, userIDValue.(string), request.Status, request.PlusOnes)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to update participant status")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update participant status"})
+		h.respondParticipantError(c, err, "Failed to update participant status")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Status updated successfully"})
+	message := "Status updated successfully"
+	if participant.Status == models.EventStatusWaitlisted {
+		message = "Event is full, you have been added to the waitlist"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "participant": participant})
+}
+
+// respondParticipantError maps participant management errors to HTTP responses
+func (h *Handler) respondParticipantError(c *gin.Context, err error, message string) {
+	switch err {
+	case ErrUnauthorized:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to manage this participant"})
+	case ErrEventNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+	case ErrParticipantMissing:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Participant not found"})
+	case ErrInvalidID, ErrInvalidUserID, ErrInvalidParticipant, ErrInvalidStatus:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case ErrConcurrentUpdate, ErrNoRoomForGuests:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
 }
 
 // AddGift adds a gift to an event
@@ -350,6 +550,114 @@ func (h *Handler) AddGift(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Gift added successfully"})
 }
 
+// LinkWishlist links a wishlist of an honoree to an event
+func (h *Handler) LinkWishlist(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	var req struct {
+		WishlistID       string `json:"wishlistId" binding:"required"`
+		ManagedAccountID string `json:"managedAccountId"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	link, err := h.service.LinkWishlist(c.Request.Context(), c.Param("id"), userIDValue.(string), req.WishlistID, req.ManagedAccountID)
+	if err != nil {
+		h.respondRegistryError(c, err, "Failed to link wishlist")
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+// UnlinkWishlist removes a wishlist from an event
+func (h *Handler) UnlinkWishlist(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	err := h.service.UnlinkWishlist(c.Request.Context(), c.Param("id"), userIDValue.(string), c.Param("wishlistId"))
+	if err != nil {
+		h.respondRegistryError(c, err, "Failed to unlink wishlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Wishlist unlinked successfully"})
+}
+
+// GetRegistry returns the items of the wishlists linked to an event
+func (h *Handler) GetRegistry(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	registry, err := h.service.GetRegistry(c.Request.Context(), c.Param("id"), userIDValue.(string))
+	if err != nil {
+		h.respondRegistryError(c, err, "Failed to get registry")
+		return
+	}
+
+	c.JSON(http.StatusOK, registry)
+}
+
+// ReserveRegistryItem reserves or releases a registry item through the event
+func (h *Handler) ReserveRegistryItem(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	var req struct {
+		Reserve  *bool `json:"reserve" binding:"required"`
+		Quantity int   `json:"quantity"` // copies to reserve of an item wished in several copies
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	err := h.service.ReserveRegistryItem(c.Request.Context(), c.Param("id"), userIDValue.(string), c.Param("itemId"), *req.Reserve, req.Quantity)
+	if err != nil {
+		h.respondRegistryError(c, err, "Failed to update reservation")
+		return
+	}
+
+	if *req.Reserve {
+		c.JSON(http.StatusOK, gin.H{"message": "Item reserved successfully"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Reservation cancelled successfully"})
+}
+
+// respondRegistryError maps registry errors to HTTP responses
+func (h *Handler) respondRegistryError(c *gin.Context, err error, message string) {
+	switch err {
+	case ErrUnauthorized:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to manage this registry"})
+	case ErrEventNotFound, ErrWishlistNotFound, ErrWishlistNotLinked, ErrItemNotInRegistry:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case ErrInvalidID, ErrInvalidUserID, ErrNotHonoreeWishlist, ErrInvalidQuantity:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case ErrWishlistAlreadyLinked, ErrItemAlreadyReserved, ErrReservationNotFound, ErrConcurrentUpdate,
+		ErrAlreadyReservedByYou, ErrInsufficientQuantity:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
 // UpdateGift updates a gift in an event
 func (h *Handler) UpdateGift(c *gin.Context) {
 	// This endpoint is not implemented yet
@@ -362,15 +670,31 @@ func (h *Handler) DeleteGift(c *gin.Context) {
 	c.JSON(http.StatusNotImplemented, gin.H{"error": "Not implemented"})
 }
 
-// ListPredefinedEvents returns all predefined event types
+// ListPredefinedEvents returns all predefined event types in the caller's language
 func (h *Handler) ListPredefinedEvents(c *gin.Context) {
 	// Get all predefined events from service
-	predefinedEvents := h.service.GetAllPredefinedEvents()
+	predefinedEvents, err := h.service.GetAllPredefinedEvents(c.Request.Context(), requestLocales(c))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list predefined events")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list predefined events"})
+		return
+	}
 
 	// Return the list
+	c.Header("Vary", "Accept-Language")
 	c.JSON(http.StatusOK, predefinedEvents)
 }
 
+// requestLocales returns the locales requested by the client: an explicit
+// ?locale= parameter first, then the Accept-Language header
+func requestLocales(c *gin.Context) []string {
+	locales := ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if locale := c.Query("locale"); locale != "" {
+		locales = append([]string{strings.ToLower(locale)}, locales...)
+	}
+	return locales
+}
+
 // CreateFromPredefined creates a new event from a predefined type
 func (h *Handler) CreateFromPredefined(c *gin.Context) {
 	// Get user ID from token using the correct key from middleware
@@ -395,8 +719,13 @@ func (h *Handler) CreateFromPredefined(c *gin.Context) {
 	}
 
 	// Check if predefined type exists
-	_, err = h.service.GetPredefinedEvent(predefinedType)
+	_, err = h.service.GetPredefinedEvent(c.Request.Context(), predefinedType, nil)
 	if err != nil {
+		if err != ErrPredefinedEventNotFound {
+			log.Error().Err(err).Msg("Failed to get predefined event")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
+			return
+		}
 		log.Error().Err(err).Str("predefinedType", predefinedType).Msg("Unknown predefined event type")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Unknown predefined event type: " + predefinedType,
@@ -415,7 +744,7 @@ func (h *Handler) CreateFromPredefined(c *gin.Context) {
 	event.CreatorID = objID
 
 	// Create the event using the service
-	createdEvent, err := h.service.CreateFromPredefinedType(c.Request.Context(), predefinedType, &event)
+	createdEvent, err := h.service.CreateFromPredefinedType(c.Request.Context(), predefinedType, &event, requestLocales(c))
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create event from predefined type")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
@@ -424,3 +753,81 @@ func (h *Handler) CreateFromPredefined(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, createdEvent)
 }
+
+// AdminListPredefinedEvents returns the raw catalog with all translations
+func (h *Handler) AdminListPredefinedEvents(c *gin.Context) {
+	predefinedEvents, err := h.service.ListPredefinedEventsForAdmin(c.Request.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list predefined events")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list predefined events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, predefinedEvents)
+}
+
+// AdminCreatePredefinedEvent adds an occasion to the catalog
+func (h *Handler) AdminCreatePredefinedEvent(c *gin.Context) {
+	var predefined PredefinedEvent
+	if err := c.ShouldBindJSON(&predefined); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	created, err := h.service.CreatePredefinedEvent(c.Request.Context(), &predefined)
+	if err != nil {
+		switch err {
+		case ErrInvalidPredefinedEvent:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case ErrPredefinedEventExists:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Msg("Failed to create predefined event")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create predefined event"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// AdminUpdatePredefinedEvent replaces a catalog entry
+func (h *Handler) AdminUpdatePredefinedEvent(c *gin.Context) {
+	var predefined PredefinedEvent
+	if err := c.ShouldBindJSON(&predefined); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	updated, err := h.service.UpdatePredefinedEvent(c.Request.Context(), c.Param("type"), &predefined)
+	if err != nil {
+		switch err {
+		case ErrInvalidPredefinedEvent:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case ErrPredefinedEventNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Msg("Failed to update predefined event")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update predefined event"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// AdminDeletePredefinedEvent removes a catalog entry
+func (h *Handler) AdminDeletePredefinedEvent(c *gin.Context) {
+	err := h.service.DeletePredefinedEvent(c.Request.Context(), c.Param("type"))
+	if err != nil {
+		if err == ErrPredefinedEventNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to delete predefined event")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete predefined event"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Predefined event deleted successfully"})
+}
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"genie/internal/models"
+)
+
+// Action is something a participant may do on an event
+type Action string
+
+const (
+	ActionView              Action = "view"
+	ActionEdit              Action = "edit"
+	ActionDelete            Action = "delete"
+	ActionInvite            Action = "invite"
+	ActionRemoveParticipant Action = "remove_participant"
+	ActionManageRoles       Action = "manage_roles"
+	ActionAddGift           Action = "add_gift"
+	ActionRSVP              Action = "rsvp"
+	ActionViewHeadcount     Action = "view_headcount"
+	ActionLinkWishlist      Action = "link_wishlist"
+	ActionReserveGift       Action = "reserve_gift"
+)
+
+// rolePermissions is the authoritative role -> action matrix for events
+var rolePermissions = map[string]map[Action]bool{
+	models.EventRoleHost: {
+		ActionView:              true,
+		ActionEdit:              true,
+		ActionDelete:            true,
+		ActionInvite:            true,
+		ActionRemoveParticipant: true,
+		ActionManageRoles:       true,
+		ActionAddGift:           true,
+		ActionRSVP:              true,
+		ActionViewHeadcount:     true,
+		ActionLinkWishlist:      true,
+		ActionReserveGift:       true,
+	},
+	models.EventRoleCoHost: {
+		ActionView:              true,
+		ActionEdit:              true,
+		ActionInvite:            true,
+		ActionRemoveParticipant: true,
+		ActionAddGift:           true,
+		ActionRSVP:              true,
+		ActionViewHeadcount:     true,
+		ActionLinkWishlist:      true,
+		ActionReserveGift:       true,
+	},
+	models.EventRoleGuest: {
+		ActionView:        true,
+		ActionAddGift:     true,
+		ActionRSVP:        true,
+		ActionReserveGift: true,
+	},
+	// Honorees may only link their own wishlists and never reserve gifts
+	models.EventRoleHonoree: {
+		ActionView:          true,
+		ActionRSVP:          true,
+		ActionViewHeadcount: true,
+		ActionLinkWishlist:  true,
+	},
+}
+
+// IsValidRole reports whether role is one of the known participant roles
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Can reports whether a role is allowed to perform an action
+func Can(role string, action Action) bool {
+	return rolePermissions[role][action]
+}
+
+// roleOf returns the role of a user in an event, or "" if they do not take part.
+// The creator is always a host.
+func roleOf(event *models.Event, uid primitive.ObjectID) string {
+	if event.CreatorID == uid {
+		return models.EventRoleHost
+	}
+	for _, p := range event.Participants {
+		if p.UserID == uid {
+			if IsValidRole(p.Role) {
+				return p.Role
+			}
+			return models.EventRoleGuest
+		}
+	}
+	return ""
+}
+
+// canOnEvent reports whether a user may perform an action on an event.
+// Anyone may view a public event.
+func canOnEvent(event *models.Event, uid primitive.ObjectID, action Action) bool {
+	role := roleOf(event, uid)
+	if role == "" {
+		return action == ActionView && !event.IsPrivate
+	}
+	return Can(role, action)
+}
@@ -3,25 +3,43 @@ package events
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"genie/internal/models"
 )
 
-// PredefinedEvent represents an event template with predefined parameters
+// PredefinedEvent represents an event template with predefined parameters.
+// Name, DefaultDate, Invitations and Info are written in DefaultLocale;
+// Translations holds their values for other locales.
 type PredefinedEvent struct {
-	ID          string   `json:"id"`
-	Name        string   `json:"name"`
-	Type        string   `json:"type"` // collectif, individuel, special
-	Icon        string   `json:"icon"`
-	Emojis      []string `json:"emojis"`
-	DefaultDate string   `json:"defaultDate,omitempty"`
-	Invitations string   `json:"invitations"`
-	Info        string   `json:"info,omitempty"`
-	DateFormat  string   `json:"dateFormat,omitempty"`
+	ID           string                                `json:"id" bson:"_id"`
+	Name         string                                `json:"name" bson:"name"`
+	Type         string                                `json:"type" bson:"type"` // collectif, individuel, special
+	Icon         string                                `json:"icon" bson:"icon"`
+	Emojis       []string                              `json:"emojis" bson:"emojis"`
+	DefaultDate  string                                `json:"defaultDate,omitempty" bson:"defaultDate,omitempty"`
+	Invitations  string                                `json:"invitations" bson:"invitations"`
+	Info         string                                `json:"info,omitempty" bson:"info,omitempty"`
+	DateFormat   string                                `json:"dateFormat,omitempty" bson:"dateFormat,omitempty"`
+	SortOrder    int                                   `json:"sortOrder" bson:"sortOrder"`
+	Translations map[string]PredefinedEventTranslation `json:"translations,omitempty" bson:"translations,omitempty"`
+	Locale       string                                `json:"locale,omitempty" bson:"-"` // locale the texts were resolved in
 }
 
+// PredefinedEventTranslation holds the localized texts of a predefined event.
+// Empty fields fall back to the DefaultLocale value.
+type PredefinedEventTranslation struct {
+	Name        string `json:"name,omitempty" bson:"name,omitempty"`
+	DefaultDate string `json:"defaultDate,omitempty" bson:"defaultDate,omitempty"`
+	Invitations string `json:"invitations,omitempty" bson:"invitations,omitempty"`
+	Info        string `json:"info,omitempty" bson:"info,omitempty"`
+}
+
+// The slices below are the seed of the predefined events catalog, which is
+// stored in MongoDB and editable by admins (see catalog.go).
+
 // List of all collective predefined events in the system
 var collectiveEvents = []PredefinedEvent{
 	{
@@ -31,7 +49,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "🎄",
 		Emojis:      []string{"🎄", "🎅", "☃️", "❄️"},
 		DefaultDate: "25 Décembre",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "saint_valentin",
@@ -40,7 +58,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "❤️",
 		Emojis:      []string{"💝", "❤️", "💕", "💘"},
 		DefaultDate: "14 Février",
-		Invitations: "Qté 1 personne de +18 ans",
+		Invitations: InvitationOnePerson,
 	},
 	{
 		ID:          "nouvel_an_lunaire",
@@ -49,7 +67,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "🏮",
 		Emojis:      []string{"🧧", "🐲", "🎊", "🌙"},
 		DefaultDate: "29 Janvier",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "nouvel_an",
@@ -58,7 +76,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "🎉",
 		Emojis:      []string{"🎆", "🍾", "🎊", "🎇"},
 		DefaultDate: "1 Janvier",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "kwanzaa",
@@ -67,7 +85,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "🕯️",
 		Emojis:      []string{"🕯️", "🌟", "🥣", "📚"},
 		DefaultDate: "26 Décembre",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "raksha_bandhan",
@@ -76,7 +94,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "🪢",
 		Emojis:      []string{"🪢", "🌸", "🎁", "🥘"},
 		DefaultDate: "19 Août",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "vesak",
@@ -85,7 +103,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "🪷",
 		Emojis:      []string{"🪷", "🛕", "🪔", "🧎‍♂️"},
 		DefaultDate: "12 Mai",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "pesach",
@@ -94,7 +112,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "🍷",
 		Emojis:      []string{"🍷", "🥖", "🥗", "✡️"},
 		DefaultDate: "15 Avril",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "hanoukka",
@@ -103,7 +121,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "🕎",
 		Emojis:      []string{"🕎", "🕯️", "🍩", "✡️"},
 		DefaultDate: "25 Décembre",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "diwali",
@@ -112,7 +130,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "🪔",
 		Emojis:      []string{"🪔", "🪄", "🟣", "✨"},
 		DefaultDate: "31 Octobre",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "eid_al_adha",
@@ -121,7 +139,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "🐑",
 		Emojis:      []string{"🐑", "☪️", "🍲", "🥮"},
 		DefaultDate: "5 Juin",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "eid_al_fitr",
@@ -130,7 +148,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "🌙",
 		Emojis:      []string{"🌙", "☪️", "🍲", "🥮"},
 		DefaultDate: "20 Mars",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "carnaval",
@@ -139,7 +157,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "🎭",
 		Emojis:      []string{"🎭", "🎪", "🎵", "🥂"},
 		DefaultDate: "27 Février",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "mi_automne",
@@ -148,7 +166,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "🥮",
 		Emojis:      []string{"🥮", "🏮", "🎑", "🌕"},
 		DefaultDate: "17 Septembre",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "saint_jean",
@@ -157,7 +175,7 @@ var collectiveEvents = []PredefinedEvent{
 		Icon:        "🔥",
 		Emojis:      []string{"🔥", "🪵", "🟣", "🏕️"},
 		DefaultDate: "24 Juin",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 }
 
@@ -171,7 +189,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"🎂", "🎉", "🍰", "🥳"},
 		DateFormat:  "personal",
 		Info:        "C'est l'anniversaire de {name}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "fiancailles",
@@ -181,7 +199,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"💍", "❤️", "🥂", "💑"},
 		DateFormat:  "date_du_jour",
 		Info:        "C'est les fiançailles de {names}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "mariage",
@@ -191,7 +209,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"👰", "🤵", "💒", "💐"},
 		DateFormat:  "date_du_jour",
 		Info:        "C'est le mariage de {names}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "bapteme",
@@ -201,7 +219,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"👶", "✝️", "🕊️", "💐"},
 		DateFormat:  "date_du_jour",
 		Info:        "C'est le baptême de {name}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "communion",
@@ -211,7 +229,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"🍞", "✝️", "🕊️", "📿"},
 		DateFormat:  "date_du_jour",
 		Info:        "C'est la communion de {name}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "confirmation",
@@ -221,7 +239,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"🙏", "✝️", "🕊️", "📿"},
 		DateFormat:  "date_du_jour",
 		Info:        "C'est la confirmation de {name}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "naissance",
@@ -231,7 +249,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"👶", "🍼", "🧸", "🎀"},
 		DateFormat:  "date_du_jour",
 		Info:        "Annonce l'arrivée de {name}, {poids} g, {taille} cm",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "baby_shower",
@@ -241,7 +259,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"🍼", "👶", "🧸", "🎀"},
 		DateFormat:  "date_du_jour",
 		Info:        "C'est l'arrivée de {name}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "gender_reveal",
@@ -251,7 +269,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"👼", "👶", "🧸", "🎀"},
 		DateFormat:  "date_du_jour",
 		Info:        "C'est l'arrivée de {name}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "fete_des_peres",
@@ -261,7 +279,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"👨", "👔", "🎁", "❤️"},
 		DefaultDate: "18 Juin",
 		Info:        "C'est la fête de {name}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "fete_des_meres",
@@ -271,7 +289,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"👩", "🌸", "🎁", "❤️"},
 		DefaultDate: "28 Mai",
 		Info:        "C'est la fête de {name}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "retraite",
@@ -281,7 +299,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"🏖️", "🧓", "🎁", "🏝️"},
 		DateFormat:  "date_du_jour",
 		Info:        "C'est la fête de {name}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "pot_de_depart",
@@ -291,7 +309,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"🥂", "🍾", "🎁", "🎊"},
 		DateFormat:  "date_du_jour",
 		Info:        "C'est le pot de départ de {name}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "a_la_maison",
@@ -301,7 +319,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"🏠", "🏡", "🌱", "🎈"},
 		DateFormat:  "date_du_jour",
 		Info:        "C'est la fête chez {name}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "remise_diplomes",
@@ -311,7 +329,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"🎓", "📜", "📚", "🏆"},
 		DateFormat:  "date_du_jour",
 		Info:        "C'est la remise des diplômes de {name}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "cremaillere",
@@ -321,7 +339,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"🏡", "🔑", "🍽️", "🥂"},
 		DateFormat:  "date_du_jour",
 		Info:        "C'est la crémaillère de {name}, {age} ans",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "quinceanera",
@@ -331,7 +349,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"👑", "🎂", "💃", "🦋"},
 		DateFormat:  "personal",
 		Info:        "Afficher grâce à la date de naissance",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 	{
 		ID:          "bar_bat_mitzvah",
@@ -341,7 +359,7 @@ var individualEvents = []PredefinedEvent{
 		Emojis:      []string{"✡️", "📜", "🕯️", "🎊"},
 		DateFormat:  "personal",
 		Info:        "Afficher grâce à la date de naissance",
-		Invitations: "Tous le monde",
+		Invitations: InvitationEveryone,
 	},
 }
 
@@ -359,57 +377,28 @@ var specialEvents = []PredefinedEvent{
 	},
 }
 
-// GetAllPredefinedEvents returns all predefined events in the system
-func (s *Service) GetAllPredefinedEvents() []PredefinedEvent {
-	// Combine all event types
-	allEvents := append([]PredefinedEvent{}, collectiveEvents...)
-	allEvents = append(allEvents, individualEvents...)
-	allEvents = append(allEvents, specialEvents...)
-	return allEvents
-}
-
-// GetPredefinedEvent returns a specific predefined event by ID
-func (s *Service) GetPredefinedEvent(id string) (*PredefinedEvent, error) {
-	// Check in collective events
-	for _, event := range collectiveEvents {
-		if event.ID == id {
-			return &event, nil
-		}
-	}
-
-	// Check in individual events
-	for _, event := range individualEvents {
-		if event.ID == id {
-			return &event, nil
-		}
-	}
-
-	// Check in special events
-	for _, event := range specialEvents {
-		if event.ID == id {
-			return &event, nil
-		}
-	}
-
-	return nil, fmt.Errorf("predefined event not found: %s", id)
-}
-
 // Helper function to parse predefined date strings into time.Time
 func parsePredefinedDate(dateStr string) (time.Time, error) {
 	// Handle various date formats
 	currentYear := time.Now().Year()
 
-	// Format: "DD Month"
+	// Format: "DD Month" (French) or "Month DD" (English)
 	parts := strings.Split(dateStr, " ")
 	if len(parts) == 2 {
 		day := strings.TrimSpace(parts[0])
 		month := strings.TrimSpace(parts[1])
+		if _, err := strconv.Atoi(day); err != nil {
+			day, month = month, day
+		}
 
-		// Map French month names to numbers
+		// Map French and English month names to numbers
 		monthMap := map[string]int{
 			"Janvier": 1, "Février": 2, "Mars": 3, "Avril": 4,
 			"Mai": 5, "Juin": 6, "Juillet": 7, "Août": 8,
 			"Septembre": 9, "Octobre": 10, "Novembre": 11, "Décembre": 12,
+			"January": 1, "February": 2, "March": 3, "April": 4,
+			"May": 5, "June": 6, "July": 7, "August": 8,
+			"September": 9, "October": 10, "November": 11, "December": 12,
 		}
 
 		monthNum, ok := monthMap[month]
@@ -432,9 +421,9 @@ func parsePredefinedDate(dateStr string) (time.Time, error) {
 }
 
 // CreateFromPredefinedType creates a new event based on a predefined event type
-func (s *Service) CreateFromPredefinedType(ctx context.Context, predefinedTypeID string, event *models.Event) (*models.Event, error) {
+func (s *Service) CreateFromPredefinedType(ctx context.Context, predefinedTypeID string, event *models.Event, locales []string) (*models.Event, error) {
 	// Get the predefined event template
-	predefined, err := s.GetPredefinedEvent(predefinedTypeID)
+	predefined, err := s.GetPredefinedEvent(ctx, predefinedTypeID, locales)
 	if err != nil {
 		return nil, err
 	}
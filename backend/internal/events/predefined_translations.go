@@ -0,0 +1,48 @@
+package events
+
+// Invitation texts used by the English seed translations
+const (
+	invitationEveryoneEN  = "Everyone"
+	invitationOnePersonEN = "1 person aged 18+"
+)
+
+// seedTranslations holds the non-French texts the catalog is seeded with,
+// keyed by predefined event ID then by locale
+var seedTranslations = map[string]map[string]PredefinedEventTranslation{
+	"noel":              {"en": {Name: "Christmas", DefaultDate: "December 25", Invitations: invitationEveryoneEN}},
+	"saint_valentin":    {"en": {Name: "Valentine's Day", DefaultDate: "February 14", Invitations: invitationOnePersonEN}},
+	"nouvel_an_lunaire": {"en": {Name: "Lunar New Year", DefaultDate: "January 29", Invitations: invitationEveryoneEN}},
+	"nouvel_an":         {"en": {Name: "New Year", DefaultDate: "January 1", Invitations: invitationEveryoneEN}},
+	"kwanzaa":           {"en": {Name: "Kwanzaa", DefaultDate: "December 26", Invitations: invitationEveryoneEN}},
+	"raksha_bandhan":    {"en": {Name: "Raksha Bandhan", DefaultDate: "August 19", Invitations: invitationEveryoneEN}},
+	"vesak":             {"en": {Name: "Vesak", DefaultDate: "May 12", Invitations: invitationEveryoneEN}},
+	"pesach":            {"en": {Name: "Passover", DefaultDate: "April 15", Invitations: invitationEveryoneEN}},
+	"hanoukka":          {"en": {Name: "Hanukkah", DefaultDate: "December 25", Invitations: invitationEveryoneEN}},
+	"diwali":            {"en": {Name: "Diwali", DefaultDate: "October 31", Invitations: invitationEveryoneEN}},
+	"eid_al_adha":       {"en": {Name: "Eid al-Adha", DefaultDate: "June 5", Invitations: invitationEveryoneEN}},
+	"eid_al_fitr":       {"en": {Name: "Eid al-Fitr", DefaultDate: "March 20", Invitations: invitationEveryoneEN}},
+	"carnaval":          {"en": {Name: "Carnival", DefaultDate: "February 27", Invitations: invitationEveryoneEN}},
+	"mi_automne":        {"en": {Name: "Mid-Autumn Festival", DefaultDate: "September 17", Invitations: invitationEveryoneEN}},
+	"saint_jean":        {"en": {Name: "Midsummer", DefaultDate: "June 24", Invitations: invitationEveryoneEN}},
+
+	"anniversaire":    {"en": {Name: "Birthday", Info: "It's {name}'s birthday, {age} years old", Invitations: invitationEveryoneEN}},
+	"fiancailles":     {"en": {Name: "Engagement", Info: "It's {names}'s engagement, {age} years old", Invitations: invitationEveryoneEN}},
+	"mariage":         {"en": {Name: "Wedding", Info: "It's {names}'s wedding, {age} years old", Invitations: invitationEveryoneEN}},
+	"bapteme":         {"en": {Name: "Baptism", Info: "It's {name}'s baptism, {age} years old", Invitations: invitationEveryoneEN}},
+	"communion":       {"en": {Name: "Communion", Info: "It's {name}'s communion, {age} years old", Invitations: invitationEveryoneEN}},
+	"confirmation":    {"en": {Name: "Confirmation", Info: "It's {name}'s confirmation, {age} years old", Invitations: invitationEveryoneEN}},
+	"naissance":       {"en": {Name: "Birth", Info: "Announcing the arrival of {name}, {poids} g, {taille} cm", Invitations: invitationEveryoneEN}},
+	"baby_shower":     {"en": {Name: "Baby Shower", Info: "It's {name}'s arrival, {age} years old", Invitations: invitationEveryoneEN}},
+	"gender_reveal":   {"en": {Name: "Gender Reveal", Info: "It's {name}'s arrival, {age} years old", Invitations: invitationEveryoneEN}},
+	"fete_des_peres":  {"en": {Name: "Father's Day", DefaultDate: "June 18", Info: "It's {name}'s day, {age} years old", Invitations: invitationEveryoneEN}},
+	"fete_des_meres":  {"en": {Name: "Mother's Day", DefaultDate: "May 28", Info: "It's {name}'s day, {age} years old", Invitations: invitationEveryoneEN}},
+	"retraite":        {"en": {Name: "Retirement", Info: "It's {name}'s party, {age} years old", Invitations: invitationEveryoneEN}},
+	"pot_de_depart":   {"en": {Name: "Farewell Party", Info: "It's {name}'s farewell party, {age} years old", Invitations: invitationEveryoneEN}},
+	"a_la_maison":     {"en": {Name: "House Party", Info: "It's a party at {name}'s, {age} years old", Invitations: invitationEveryoneEN}},
+	"remise_diplomes": {"en": {Name: "Graduation", Info: "It's {name}'s graduation, {age} years old", Invitations: invitationEveryoneEN}},
+	"cremaillere":     {"en": {Name: "Housewarming", Info: "It's {name}'s housewarming, {age} years old", Invitations: invitationEveryoneEN}},
+	"quinceanera":     {"en": {Name: "Quinceañera", Info: "Shown from the date of birth", Invitations: invitationEveryoneEN}},
+	"bar_bat_mitzvah": {"en": {Name: "Bar/Bat Mitzvah", Info: "Shown from the date of birth", Invitations: invitationEveryoneEN}},
+
+	"secret_santa": {"en": {Name: "Secret Santa", Info: "Participants are anonymous...", Invitations: "Show today's date"}},
+}
@@ -0,0 +1,311 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"genie/internal/models"
+	"genie/internal/wishlist"
+)
+
+const (
+	// Collection owned by the accounts service
+	managedAccountsCollection = "managed_accounts"
+)
+
+// Wishlists is the part of the wishlist service the registry relies on. The event decides
+// who takes part in the registry; the wishlist service alone decides who may share a
+// wishlist, what its recipient sees of the reservations and how items are reserved.
+type Wishlists interface {
+	// ShareableWishlist returns a wishlist the user may share, or nil
+	ShareableWishlist(ctx context.Context, wishlistID, userID primitive.ObjectID) (*models.Wishlist, error)
+	// RegistryItems returns wishlists and their items as a participant of an event sees them
+	RegistryItems(ctx context.Context, wishlistIDs []primitive.ObjectID, viewerID primitive.ObjectID) ([]models.Wishlist, []*models.WishItemResponse, error)
+	// ReserveForEvent reserves, or releases, an item of one of the given wishlists on behalf of an event.
+	// quantity is the number of copies to reserve of an item wished in several copies.
+	ReserveForEvent(ctx context.Context, itemID, userID, eventID primitive.ObjectID, wishlistIDs []primitive.ObjectID, reserve bool, quantity int) error
+}
+
+var (
+	ErrWishlistNotFound      = errors.New("wishlist not found")
+	ErrWishlistAlreadyLinked = errors.New("wishlist is already linked to this event")
+	ErrWishlistNotLinked     = errors.New("wishlist is not linked to this event")
+	ErrNotHonoreeWishlist    = errors.New("wishlist does not belong to an honoree of this event")
+	ErrItemNotInRegistry     = errors.New("item is not part of this event's registry")
+	ErrItemAlreadyReserved   = errors.New("item is already reserved")
+	ErrReservationNotFound   = errors.New("no reservation of yours on this item")
+	ErrAlreadyReservedByYou  = errors.New("you have already reserved this item")
+	ErrInvalidQuantity       = errors.New("invalid quantity")
+	ErrInsufficientQuantity  = errors.New("not enough copies left to reserve")
+)
+
+// LinkWishlist attaches a wishlist of one of the event honorees to the event.
+// managedAccountID is optional and identifies the managed account the wishlist
+// is kept for when the honoree is a managed account of the wishlist owner.
+func (s *Service) LinkWishlist(ctx context.Context, eventID string, userID string, wishlistID string, managedAccountID string) (*models.EventWishlist, error) {
+	id, err := primitive.ObjectIDFromHex(eventID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	wid, err := primitive.ObjectIDFromHex(wishlistID)
+	if err != nil {
+		return nil, ErrWishlistNotFound
+	}
+
+	// Linking exposes the items to the guests, so the user must be allowed to share the wishlist
+	wishlist, err := s.wishlists.ShareableWishlist(ctx, wid, uid)
+	if err != nil {
+		return nil, err
+	}
+	if wishlist == nil {
+		return nil, ErrWishlistNotFound
+	}
+
+	// A wishlist kept for a managed account is linked on behalf of that account
+	link := models.EventWishlist{
+		WishlistID:       wid,
+		OwnerID:          wishlist.UserID,
+		LinkedBy:         uid,
+		ManagedAccountID: wishlist.ManagedAccountID,
+	}
+
+	if managedAccountID != "" && link.ManagedAccountID != nil {
+		if link.ManagedAccountID.Hex() != managedAccountID {
+			return nil, ErrNotHonoreeWishlist
+		}
+	} else if managedAccountID != "" {
+		accountID, err := primitive.ObjectIDFromHex(managedAccountID)
+		if err != nil {
+			return nil, ErrNotHonoreeWishlist
+		}
+
+		count, err := s.db.Collection(managedAccountsCollection).CountDocuments(ctx, bson.M{"_id": accountID, "ownerId": wishlist.UserID})
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, ErrNotHonoreeWishlist
+		}
+		link.ManagedAccountID = &accountID
+	}
+
+	_, err = s.mutateEvent(ctx, id, func(event *models.Event, now time.Time) error {
+		if !canOnEvent(event, uid, ActionLinkWishlist) {
+			return ErrUnauthorized
+		}
+		if roleOf(event, uid) == models.EventRoleHonoree && wishlist.UserID != uid {
+			return ErrUnauthorized
+		}
+		if !isHonoreeWishlist(event, &link) {
+			return ErrNotHonoreeWishlist
+		}
+
+		for _, existing := range event.Wishlists {
+			if existing.WishlistID == wid {
+				return ErrWishlistAlreadyLinked
+			}
+		}
+
+		link.LinkedAt = now
+		event.Wishlists = append(event.Wishlists, link)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+// UnlinkWishlist detaches a wishlist from an event.
+// Reservations already made through the event are kept.
+func (s *Service) UnlinkWishlist(ctx context.Context, eventID string, userID string, wishlistID string) error {
+	id, err := primitive.ObjectIDFromHex(eventID)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	wid, err := primitive.ObjectIDFromHex(wishlistID)
+	if err != nil {
+		return ErrWishlistNotLinked
+	}
+
+	_, err = s.mutateEvent(ctx, id, func(event *models.Event, now time.Time) error {
+		if !canOnEvent(event, uid, ActionLinkWishlist) {
+			return ErrUnauthorized
+		}
+
+		for i, link := range event.Wishlists {
+			if link.WishlistID != wid {
+				continue
+			}
+			if roleOf(event, uid) == models.EventRoleHonoree && link.OwnerID != uid {
+				return ErrUnauthorized
+			}
+			event.Wishlists = append(event.Wishlists[:i], event.Wishlists[i+1:]...)
+			return nil
+		}
+		return ErrWishlistNotLinked
+	})
+	return err
+}
+
+// GetRegistry returns the items of the wishlists linked to an event with their reservation state.
+// Only the participants of the event see its registry, even when the event is public.
+func (s *Service) GetRegistry(ctx context.Context, eventID string, userID string) ([]models.EventRegistryWishlist, error) {
+	event, err := s.GetEvent(ctx, eventID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	uid, _ := primitive.ObjectIDFromHex(userID)
+	if roleOf(event, uid) == "" {
+		return nil, ErrUnauthorized
+	}
+
+	registry := []models.EventRegistryWishlist{}
+	if len(event.Wishlists) == 0 {
+		return registry, nil
+	}
+
+	wishlists, items, err := s.wishlists.RegistryItems(ctx, linkedWishlistIDs(event), uid)
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make(map[primitive.ObjectID]string, len(wishlists))
+	for _, wishlist := range wishlists {
+		titles[wishlist.ID] = wishlist.Title
+	}
+
+	itemsByWishlist := make(map[string][]models.EventRegistryItem)
+	for _, item := range items {
+		itemsByWishlist[item.WishlistID] = append(itemsByWishlist[item.WishlistID], registryItem(item, userID))
+	}
+
+	for _, link := range event.Wishlists {
+		title, found := titles[link.WishlistID]
+		if !found {
+			continue // The wishlist was deleted after being linked
+		}
+
+		entry := models.EventRegistryWishlist{
+			WishlistID: link.WishlistID.Hex(),
+			Title:      title,
+			OwnerID:    link.OwnerID.Hex(),
+			Items:      itemsByWishlist[link.WishlistID.Hex()],
+		}
+		if entry.Items == nil {
+			entry.Items = []models.EventRegistryItem{}
+		}
+		if link.ManagedAccountID != nil {
+			entry.ManagedAccountID = link.ManagedAccountID.Hex()
+		}
+		registry = append(registry, entry)
+	}
+
+	return registry, nil
+}
+
+// ReserveRegistryItem reserves, or releases, an item of a linked wishlist.
+// The reservation is recorded against the event, partial ones included: quantity is the
+// number of copies to reserve of an item wished in several copies, 1 by default.
+func (s *Service) ReserveRegistryItem(ctx context.Context, eventID string, userID string, itemID string, reserve bool, quantity int) error {
+	event, err := s.GetEvent(ctx, eventID, userID)
+	if err != nil {
+		return err
+	}
+
+	uid, _ := primitive.ObjectIDFromHex(userID)
+	if !canOnEvent(event, uid, ActionReserveGift) {
+		return ErrUnauthorized
+	}
+
+	iid, err := primitive.ObjectIDFromHex(itemID)
+	if err != nil {
+		return ErrItemNotInRegistry
+	}
+
+	err = s.wishlists.ReserveForEvent(ctx, iid, uid, event.ID, linkedWishlistIDs(event), reserve, quantity)
+	switch err {
+	case wishlist.ErrItemNotFound:
+		return ErrItemNotInRegistry
+	case wishlist.ErrOwnItem:
+		return ErrUnauthorized
+	case wishlist.ErrAlreadyReserved:
+		return ErrItemAlreadyReserved
+	case wishlist.ErrCannotCancel:
+		return ErrReservationNotFound
+	case wishlist.ErrAlreadyReservedByYou:
+		return ErrAlreadyReservedByYou
+	case wishlist.ErrInvalidQuantity:
+		return ErrInvalidQuantity
+	case wishlist.ErrInsufficientQuantity:
+		return ErrInsufficientQuantity
+	}
+	return err
+}
+
+// linkedWishlistIDs returns the IDs of the wishlists linked to an event
+func linkedWishlistIDs(event *models.Event) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(event.Wishlists))
+	for _, link := range event.Wishlists {
+		ids = append(ids, link.WishlistID)
+	}
+	return ids
+}
+
+// isHonoreeWishlist reports whether a linked wishlist belongs to someone the
+// event is about: an honoree, the creator, or, for a managed account, a host
+// or honoree managing that account
+func isHonoreeWishlist(event *models.Event, link *models.EventWishlist) bool {
+	if link.OwnerID == event.CreatorID {
+		return true
+	}
+
+	switch roleOf(event, link.OwnerID) {
+	case models.EventRoleHonoree:
+		return true
+	case models.EventRoleHost, models.EventRoleCoHost:
+		return link.ManagedAccountID != nil
+	}
+	return false
+}
+
+// registryItem converts a wish item, as the wishlist service lets the participant see it
+func registryItem(item *models.WishItemResponse, userID string) models.EventRegistryItem {
+	return models.EventRegistryItem{
+		ID:                 item.ID,
+		WishlistID:         item.WishlistID,
+		Name:               item.Name,
+		Description:        item.Description,
+		Price:              item.Price,
+		Currency:           item.Currency,
+		ImageURL:           item.ImageURL,
+		Link:               item.Link,
+		IsFavorite:         item.IsFavorite,
+		Quantity:           item.Quantity,
+		ReservedQuantity:   item.ReservedQuantity,
+		MyReservedQuantity: item.MyReservedQuantity,
+		IsReserved:         item.IsReserved,
+		ReservedByMe:       item.ReservedBy != "" && item.ReservedBy == userID,
+		ReservedBy:         item.ReservedBy,
+		ReservedForEventID: item.ReservedForEventID,
+		ReservationStatus:  item.ReservationStatus,
+	}
+}
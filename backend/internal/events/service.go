@@ -5,11 +5,13 @@ import (
 	"errors"
 	"time"
 
+	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"genie/internal/messaging"
 	"genie/internal/models"
 )
 
@@ -26,26 +28,114 @@ var (
 	ErrInvalidUserID      = errors.New("invalid user ID")
 	ErrInvalidParticipant = errors.New("invalid participant data")
 	ErrInvalidGift        = errors.New("invalid gift data")
+	ErrInvalidStatus      = errors.New("invalid status value")
 )
 
 // Service handles event business logic
 type Service struct {
-	db *mongo.Database
+	db        *mongo.Database
+	wishlists Wishlists
+	pusher    messaging.Pusher
 }
 
-// NewService creates a new event service
-func NewService(db *mongo.Database) *Service {
-	return &Service{
-		db: db,
+// NewService creates a new event service. wishlists gives access to the
+// wishlists linked to events as gift registries. pusher tells waitlisted
+// guests when they get a seat; it may be nil.
+func NewService(db *mongo.Database, wishlists Wishlists, pusher messaging.Pusher) *Service {
+	ctx := context.Background()
+	collection := db.Collection(eventsCollection)
+
+	// Backfill the GeoJSON mirror of coordinates for events created before it existed
+	_, err := collection.UpdateMany(ctx,
+		bson.M{
+			"location.coordinates.latitude": bson.M{"$exists": true},
+			"location.geo":                  bson.M{"$exists": false},
+		},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"location.geo": bson.M{
+				"type": "Point",
+				"coordinates": bson.A{
+					bson.M{"$ifNull": bson.A{"$location.coordinates.longitude", 0}},
+					"$location.coordinates.latitude",
+				},
+			},
+		}}}},
+	)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to backfill event geo locations")
+	}
+
+	// Participants invited before invitations were dated are considered invited
+	// when the event was created, so that their invitation can still be nudged
+	noInvitedAt := bson.M{"$not": bson.M{"$gt": time.Time{}}}
+	_, err = collection.UpdateMany(ctx,
+		bson.M{"participants": bson.M{"$elemMatch": bson.M{"invitedAt": noInvitedAt}}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"participants": bson.M{"$map": bson.M{
+				"input": "$participants",
+				"as":    "p",
+				"in": bson.M{"$mergeObjects": bson.A{"$$p", bson.M{
+					"invitedAt": bson.M{"$cond": bson.A{
+						bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$$p.invitedAt", time.Time{}}}, time.Time{}}},
+						"$$p.invitedAt",
+						"$createdAt",
+					}},
+				}}},
+			}},
+		}}}},
+	)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to backfill participant invitation dates")
+	}
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "location.geo", Value: "2dsphere"}},
+			Options: options.Index().SetBackground(true),
+		},
+		{
+			Keys:    bson.D{{Key: "participants.userId", Value: 1}, {Key: "startDate", Value: 1}},
+			Options: options.Index().SetBackground(true),
+		},
+		{
+			Keys:    bson.D{{Key: "isPrivate", Value: 1}, {Key: "startDate", Value: 1}},
+			Options: options.Index().SetBackground(true),
+		},
+	}
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		log.Warn().Err(err).Msg("Failed to create indexes on events collection")
 	}
+
+	service := &Service{
+		db:        db,
+		wishlists: wishlists,
+	}
+
+	if err := service.seedPredefinedEvents(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to seed predefined events catalog")
+	}
+
+	return service
 }
 
 // CreateEvent creates a new event
 func (s *Service) CreateEvent(ctx context.Context, event *models.Event) (*models.Event, error) {
 	// Validate event data
-	if event.Title == "" || event.CreatorID.IsZero() {
+	if event.Title == "" || event.CreatorID.IsZero() || event.MaxCapacity < 0 {
 		return nil, ErrInvalidEvent
 	}
+	for i := range event.Participants {
+		p := &event.Participants[i]
+		if p.Role == "" {
+			p.Role = models.EventRoleGuest
+		}
+		if !IsValidRole(p.Role) || p.PlusOnes < 0 || p.PlusOnes > maxPlusOnes {
+			return nil, ErrInvalidParticipant
+		}
+	}
+
+	// Wishlists are linked afterwards so that each link is checked
+	event.Wishlists = nil
 
 	// Set metadata
 	now := time.Now()
@@ -56,6 +146,10 @@ func (s *Service) CreateEvent(ctx context.Context, event *models.Event) (*models
 		event.ID = primitive.NewObjectID()
 	}
 
+	if event.Location != nil {
+		event.Location.SyncGeo()
+	}
+
 	// If creator is not in participants, add them as host
 	creatorExists := false
 	for _, p := range event.Participants {
@@ -68,8 +162,8 @@ func (s *Service) CreateEvent(ctx context.Context, event *models.Event) (*models
 	if !creatorExists {
 		event.Participants = append(event.Participants, models.EventParticipant{
 			UserID:    event.CreatorID,
-			Role:      "host",
-			Status:    "confirmed",
+			Role:      models.EventRoleHost,
+			Status:    models.EventStatusConfirmed,
 			InvitedAt: now,
 		})
 	}
@@ -115,41 +209,57 @@ func (s *Service) GetEvent(ctx context.Context, eventID string, userID string) (
 		return nil, err
 	}
 
-	return &event, nil
+	return withHeadcount(&event, uid), nil
 }
 
-// ListUserEvents lists events where the user is a participant
-func (s *Service) ListUserEvents(ctx context.Context, userID string) ([]*models.Event, error) {
+// ListUserEvents lists the events matching a filter, one page at a time.
+// It returns the page and the cursor of the next page, empty on the last page.
+func (s *Service) ListUserEvents(ctx context.Context, userID string, filter ListFilter) ([]*models.Event, string, error) {
 	uid, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, ErrInvalidUserID
+		return nil, "", ErrInvalidUserID
 	}
 
-	// Find events where user is participant or creator
-	query := bson.M{
-		"$or": []bson.M{
-			{"participants.userId": uid},
-			{"creatorId": uid},
-		},
-		"deletedAt": nil,
+	if err := filter.normalize(); err != nil {
+		return nil, "", err
 	}
 
-	opts := options.Find().SetSort(bson.D{{Key: "startDate", Value: 1}})
+	query, err := filter.buildQuery(uid, time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Fetch one extra event to know whether there is a next page
+	direction := filter.sortDirection()
+	opts := options.Find().
+		SetSort(bson.D{{Key: "startDate", Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(int64(filter.Limit + 1))
 	cursor, err := s.db.Collection(eventsCollection).Find(ctx, query, opts)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer cursor.Close(ctx)
 
 	events := []*models.Event{}
 	if err = cursor.All(ctx, &events); err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	for _, event := range events {
+		withHeadcount(event, uid)
 	}
 
-	return events, nil
+	nextCursor := ""
+	if len(events) > filter.Limit {
+		events = events[:filter.Limit]
+		nextCursor = encodeCursor(events[len(events)-1])
+	}
+
+	return events, nextCursor, nil
 }
 
-// UpdateEvent updates an existing event
+// UpdateEvent updates an existing event.
+// Participants are managed through the dedicated participant methods and are preserved.
 func (s *Service) UpdateEvent(ctx context.Context, eventID string, userID string, updates *models.Event) (*models.Event, error) {
 	id, err := primitive.ObjectIDFromHex(eventID)
 	if err != nil {
@@ -161,44 +271,39 @@ func (s *Service) UpdateEvent(ctx context.Context, eventID string, userID string
 		return nil, ErrInvalidUserID
 	}
 
-	// First check if user has permission to update (must be creator or host)
-	query := bson.M{
-		"_id": id,
-		"$or": []bson.M{
-			{"creatorId": uid},
-			{"participants": bson.M{
-				"$elemMatch": bson.M{
-					"userId": uid,
-					"role":   "host",
-				},
-			}},
-		},
-		"deletedAt": nil,
+	if updates.MaxCapacity < 0 {
+		return nil, ErrInvalidEvent
 	}
 
-	var existingEvent models.Event
-	err = s.db.Collection(eventsCollection).FindOne(ctx, query).Decode(&existingEvent)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, ErrUnauthorized
+	var promoted []primitive.ObjectID
+	updated, err := s.mutateEvent(ctx, id, func(existing *models.Event, now time.Time) error {
+		if !canOnEvent(existing, uid, ActionEdit) {
+			return ErrUnauthorized
 		}
-		return nil, err
-	}
 
-	// Prepare updates
-	updates.ID = id
-	updates.CreatorID = existingEvent.CreatorID // Can't change creator
-	updates.UpdatedAt = time.Now()
-	updates.CreatedAt = existingEvent.CreatedAt // Preserve creation time
+		// Prepare updates
+		updates.ID = id
+		updates.CreatorID = existing.CreatorID       // Can't change creator
+		updates.CreatedAt = existing.CreatedAt       // Preserve creation time
+		updates.Participants = existing.Participants // Managed by participant endpoints
+		updates.Wishlists = existing.Wishlists       // Managed by wishlist endpoints
+		updates.DeletedAt = nil
+		if updates.Location != nil {
+			updates.Location.SyncGeo()
+		}
 
-	// Perform update
-	updateQuery := bson.M{"_id": id}
-	_, err = s.db.Collection(eventsCollection).ReplaceOne(ctx, updateQuery, updates)
+		// A larger capacity may free seats for waitlisted guests
+		promoted = promoteWaitlist(updates, now)
+
+		*existing = *updates
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	s.notifyPromoted(updated, promoted)
 
-	return updates, nil
+	return withHeadcount(updated, uid), nil
 }
 
 // DeleteEvent marks an event as deleted (soft delete)
@@ -213,35 +318,73 @@ func (s *Service) DeleteEvent(ctx context.Context, eventID string, userID string
 		return ErrInvalidUserID
 	}
 
-	// User must be creator to delete
-	query := bson.M{
-		"_id":       id,
-		"creatorId": uid,
-		"deletedAt": nil,
+	_, err = s.mutateEvent(ctx, id, func(event *models.Event, now time.Time) error {
+		if !canOnEvent(event, uid, ActionDelete) {
+			return ErrUnauthorized
+		}
+		event.DeletedAt = &now
+		return nil
+	})
+	if err == ErrEventNotFound {
+		return ErrUnauthorized
 	}
+	return err
+}
 
-	now := time.Now()
-	update := bson.M{
-		"$set": bson.M{
-			"deletedAt": now,
-			"updatedAt": now,
-		},
+// AddParticipant adds a participant to an event
+func (s *Service) AddParticipant(ctx context.Context, eventID string, userID string, participant *models.EventParticipant) error {
+	id, err := primitive.ObjectIDFromHex(eventID)
+	if err != nil {
+		return ErrInvalidID
 	}
 
-	result, err := s.db.Collection(eventsCollection).UpdateOne(ctx, query, update)
+	uid, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return err
+		return ErrInvalidUserID
 	}
 
-	if result.MatchedCount == 0 {
-		return ErrUnauthorized
+	if participant.UserID.IsZero() || participant.PlusOnes < 0 || participant.PlusOnes > maxPlusOnes {
+		return ErrInvalidParticipant
+	}
+	if participant.Role == "" {
+		participant.Role = models.EventRoleGuest
+	}
+	if !IsValidRole(participant.Role) {
+		return ErrInvalidParticipant
 	}
 
-	return nil
+	_, err = s.mutateEvent(ctx, id, func(event *models.Event, now time.Time) error {
+		if !canOnEvent(event, uid, ActionInvite) {
+			return ErrUnauthorized
+		}
+
+		// Only those who manage roles may appoint hosts and co-hosts
+		if (participant.Role == models.EventRoleHost || participant.Role == models.EventRoleCoHost) &&
+			!canOnEvent(event, uid, ActionManageRoles) {
+			return ErrUnauthorized
+		}
+
+		if findParticipant(event, participant.UserID) >= 0 {
+			return ErrAlreadyParticipant
+		}
+
+		// Set invite time if not provided
+		if participant.InvitedAt.IsZero() {
+			participant.InvitedAt = now
+		}
+		participant.Status = models.EventStatusInvited
+		participant.RespondedAt = nil
+		participant.WaitlistedAt = nil
+
+		event.Participants = append(event.Participants, *participant)
+		return nil
+	})
+	return err
 }
 
-// AddParticipant adds a participant to an event
-func (s *Service) AddParticipant(ctx context.Context, eventID string, userID string, participant *models.EventParticipant) error {
+// RemoveParticipant removes a participant from an event. Participants may
+// always remove themselves; co-hosts cannot remove hosts or other co-hosts.
+func (s *Service) RemoveParticipant(ctx context.Context, eventID string, userID string, targetUserID string) error {
 	id, err := primitive.ObjectIDFromHex(eventID)
 	if err != nil {
 		return ErrInvalidID
@@ -252,48 +395,48 @@ func (s *Service) AddParticipant(ctx context.Context, eventID string, userID str
 		return ErrInvalidUserID
 	}
 
-	// Check if user has permission to add participants (must be creator or host)
-	query := bson.M{
-		"_id": id,
-		"$or": []bson.M{
-			{"creatorId": uid},
-			{"participants": bson.M{
-				"$elemMatch": bson.M{
-					"userId": uid,
-					"role":   "host",
-				},
-			}},
-		},
-		"deletedAt": nil,
+	targetID, err := primitive.ObjectIDFromHex(targetUserID)
+	if err != nil {
+		return ErrInvalidUserID
 	}
 
-	var event models.Event
-	err = s.db.Collection(eventsCollection).FindOne(ctx, query).Decode(&event)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
+	var promoted []primitive.ObjectID
+	updated, err := s.mutateEvent(ctx, id, func(event *models.Event, now time.Time) error {
+		index := findParticipant(event, targetID)
+		if index < 0 {
+			return ErrParticipantMissing
+		}
+
+		// The creator cannot leave or be removed from their own event
+		if targetID == event.CreatorID {
 			return ErrUnauthorized
 		}
-		return err
-	}
 
-	// Set invite time if not provided
-	if participant.InvitedAt.IsZero() {
-		participant.InvitedAt = time.Now()
-	}
+		if targetID != uid {
+			if !canOnEvent(event, uid, ActionRemoveParticipant) {
+				return ErrUnauthorized
+			}
+			targetRole := event.Participants[index].Role
+			if (targetRole == models.EventRoleHost || targetRole == models.EventRoleCoHost) &&
+				!canOnEvent(event, uid, ActionManageRoles) {
+				return ErrUnauthorized
+			}
+		}
 
-	// Add participant to the event
-	updateQuery := bson.M{"_id": id}
-	update := bson.M{
-		"$push": bson.M{"participants": participant},
-		"$set":  bson.M{"updatedAt": time.Now()},
+		event.Participants = append(event.Participants[:index], event.Participants[index+1:]...)
+		promoted = promoteWaitlist(event, now)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	_, err = s.db.Collection(eventsCollection).UpdateOne(ctx, updateQuery, update)
-	return err
+	s.notifyPromoted(updated, promoted)
+	return nil
 }
 
-// AddGift adds a gift to an event
-func (s *Service) AddGift(ctx context.Context, eventID string, userID string, gift *models.EventGift) error {
+// UpdateParticipantRole changes the role of a participant
+func (s *Service) UpdateParticipantRole(ctx context.Context, eventID string, userID string, targetUserID string, role string) error {
 	id, err := primitive.ObjectIDFromHex(eventID)
 	if err != nil {
 		return ErrInvalidID
@@ -304,92 +447,127 @@ func (s *Service) AddGift(ctx context.Context, eventID string, userID string, gi
 		return ErrInvalidUserID
 	}
 
-	// Check if user has permission to add gifts (must be participant)
-	query := bson.M{
-		"_id": id,
-		"$or": []bson.M{
-			{"creatorId": uid},
-			{"participants.userId": uid},
-		},
-		"deletedAt": nil,
+	targetID, err := primitive.ObjectIDFromHex(targetUserID)
+	if err != nil {
+		return ErrInvalidUserID
 	}
 
-	var event models.Event
-	err = s.db.Collection(eventsCollection).FindOne(ctx, query).Decode(&event)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
+	if !IsValidRole(role) {
+		return ErrInvalidParticipant
+	}
+
+	_, err = s.mutateEvent(ctx, id, func(event *models.Event, now time.Time) error {
+		if !canOnEvent(event, uid, ActionManageRoles) {
 			return ErrUnauthorized
 		}
-		return err
+
+		index := findParticipant(event, targetID)
+		if index < 0 {
+			return ErrParticipantMissing
+		}
+
+		// The creator always stays a host
+		if targetID == event.CreatorID && role != models.EventRoleHost {
+			return ErrUnauthorized
+		}
+
+		event.Participants[index].Role = role
+		return nil
+	})
+	return err
+}
+
+// AddGift adds a gift to an event
+func (s *Service) AddGift(ctx context.Context, eventID string, userID string, gift *models.EventGift) error {
+	id, err := primitive.ObjectIDFromHex(eventID)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrInvalidUserID
 	}
 
 	// Set gift metadata
 	if gift.ID.IsZero() {
 		gift.ID = primitive.NewObjectID()
 	}
-	now := time.Now()
-	gift.CreatedAt = now
-	gift.UpdatedAt = now
 
-	// Add gift to the event
-	updateQuery := bson.M{"_id": id}
-	update := bson.M{
-		"$push": bson.M{"gifts": gift},
-		"$set":  bson.M{"updatedAt": now},
-	}
+	_, err = s.mutateEvent(ctx, id, func(event *models.Event, now time.Time) error {
+		if !canOnEvent(event, uid, ActionAddGift) {
+			return ErrUnauthorized
+		}
 
-	_, err = s.db.Collection(eventsCollection).UpdateOne(ctx, updateQuery, update)
+		gift.CreatedAt = now
+		gift.UpdatedAt = now
+		event.Gifts = append(event.Gifts, *gift)
+		return nil
+	})
+	if err == ErrEventNotFound {
+		return ErrUnauthorized
+	}
 	return err
 }
 
-// UpdateParticipantStatus updates a participant status (accept/decline invitation)
-func (s *Service) UpdateParticipantStatus(ctx context.Context, eventID string, userID string, status string) error {
+// UpdateParticipantStatus updates a participant status (accept/decline invitation).
+// plusOnes is optional; when nil the participant keeps their current plus-ones.
+// Confirming beyond the event capacity puts the participant on the waitlist,
+// and seats freed by a decline are given to waitlisted participants.
+func (s *Service) UpdateParticipantStatus(ctx context.Context, eventID string, userID string, status string, plusOnes *int) (*models.EventParticipant, error) {
 	id, err := primitive.ObjectIDFromHex(eventID)
 	if err != nil {
-		return ErrInvalidID
+		return nil, ErrInvalidID
 	}
 
 	uid, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return ErrInvalidUserID
+		return nil, ErrInvalidUserID
 	}
 
 	// Valid statuses
 	validStatuses := map[string]bool{
-		"confirmed": true,
-		"declined":  true,
-		"maybe":     true,
+		models.EventStatusConfirmed: true,
+		models.EventStatusDeclined:  true,
+		models.EventStatusMaybe:     true,
 	}
 
 	if !validStatuses[status] {
-		return errors.New("invalid status value")
+		return nil, ErrInvalidStatus
 	}
 
-	now := time.Now()
-
-	// Update participant status
-	query := bson.M{
-		"_id":                 id,
-		"participants.userId": uid,
-		"deletedAt":           nil,
+	if plusOnes != nil && (*plusOnes < 0 || *plusOnes > maxPlusOnes) {
+		return nil, ErrInvalidParticipant
 	}
 
-	update := bson.M{
-		"$set": bson.M{
-			"participants.$.status":      status,
-			"participants.$.respondedAt": now,
-			"updatedAt":                  now,
-		},
-	}
+	var participant models.EventParticipant
+	var promoted []primitive.ObjectID
+	updated, err := s.mutateEvent(ctx, id, func(event *models.Event, now time.Time) error {
+		index := findParticipant(event, uid)
+		if index < 0 {
+			return ErrEventNotFound
+		}
+		if !canOnEvent(event, uid, ActionRSVP) {
+			return ErrUnauthorized
+		}
 
-	result, err := s.db.Collection(eventsCollection).UpdateOne(ctx, query, update)
-	if err != nil {
-		return err
-	}
+		guests := event.Participants[index].PlusOnes
+		if plusOnes != nil {
+			guests = *plusOnes
+		}
 
-	if result.MatchedCount == 0 {
-		return ErrEventNotFound
+		if err := applyRSVP(event, index, status, guests, now); err != nil {
+			return err
+		}
+		promoted = promoteWaitlist(event, now)
+
+		participant = event.Participants[index]
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	s.notifyPromoted(updated, promoted)
+	return &participant, nil
 }
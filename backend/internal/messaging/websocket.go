@@ -240,6 +240,38 @@ func (h *WebsocketHub) SendMessageToChat(chatID string, message []byte) {
 	}
 }
 
+// Pusher delivers real-time notifications to a user's connected clients.
+// WebsocketHub implements it; services that notify users depend on it
+// rather than on the hub so they can be tested without connections.
+type Pusher interface {
+	SendToUser(userID string, message []byte) int
+}
+
+// SendToUser sends a message to every connected client of a user and
+// returns the number of clients it was delivered to
+func (h *WebsocketHub) SendToUser(userID string, message []byte) int {
+	var targets []*Client
+	h.mu.Lock()
+	for client := range h.clients {
+		if client.UserID == userID {
+			targets = append(targets, client)
+		}
+	}
+	h.mu.Unlock()
+
+	delivered := 0
+	for _, client := range targets {
+		select {
+		case client.Send <- message:
+			delivered++
+		default:
+			// If the client's send buffer is full, unregister the client
+			h.unregister <- client
+		}
+	}
+	return delivered
+}
+
 // NotifyNewMessage sends a notification about a new message to all clients subscribed to a chat
 func (h *WebsocketHub) NotifyNewMessage(message *models.Message) {
 	// Don't notify clients about their own messages
@@ -11,37 +11,82 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
 // Clés de contexte
 const (
-	UserIDKey = "userID"
+	UserIDKey         = "userID"
+	SessionIDKey      = "sessionID"
+	ScopesKey         = "scopes"
+	ImpersonatorIDKey = "impersonatorID"
 )
 
 // JWTClaims représente les claims d'un token JWT
 type JWTClaims struct {
-	UserID string `json:"userId"`
+	UserID    string      `json:"userId"`
+	SessionID string      `json:"sid,omitempty"`   // session (appareil) à laquelle le token est rattaché
+	Scope     string      `json:"scope,omitempty"` // scopes du back-office, séparés par des espaces
+	Actor     *ActorClaim `json:"act,omitempty"`   // membre du support agissant en tant que l'utilisateur
 	jwt.RegisteredClaims
 }
 
-// JWTService est le service d'authentification JWT
+// ActorClaim identifie l'auteur réel des requêtes d'un token d'usurpation (RFC 8693)
+type ActorClaim struct {
+	Subject string `json:"sub"`
+}
+
+// Scopes renvoie la liste des scopes du token
+func (c *JWTClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// JWTService est le service d'authentification JWT.
+// Les tokens d'accès sont signés par la première clé asymétrique configurée (RS256 ou EdDSA, avec
+// un en-tête kid), ou à défaut par le secret HMAC ; les tokens de rafraîchissement, que seul
+// ce service vérifie, restent signés par le secret HMAC.
 type JWTService struct {
 	config config.JWTConfig
+	keys   []*signingKey // la première signe, toutes vérifient
 }
 
-// NewJWTService crée une nouvelle instance du service JWT
-func NewJWTService(cfg config.JWTConfig) *JWTService {
+// NewJWTService crée une nouvelle instance du service JWT et charge ses clés de signature
+func NewJWTService(cfg config.JWTConfig) (*JWTService, error) {
+	keys, err := loadSigningKeys(cfg.SigningKeys)
+	if err != nil {
+		return nil, err
+	}
 	return &JWTService{
 		config: cfg,
+		keys:   keys,
+	}, nil
+}
+
+// verificationKey renvoie la clé publique qui vérifie un token d'accès signé par une clé asymétrique
+func (s *JWTService) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	for _, key := range s.keys {
+		if key.id != kid {
+			continue
+		}
+		// Une clé ne vérifie que l'algorithme pour lequel elle a été chargée
+		if key.method.Alg() != token.Method.Alg() {
+			return nil, fmt.Errorf("algorithme %s inattendu pour la clé %s", token.Method.Alg(), kid)
+		}
+		return key.public, nil
 	}
+	return nil, fmt.Errorf("clé de signature inconnue: %q", kid)
 }
 
-// GenerateAccessToken génère un nouveau token d'accès JWT
-func (s *JWTService) GenerateAccessToken(userID string) (string, error) {
+// GenerateAccessToken génère un nouveau token d'accès JWT pour une session,
+// portant les scopes du back-office accordés par les rôles de l'utilisateur
+func (s *JWTService) GenerateAccessToken(userID string, sessionID string, scopes []string) (string, error) {
 	now := time.Now()
 	claims := JWTClaims{
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
+		Scope:     strings.Join(scopes, " "),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.AccessExpiryTime)),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -51,8 +96,42 @@ func (s *JWTService) GenerateAccessToken(userID string) (string, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(s.config.AccessSecret))
+	return s.signAccessToken(claims)
+}
+
+// GenerateImpersonationToken génère un token d'accès de courte durée permettant à actorID d'agir
+// en tant que userID. Il n'est rattaché à aucune session, ne porte aucun scope et ne peut pas être rafraîchi.
+func (s *JWTService) GenerateImpersonationToken(userID string, actorID string, lifetime time.Duration) (string, error) {
+	now := time.Now()
+	claims := JWTClaims{
+		UserID: userID,
+		Actor:  &ActorClaim{Subject: actorID},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(lifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    s.config.Issuer,
+			Subject:   userID,
+		},
+	}
+
+	return s.signAccessToken(claims)
+}
+
+// signAccessToken signe un token d'accès avec la clé active, ou à défaut le secret HMAC
+func (s *JWTService) signAccessToken(claims JWTClaims) (string, error) {
+	var signedToken string
+	var err error
+	if len(s.keys) > 0 {
+		active := s.keys[0]
+		token := jwt.NewWithClaims(active.method, claims)
+		token.Header["kid"] = active.id
+		signedToken, err = token.SignedString(active.private)
+	} else {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		signedToken, err = token.SignedString([]byte(s.config.AccessSecret))
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("Erreur lors de la génération du token d'accès")
 		return "", err
@@ -61,12 +140,15 @@ func (s *JWTService) GenerateAccessToken(userID string) (string, error) {
 	return signedToken, nil
 }
 
-// GenerateRefreshToken génère un nouveau token de rafraîchissement JWT
-func (s *JWTService) GenerateRefreshToken(userID string) (string, error) {
+// GenerateRefreshToken génère un nouveau token de rafraîchissement JWT pour une session.
+// Chaque token reçoit un identifiant unique : deux tokens d'une même session ne sont jamais identiques.
+func (s *JWTService) GenerateRefreshToken(userID string, sessionID string) (string, error) {
 	now := time.Now()
 	claims := JWTClaims{
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.RefreshExpiryTime)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
@@ -93,13 +175,21 @@ func (s *JWTService) VerifyAccessToken(tokenString string) (*JWTClaims, error) {
 
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		// Vérifier la méthode de signature
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			log.Error().Str("alg", fmt.Sprintf("%v", token.Header["alg"])).Msg("!!! VerifyAccessToken: Méthode de signature inattendue")
-			return nil, fmt.Errorf("méthode de signature inattendue: %v", token.Header["alg"])
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
+			if len(s.keys) > 0 {
+				return s.verificationKey(token)
+			}
+		case *jwt.SigningMethodHMAC:
+			// Après le passage aux clés asymétriques, les tokens HMAC déjà émis restent acceptés
+			// jusqu'à leur expiration, sauf si la configuration l'interdit
+			if len(s.keys) == 0 || s.config.AcceptHMACAccessTokens {
+				log.Debug().Msg(">>> VerifyAccessToken: Utilisation de AccessSecret pour la vérification")
+				return []byte(s.config.AccessSecret), nil
+			}
 		}
-		// Retourner la clé secrète
-		log.Debug().Msg(">>> VerifyAccessToken: Utilisation de AccessSecret pour la vérification")
-		return []byte(s.config.AccessSecret), nil
+		log.Error().Str("alg", fmt.Sprintf("%v", token.Header["alg"])).Msg("!!! VerifyAccessToken: Méthode de signature inattendue")
+		return nil, fmt.Errorf("méthode de signature inattendue: %v", token.Header["alg"])
 	}, jwt.WithLeeway(leeway), jwt.WithIssuer(s.config.Issuer)) // Ajouter la validation de l'issuer
 
 	// Log après ParseWithClaims, avant la vérification de l'erreur
@@ -235,15 +325,46 @@ func AuthRequired() gin.HandlerFunc {
 			return
 		}
 
+		// Un compte suspendu n'a plus accès à l'API, même avec un token encore valide
+		if checker, ok := accountCheckerFrom(c); ok {
+			suspended, err := checker.IsSuspended(c.Request.Context(), claims.UserID)
+			if err != nil {
+				log.Error().Err(err).Str("userID", claims.UserID).Msg("AuthRequired: Vérification du compte impossible")
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erreur lors de la vérification du compte"})
+				return
+			}
+			if suspended {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Compte suspendu", "code": "account_suspended"})
+				return
+			}
+		}
+
 		// Stocker l'ID de l'utilisateur dans le contexte
 		log.Debug().Str("userID", claims.UserID).Msg(">>> AuthRequired: Authentification réussie, userID ajouté au contexte")
-		c.Set(UserIDKey, claims.UserID)
+		setClaims(c, claims)
 
 		// Continuer l'exécution
 		c.Next()
 	}
 }
 
+// setClaims stocke dans le contexte l'utilisateur, la session et les scopes du token.
+// Les requêtes faites par usurpation sont tracées avec l'identifiant du membre du support.
+func setClaims(c *gin.Context, claims *JWTClaims) {
+	c.Set(UserIDKey, claims.UserID)
+	c.Set(SessionIDKey, claims.SessionID)
+	c.Set(ScopesKey, claims.Scopes())
+	if claims.Actor != nil {
+		c.Set(ImpersonatorIDKey, claims.Actor.Subject)
+		log.Info().
+			Str("userID", claims.UserID).
+			Str("impersonatorID", claims.Actor.Subject).
+			Str("method", c.Request.Method).
+			Str("path", c.Request.URL.Path).
+			Msg("Requête effectuée par usurpation d'identité")
+	}
+}
+
 // Optional est un middleware qui récupère l'identifiant de l'utilisateur s'il est authentifié,
 // mais continue l'exécution même si l'utilisateur n'est pas authentifié
 func Optional() gin.HandlerFunc {
@@ -285,7 +406,7 @@ func Optional() gin.HandlerFunc {
 		}
 
 		// Stocker l'ID de l'utilisateur dans le contexte
-		c.Set(UserIDKey, claims.UserID)
+		setClaims(c, claims)
 
 		// Continuer l'exécution
 		c.Next()
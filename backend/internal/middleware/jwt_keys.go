@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksMaxAge est la durée de cache annoncée pour le document JWKS. Une nouvelle clé doit être
+// publiée (ajoutée en fin de liste) au moins aussi longtemps avant de signer des tokens.
+const jwksMaxAge = 15 * 60
+
+// rsaMinBits est la taille minimale acceptée pour une clé RSA
+const rsaMinBits = 2048
+
+// signingKey est une clé asymétrique des tokens d'accès, identifiée par son kid
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod // RS256 pour une clé RSA, EdDSA pour une clé Ed25519
+	private crypto.Signer     // nil pour une clé retirée, conservée seulement pour la vérification
+	public  crypto.PublicKey
+}
+
+// JWK est une clé publique au format JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA : module
+	E   string `json:"e,omitempty"`   // RSA : exposant
+	Crv string `json:"crv,omitempty"` // OKP : courbe
+	X   string `json:"x,omitempty"`   // OKP : clé publique
+}
+
+// JWKS est le document publiant les clés publiques de vérification des tokens d'accès
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// loadSigningKeys charge les clés déclarées sous la forme "kid=chemin/vers/cle.pem".
+// La première clé signe les nouveaux tokens et doit donc être une clé privée ;
+// les suivantes (privées ou publiques) ne servent qu'à vérifier les tokens déjà émis.
+func loadSigningKeys(entries []string) ([]*signingKey, error) {
+	keys := make([]*signingKey, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+
+	for i, entry := range entries {
+		kid, path, found := strings.Cut(entry, "=")
+		kid, path = strings.TrimSpace(kid), strings.TrimSpace(path)
+		if !found || kid == "" || path == "" {
+			return nil, fmt.Errorf("clé JWT %q: format attendu kid=chemin", entry)
+		}
+		if seen[kid] {
+			return nil, fmt.Errorf("clé JWT %q déclarée deux fois", kid)
+		}
+		seen[kid] = true
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("clé JWT %q: %w", kid, err)
+		}
+		key, err := parseSigningKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("clé JWT %q: %w", kid, err)
+		}
+		if i == 0 && key.private == nil {
+			return nil, fmt.Errorf("clé JWT %q: la clé active doit être une clé privée", kid)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// parseSigningKey lit une clé RSA ou Ed25519 au format PEM (PKCS#8, PKCS#1 ou PKIX)
+func parseSigningKey(kid string, data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("aucun bloc PEM trouvé")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("type de bloc PEM non supporté: %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{id: kid}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.private = signer
+		parsed = signer.Public()
+	}
+
+	switch public := parsed.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < rsaMinBits {
+			return nil, fmt.Errorf("clé RSA trop courte (%d bits, minimum %d)", public.N.BitLen(), rsaMinBits)
+		}
+		key.method, key.public = jwt.SigningMethodRS256, public
+	case ed25519.PublicKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, public
+	default:
+		return nil, fmt.Errorf("type de clé non supporté: %T", public)
+	}
+	return key, nil
+}
+
+// jwk renvoie la partie publique de la clé au format JWK
+func (k *signingKey) jwk() JWK {
+	jwk := JWK{Kid: k.id, Use: "sig", Alg: k.method.Alg()}
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+	return jwk
+}
+
+// JWKS renvoie les clés publiques permettant de vérifier les tokens d'accès.
+// Le document est vide lorsque les tokens sont signés par un secret HMAC.
+func (s *JWTService) JWKS() JWKS {
+	document := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		document.Keys = append(document.Keys, key.jwk())
+	}
+	return document
+}
+
+// JWKSHandler publie les clés publiques des tokens d'accès (/.well-known/jwks.json),
+// pour que d'autres services puissent les vérifier sans partager de secret
+func JWKSHandler(s *JWTService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", jwksMaxAge))
+		c.JSON(http.StatusOK, s.JWKS())
+	}
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// accountCheckerKey est la clé de contexte du AccountChecker injecté par SetAccountChecker
+const accountCheckerKey = "accountChecker"
+
+// AccountChecker donne l'état courant d'un compte, qui peut avoir changé depuis l'émission du token
+type AccountChecker interface {
+	// IsSuspended indique si le compte est suspendu
+	IsSuspended(ctx context.Context, userID string) (bool, error)
+	// Scopes renvoie les scopes du back-office que les rôles actuels de l'utilisateur accordent
+	Scopes(ctx context.Context, userID string) ([]string, error)
+}
+
+// SetAccountChecker injecte dans le contexte le service qui permet à AuthRequired
+// de refuser les comptes suspendus
+func SetAccountChecker(checker AccountChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(accountCheckerKey, checker)
+		c.Next()
+	}
+}
+
+// accountCheckerFrom renvoie le AccountChecker injecté dans le contexte
+func accountCheckerFrom(c *gin.Context) (AccountChecker, bool) {
+	value, exists := c.Get(accountCheckerKey)
+	if !exists {
+		return nil, false
+	}
+	checker, ok := value.(AccountChecker)
+	return checker, ok
+}
+
+// ScopeRequired restreint l'accès aux utilisateurs dont le token porte tous les scopes demandés.
+// Les rôles sont vérifiés à nouveau à chaque requête : un rôle retiré prend effet sans attendre
+// l'expiration du token, un rôle accordé au prochain rafraîchissement. Doit être utilisé après AuthRequired.
+func ScopeRequired(checker AccountChecker, scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := GetUserIDFromContext(c)
+
+		granted, err := checker.Scopes(c.Request.Context(), userID)
+		if err != nil {
+			log.Error().Err(err).Str("userID", userID).Msg("ScopeRequired: Vérification des rôles impossible")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erreur lors de la vérification des droits"})
+			return
+		}
+
+		tokenScopes := GetScopesFromContext(c)
+		for _, scope := range scopes {
+			if !contains(tokenScopes, scope) || !contains(granted, scope) {
+				log.Warn().Str("userID", userID).Str("scope", scope).Str("path", c.Request.URL.Path).Msg("ScopeRequired: Accès refusé")
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Accès non autorisé", "scope": scope})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// NotImpersonated refuse les requêtes faites avec un token d'usurpation d'identité,
+// pour les actions que seul le titulaire du compte peut effectuer (sécurité, suppression...)
+func NotImpersonated() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if GetImpersonatorIDFromContext(c) != "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Action impossible en tant qu'un autre utilisateur"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetScopesFromContext récupère les scopes du token depuis le contexte Gin
+func GetScopesFromContext(c *gin.Context) []string {
+	scopes, _ := c.Get(ScopesKey)
+	list, _ := scopes.([]string)
+	return list
+}
+
+// GetImpersonatorIDFromContext récupère l'identifiant du membre du support qui agit en tant
+// que l'utilisateur, ou une chaîne vide
+func GetImpersonatorIDFromContext(c *gin.Context) string {
+	impersonatorID, _ := c.Get(ImpersonatorIDKey)
+	id, _ := impersonatorID.(string)
+	return id
+}
+
+// contains indique si values contient value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
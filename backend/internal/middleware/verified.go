@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// VerificationChecker indique si un utilisateur a vérifié ses coordonnées
+type VerificationChecker interface {
+	IsVerified(ctx context.Context, userID string) (bool, error)
+}
+
+// verificationActions associe chaque action pouvant être réservée aux comptes vérifiés à ses routes
+var verificationActions = map[string][]string{
+	"transfers": {
+		"POST /api/users/me/balance/transfer",
+	},
+	"sharing": {
+		"POST /api/wishlists/:id/share",
+		"POST /api/wishlists/:id/share-link",
+		"PUT /api/wishlists/:id/share-link",
+	},
+	"invitations": {
+		"POST /api/events/:id/participants",
+	},
+}
+
+// VerifiedRequired refuse aux comptes non vérifiés les routes des actions restreintes
+// (voir Security.UnverifiedRestrictions). Les autres routes ne sont pas concernées.
+// À placer après AuthRequired.
+func VerifiedRequired(checker VerificationChecker, restrictions []string) gin.HandlerFunc {
+	restricted := make(map[string]string)
+	for _, action := range restrictions {
+		routes, found := verificationActions[action]
+		if !found {
+			log.Warn().Str("action", action).Msg("VerifiedRequired: action inconnue ignorée")
+			continue
+		}
+		for _, route := range routes {
+			restricted[route] = action
+		}
+	}
+
+	return func(c *gin.Context) {
+		action, found := restricted[c.Request.Method+" "+c.FullPath()]
+		if !found {
+			c.Next()
+			return
+		}
+
+		userID := GetUserIDFromContext(c)
+		verified, err := checker.IsVerified(c.Request.Context(), userID)
+		if err != nil {
+			log.Error().Err(err).Str("userID", userID).Msg("VerifiedRequired: vérification du compte impossible")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erreur lors de la vérification du compte"})
+			return
+		}
+		if !verified {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":  "Veuillez vérifier votre email ou votre numéro de téléphone pour effectuer cette action",
+				"code":   "verification_required",
+				"action": action,
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WishlistActivityType identifies what changed on a wishlist
+type WishlistActivityType string
+
+const (
+	ActivityItemAdded            WishlistActivityType = "item_added"
+	ActivityItemRemoved          WishlistActivityType = "item_removed"
+	ActivityItemUpdated          WishlistActivityType = "item_updated"
+	ActivityItemReserved         WishlistActivityType = "item_reserved"
+	ActivityReservationCancelled WishlistActivityType = "reservation_cancelled"
+	ActivityItemPurchased        WishlistActivityType = "item_purchased"
+	ActivityItemReceived         WishlistActivityType = "item_received"
+	ActivityWishlistUpdated      WishlistActivityType = "wishlist_updated"
+	ActivityLayoutChanged        WishlistActivityType = "layout_changed"
+	ActivityShared               WishlistActivityType = "shared"
+	ActivityShareAccepted        WishlistActivityType = "share_accepted"
+	ActivityShareDeclined        WishlistActivityType = "share_declined"
+	ActivityShareRemoved         WishlistActivityType = "share_removed"
+	ActivityShareLinkUpdated     WishlistActivityType = "share_link_updated"
+	ActivityShareLinkRevoked     WishlistActivityType = "share_link_revoked"
+)
+
+// WishlistActivity is an entry of a wishlist's activity log
+type WishlistActivity struct {
+	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	WishlistID   primitive.ObjectID   `bson:"wishlistId" json:"wishlistId"`
+	Type         WishlistActivityType `bson:"type" json:"type"`
+	ActorID      *primitive.ObjectID  `bson:"actorId,omitempty" json:"actorId,omitempty"`     // nil for guests without an account
+	ActorName    string               `bson:"actorName,omitempty" json:"actorName,omitempty"` // guest name, or resolved when reading the feed
+	ItemID       *primitive.ObjectID  `bson:"itemId,omitempty" json:"itemId,omitempty"`
+	ItemName     string               `bson:"itemName,omitempty" json:"itemName,omitempty"`
+	TargetUserID *primitive.ObjectID  `bson:"targetUserId,omitempty" json:"targetUserId,omitempty"` // user a sharing change is about
+	EventID      *primitive.ObjectID  `bson:"eventId,omitempty" json:"eventId,omitempty"`           // event a reservation was made through
+	Quantity     int                  `bson:"quantity,omitempty" json:"quantity,omitempty"`         // copies concerned by a partial reservation
+	Changes      []string             `bson:"changes,omitempty" json:"changes,omitempty"`           // fields changed by an update
+	// Private activities reveal reservations: the wishlist owner only sees them
+	// when the wishlist's surprise mode is off
+	Private   bool      `bson:"private" json:"-"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+}
+
+// WishlistActivityPage is a page of a wishlist's activity feed, newest first
+type WishlistActivityPage struct {
+	Activities []WishlistActivity `json:"activities"`
+	NextCursor string             `json:"nextCursor,omitempty"`
+}
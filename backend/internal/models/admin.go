@@ -0,0 +1,149 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Rôles attribuables aux utilisateurs pour le back-office
+const (
+	RoleAdmin     = "admin"     // tous les droits
+	RoleSupport   = "support"   // assistance aux utilisateurs
+	RoleModerator = "moderator" // modération des contenus
+)
+
+// Scopes portés par les tokens d'accès, accordés selon les rôles
+const (
+	ScopeUsersRead         = "admin:users:read"         // recherche et consultation des comptes
+	ScopeUsersWrite        = "admin:users:write"        // suspension, réinitialisation forcée du mot de passe
+	ScopeRoles             = "admin:roles"              // attribution des rôles
+	ScopeTransactionsRead  = "admin:transactions:read"  // consultation des transactions
+	ScopeTransactionsWrite = "admin:transactions:write" // annulation des transactions
+	ScopeContent           = "admin:content"            // retrait de stories et de messages
+	ScopeImpersonate       = "admin:impersonate"        // connexion en tant qu'un utilisateur
+	ScopeAudit             = "admin:audit"              // consultation du journal d'audit
+	ScopeCatalog           = "admin:catalog"            // catalogue des événements prédéfinis
+)
+
+// roleScopes associe chaque rôle aux scopes qu'il accorde
+var roleScopes = map[string][]string{
+	RoleAdmin: {
+		ScopeUsersRead, ScopeUsersWrite, ScopeRoles, ScopeTransactionsRead, ScopeTransactionsWrite,
+		ScopeContent, ScopeImpersonate, ScopeAudit, ScopeCatalog,
+	},
+	RoleSupport:   {ScopeUsersRead, ScopeUsersWrite, ScopeTransactionsRead, ScopeImpersonate},
+	RoleModerator: {ScopeUsersRead, ScopeContent},
+}
+
+// IsValidRole indique si role est un rôle connu
+func IsValidRole(role string) bool {
+	_, found := roleScopes[role]
+	return found
+}
+
+// ScopesForRoles renvoie les scopes accordés par un ensemble de rôles, sans doublon
+func ScopesForRoles(roles []string) []string {
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, role := range roles {
+		for _, scope := range roleScopes[role] {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
+
+// AdminUserResponse représente un compte tel que présenté au back-office
+type AdminUserResponse struct {
+	UserResponse
+	SuspendedAt           *time.Time `json:"suspendedAt,omitempty"`
+	SuspensionReason      string     `json:"suspensionReason,omitempty"`
+	PasswordResetRequired bool       `json:"passwordResetRequired,omitempty"`
+	ActiveSessions        int        `json:"activeSessions"`
+	ManagedAccounts       int        `json:"managedAccounts"`
+}
+
+// ToAdminResponse convertit un User en AdminUserResponse
+func (u *User) ToAdminResponse() AdminUserResponse {
+	now := time.Now()
+	sessions := 0
+	for _, session := range u.RefreshTokens {
+		if now.Before(session.ExpiresAt) {
+			sessions++
+		}
+	}
+
+	return AdminUserResponse{
+		UserResponse:          u.ToResponse(),
+		SuspendedAt:           u.SuspendedAt,
+		SuspensionReason:      u.SuspensionReason,
+		PasswordResetRequired: u.PasswordResetRequired,
+		ActiveSessions:        sessions,
+		ManagedAccounts:       len(u.ManagedAccounts),
+	}
+}
+
+// AdminUserListResponse est une page de résultats de recherche de comptes
+type AdminUserListResponse struct {
+	Users  []AdminUserResponse `json:"users"`
+	Total  int64               `json:"total"`
+	Limit  int64               `json:"limit"`
+	Offset int64               `json:"offset"`
+}
+
+// AdminTransactionResponse représente une transaction telle que présentée au back-office
+type AdminTransactionResponse struct {
+	TransactionResponse
+	UserID string `json:"userId"` // titulaire du compte
+}
+
+// AdminTransactionListResponse est une page de transactions
+type AdminTransactionListResponse struct {
+	Transactions []AdminTransactionResponse `json:"transactions"`
+	Total        int64                      `json:"total"`
+	Limit        int64                      `json:"limit"`
+	Offset       int64                      `json:"offset"`
+}
+
+// AdminActionRequest porte le motif d'une action du back-office, enregistré dans le journal d'audit
+type AdminActionRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// UpdateRolesRequest remplace les rôles d'un utilisateur
+type UpdateRolesRequest struct {
+	Roles []string `json:"roles"`
+}
+
+// ImpersonationResponse est le token d'accès délivré pour agir en tant qu'un utilisateur
+type ImpersonationResponse struct {
+	AccessToken string `json:"accessToken"`
+	ExpiresIn   int64  `json:"expiresIn"` // en millisecondes
+	UserID      string `json:"userId"`
+}
+
+// AuditLogEntry est une action du back-office enregistrée dans le journal d'audit
+type AuditLogEntry struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	ActorID    primitive.ObjectID     `bson:"actorId" json:"actorId"`
+	Action     string                 `bson:"action" json:"action"`
+	TargetType string                 `bson:"targetType" json:"targetType"` // user, transaction, story, message
+	TargetID   primitive.ObjectID     `bson:"targetId" json:"targetId"`
+	Reason     string                 `bson:"reason,omitempty" json:"reason,omitempty"`
+	Details    map[string]interface{} `bson:"details,omitempty" json:"details,omitempty"`
+	IP         string                 `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent  string                 `bson:"userAgent,omitempty" json:"userAgent,omitempty"`
+	CreatedAt  time.Time              `bson:"createdAt" json:"createdAt"`
+}
+
+// AuditLogListResponse est une page du journal d'audit
+type AuditLogListResponse struct {
+	Entries []AuditLogEntry `json:"entries"`
+	Total   int64           `json:"total"`
+	Limit   int64           `json:"limit"`
+	Offset  int64           `json:"offset"`
+}
@@ -14,6 +14,12 @@ const (
 	EventTypeSpecial    EventType = "special"
 )
 
+// GeoPoint is a GeoJSON point ([longitude, latitude]) used for 2dsphere queries
+type GeoPoint struct {
+	Type        string    `json:"type" bson:"type"`
+	Coordinates []float64 `json:"coordinates" bson:"coordinates"`
+}
+
 type EventLocation struct {
 	Address     string `json:"address,omitempty" bson:"address,omitempty"`
 	City        string `json:"city,omitempty" bson:"city,omitempty"`
@@ -23,14 +29,60 @@ type EventLocation struct {
 		Latitude  float64 `json:"latitude,omitempty" bson:"latitude,omitempty"`
 		Longitude float64 `json:"longitude,omitempty" bson:"longitude,omitempty"`
 	} `json:"coordinates,omitempty" bson:"coordinates,omitempty"`
+	// Geo mirrors Coordinates as GeoJSON so that it can carry a 2dsphere index
+	Geo *GeoPoint `json:"-" bson:"geo,omitempty"`
 }
 
+// SyncGeo refreshes Geo from Coordinates
+func (l *EventLocation) SyncGeo() {
+	if l.Coordinates.Latitude == 0 && l.Coordinates.Longitude == 0 {
+		l.Geo = nil
+		return
+	}
+	l.Geo = &GeoPoint{
+		Type:        "Point",
+		Coordinates: []float64{l.Coordinates.Longitude, l.Coordinates.Latitude},
+	}
+}
+
+// Participant roles
+const (
+	EventRoleHost    = "host"
+	EventRoleCoHost  = "co-host"
+	EventRoleGuest   = "guest"
+	EventRoleHonoree = "honoree"
+)
+
+// Participant RSVP statuses
+const (
+	EventStatusInvited    = "invited"
+	EventStatusConfirmed  = "confirmed"
+	EventStatusDeclined   = "declined"
+	EventStatusMaybe      = "maybe"
+	EventStatusWaitlisted = "waitlisted"
+)
+
 type EventParticipant struct {
-	UserID     primitive.ObjectID `json:"userId" bson:"userId"`
-	Role       string             `json:"role" bson:"role"` // host, guest, etc.
-	Status     string             `json:"status" bson:"status"` // invited, confirmed, declined
-	InvitedAt  time.Time          `json:"invitedAt" bson:"invitedAt"`
-	RespondedAt *time.Time         `json:"respondedAt,omitempty" bson:"respondedAt,omitempty"`
+	UserID       primitive.ObjectID `json:"userId" bson:"userId"`
+	Role         string             `json:"role" bson:"role"`     // host, co-host, guest, honoree
+	Status       string             `json:"status" bson:"status"` // invited, confirmed, declined, maybe, waitlisted
+	PlusOnes     int                `json:"plusOnes" bson:"plusOnes"`
+	InvitedAt    time.Time          `json:"invitedAt" bson:"invitedAt"`
+	RespondedAt  *time.Time         `json:"respondedAt,omitempty" bson:"respondedAt,omitempty"`
+	WaitlistedAt *time.Time         `json:"waitlistedAt,omitempty" bson:"waitlistedAt,omitempty"`
+}
+
+// EventHeadcount summarizes attendance for the hosts of an event
+type EventHeadcount struct {
+	Confirmed  int `json:"confirmed"`
+	PlusOnes   int `json:"plusOnes"`
+	Attending  int `json:"attending"` // confirmed participants and their plus-ones
+	Maybe      int `json:"maybe"`
+	Invited    int `json:"invited"`
+	Declined   int `json:"declined"`
+	Waitlisted int `json:"waitlisted"`
+	Capacity   int `json:"capacity,omitempty"`
+	SeatsLeft  int `json:"seatsLeft,omitempty"`
 }
 
 type EventGift struct {
@@ -46,6 +98,45 @@ type EventGift struct {
 	UpdatedAt   time.Time           `json:"updatedAt" bson:"updatedAt"`
 }
 
+// EventWishlist links an event to the wishlist of one of its honorees
+type EventWishlist struct {
+	WishlistID       primitive.ObjectID  `json:"wishlistId" bson:"wishlistId"`
+	OwnerID          primitive.ObjectID  `json:"ownerId" bson:"ownerId"`
+	ManagedAccountID *primitive.ObjectID `json:"managedAccountId,omitempty" bson:"managedAccountId,omitempty"` // set when the honoree is a managed account of the owner
+	LinkedBy         primitive.ObjectID  `json:"linkedBy" bson:"linkedBy"`
+	LinkedAt         time.Time           `json:"linkedAt" bson:"linkedAt"`
+}
+
+// EventRegistryItem is a wishlist item as seen by the participants of an event
+type EventRegistryItem struct {
+	ID                 string  `json:"id"`
+	WishlistID         string  `json:"wishlistId"`
+	Name               string  `json:"name"`
+	Description        string  `json:"description,omitempty"`
+	Price              float64 `json:"price,omitempty"`
+	Currency           string  `json:"currency,omitempty"`
+	ImageURL           string  `json:"imageUrl,omitempty"`
+	Link               string  `json:"link,omitempty"`
+	IsFavorite         bool    `json:"isFavorite"`
+	Quantity           int     `json:"quantity"`                     // copies wished
+	ReservedQuantity   int     `json:"reservedQuantity"`             // copies already reserved, hidden from the wishlist owner
+	MyReservedQuantity int     `json:"myReservedQuantity,omitempty"` // copies reserved by the caller
+	IsReserved         bool    `json:"isReserved"`
+	ReservedByMe       bool    `json:"reservedByMe"`
+	ReservedBy         string  `json:"reservedBy,omitempty"`         // hidden from the wishlist owner
+	ReservedForEventID string  `json:"reservedForEventId,omitempty"` // event the item was reserved through, if any
+	ReservationStatus  string  `json:"reservationStatus,omitempty"`  // reserved, purchased or received
+}
+
+// EventRegistryWishlist groups the items of a linked wishlist
+type EventRegistryWishlist struct {
+	WishlistID       string              `json:"wishlistId"`
+	Title            string              `json:"title"`
+	OwnerID          string              `json:"ownerId"`
+	ManagedAccountID string              `json:"managedAccountId,omitempty"`
+	Items            []EventRegistryItem `json:"items"`
+}
+
 type Event struct {
 	ID            primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
 	Title         string              `json:"title" bson:"title"`
@@ -63,7 +154,10 @@ type Event struct {
 	CreatorID     primitive.ObjectID  `json:"creatorId" bson:"creatorId"`
 	Participants  []EventParticipant  `json:"participants" bson:"participants"`
 	Gifts         []EventGift         `json:"gifts,omitempty" bson:"gifts,omitempty"`
+	Wishlists     []EventWishlist     `json:"wishlists,omitempty" bson:"wishlists,omitempty"`
 	IsPrivate     bool                `json:"isPrivate" bson:"isPrivate"`
+	MaxCapacity   int                 `json:"maxCapacity,omitempty" bson:"maxCapacity,omitempty"` // 0 means unlimited
+	Headcount     *EventHeadcount     `json:"headcount,omitempty" bson:"-"`
 	CreatedAt     time.Time           `json:"createdAt" bson:"createdAt"`
 	UpdatedAt     time.Time           `json:"updatedAt" bson:"updatedAt"`
 	DeletedAt     *time.Time          `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AccountExport is the GDPR subject-access bundle of everything stored about a user
+type AccountExport struct {
+	GeneratedAt     time.Time             `json:"generatedAt"`
+	Profile         *User                 `json:"profile"`
+	ManagedAccounts []ManagedAccount      `json:"managedAccounts"`
+	Wishlists       []ExportedWishlist    `json:"wishlists"`
+	Events          []Event               `json:"events"`   // events the user created or takes part in
+	Messages        []Message             `json:"messages"` // messages the user sent
+	Friendships     []ExportedFriendship  `json:"friendships"`
+	Transactions    []TransactionResponse `json:"transactions"`
+	Stories         []Story               `json:"stories"`
+}
+
+// ExportedWishlist is a wishlist of the user with its items
+type ExportedWishlist struct {
+	Wishlist Wishlist   `json:"wishlist"`
+	Items    []WishItem `json:"items"`
+}
+
+// ExportedFriendship is a friendship or pending friend request of the user
+type ExportedFriendship struct {
+	FriendID  primitive.ObjectID `json:"friendId"`
+	Status    string             `json:"status"`
+	Initiator bool               `json:"initiator"` // the user sent the request
+	CreatedAt time.Time          `json:"createdAt"`
+}
+
+// AccountDeletionRequest asks for the deletion of the current account.
+// Accounts with a password must confirm it.
+type AccountDeletionRequest struct {
+	Password string `json:"password,omitempty"`
+}
+
+// AccountDeletionStatus describes a pending account deletion
+type AccountDeletionStatus struct {
+	Scheduled   bool       `json:"scheduled"`
+	RequestedAt *time.Time `json:"requestedAt,omitempty"`
+	ScheduledAt *time.Time `json:"scheduledAt,omitempty"` // permanent deletion, cancellable until then
+}
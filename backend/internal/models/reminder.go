@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReminderKind identifies what a reminder job is about
+type ReminderKind string
+
+const (
+	// ReminderInvitationNudge reminds a guest that an invitation is still unanswered
+	ReminderInvitationNudge ReminderKind = "invitation_nudge"
+	// ReminderEventInOneWeek announces an event happening in 7 days
+	ReminderEventInOneWeek ReminderKind = "event_in_one_week"
+	// ReminderEventTomorrow announces an event happening tomorrow
+	ReminderEventTomorrow ReminderKind = "event_tomorrow"
+	// ReminderFriendBirthday announces that a friend's birthday is coming
+	ReminderFriendBirthday ReminderKind = "friend_birthday"
+	// ReminderPriceDrop announces that a tracked wishlist item got cheaper
+	ReminderPriceDrop ReminderKind = "price_drop"
+	// ReminderOutOfStock announces that a tracked wishlist item is no longer available
+	ReminderOutOfStock ReminderKind = "out_of_stock"
+	// ReminderBackInStock announces that a tracked wishlist item is available again
+	ReminderBackInStock ReminderKind = "back_in_stock"
+)
+
+// ReminderStatus is the lifecycle state of a reminder job
+type ReminderStatus string
+
+const (
+	ReminderStatusPending    ReminderStatus = "pending"
+	ReminderStatusProcessing ReminderStatus = "processing"
+	ReminderStatusSent       ReminderStatus = "sent"
+	ReminderStatusSkipped    ReminderStatus = "skipped"
+	ReminderStatusFailed     ReminderStatus = "failed"
+)
+
+// NotificationChannel is a delivery channel for notifications
+type NotificationChannel string
+
+const (
+	NotificationChannelEmail NotificationChannel = "email"
+	NotificationChannelSMS   NotificationChannel = "sms"
+	NotificationChannelPush  NotificationChannel = "push"
+)
+
+// ReminderJob is a persisted reminder waiting to be delivered.
+// DedupeKey is unique so that planning the same reminder twice (for example
+// after a restart) never produces duplicate notifications.
+type ReminderJob struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Kind        ReminderKind        `json:"kind" bson:"kind"`
+	DedupeKey   string              `json:"-" bson:"dedupeKey"`
+	UserID      primitive.ObjectID  `json:"userId" bson:"userId"` // recipient
+	EventID     *primitive.ObjectID `json:"eventId,omitempty" bson:"eventId,omitempty"`
+	FriendID    *primitive.ObjectID `json:"friendId,omitempty" bson:"friendId,omitempty"`
+	RunAt       time.Time           `json:"runAt" bson:"runAt"`
+	Status      ReminderStatus      `json:"status" bson:"status"`
+	Attempts    int                 `json:"attempts" bson:"attempts"`
+	LastError   string              `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	LockedUntil *time.Time          `json:"-" bson:"lockedUntil,omitempty"`
+	SentAt      *time.Time          `json:"sentAt,omitempty" bson:"sentAt,omitempty"`
+	CreatedAt   time.Time           `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time           `json:"updatedAt" bson:"updatedAt"`
+}
+
+// NotificationPreferences holds a user's notification opt-outs.
+// The zero value means "everything enabled".
+type NotificationPreferences struct {
+	DisabledChannels []NotificationChannel `json:"disabledChannels" bson:"disabledChannels,omitempty"`
+	DisabledKinds    []ReminderKind        `json:"disabledKinds" bson:"disabledKinds,omitempty"`
+}
+
+// ChannelEnabled reports whether the user accepts notifications on a channel
+func (p NotificationPreferences) ChannelEnabled(channel NotificationChannel) bool {
+	for _, c := range p.DisabledChannels {
+		if c == channel {
+			return false
+		}
+	}
+	return true
+}
+
+// KindEnabled reports whether the user accepts a kind of reminder
+func (p NotificationPreferences) KindEnabled(kind ReminderKind) bool {
+	for _, k := range p.DisabledKinds {
+		if k == kind {
+			return false
+		}
+	}
+	return true
+}
@@ -17,6 +17,8 @@ type Transaction struct {
 	RecipientName    string             `bson:"recipientName,omitempty" json:"recipientName,omitempty"`
 	RecipientAvatar  string             `bson:"recipientAvatar,omitempty" json:"recipientAvatar,omitempty"`
 	IsManagedAccount bool               `bson:"isManagedAccount,omitempty" json:"isManagedAccount,omitempty"`
+	ReversedAt       *time.Time         `bson:"reversedAt,omitempty" json:"reversedAt,omitempty"` // annulée depuis le back-office
+	ReversalOf       *primitive.ObjectID `bson:"reversalOf,omitempty" json:"reversalOf,omitempty"` // transaction annulée par celle-ci
 	CreatedAt        time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt        time.Time          `bson:"updatedAt" json:"updatedAt"`
 }
@@ -31,6 +33,8 @@ type TransactionResponse struct {
 	RecipientName    string    `json:"recipientName,omitempty"`
 	RecipientAvatar  string    `json:"recipientAvatar,omitempty"`
 	IsManagedAccount bool      `json:"isManagedAccount,omitempty"`
+	ReversedAt       *time.Time `json:"reversedAt,omitempty"`
+	ReversalOf       string    `json:"reversalOf,omitempty"`
 	CreatedAt        time.Time `json:"createdAt"`
 	UpdatedAt        time.Time `json:"updatedAt"`
 }
@@ -45,6 +49,7 @@ func (t *Transaction) ToResponse() TransactionResponse {
 		RecipientName:    t.RecipientName,
 		RecipientAvatar:  t.RecipientAvatar,
 		IsManagedAccount: t.IsManagedAccount,
+		ReversedAt:       t.ReversedAt,
 		CreatedAt:        t.CreatedAt,
 		UpdatedAt:        t.UpdatedAt,
 	}
@@ -52,6 +57,9 @@ func (t *Transaction) ToResponse() TransactionResponse {
 	if !t.RecipientID.IsZero() {
 		response.RecipientID = t.RecipientID.Hex()
 	}
+	if t.ReversalOf != nil {
+		response.ReversalOf = t.ReversalOf.Hex()
+	}
 
 	return response
 }
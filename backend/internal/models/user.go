@@ -23,13 +23,29 @@ type User struct {
 	SocialAuth        []SocialAuth         `bson:"socialAuth,omitempty" json:"-"`
 	ResetToken        string               `bson:"resetToken,omitempty" json:"-"`
 	ResetTokenExpires time.Time            `bson:"resetTokenExpires,omitempty" json:"-"`
+	ResetAttempts     int                  `bson:"resetAttempts,omitempty" json:"-"` // codes de réinitialisation erronés
 	RefreshTokens     []RefreshToken       `bson:"refreshTokens,omitempty" json:"-"`
 	IsVerified        bool                 `bson:"isVerified" json:"isVerified"`
+	EmailVerification *ContactVerification `bson:"emailVerification,omitempty" json:"-"` // vérification de l'email en attente
+	PhoneVerification *ContactVerification `bson:"phoneVerification,omitempty" json:"-"` // vérification du téléphone en attente
 	IsTwoFactorEnabled bool                `bson:"isTwoFactorEnabled" json:"isTwoFactorEnabled"`
 	TwoFactorSecret   string               `bson:"twoFactorSecret,omitempty" json:"-"`
+	TwoFactorPendingSecret string          `bson:"twoFactorPendingSecret,omitempty" json:"-"` // secret en attente de confirmation
+	TwoFactorRecoveryCodes []string        `bson:"twoFactorRecoveryCodes,omitempty" json:"-"` // condensés des codes de secours restants
+	TwoFactorLastStep int64                `bson:"twoFactorLastStep,omitempty" json:"-"`      // dernier pas TOTP utilisé
+	TwoFactorChallenge *TwoFactorChallenge `bson:"twoFactorChallenge,omitempty" json:"-"`
+	Passkeys          []Passkey            `bson:"passkeys,omitempty" json:"-"`
+	NotificationPreferences NotificationPreferences `bson:"notificationPreferences,omitempty" json:"notificationPreferences"`
 	CreatedAt         time.Time            `bson:"createdAt" json:"createdAt"`
 	UpdatedAt         time.Time            `bson:"updatedAt" json:"updatedAt"`
 	LastLoginAt       time.Time            `bson:"lastLoginAt,omitempty" json:"lastLoginAt,omitempty"`
+	DeletionRequestedAt *time.Time         `bson:"deletionRequestedAt,omitempty" json:"-"`
+	DeletionScheduledAt *time.Time         `bson:"deletionScheduledAt,omitempty" json:"deletionScheduledAt,omitempty"` // suppression définitive, annulable d'ici là
+	PurgingAt         *time.Time           `bson:"purgingAt,omitempty" json:"-"`            // suppression définitive commencée, plus annulable
+	Roles             []string             `bson:"roles,omitempty" json:"roles,omitempty"` // rôles du back-office (admin, support, moderator)
+	SuspendedAt       *time.Time           `bson:"suspendedAt,omitempty" json:"-"`          // compte suspendu : connexion et API refusées
+	SuspensionReason  string               `bson:"suspensionReason,omitempty" json:"-"`
+	PasswordResetRequired bool             `bson:"passwordResetRequired,omitempty" json:"-"` // connexion par mot de passe refusée jusqu'à sa réinitialisation
 }
 
 // SocialAuth définit les informations d'authentification sociale
@@ -40,13 +56,64 @@ type SocialAuth struct {
 	Email       string `bson:"email,omitempty" json:"email,omitempty"`
 }
 
-// RefreshToken définit la structure d'un token de rafraîchissement
+// ContactVerification est la vérification en attente d'un email ou d'un numéro de téléphone
+type ContactVerification struct {
+	Target          string    `bson:"target"`          // email ou téléphone à vérifier
+	CodeHash        string    `bson:"codeHash"`
+	Attempts        int       `bson:"attempts"`
+	ExpiresAt       time.Time `bson:"expiresAt"`
+	SentAt          time.Time `bson:"sentAt"`
+	SendCount       int       `bson:"sendCount"`       // envois depuis SendWindowStart
+	SendWindowStart time.Time `bson:"sendWindowStart"`
+}
+
+// TwoFactorChallenge est une connexion en attente du second facteur
+type TwoFactorChallenge struct {
+	TokenHash string    `bson:"tokenHash"`
+	Attempts  int       `bson:"attempts"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// Passkey est un identifiant WebAuthn enregistré par l'utilisateur pour se connecter sans mot de passe
+type Passkey struct {
+	ID             primitive.ObjectID `bson:"id"`
+	CredentialID   string    `bson:"credentialId"`   // identifiant de l'authentificateur, en base64url
+	PublicKey      []byte    `bson:"publicKey"`      // clé publique au format COSE
+	Algorithm      int       `bson:"algorithm"`      // algorithme COSE de la clé
+	SignCount      uint32    `bson:"signCount"`      // dernier compteur de signatures reçu
+	AAGUID         string    `bson:"aaguid,omitempty"` // modèle d'authentificateur
+	Transports     []string  `bson:"transports,omitempty"`
+	BackupEligible bool      `bson:"backupEligible"` // passkey synchronisable (trousseau, gestionnaire de mots de passe)
+	BackedUp       bool      `bson:"backedUp"`
+	Name           string    `bson:"name"`
+	CreatedAt      time.Time `bson:"createdAt"`
+	LastUsedAt     time.Time `bson:"lastUsedAt,omitempty"`
+}
+
+// RefreshToken définit une session : un appareil connecté et son token de rafraîchissement courant.
+// Le token change à chaque rafraîchissement ; seul son condensé est conservé.
 type RefreshToken struct {
-	Token     string    `bson:"token" json:"-"`
-	ExpiresAt time.Time `bson:"expiresAt" json:"-"`
-	IP        string    `bson:"ip,omitempty" json:"-"`
-	UserAgent string    `bson:"userAgent,omitempty" json:"-"`
-	IssuedAt  time.Time `bson:"issuedAt" json:"-"`
+	SessionID  primitive.ObjectID `bson:"sessionId,omitempty" json:"-"`
+	Token      string    `bson:"token,omitempty" json:"-"` // ancien format, token en clair
+	TokenHash  string    `bson:"tokenHash,omitempty" json:"-"`
+	ExpiresAt  time.Time `bson:"expiresAt" json:"-"`
+	IP         string    `bson:"ip,omitempty" json:"-"`
+	UserAgent  string    `bson:"userAgent,omitempty" json:"-"`
+	DeviceName string    `bson:"deviceName,omitempty" json:"-"`
+	IssuedAt   time.Time `bson:"issuedAt" json:"-"`
+	LastUsedAt time.Time `bson:"lastUsedAt,omitempty" json:"-"`
+}
+
+// SessionResponse décrit une session active
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	DeviceName string    `json:"deviceName,omitempty"`
+	UserAgent  string    `json:"userAgent,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"` // session de la requête
 }
 
 // UserResponse représente les données utilisateur retournées aux clients
@@ -62,14 +129,25 @@ type UserResponse struct {
 	ProfilePictureURL string    `json:"profilePictureUrl,omitempty"`
 	Balance           float64   `json:"balance"`
 	IsVerified        bool      `json:"isVerified"`
+	PendingVerifications []string `json:"pendingVerifications,omitempty"` // "email" et/ou "phone"
 	IsTwoFactorEnabled bool     `json:"isTwoFactorEnabled"`
 	CreatedAt         time.Time `json:"createdAt"`
 	UpdatedAt         time.Time `json:"updatedAt"`
 	LastLoginAt       time.Time `json:"lastLoginAt,omitempty"`
+	DeletionScheduledAt *time.Time `json:"deletionScheduledAt,omitempty"` // suppression du compte demandée
+	Roles             []string  `json:"roles,omitempty"`
 }
 
 // ToResponse convertit un User en UserResponse
 func (u *User) ToResponse() UserResponse {
+	var pending []string
+	if u.EmailVerification != nil {
+		pending = append(pending, "email")
+	}
+	if u.PhoneVerification != nil {
+		pending = append(pending, "phone")
+	}
+
 	return UserResponse{
 		ID:                u.ID.Hex(),
 		Email:             u.Email,
@@ -82,10 +160,13 @@ func (u *User) ToResponse() UserResponse {
 		ProfilePictureURL: u.ProfilePictureURL,
 		Balance:           u.Balance,
 		IsVerified:        u.IsVerified,
+		PendingVerifications: pending,
 		IsTwoFactorEnabled: u.IsTwoFactorEnabled,
 		CreatedAt:         u.CreatedAt,
 		UpdatedAt:         u.UpdatedAt,
 		LastLoginAt:       u.LastLoginAt,
+		DeletionScheduledAt: u.DeletionScheduledAt,
+		Roles:             u.Roles,
 	}
 }
 
@@ -124,7 +205,8 @@ type SocialLoginRequest struct {
 	FirstName   string `json:"firstName,omitempty"`
 	LastName    string `json:"lastName,omitempty"`
 	AvatarURL   string `json:"avatarUrl,omitempty"`
-	SocialID    string `json:"socialId,omitempty"`
+	SocialID    string `json:"socialId,omitempty"` // ignoré : l'identifiant et l'email sont lus dans le jeton vérifié
+	Nonce       string `json:"nonce,omitempty"`    // nonce transmis au fournisseur lors de la connexion
 }
 
 // AuthResponse représente une réponse d'authentification
@@ -133,6 +215,155 @@ type AuthResponse struct {
 	RefreshToken string       `json:"refreshToken"`
 	ExpiresIn    int64        `json:"expiresIn"` // En millisecondes
 	User         UserResponse `json:"user"`
+	MFARequired  bool         `json:"mfaRequired,omitempty"` // le code de double authentification est attendu
+	MFAToken     string       `json:"mfaToken,omitempty"`    // jeton à renvoyer avec le code
+}
+
+// VerifyMFARequest termine une connexion avec un code TOTP ou un code de secours
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// SendVerificationRequest demande l'envoi d'un code de vérification
+type SendVerificationRequest struct {
+	Channel string `json:"channel" binding:"required,oneof=email phone"`
+}
+
+// ConfirmVerificationRequest confirme un email ou un téléphone avec le code reçu
+type ConfirmVerificationRequest struct {
+	Channel string `json:"channel" binding:"required,oneof=email phone"`
+	Code    string `json:"code" binding:"required"`
+}
+
+// TwoFactorCodeRequest transmet un code TOTP ou un code de secours
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorSetupResponse contient le secret à enregistrer dans l'application d'authentification
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+// RecoveryCodesResponse contient les codes de secours, affichés une seule fois
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// PasskeyCreationOptions sont les options d'enregistrement d'une passkey (PublicKeyCredentialCreationOptions),
+// binaires encodés en base64url
+type PasskeyCreationOptions struct {
+	Challenge              string                     `json:"challenge"`
+	RP                     PasskeyRelyingParty        `json:"rp"`
+	User                   PasskeyUserEntity          `json:"user"`
+	PubKeyCredParams       []PasskeyCredentialParam   `json:"pubKeyCredParams"`
+	Timeout                int64                      `json:"timeout"` // En millisecondes
+	ExcludeCredentials     []PasskeyCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection PasskeyAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                     `json:"attestation"`
+}
+
+// PasskeyRequestOptions sont les options de connexion par passkey (PublicKeyCredentialRequestOptions)
+type PasskeyRequestOptions struct {
+	Challenge        string `json:"challenge"`
+	RPID             string `json:"rpId"`
+	Timeout          int64  `json:"timeout"` // En millisecondes
+	UserVerification string `json:"userVerification"`
+}
+
+// PasskeyRelyingParty identifie le service auprès de l'authentificateur
+type PasskeyRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// PasskeyUserEntity identifie le compte auquel la passkey est rattachée
+type PasskeyUserEntity struct {
+	ID          string `json:"id"` // identifiant de l'utilisateur, en base64url
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// PasskeyCredentialParam est un algorithme de clé accepté
+type PasskeyCredentialParam struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// PasskeyCredentialDescriptor désigne une passkey déjà enregistrée
+type PasskeyCredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// PasskeyAuthenticatorSelection précise les authentificateurs acceptés
+type PasskeyAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	RequireResidentKey bool `json:"requireResidentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// PasskeyRegistrationRequest est la réponse de l'authentificateur à l'enregistrement (RegistrationResponseJSON)
+type PasskeyRegistrationRequest struct {
+	ID       string `json:"id" binding:"required"`
+	RawID    string `json:"rawId" binding:"required"`
+	Type     string `json:"type" binding:"required,eq=public-key"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON" binding:"required"`
+		AttestationObject string   `json:"attestationObject" binding:"required"`
+		Transports        []string `json:"transports,omitempty"`
+	} `json:"response" binding:"required"`
+	Name string `json:"name,omitempty" binding:"max=64"` // nom choisi pour la passkey
+}
+
+// PasskeyLoginRequest est la réponse de l'authentificateur à la connexion (AuthenticationResponseJSON)
+type PasskeyLoginRequest struct {
+	ID       string `json:"id" binding:"required"`
+	RawID    string `json:"rawId" binding:"required"`
+	Type     string `json:"type" binding:"required,eq=public-key"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON" binding:"required"`
+		AuthenticatorData string `json:"authenticatorData" binding:"required"`
+		Signature         string `json:"signature" binding:"required"`
+		UserHandle        string `json:"userHandle,omitempty"`
+	} `json:"response" binding:"required"`
+}
+
+// PasswordlessStartRequest représente une demande de connexion sans mot de passe :
+// code par SMS pour un téléphone, lien de connexion pour un email
+type PasswordlessStartRequest struct {
+	EmailOrPhone string `json:"emailOrPhone" binding:"required"`
+}
+
+// PasswordlessStartResponse contient le jeton qui lie la demande à l'appareil qui l'a faite
+type PasswordlessStartResponse struct {
+	RequestToken string `json:"requestToken"` // à renvoyer avec le code ou le jeton du lien
+	Channel      string `json:"channel"`      // "email" ou "phone"
+	ExpiresIn    int64  `json:"expiresIn"`    // En millisecondes
+}
+
+// PasswordlessVerifyRequest échange le code reçu, ou le jeton du lien, contre les tokens
+type PasswordlessVerifyRequest struct {
+	RequestToken string `json:"requestToken" binding:"required"`
+	Code         string `json:"code" binding:"required"`
+}
+
+// RenamePasskeyRequest représente une demande de renommage d'une passkey
+type RenamePasskeyRequest struct {
+	Name string `json:"name" binding:"required,max=64"`
+}
+
+// PasskeyResponse décrit une passkey enregistrée
+type PasskeyResponse struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Transports []string  `json:"transports,omitempty"`
+	BackedUp   bool      `json:"backedUp"` // synchronisée entre appareils
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt,omitempty"`
 }
 
 // RefreshTokenRequest représente une demande de rafraîchissement de token
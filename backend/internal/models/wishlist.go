@@ -9,18 +9,37 @@ import (
 // Wishlist définit la structure d'une liste de souhaits
 type Wishlist struct {
 	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
-	UserID      primitive.ObjectID   `bson:"userId" json:"userId"`
+	UserID      primitive.ObjectID   `bson:"userId" json:"userId"` // propriétaire, ou parent qui gère le compte de ManagedAccountID
+	ManagedAccountID *primitive.ObjectID `bson:"managedAccountId,omitempty" json:"managedAccountId,omitempty"` // compte géré à qui la wishlist est destinée
 	Title       string               `bson:"title" json:"title"`
 	Description string               `bson:"description,omitempty" json:"description,omitempty"`
 	CoverImage  string               `bson:"coverImage,omitempty" json:"coverImage,omitempty"`
 	IsPublic    bool                 `bson:"isPublic" json:"isPublic"`
 	IsFavorite  bool                 `bson:"isFavorite" json:"isFavorite"`
-	Items       []primitive.ObjectID `bson:"items,omitempty" json:"items,omitempty"`
+	Items       []primitive.ObjectID `bson:"items,omitempty" json:"items,omitempty"` // dans l'ordre d'affichage
+	Sections    []WishlistSection    `bson:"sections,omitempty" json:"sections,omitempty"` // dans l'ordre d'affichage
+	LayoutVersion int64              `bson:"layoutVersion" json:"layoutVersion"` // incrémentée à chaque changement d'ordre ou de section
 	SharedWith  []SharedWith         `bson:"sharedWith,omitempty" json:"sharedWith,omitempty"`
+	SurpriseMode string              `bson:"surpriseMode,omitempty" json:"surpriseMode,omitempty"` // hidden (par défaut), count, off
+	ShareLink   *ShareLink           `bson:"shareLink,omitempty" json:"-"`
 	CreatedAt   time.Time            `bson:"createdAt" json:"createdAt"`
 	UpdatedAt   time.Time            `bson:"updatedAt" json:"updatedAt"`
 }
 
+// WishlistSection est une section nommée regroupant des items d'une wishlist
+type WishlistSection struct {
+	ID   primitive.ObjectID `bson:"_id" json:"id"`
+	Name string             `bson:"name" json:"name"`
+}
+
+// ShareLink est le lien public d'une wishlist, consultable sans compte
+type ShareLink struct {
+	Slug         string     `bson:"slug" json:"slug"`
+	PasswordHash string     `bson:"passwordHash,omitempty" json:"-"`
+	ExpiresAt    *time.Time `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+	CreatedAt    time.Time  `bson:"createdAt" json:"createdAt"`
+}
+
 // SharedWith définit avec qui la wishlist est partagée et leurs permissions
 type SharedWith struct {
 	UserID     primitive.ObjectID `bson:"userId" json:"userId"`
@@ -41,12 +60,81 @@ type WishItem struct {
 	ImageURL    string             `bson:"imageUrl,omitempty" json:"imageUrl,omitempty"`
 	Link        string             `bson:"link,omitempty" json:"link,omitempty"`
 	IsFavorite  bool               `bson:"isFavorite" json:"isFavorite"`
-	IsReserved  bool               `bson:"isReserved" json:"isReserved"`
+	SectionID   *primitive.ObjectID `bson:"sectionId,omitempty" json:"sectionId,omitempty"`
+	Priority    string             `bson:"priority,omitempty" json:"priority,omitempty"` // low, medium (par défaut), high
+	Quantity    int                `bson:"quantity,omitempty" json:"quantity,omitempty"` // quantité souhaitée, 1 par défaut
+	ReservedQuantity int           `bson:"reservedQuantity,omitempty" json:"reservedQuantity,omitempty"` // somme des réservations partielles
+	Reservations []ItemReservation `bson:"reservations,omitempty" json:"reservations,omitempty"` // réservations partielles d'un item souhaité en plusieurs exemplaires
+	IsReserved  bool               `bson:"isReserved" json:"isReserved"` // vrai lorsque la quantité souhaitée est entièrement réservée
 	ReservedBy  primitive.ObjectID `bson:"reservedBy,omitempty" json:"reservedBy,omitempty"`
+	GuestReservationID *primitive.ObjectID `bson:"guestReservationId,omitempty" json:"guestReservationId,omitempty"` // réservation d'un invité sans compte
+	ReservedByGuest string         `bson:"reservedByGuest,omitempty" json:"reservedByGuest,omitempty"`       // nom de l'invité
+	ReservedForEventID *primitive.ObjectID `bson:"reservedForEventId,omitempty" json:"reservedForEventId,omitempty"` // événement via lequel l'item a été réservé
+	ReservationStatus string       `bson:"reservationStatus,omitempty" json:"reservationStatus,omitempty"` // reserved, purchased, received
+	ReservedAt  *time.Time         `bson:"reservedAt,omitempty" json:"reservedAt,omitempty"`
+	PurchasedAt *time.Time         `bson:"purchasedAt,omitempty" json:"purchasedAt,omitempty"`
+	ReceivedAt  *time.Time         `bson:"receivedAt,omitempty" json:"receivedAt,omitempty"`
+	PriceTracking *PriceTracking   `bson:"priceTracking,omitempty" json:"priceTracking,omitempty"`
 	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
 }
 
+// ItemReservation est la réservation d'une partie de la quantité souhaitée d'un item
+type ItemReservation struct {
+	UserID      primitive.ObjectID `bson:"userId" json:"userId"` // nul pour un invité
+	GuestReservationID *primitive.ObjectID `bson:"guestReservationId,omitempty" json:"guestReservationId,omitempty"`
+	GuestName   string             `bson:"guestName,omitempty" json:"guestName,omitempty"`
+	EventID     *primitive.ObjectID `bson:"eventId,omitempty" json:"eventId,omitempty"` // événement depuis lequel la réservation a été faite
+	Quantity    int                `bson:"quantity" json:"quantity"`
+	Status      string             `bson:"status" json:"status"` // reserved, purchased
+	ReservedAt  time.Time          `bson:"reservedAt" json:"reservedAt"`
+	PurchasedAt *time.Time         `bson:"purchasedAt,omitempty" json:"purchasedAt,omitempty"`
+}
+
+// GuestReservation est la réservation d'un item par un invité sans compte depuis la page publique.
+// Elle ne bloque l'item qu'une fois confirmée depuis le lien envoyé par email.
+type GuestReservation struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WishlistID  primitive.ObjectID `bson:"wishlistId" json:"wishlistId"`
+	ItemID      primitive.ObjectID `bson:"itemId" json:"itemId"`
+	Name        string             `bson:"name" json:"name"`
+	Email       string             `bson:"email" json:"-"`
+	Quantity    int                `bson:"quantity" json:"quantity"`
+	TokenHash   string             `bson:"tokenHash" json:"-"`
+	Status      string             `bson:"status" json:"status"` // pending, confirmed, cancelled
+	ExpiresAt   *time.Time         `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"` // tant qu'elle n'est pas confirmée
+	ConfirmedAt *time.Time         `bson:"confirmedAt,omitempty" json:"confirmedAt,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// PriceTracking définit le suivi de prix d'un item à partir de son lien
+type PriceTracking struct {
+	Enabled       bool       `bson:"enabled" json:"enabled"`
+	TargetPrice   float64    `bson:"targetPrice,omitempty" json:"targetPrice,omitempty"` // alerte sous ce prix ; 0 = toute baisse significative
+	LastPrice     float64    `bson:"lastPrice,omitempty" json:"lastPrice,omitempty"`
+	Currency      string     `bson:"currency,omitempty" json:"currency,omitempty"`
+	InStock       *bool      `bson:"inStock,omitempty" json:"inStock,omitempty"` // nil tant que le produit n'a pas été vérifié
+	LastCheckedAt *time.Time `bson:"lastCheckedAt,omitempty" json:"lastCheckedAt,omitempty"`
+	NextCheckAt   time.Time  `bson:"nextCheckAt" json:"nextCheckAt"`
+	LastError     string     `bson:"lastError,omitempty" json:"lastError,omitempty"`
+}
+
+// PricePoint est un relevé de prix d'un item suivi
+type PricePoint struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ItemID    primitive.ObjectID `bson:"itemId" json:"itemId"`
+	Price     float64            `bson:"price" json:"price"`
+	Currency  string             `bson:"currency,omitempty" json:"currency,omitempty"`
+	InStock   bool               `bson:"inStock" json:"inStock"`
+	CheckedAt time.Time          `bson:"checkedAt" json:"checkedAt"`
+}
+
+// UpdatePriceTrackingRequest est une demande d'activation du suivi de prix d'un item
+type UpdatePriceTrackingRequest struct {
+	Enabled     *bool   `json:"enabled" binding:"required"`
+	TargetPrice float64 `json:"targetPrice,omitempty"`
+}
+
 // WishlistResponse est la réponse pour une wishlist avec ses items
 type WishlistResponse struct {
 	ID          string              `json:"id"`
@@ -57,12 +145,71 @@ type WishlistResponse struct {
 	IsPublic    bool                `json:"isPublic"`
 	IsFavorite  bool                `json:"isFavorite"`
 	IsOwner     bool                `json:"isOwner"`
+	ManagedAccountID string         `json:"managedAccountId,omitempty"`
+	Recipient   *WishlistRecipient  `json:"recipient,omitempty"` // renseigné pour les wishlists d'un compte géré
+	SurpriseMode string             `json:"surpriseMode"`
+	ReservedCount *int64            `json:"reservedCount,omitempty"` // nombre d'items réservés, masqué au propriétaire en mode hidden
+	Sections    []WishlistSection   `json:"sections,omitempty"`
+	LayoutVersion int64             `json:"layoutVersion"`
+	ShareLink   *ShareLinkResponse  `json:"shareLink,omitempty"` // visible du propriétaire uniquement
 	SharedWith  []SharedWithResponse `json:"sharedWith,omitempty"`
 	Items       []WishItemResponse  `json:"items,omitempty"`
 	CreatedAt   time.Time           `json:"createdAt"`
 	UpdatedAt   time.Time           `json:"updatedAt"`
 }
 
+// WishlistRecipient est le compte géré à qui une wishlist est destinée
+type WishlistRecipient struct {
+	ManagedAccountID string `json:"managedAccountId"`
+	GuardianID       string `json:"guardianId"` // parent qui gère le compte
+	FirstName        string `json:"firstName"`
+	LastName         string `json:"lastName"`
+	AvatarURL        string `json:"avatarUrl,omitempty"`
+}
+
+// ManagedAccountWishlists regroupe les wishlists d'un compte géré
+type ManagedAccountWishlists struct {
+	Recipient WishlistRecipient   `json:"recipient"`
+	Wishlists []*WishlistResponse `json:"wishlists"`
+}
+
+// ShareLinkResponse décrit le lien public d'une wishlist à son propriétaire
+type ShareLinkResponse struct {
+	Slug        string     `json:"slug"`
+	URL         string     `json:"url"`
+	HasPassword bool       `json:"hasPassword"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// PublicWishlistResponse est la vue en lecture seule d'une wishlist pour les visiteurs de sa page publique
+type PublicWishlistResponse struct {
+	Title       string               `json:"title"`
+	Description string               `json:"description,omitempty"`
+	CoverImage  string               `json:"coverImage,omitempty"`
+	OwnerName   string               `json:"ownerName"`
+	Sections    []WishlistSection    `json:"sections,omitempty"`
+	Items       []PublicWishItemResponse `json:"items"`
+}
+
+// PublicWishItemResponse est un item vu depuis la page publique, sans l'identité de ceux qui l'ont réservé.
+// Le visiteur peut être le destinataire : l'état des réservations suit le mode surprise.
+type PublicWishItemResponse struct {
+	ID                string  `json:"id"`
+	Name              string  `json:"name"`
+	Description       string  `json:"description,omitempty"`
+	Price             float64 `json:"price,omitempty"`
+	Currency          string  `json:"currency,omitempty"`
+	ImageURL          string  `json:"imageUrl,omitempty"`
+	Link              string  `json:"link,omitempty"`
+	SectionID         string  `json:"sectionId,omitempty"`
+	Priority          string  `json:"priority"`
+	Quantity          int     `json:"quantity"`
+	AvailableQuantity int     `json:"availableQuantity"`
+	CanReserve        bool    `json:"canReserve"`
+	IsReserved        *bool   `json:"isReserved,omitempty"` // renseigné uniquement en mode surprise off
+}
+
 // SharedWithResponse est la réponse pour les partages de wishlist
 type SharedWithResponse struct {
 	UserID     string    `json:"userId"`
@@ -88,8 +235,21 @@ type WishItemResponse struct {
 	ImageURL    string    `json:"imageUrl,omitempty"`
 	Link        string    `json:"link,omitempty"`
 	IsFavorite  bool      `json:"isFavorite"`
+	SectionID   string    `json:"sectionId,omitempty"`
+	Position    int       `json:"position"`
+	Priority    string    `json:"priority"`
+	Quantity    int       `json:"quantity"`
+	ReservedQuantity int  `json:"reservedQuantity"`
+	MyReservedQuantity int `json:"myReservedQuantity,omitempty"`
 	IsReserved  bool      `json:"isReserved"`
 	ReservedBy  string    `json:"reservedBy,omitempty"`
+	ReservedByGuest string `json:"reservedByGuest,omitempty"`
+	ReservedForEventID string `json:"reservedForEventId,omitempty"`
+	ReservationStatus string `json:"reservationStatus,omitempty"`
+	PurchasedAt *time.Time    `json:"purchasedAt,omitempty"`
+	ReceivedAt  *time.Time    `json:"receivedAt,omitempty"`
+	PriceTracking *PriceTracking `json:"priceTracking,omitempty"`
+	Recipient   *WishlistRecipient `json:"recipient,omitempty"` // compte géré à qui le cadeau est destiné
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
@@ -101,6 +261,8 @@ type CreateWishlistRequest struct {
 	CoverImage  string `json:"coverImage,omitempty"`
 	IsPublic    bool   `json:"isPublic"`
 	IsFavorite  bool   `json:"isFavorite"`
+	SurpriseMode string `json:"surpriseMode,omitempty"`
+	ManagedAccountID string `json:"managedAccountId,omitempty"` // crée la wishlist pour un compte géré de l'utilisateur
 }
 
 // UpdateWishlistRequest est une demande de mise à jour de wishlist
@@ -110,18 +272,23 @@ type UpdateWishlistRequest struct {
 	CoverImage  string `json:"coverImage,omitempty"`
 	IsPublic    *bool  `json:"isPublic,omitempty"`
 	IsFavorite  *bool  `json:"isFavorite,omitempty"`
+	SurpriseMode string `json:"surpriseMode,omitempty"`
 }
 
-// CreateWishItemRequest est une demande de création d'item
+// CreateWishItemRequest est une demande de création d'item.
+// Le nom est facultatif lorsqu'un lien est fourni : il est alors extrait de la page produit.
 type CreateWishItemRequest struct {
 	WishlistID  string  `json:"wishlistId" binding:"required"`
-	Name        string  `json:"name" binding:"required"`
+	Name        string  `json:"name"`
 	Description string  `json:"description,omitempty"`
 	Price       float64 `json:"price,omitempty"`
 	Currency    string  `json:"currency,omitempty"`
 	ImageURL    string  `json:"imageUrl,omitempty"`
 	Link        string  `json:"link,omitempty"`
 	IsFavorite  bool    `json:"isFavorite"`
+	SectionID   string  `json:"sectionId,omitempty"`
+	Priority    string  `json:"priority,omitempty"`
+	Quantity    int     `json:"quantity,omitempty"`
 }
 
 // UpdateWishItemRequest est une demande de mise à jour d'item
@@ -133,6 +300,13 @@ type UpdateWishItemRequest struct {
 	ImageURL    string  `json:"imageUrl,omitempty"`
 	Link        string  `json:"link,omitempty"`
 	IsFavorite  *bool   `json:"isFavorite,omitempty"`
+	Priority    string  `json:"priority,omitempty"`
+	Quantity    int     `json:"quantity,omitempty"`
+}
+
+// UnfurlLinkRequest est une demande d'aperçu d'un produit à partir de son URL
+type UnfurlLinkRequest struct {
+	URL string `json:"url" binding:"required"`
 }
 
 // ShareWishlistRequest est une demande de partage de wishlist
@@ -141,7 +315,171 @@ type ShareWishlistRequest struct {
 	Permission string `json:"permission" binding:"required"`
 }
 
-// ReserveWishItemRequest est une demande de réservation d'item
+// ReserveWishItemRequest est une demande de réservation d'item.
+// Quantity n'est utilisée que pour les items souhaités en plusieurs exemplaires (1 par défaut).
 type ReserveWishItemRequest struct {
-	Reserve bool `json:"reserve" binding:"required"`
-}
\ No newline at end of file
+	Reserve  bool `json:"reserve" binding:"required"`
+	Quantity int  `json:"quantity,omitempty"`
+}
+
+// SectionRequest est une demande de création ou de renommage de section
+type SectionRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// ItemPlacement place un item dans une section ; une section vide le place hors section
+type ItemPlacement struct {
+	ItemID    string `json:"itemId" binding:"required"`
+	SectionID string `json:"sectionId,omitempty"`
+}
+
+// ReorderWishlistRequest réorganise d'un bloc les items et sections d'une wishlist.
+// Items liste tous les items dans leur nouvel ordre ; Version est la layoutVersion
+// sur laquelle le client s'est basé, la demande est rejetée si elle a changé depuis.
+type ReorderWishlistRequest struct {
+	Version  *int64          `json:"version" binding:"required"`
+	Sections []string        `json:"sections,omitempty"`
+	Items    []ItemPlacement `json:"items" binding:"required,dive"`
+}
+
+// MarkReceivedRequest est une demande de confirmation de réception d'un cadeau
+type MarkReceivedRequest struct {
+	SendThankYou bool   `json:"sendThankYou"`
+	Message      string `json:"message,omitempty"`
+}
+
+// ShareLinkRequest est une demande de création, de rotation ou de modification du lien public d'une wishlist.
+// Un mot de passe vide supprime la protection ; ExpiresAt nul rend le lien permanent.
+type ShareLinkRequest struct {
+	Password  string     `json:"password,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// GuestReserveRequest est une demande de réservation d'un invité depuis la page publique
+type GuestReserveRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Quantity int    `json:"quantity,omitempty"`
+}
+
+// GuestTokenRequest confirme ou annule une réservation d'invité avec le jeton reçu par email
+type GuestTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// WishlistTemplate est un modèle de wishlist réutilisable, enregistré par un utilisateur
+// ou proposé par Genie à partir des catégories d'inspiration du scraper
+type WishlistTemplate struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID      *primitive.ObjectID `bson:"userId,omitempty" json:"userId,omitempty"` // nul pour un modèle proposé par Genie
+	Curated     bool                `bson:"curated" json:"curated"`
+	SourceID    string              `bson:"sourceId,omitempty" json:"sourceId,omitempty"` // catégorie d'inspiration d'un modèle proposé
+	Name        string              `bson:"name" json:"name"`
+	Description string              `bson:"description,omitempty" json:"description,omitempty"`
+	CoverImage  string              `bson:"coverImage,omitempty" json:"coverImage,omitempty"`
+	Sections    []string            `bson:"sections,omitempty" json:"sections,omitempty"` // noms des sections, dans l'ordre
+	Items       []TemplateItem      `bson:"items" json:"items"`
+	CreatedAt   time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
+
+// TemplateItem est un item d'un modèle de wishlist, sans réservation
+type TemplateItem struct {
+	Name        string  `bson:"name" json:"name"`
+	Description string  `bson:"description,omitempty" json:"description,omitempty"`
+	Price       float64 `bson:"price,omitempty" json:"price,omitempty"`
+	Currency    string  `bson:"currency,omitempty" json:"currency,omitempty"`
+	ImageURL    string  `bson:"imageUrl,omitempty" json:"imageUrl,omitempty"`
+	Link        string  `bson:"link,omitempty" json:"link,omitempty"`
+	Priority    string  `bson:"priority,omitempty" json:"priority,omitempty"`
+	Quantity    int     `bson:"quantity,omitempty" json:"quantity,omitempty"`
+	Section     string  `bson:"section,omitempty" json:"section,omitempty"` // nom de la section, vide hors section
+}
+
+// CloneWishlistRequest est une demande de duplication d'une wishlist.
+// Un titre vide reprend celui de la wishlist d'origine ; les images ne sont copiées que sur demande.
+type CloneWishlistRequest struct {
+	Title            string `json:"title,omitempty"`
+	IncludeImages    bool   `json:"includeImages"`
+	ManagedAccountID string `json:"managedAccountId,omitempty"` // crée la copie pour un compte géré de l'utilisateur
+}
+
+// SaveTemplateRequest est une demande d'enregistrement d'une wishlist comme modèle
+type SaveTemplateRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Description   string `json:"description,omitempty"`
+	IncludeImages bool   `json:"includeImages"`
+}
+
+// UseTemplateRequest est une demande de création d'une wishlist à partir d'un modèle.
+// Un titre vide reprend le nom du modèle.
+type UseTemplateRequest struct {
+	Title            string `json:"title,omitempty"`
+	IsPublic         bool   `json:"isPublic"`
+	ManagedAccountID string `json:"managedAccountId,omitempty"` // crée la wishlist pour un compte géré de l'utilisateur
+}
+
+// MergeWishlistsRequest est une demande de fusion d'une wishlist dans une autre.
+// Les items dont le lien produit existe déjà dans la wishlist cible sont ignorés.
+// Avec DeleteSource, les items sont déplacés avec leurs réservations et la wishlist source est supprimée.
+type MergeWishlistsRequest struct {
+	SourceID     string `json:"sourceId" binding:"required"`
+	DeleteSource bool   `json:"deleteSource"`
+}
+
+// MergeWishlistsResponse est le résultat d'une fusion de wishlists
+type MergeWishlistsResponse struct {
+	Added    int               `json:"added"`
+	Skipped  int               `json:"skipped"` // doublons ignorés
+	Wishlist *WishlistResponse `json:"wishlist"`
+}
+
+// WishlistExport est le format d'export d'une wishlist, relu à l'import JSON
+type WishlistExport struct {
+	Version     int            `json:"version"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Sections    []string       `json:"sections,omitempty"`
+	Items       []TemplateItem `json:"items"`
+	ExportedAt  time.Time      `json:"exportedAt"`
+}
+
+// CSVColumnMapping associe les champs d'un item aux en-têtes de colonnes d'un CSV quelconque.
+// Seul le nom est obligatoire ; un champ vide n'est pas importé.
+type CSVColumnMapping struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Price       string `json:"price,omitempty"`
+	Currency    string `json:"currency,omitempty"`
+	ImageURL    string `json:"imageUrl,omitempty"`
+	Link        string `json:"link,omitempty"`
+	Priority    string `json:"priority,omitempty"`
+	Quantity    string `json:"quantity,omitempty"`
+	Section     string `json:"section,omitempty"`
+}
+
+// ImportWishlistRequest décrit un fichier importé dans une wishlist.
+// Title et ManagedAccountID ne servent qu'à la création d'une nouvelle wishlist.
+type ImportWishlistRequest struct {
+	Format           string            `form:"format" binding:"required"` // json, csv, amazon ou mapped_csv
+	Title            string            `form:"title"`
+	ManagedAccountID string            `form:"managedAccountId"`
+	Mapping          *CSVColumnMapping `form:"-"` // requis pour mapped_csv
+}
+
+// ImportRowResult est le résultat de l'import d'une ligne du fichier
+type ImportRowResult struct {
+	Row    int    `json:"row"` // numéro de ligne des données, à partir de 1
+	Name   string `json:"name,omitempty"`
+	Status string `json:"status"` // imported, duplicate ou invalid
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportWishlistResponse est le résultat d'un import
+type ImportWishlistResponse struct {
+	Imported   int               `json:"imported"`
+	Duplicates int               `json:"duplicates"`
+	Invalid    int               `json:"invalid"`
+	Rows       []ImportRowResult `json:"rows"`
+	Wishlist   *WishlistResponse `json:"wishlist"`
+}
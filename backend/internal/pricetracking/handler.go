@@ -0,0 +1,84 @@
+package pricetracking
+
+import (
+	"net/http"
+
+	"genie/internal/middleware"
+	"genie/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Handler is the price tracking API handler
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new price tracking handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the price tracking routes
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	router.PUT("/items/:id", h.UpdateTracking)
+	router.GET("/items/:id/history", h.GetHistory)
+}
+
+// UpdateTracking enables or disables price tracking on a wishlist item
+func (h *Handler) UpdateTracking(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	var req models.UpdatePriceTrackingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	tracking, err := h.service.UpdateTracking(c.Request.Context(), c.Param("id"), userIDValue.(string), req)
+	if err != nil {
+		respondError(c, err, "Failed to update price tracking")
+		return
+	}
+
+	c.JSON(http.StatusOK, tracking)
+}
+
+// GetHistory returns the price history of a wishlist item
+func (h *Handler) GetHistory(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	history, err := h.service.GetHistory(c.Request.Context(), c.Param("id"), userIDValue.(string))
+	if err != nil {
+		respondError(c, err, "Failed to get price history")
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// respondError maps service errors to HTTP responses
+func respondError(c *gin.Context, err error, message string) {
+	switch err {
+	case ErrInvalidID, ErrInvalidTarget, ErrUnsupportedLink:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case ErrItemNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case ErrAccessDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,341 @@
+package pricetracking
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"genie/internal/config"
+	"genie/internal/messaging"
+	"genie/internal/models"
+	"genie/internal/utils"
+)
+
+const (
+	// Collection names
+	wishItemsCollection    = "wishItems"
+	usersCollection        = "users"
+	priceHistoryCollection = "priceHistory"
+
+	// maxHistoryPoints bounds the price history returned for an item
+	maxHistoryPoints = 500
+)
+
+var (
+	ErrInvalidID     = errors.New("invalid ID")
+	ErrItemNotFound  = errors.New("wish item not found")
+	ErrAccessDenied  = errors.New("access denied")
+	ErrInvalidTarget = errors.New("invalid target price")
+)
+
+// Permissions tells who may see and change the items of a wishlist.
+// It is backed by the wishlist service, so tracking follows the same roles as the rest of the app.
+type Permissions interface {
+	// PriceAccess reports whether a user may view a wishlist and whether they may edit its items.
+	// It returns mongo.ErrNoDocuments when the wishlist does not exist.
+	PriceAccess(ctx context.Context, wishlistID, userID primitive.ObjectID) (bool, bool, error)
+	// PriceAlertRecipients returns the members of a wishlist to alert of a price change
+	PriceAlertRecipients(ctx context.Context, wishlistID primitive.ObjectID) ([]primitive.ObjectID, error)
+}
+
+// Service periodically re-fetches tracked wishlist items and alerts their audience
+type Service struct {
+	store       store
+	cfg         config.PriceTrackingConfig
+	source      ProductSource
+	permissions Permissions
+	email       *utils.EmailService
+	pusher      messaging.Pusher
+}
+
+// NewService creates a new price tracking service
+func NewService(db *mongo.Database, cfg config.PriceTrackingConfig, source ProductSource, permissions Permissions, email *utils.EmailService, pusher messaging.Pusher) *Service {
+	return &Service{
+		store:       newMongoStore(db),
+		cfg:         cfg,
+		source:      source,
+		permissions: permissions,
+		email:       email,
+		pusher:      pusher,
+	}
+}
+
+// Start runs the tracking loop until ctx is cancelled
+func (s *Service) Start(ctx context.Context) {
+	go func() {
+		log.Info().Dur("pollInterval", s.cfg.PollInterval).Msg("Starting price tracker")
+
+		ticker := time.NewTicker(s.cfg.PollInterval)
+		defer ticker.Stop()
+
+		s.tick(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info().Msg("Price tracker stopped")
+				return
+			case <-ticker.C:
+				s.tick(ctx)
+			}
+		}
+	}()
+}
+
+// tick checks the items that are due
+func (s *Service) tick(ctx context.Context) {
+	if err := s.CheckDue(ctx, time.Now()); err != nil {
+		log.Error().Err(err).Msg("Failed to check tracked prices")
+	}
+}
+
+// CheckDue re-fetches up to BatchSize items whose next check is due
+func (s *Service) CheckDue(ctx context.Context, now time.Time) error {
+	for i := 0; i < s.cfg.BatchSize; i++ {
+		item, err := s.store.claimNext(ctx, now, now.Add(s.cfg.CheckInterval))
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			return nil
+		}
+
+		if err := s.check(ctx, item, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check fetches the current state of an item, records it and sends the resulting alerts.
+// Only database errors are returned: a failing product source is recorded on the item.
+func (s *Service) check(ctx context.Context, item *models.WishItem, now time.Time) error {
+	snapshot, err := s.source.Fetch(ctx, item.Link)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Warn().Err(err).Str("itemID", item.ID.Hex()).Msg("Failed to fetch tracked product")
+		return s.store.saveError(ctx, item.ID, err.Error(), errors.Is(err, ErrUnsupportedLink))
+	}
+
+	point := &models.PricePoint{
+		ID:        primitive.NewObjectID(),
+		ItemID:    item.ID,
+		Price:     snapshot.Price,
+		Currency:  snapshot.Currency,
+		InStock:   snapshot.InStock,
+		CheckedAt: now,
+	}
+	if err := s.store.appendHistory(ctx, point); err != nil {
+		return err
+	}
+
+	previous := *item.PriceTracking
+	alerts := detectAlerts(&previous, snapshot, s.cfg.MinDropPercent)
+
+	if err := s.store.saveCheck(ctx, item.ID, snapshot, now); err != nil {
+		return err
+	}
+
+	for _, a := range alerts {
+		if err := s.notify(ctx, item, a); err != nil {
+			log.Warn().Err(err).Str("itemID", item.ID.Hex()).Str("kind", string(a.kind)).Msg("Failed to send price alert")
+		}
+	}
+	return nil
+}
+
+// alert is a change worth telling the audience of an item about
+type alert struct {
+	kind          models.ReminderKind
+	price         float64
+	previousPrice float64
+	currency      string
+}
+
+// detectAlerts compares a fresh snapshot with the previous tracking state.
+// An item coming back in stock is reported once, with its current price.
+// With a target price, a drop is reported when the price crosses the target;
+// otherwise any drop of at least minDropPercent is reported.
+func detectAlerts(previous *models.PriceTracking, snapshot *ProductSnapshot, minDropPercent float64) []alert {
+	var alerts []alert
+
+	if previous.InStock != nil && *previous.InStock && !snapshot.InStock {
+		alerts = append(alerts, alert{kind: models.ReminderOutOfStock, previousPrice: previous.LastPrice, currency: previous.Currency})
+	}
+
+	if !snapshot.InStock || snapshot.Price <= 0 {
+		return alerts
+	}
+
+	if previous.InStock != nil && !*previous.InStock {
+		return append(alerts, alert{kind: models.ReminderBackInStock, price: snapshot.Price, previousPrice: previous.LastPrice, currency: snapshot.Currency})
+	}
+
+	drop := alert{kind: models.ReminderPriceDrop, price: snapshot.Price, previousPrice: previous.LastPrice, currency: snapshot.Currency}
+	if previous.TargetPrice > 0 {
+		if snapshot.Price <= previous.TargetPrice && (previous.LastPrice == 0 || previous.LastPrice > previous.TargetPrice) {
+			alerts = append(alerts, drop)
+		}
+		return alerts
+	}
+
+	if previous.LastPrice > 0 && snapshot.Price <= previous.LastPrice*(1-minDropPercent/100) {
+		alerts = append(alerts, drop)
+	}
+	return alerts
+}
+
+// notify sends an alert to the members of the item's wishlist
+func (s *Service) notify(ctx context.Context, item *models.WishItem, a alert) error {
+	recipients, err := s.permissions.PriceAlertRecipients(ctx, item.WishlistID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+
+	users, err := s.store.users(ctx, recipients)
+	if err != nil {
+		return err
+	}
+
+	title, message := renderAlert(item, a)
+	payload := map[string]interface{}{
+		"kind":       a.kind,
+		"itemId":     item.ID.Hex(),
+		"wishlistId": item.WishlistID.Hex(),
+		"name":       item.Name,
+		"price":      a.price,
+		"previous":   a.previousPrice,
+		"currency":   a.currency,
+	}
+	data, err := json.Marshal(messaging.WebsocketMessage{Type: "price_alert", Payload: payload})
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		prefs := user.NotificationPreferences
+		if !prefs.KindEnabled(a.kind) {
+			continue
+		}
+
+		if user.Email != "" && s.email != nil && prefs.ChannelEnabled(models.NotificationChannelEmail) {
+			if err := s.email.SendAccountNotification(user.Email, title, message); err != nil {
+				log.Warn().Err(err).Str("userID", user.ID.Hex()).Msg("Failed to email price alert")
+			}
+		}
+
+		// Push is best effort: the user may simply not be connected
+		if s.pusher != nil && prefs.ChannelEnabled(models.NotificationChannelPush) {
+			s.pusher.SendToUser(user.ID.Hex(), data)
+		}
+	}
+	return nil
+}
+
+// renderAlert returns the title and message of an alert
+func renderAlert(item *models.WishItem, a alert) (string, string) {
+	switch a.kind {
+	case models.ReminderOutOfStock:
+		return "Article en rupture de stock", fmt.Sprintf("« %s » n'est plus disponible pour le moment.", item.Name)
+	case models.ReminderBackInStock:
+		return "De nouveau disponible", fmt.Sprintf("« %s » est de nouveau disponible à %.2f %s.", item.Name, a.price, a.currency)
+	}
+
+	if a.previousPrice > 0 {
+		return "Baisse de prix", fmt.Sprintf("« %s » est passé de %.2f %s à %.2f %s.", item.Name, a.previousPrice, a.currency, a.price, a.currency)
+	}
+	return "Baisse de prix", fmt.Sprintf("« %s » est disponible à %.2f %s.", item.Name, a.price, a.currency)
+}
+
+// UpdateTracking enables or disables price tracking on an item.
+// Only users allowed to edit the wishlist may change it.
+func (s *Service) UpdateTracking(ctx context.Context, itemID string, userID string, req models.UpdatePriceTrackingRequest) (*models.PriceTracking, error) {
+	item, err := s.loadItem(ctx, itemID, userID, true)
+	if err != nil {
+		return nil, err
+	}
+	if req.TargetPrice < 0 {
+		return nil, ErrInvalidTarget
+	}
+
+	tracking := item.PriceTracking
+	if tracking == nil {
+		tracking = &models.PriceTracking{}
+	}
+
+	if *req.Enabled {
+		if !s.source.Supports(item.Link) {
+			return nil, ErrUnsupportedLink
+		}
+		if !tracking.Enabled {
+			tracking.NextCheckAt = time.Now() // Check as soon as possible
+		}
+		tracking.LastError = ""
+	}
+	tracking.Enabled = *req.Enabled
+	tracking.TargetPrice = req.TargetPrice
+
+	if err := s.store.saveTracking(ctx, item.ID, tracking, time.Now()); err != nil {
+		return nil, err
+	}
+	return tracking, nil
+}
+
+// GetHistory returns the recorded prices of an item, oldest first
+func (s *Service) GetHistory(ctx context.Context, itemID string, userID string) ([]models.PricePoint, error) {
+	item, err := s.loadItem(ctx, itemID, userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.store.history(ctx, item.ID, maxHistoryPoints)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetched newest first to keep the most recent points, returned in chronological order
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	return history, nil
+}
+
+// loadItem parses the IDs and loads an item the user may view, or edit when edit is set
+func (s *Service) loadItem(ctx context.Context, itemID string, userID string, edit bool) (*models.WishItem, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	id, err := primitive.ObjectIDFromHex(itemID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	item, err := s.store.item(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	canView, canEdit, err := s.permissions.PriceAccess(ctx, item.WishlistID, uid)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrItemNotFound
+		}
+		return nil, err
+	}
+	if !canView || (edit && !canEdit) {
+		return nil, ErrAccessDenied
+	}
+	return item, nil
+}
@@ -0,0 +1,530 @@
+package pricetracking
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"genie/internal/config"
+	"genie/internal/messaging"
+	"genie/internal/models"
+)
+
+// fakeSource returns scripted snapshots for each link, one per fetch
+type fakeSource struct {
+	mu      sync.Mutex
+	results map[string][]fakeResult
+	fetches int
+}
+
+type fakeResult struct {
+	snapshot *ProductSnapshot
+	err      error
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{results: make(map[string][]fakeResult)}
+}
+
+// queue adds the next results returned for a link
+func (f *fakeSource) queue(link string, results ...fakeResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results[link] = append(f.results[link], results...)
+}
+
+func (f *fakeSource) Supports(link string) bool {
+	return strings.Contains(link, "amazon.")
+}
+
+func (f *fakeSource) Fetch(_ context.Context, link string) (*ProductSnapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetches++
+
+	queued := f.results[link]
+	if len(queued) == 0 {
+		return nil, errors.New("no scripted result")
+	}
+	f.results[link] = queued[1:]
+	return queued[0].snapshot, queued[0].err
+}
+
+func inStock(price float64) fakeResult {
+	return fakeResult{snapshot: &ProductSnapshot{Price: price, Currency: "EUR", InStock: true}}
+}
+
+func outOfStock() fakeResult {
+	return fakeResult{snapshot: &ProductSnapshot{InStock: false}}
+}
+
+// fakeStore keeps items, users and history in memory,
+// applying updates the way the MongoDB store does
+type fakeStore struct {
+	mu        sync.Mutex
+	items     map[primitive.ObjectID]*models.WishItem
+	usersByID map[primitive.ObjectID]models.User
+	points    []models.PricePoint
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		items:     make(map[primitive.ObjectID]*models.WishItem),
+		usersByID: make(map[primitive.ObjectID]models.User),
+	}
+}
+
+// copyItem returns a deep enough copy for the tracker to work on
+func copyItem(item *models.WishItem) *models.WishItem {
+	c := *item
+	if item.PriceTracking != nil {
+		tracking := *item.PriceTracking
+		c.PriceTracking = &tracking
+	}
+	return &c
+}
+
+func (f *fakeStore) claimNext(_ context.Context, now time.Time, next time.Time) (*models.WishItem, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var due []*models.WishItem
+	for _, item := range f.items {
+		if item.PriceTracking != nil && item.PriceTracking.Enabled && !item.PriceTracking.NextCheckAt.After(now) {
+			due = append(due, item)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].PriceTracking.NextCheckAt.Before(due[j].PriceTracking.NextCheckAt)
+	})
+
+	before := copyItem(due[0])
+	due[0].PriceTracking.NextCheckAt = next
+	return before, nil
+}
+
+func (f *fakeStore) appendHistory(_ context.Context, point *models.PricePoint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.points = append(f.points, *point)
+	return nil
+}
+
+func (f *fakeStore) history(_ context.Context, itemID primitive.ObjectID, limit int64) ([]models.PricePoint, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	history := []models.PricePoint{}
+	for i := len(f.points) - 1; i >= 0 && int64(len(history)) < limit; i-- {
+		if f.points[i].ItemID == itemID {
+			history = append(history, f.points[i])
+		}
+	}
+	return history, nil
+}
+
+func (f *fakeStore) saveCheck(_ context.Context, itemID primitive.ObjectID, snapshot *ProductSnapshot, now time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	item := f.items[itemID]
+	tracking := item.PriceTracking
+	stock := snapshot.InStock
+	tracking.InStock = &stock
+	tracking.LastCheckedAt = &now
+	tracking.LastError = ""
+	if snapshot.InStock && snapshot.Price > 0 {
+		tracking.LastPrice = snapshot.Price
+		tracking.Currency = snapshot.Currency
+		item.Price = snapshot.Price
+		if snapshot.Currency != "" {
+			item.Currency = snapshot.Currency
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) saveError(_ context.Context, itemID primitive.ObjectID, message string, disable bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tracking := f.items[itemID].PriceTracking
+	tracking.LastError = message
+	if disable {
+		tracking.Enabled = false
+	}
+	return nil
+}
+
+func (f *fakeStore) saveTracking(_ context.Context, itemID primitive.ObjectID, tracking *models.PriceTracking, _ time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	saved := *tracking
+	f.items[itemID].PriceTracking = &saved
+	return nil
+}
+
+func (f *fakeStore) item(_ context.Context, id primitive.ObjectID) (*models.WishItem, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	item, ok := f.items[id]
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+	return copyItem(item), nil
+}
+
+// fakePermissions gives each member of a single wishlist a view or edit role
+type fakePermissions struct {
+	wishlistID primitive.ObjectID
+	roles      map[primitive.ObjectID]string
+}
+
+func (p *fakePermissions) PriceAccess(_ context.Context, wishlistID, userID primitive.ObjectID) (bool, bool, error) {
+	if wishlistID != p.wishlistID {
+		return false, false, mongo.ErrNoDocuments
+	}
+	role, ok := p.roles[userID]
+	return ok, role == "edit", nil
+}
+
+func (p *fakePermissions) PriceAlertRecipients(_ context.Context, wishlistID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	if wishlistID != p.wishlistID {
+		return nil, mongo.ErrNoDocuments
+	}
+	var recipients []primitive.ObjectID
+	for id := range p.roles {
+		recipients = append(recipients, id)
+	}
+	return recipients, nil
+}
+
+func (f *fakeStore) users(_ context.Context, ids []primitive.ObjectID) ([]models.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var users []models.User
+	for _, id := range ids {
+		if user, ok := f.usersByID[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// fakePusher records the pushed notifications
+type fakePusher struct {
+	mu     sync.Mutex
+	pushes []push
+}
+
+type push struct {
+	userID string
+	kind   string
+	price  float64
+}
+
+func (p *fakePusher) SendToUser(userID string, message []byte) int {
+	var decoded messaging.WebsocketMessage
+	if err := json.Unmarshal(message, &decoded); err != nil {
+		panic(err)
+	}
+	price, _ := decoded.Payload["price"].(float64)
+	kind, _ := decoded.Payload["kind"].(string)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pushes = append(p.pushes, push{userID: userID, kind: kind, price: price})
+	return 1
+}
+
+// kinds returns the kinds pushed to a user, in order
+func (p *fakePusher) kinds(userID primitive.ObjectID) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var kinds []string
+	for _, push := range p.pushes {
+		if push.userID == userID.Hex() {
+			kinds = append(kinds, push.kind)
+		}
+	}
+	return kinds
+}
+
+// fixture is a tracked item on a wishlist the owner edits and a friend views.
+// A user whose share is still pending has no access, like a stranger.
+type fixture struct {
+	service  *Service
+	source   *fakeSource
+	store    *fakeStore
+	pusher   *fakePusher
+	owner    primitive.ObjectID
+	friend   primitive.ObjectID
+	pending  primitive.ObjectID
+	stranger primitive.ObjectID
+	item     *models.WishItem
+	now      time.Time
+}
+
+const trackedLink = "https://www.amazon.fr/dp/B098RJXBTY"
+
+func newFixture(t *testing.T, tracking models.PriceTracking) *fixture {
+	t.Helper()
+	f := &fixture{
+		source:   newFakeSource(),
+		store:    newFakeStore(),
+		pusher:   &fakePusher{},
+		owner:    primitive.NewObjectID(),
+		friend:   primitive.NewObjectID(),
+		pending:  primitive.NewObjectID(),
+		stranger: primitive.NewObjectID(),
+		now:      time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC),
+	}
+	wishlistID := primitive.NewObjectID()
+	f.service = &Service{
+		store:  f.store,
+		source: f.source,
+		permissions: &fakePermissions{
+			wishlistID: wishlistID,
+			roles:      map[primitive.ObjectID]string{f.owner: "edit", f.friend: "view"},
+		},
+		pusher: f.pusher,
+		cfg: config.PriceTrackingConfig{
+			Enabled:        true,
+			CheckInterval:  6 * time.Hour,
+			BatchSize:      10,
+			MinDropPercent: 5,
+		},
+	}
+
+	tracking.Enabled = true
+	tracking.NextCheckAt = f.now
+	f.item = &models.WishItem{
+		ID:            primitive.NewObjectID(),
+		WishlistID:    wishlistID,
+		Name:          "Nintendo Switch OLED",
+		Link:          trackedLink,
+		PriceTracking: &tracking,
+	}
+	f.store.items[f.item.ID] = f.item
+
+	for _, id := range []primitive.ObjectID{f.owner, f.friend, f.pending, f.stranger} {
+		f.store.usersByID[id] = models.User{ID: id}
+	}
+	return f
+}
+
+// checkAll runs the tracker once per queued result, each time the item is due
+func (f *fixture) checkAll(t *testing.T, results ...fakeResult) {
+	t.Helper()
+	f.source.queue(trackedLink, results...)
+	for range results {
+		if err := f.service.CheckDue(context.Background(), f.now); err != nil {
+			t.Fatalf("CheckDue: %v", err)
+		}
+		f.now = f.now.Add(f.service.cfg.CheckInterval)
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestPriceDropThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		tracking  models.PriceTracking
+		price     float64
+		wantAlert bool
+	}{
+		{name: "first check", tracking: models.PriceTracking{}, price: 50},
+		{name: "small drop", tracking: models.PriceTracking{LastPrice: 100, InStock: boolPtr(true)}, price: 96},
+		{name: "drop at threshold", tracking: models.PriceTracking{LastPrice: 100, InStock: boolPtr(true)}, price: 95, wantAlert: true},
+		{name: "large drop", tracking: models.PriceTracking{LastPrice: 100, InStock: boolPtr(true)}, price: 60, wantAlert: true},
+		{name: "price increase", tracking: models.PriceTracking{LastPrice: 100, InStock: boolPtr(true)}, price: 120},
+		{name: "above target", tracking: models.PriceTracking{LastPrice: 100, TargetPrice: 80, InStock: boolPtr(true)}, price: 85},
+		{name: "reaches target", tracking: models.PriceTracking{LastPrice: 100, TargetPrice: 80, InStock: boolPtr(true)}, price: 80, wantAlert: true},
+		{name: "first check under target", tracking: models.PriceTracking{TargetPrice: 80}, price: 70, wantAlert: true},
+		{name: "already under target", tracking: models.PriceTracking{LastPrice: 75, TargetPrice: 80, InStock: boolPtr(true)}, price: 70},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, tt.tracking)
+			f.checkAll(t, inStock(tt.price))
+
+			var want []string
+			if tt.wantAlert {
+				want = []string{string(models.ReminderPriceDrop)}
+			}
+			if got := f.pusher.kinds(f.owner); !equal(got, want) {
+				t.Fatalf("owner got %v, want %v", got, want)
+			}
+			if tt.wantAlert && f.pusher.pushes[0].price != tt.price {
+				t.Errorf("alert price %v, want %v", f.pusher.pushes[0].price, tt.price)
+			}
+		})
+	}
+}
+
+func TestAlertAudience(t *testing.T) {
+	f := newFixture(t, models.PriceTracking{LastPrice: 100, InStock: boolPtr(true)})
+
+	// The friend muted price drops
+	friend := f.store.usersByID[f.friend]
+	friend.NotificationPreferences.DisabledKinds = []models.ReminderKind{models.ReminderPriceDrop}
+	f.store.usersByID[f.friend] = friend
+
+	f.checkAll(t, inStock(50))
+
+	if got := f.pusher.kinds(f.owner); len(got) != 1 {
+		t.Errorf("owner got %v, want one alert", got)
+	}
+	for name, id := range map[string]primitive.ObjectID{"muted friend": f.friend, "pending share": f.pending, "stranger": f.stranger} {
+		if got := f.pusher.kinds(id); len(got) != 0 {
+			t.Errorf("%s got %v, want nothing", name, got)
+		}
+	}
+}
+
+func TestStockAlerts(t *testing.T) {
+	f := newFixture(t, models.PriceTracking{})
+
+	f.checkAll(t,
+		outOfStock(),  // first check: nothing known before, no alert
+		inStock(100),  // back in stock
+		inStock(100),  // unchanged
+		outOfStock(),  // out of stock
+		outOfStock(),  // still out of stock: not repeated
+		inStock(90),   // back in stock, reported once even though the price dropped
+		inStock(89.5), // small drop
+	)
+
+	want := []string{
+		string(models.ReminderBackInStock),
+		string(models.ReminderOutOfStock),
+		string(models.ReminderBackInStock),
+	}
+	for _, id := range []primitive.ObjectID{f.owner, f.friend} {
+		if got := f.pusher.kinds(id); !equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+
+	tracking := f.store.items[f.item.ID].PriceTracking
+	if tracking.InStock == nil || !*tracking.InStock || tracking.LastPrice != 89.5 {
+		t.Errorf("tracking state %+v not updated", tracking)
+	}
+	// The last known price is kept while the item is out of stock
+	if f.store.items[f.item.ID].Price != 89.5 {
+		t.Errorf("item price %v, want 89.5", f.store.items[f.item.ID].Price)
+	}
+}
+
+func TestCheckAppendsHistory(t *testing.T) {
+	f := newFixture(t, models.PriceTracking{})
+	start := f.now
+
+	f.checkAll(t, inStock(100), outOfStock(), inStock(80))
+
+	// Not due again before the check interval
+	if err := f.service.CheckDue(context.Background(), f.now.Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if f.source.fetches != 3 {
+		t.Fatalf("%d fetches, want 3", f.source.fetches)
+	}
+
+	history, err := f.service.GetHistory(context.Background(), f.item.ID.Hex(), f.friend.Hex())
+	if err != nil {
+		t.Fatalf("GetHistory: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("%d points, want 3", len(history))
+	}
+	wantPrices := []float64{100, 0, 80}
+	wantStock := []bool{true, false, true}
+	for i, point := range history {
+		if point.ItemID != f.item.ID || point.Price != wantPrices[i] || point.InStock != wantStock[i] {
+			t.Errorf("point %d = %+v", i, point)
+		}
+		if want := start.Add(time.Duration(i) * f.service.cfg.CheckInterval); !point.CheckedAt.Equal(want) {
+			t.Errorf("point %d checked at %v, want %v (chronological order)", i, point.CheckedAt, want)
+		}
+	}
+
+	if _, err := f.service.GetHistory(context.Background(), f.item.ID.Hex(), f.stranger.Hex()); err != ErrAccessDenied {
+		t.Errorf("stranger got %v, want ErrAccessDenied", err)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	f := newFixture(t, models.PriceTracking{LastPrice: 100, InStock: boolPtr(true)})
+
+	f.checkAll(t, fakeResult{err: errors.New("canopy unavailable")})
+	tracking := f.store.items[f.item.ID].PriceTracking
+	if !tracking.Enabled || tracking.LastError == "" {
+		t.Fatalf("temporary failure: tracking %+v, want enabled with the error recorded", tracking)
+	}
+
+	f.checkAll(t, inStock(100))
+	if tracking := f.store.items[f.item.ID].PriceTracking; tracking.LastError != "" {
+		t.Errorf("error %q not cleared by a successful check", tracking.LastError)
+	}
+
+	f.checkAll(t, fakeResult{err: ErrUnsupportedLink})
+	if f.store.items[f.item.ID].PriceTracking.Enabled {
+		t.Errorf("tracking still enabled on an unsupported link")
+	}
+
+	if len(f.store.points) != 1 {
+		t.Errorf("%d points recorded, want only the successful check", len(f.store.points))
+	}
+	if len(f.pusher.pushes) != 0 {
+		t.Errorf("failures sent alerts: %v", f.pusher.pushes)
+	}
+}
+
+func TestUpdateTracking(t *testing.T) {
+	f := newFixture(t, models.PriceTracking{})
+	ctx := context.Background()
+
+	if _, err := f.service.UpdateTracking(ctx, f.item.ID.Hex(), f.friend.Hex(), models.UpdatePriceTrackingRequest{Enabled: boolPtr(true)}); err != ErrAccessDenied {
+		t.Errorf("view-only friend got %v, want ErrAccessDenied", err)
+	}
+
+	tracking, err := f.service.UpdateTracking(ctx, f.item.ID.Hex(), f.owner.Hex(), models.UpdatePriceTrackingRequest{Enabled: boolPtr(true), TargetPrice: 80})
+	if err != nil {
+		t.Fatalf("UpdateTracking: %v", err)
+	}
+	if !tracking.Enabled || tracking.TargetPrice != 80 {
+		t.Errorf("tracking %+v not saved", tracking)
+	}
+
+	f.store.items[f.item.ID].Link = "https://shop.example/product"
+	if _, err := f.service.UpdateTracking(ctx, f.item.ID.Hex(), f.owner.Hex(), models.UpdatePriceTrackingRequest{Enabled: boolPtr(true)}); err != ErrUnsupportedLink {
+		t.Errorf("unsupported link got %v, want ErrUnsupportedLink", err)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package pricetracking
+
+import (
+	"context"
+	"errors"
+
+	"genie/internal/scraper"
+)
+
+// ErrUnsupportedLink is returned by a ProductSource for links it cannot follow
+var ErrUnsupportedLink = errors.New("product link not supported for price tracking")
+
+// ProductSnapshot is the state of a product at the time it was fetched
+type ProductSnapshot struct {
+	Price    float64
+	Currency string
+	InStock  bool
+}
+
+// ProductSource fetches the current price of the product behind a link.
+// The tracker only depends on this interface so that it can run against a fake source.
+type ProductSource interface {
+	Supports(link string) bool
+	Fetch(ctx context.Context, link string) (*ProductSnapshot, error)
+}
+
+// CanopySource fetches Amazon products through the Canopy API
+type CanopySource struct {
+	client *scraper.CanopyClient
+}
+
+// NewCanopySource creates a product source backed by the Canopy client
+func NewCanopySource(client *scraper.CanopyClient) *CanopySource {
+	return &CanopySource{client: client}
+}
+
+// Supports reports whether the link is an Amazon product page
+func (s *CanopySource) Supports(link string) bool {
+	_, ok := scraper.ExtractASIN(link)
+	return ok
+}
+
+// Fetch returns the current offer of the Amazon product behind the link
+func (s *CanopySource) Fetch(ctx context.Context, link string) (*ProductSnapshot, error) {
+	asin, ok := scraper.ExtractASIN(link)
+	if !ok {
+		return nil, ErrUnsupportedLink
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	offer, err := s.client.GetAmazonProductOffer(asin)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProductSnapshot{
+		Price:    offer.Price,
+		Currency: offer.Currency,
+		InStock:  offer.InStock,
+	}, nil
+}
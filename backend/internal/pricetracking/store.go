@@ -0,0 +1,175 @@
+package pricetracking
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"genie/internal/models"
+)
+
+// store persists the tracked items and their price history.
+// Like the product source, it is an interface so the tracker can run against a fake.
+type store interface {
+	// claimNext reschedules the next item due at now to next and returns it as it was before,
+	// or nil when no item is due
+	claimNext(ctx context.Context, now time.Time, next time.Time) (*models.WishItem, error)
+	// appendHistory records a price point
+	appendHistory(ctx context.Context, point *models.PricePoint) error
+	// history returns up to limit price points of an item, newest first
+	history(ctx context.Context, itemID primitive.ObjectID, limit int64) ([]models.PricePoint, error)
+	// saveCheck records the result of a successful check on an item
+	saveCheck(ctx context.Context, itemID primitive.ObjectID, snapshot *ProductSnapshot, now time.Time) error
+	// saveError records a failed check; disable stops tracking the item
+	saveError(ctx context.Context, itemID primitive.ObjectID, message string, disable bool) error
+	// saveTracking replaces the tracking settings of an item
+	saveTracking(ctx context.Context, itemID primitive.ObjectID, tracking *models.PriceTracking, now time.Time) error
+	// item returns a wish item, or ErrItemNotFound
+	item(ctx context.Context, id primitive.ObjectID) (*models.WishItem, error)
+	// users returns the users with the given IDs
+	users(ctx context.Context, ids []primitive.ObjectID) ([]models.User, error)
+}
+
+// mongoStore is the MongoDB implementation of store
+type mongoStore struct {
+	db *mongo.Database
+}
+
+// newMongoStore creates the store and the indexes the tracker relies on
+func newMongoStore(db *mongo.Database) *mongoStore {
+	ctx := context.Background()
+
+	// Index used by the scheduler to find items due for a check
+	dueIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "priceTracking.enabled", Value: 1}, {Key: "priceTracking.nextCheckAt", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	}
+	if _, err := db.Collection(wishItemsCollection).Indexes().CreateOne(ctx, dueIndex); err != nil {
+		log.Warn().Err(err).Msg("Failed to create price tracking index on wishItems collection")
+	}
+
+	historyIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "itemId", Value: 1}, {Key: "checkedAt", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	}
+	if _, err := db.Collection(priceHistoryCollection).Indexes().CreateOne(ctx, historyIndex); err != nil {
+		log.Warn().Err(err).Msg("Failed to create indexes on priceHistory collection")
+	}
+
+	return &mongoStore{db: db}
+}
+
+// claimNext atomically reschedules the next due item, so that concurrent trackers
+// never check the same item twice
+func (m *mongoStore) claimNext(ctx context.Context, now time.Time, next time.Time) (*models.WishItem, error) {
+	query := bson.M{
+		"priceTracking.enabled":     true,
+		"priceTracking.nextCheckAt": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"priceTracking.nextCheckAt": next},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "priceTracking.nextCheckAt", Value: 1}}).
+		SetReturnDocument(options.Before)
+
+	var item models.WishItem
+	err := m.db.Collection(wishItemsCollection).FindOneAndUpdate(ctx, query, update, opts).Decode(&item)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (m *mongoStore) appendHistory(ctx context.Context, point *models.PricePoint) error {
+	_, err := m.db.Collection(priceHistoryCollection).InsertOne(ctx, point)
+	return err
+}
+
+func (m *mongoStore) history(ctx context.Context, itemID primitive.ObjectID, limit int64) ([]models.PricePoint, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "checkedAt", Value: -1}}).
+		SetLimit(limit)
+	cursor, err := m.db.Collection(priceHistoryCollection).Find(ctx, bson.M{"itemId": itemID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	history := []models.PricePoint{}
+	if err := cursor.All(ctx, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (m *mongoStore) saveCheck(ctx context.Context, itemID primitive.ObjectID, snapshot *ProductSnapshot, now time.Time) error {
+	set := bson.M{
+		"priceTracking.inStock":       snapshot.InStock,
+		"priceTracking.lastCheckedAt": now,
+	}
+	if snapshot.InStock && snapshot.Price > 0 {
+		// Keep the displayed price up to date
+		set["priceTracking.lastPrice"] = snapshot.Price
+		set["priceTracking.currency"] = snapshot.Currency
+		set["price"] = snapshot.Price
+		if snapshot.Currency != "" {
+			set["currency"] = snapshot.Currency
+		}
+		set["updatedAt"] = now
+	}
+
+	_, err := m.db.Collection(wishItemsCollection).UpdateOne(ctx,
+		bson.M{"_id": itemID},
+		bson.M{"$set": set, "$unset": bson.M{"priceTracking.lastError": ""}},
+	)
+	return err
+}
+
+func (m *mongoStore) saveError(ctx context.Context, itemID primitive.ObjectID, message string, disable bool) error {
+	set := bson.M{"priceTracking.lastError": message}
+	if disable {
+		set["priceTracking.enabled"] = false
+	}
+	_, err := m.db.Collection(wishItemsCollection).UpdateOne(ctx, bson.M{"_id": itemID}, bson.M{"$set": set})
+	return err
+}
+
+func (m *mongoStore) saveTracking(ctx context.Context, itemID primitive.ObjectID, tracking *models.PriceTracking, now time.Time) error {
+	_, err := m.db.Collection(wishItemsCollection).UpdateOne(ctx,
+		bson.M{"_id": itemID},
+		bson.M{"$set": bson.M{"priceTracking": tracking, "updatedAt": now}},
+	)
+	return err
+}
+
+func (m *mongoStore) item(ctx context.Context, id primitive.ObjectID) (*models.WishItem, error) {
+	var item models.WishItem
+	if err := m.db.Collection(wishItemsCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&item); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrItemNotFound
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (m *mongoStore) users(ctx context.Context, ids []primitive.ObjectID) ([]models.User, error) {
+	cursor, err := m.db.Collection(usersCollection).Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,140 @@
+package privacy
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"genie/internal/models"
+)
+
+// Export gathers everything stored about a user: profile, managed accounts, wishlists with
+// their items, events, sent messages, friendships, transactions and stories
+func (s *Service) Export(ctx context.Context, userID string) (*models.AccountExport, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	uid := user.ID
+
+	export := &models.AccountExport{
+		GeneratedAt:     time.Now(),
+		Profile:         user,
+		ManagedAccounts: []models.ManagedAccount{},
+		Wishlists:       []models.ExportedWishlist{},
+		Events:          []models.Event{},
+		Messages:        []models.Message{},
+		Friendships:     []models.ExportedFriendship{},
+		Transactions:    []models.TransactionResponse{},
+		Stories:         []models.Story{},
+	}
+
+	if err := s.findAll(ctx, managedAccountsCollection, bson.M{"ownerId": uid}, &export.ManagedAccounts); err != nil {
+		return nil, err
+	}
+
+	var wishlists []models.Wishlist
+	if err := s.findAll(ctx, wishlistsCollection, bson.M{"userId": uid}, &wishlists); err != nil {
+		return nil, err
+	}
+	for _, wishlist := range wishlists {
+		items := []models.WishItem{}
+		if err := s.findAll(ctx, wishItemsCollection, bson.M{"wishlistId": wishlist.ID}, &items); err != nil {
+			return nil, err
+		}
+		export.Wishlists = append(export.Wishlists, models.ExportedWishlist{Wishlist: wishlist, Items: items})
+	}
+
+	eventFilter := bson.M{"$or": []bson.M{{"creatorId": uid}, {"participants.userId": uid}}}
+	if err := s.findAll(ctx, eventsCollection, eventFilter, &export.Events); err != nil {
+		return nil, err
+	}
+
+	if err := s.findAll(ctx, messagesCollection, bson.M{"senderId": uid}, &export.Messages); err != nil {
+		return nil, err
+	}
+
+	var friendships []struct {
+		UserID    primitive.ObjectID `bson:"userId"`
+		FriendID  primitive.ObjectID `bson:"friendId"`
+		Status    string             `bson:"status"`
+		CreatedAt time.Time          `bson:"createdAt"`
+	}
+	friendshipFilter := bson.M{"$or": []bson.M{{"userId": uid}, {"friendId": uid}}}
+	if err := s.findAll(ctx, friendshipsCollection, friendshipFilter, &friendships); err != nil {
+		return nil, err
+	}
+	for _, f := range friendships {
+		exported := models.ExportedFriendship{FriendID: f.FriendID, Status: f.Status, Initiator: true, CreatedAt: f.CreatedAt}
+		if f.FriendID == uid {
+			exported.FriendID, exported.Initiator = f.UserID, false
+		}
+		export.Friendships = append(export.Friendships, exported)
+	}
+
+	var transactions []models.Transaction
+	if err := s.findAll(ctx, transactionsCollection, bson.M{"userId": uid}, &transactions); err != nil {
+		return nil, err
+	}
+	for i := range transactions {
+		export.Transactions = append(export.Transactions, transactions[i].ToResponse())
+	}
+
+	if err := s.findAll(ctx, storiesCollection, bson.M{"userId": uid}, &export.Stories); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// WriteZip writes an export as a zip archive holding one JSON file per section
+func WriteZip(w io.Writer, export *models.AccountExport) error {
+	archive := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"managed_accounts.json", export.ManagedAccounts},
+		{"wishlists.json", export.Wishlists},
+		{"events.json", export.Events},
+		{"messages.json", export.Messages},
+		{"friendships.json", export.Friendships},
+		{"transactions.json", export.Transactions},
+		{"stories.json", export.Stories},
+	}
+	for _, file := range files {
+		entry, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.GeneratedAt,
+		})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// findAll decodes every document of a collection matching filter into results, oldest first
+func (s *Service) findAll(ctx context.Context, collection string, filter bson.M, results interface{}) error {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := s.db.Collection(collection).Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, results)
+}
@@ -0,0 +1,156 @@
+package privacy
+
+import (
+	"fmt"
+	"net/http"
+
+	"genie/internal/middleware"
+	"genie/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Handler is the account privacy API handler
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new privacy handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the data export and account deletion routes
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/export", h.Export)
+	router.GET("/deletion", h.GetDeletionStatus)
+	router.POST("/deletion", h.RequestDeletion)
+	router.DELETE("/deletion", h.CancelDeletion)
+}
+
+// Export downloads everything stored about the current user, as a zip archive of JSON files
+// or, with ?format=json, as a single JSON document
+func (h *Handler) Export(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "zip")
+	if format != "zip" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be zip or json"})
+		return
+	}
+
+	export, err := h.service.Export(c.Request.Context(), userIDValue.(string))
+	if err != nil {
+		if err == ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to export account data")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account data"})
+		return
+	}
+
+	filename := fmt.Sprintf("genie-export-%s", export.GeneratedAt.Format("20060102"))
+	if format == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json"))
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".zip"))
+	c.Status(http.StatusOK)
+	if err := WriteZip(c.Writer, export); err != nil {
+		// Headers are already sent: the client gets a truncated archive
+		log.Error().Err(err).Msg("Failed to write account export archive")
+	}
+}
+
+// GetDeletionStatus returns the pending deletion of the current account, if any
+func (h *Handler) GetDeletionStatus(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	status, err := h.service.GetDeletionStatus(c.Request.Context(), userIDValue.(string))
+	if err != nil {
+		if err == ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to get account deletion status")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get account deletion status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// RequestDeletion schedules the deletion of the current account at the end of the grace period
+func (h *Handler) RequestDeletion(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	var req models.AccountDeletionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+
+	status, err := h.service.RequestDeletion(c.Request.Context(), userIDValue.(string), req.Password)
+	if err != nil {
+		switch err {
+		case ErrInvalidPassword:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		case ErrDeletionPending:
+			c.JSON(http.StatusConflict, gin.H{"error": "Account deletion already scheduled"})
+		case ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			log.Error().Err(err).Msg("Failed to schedule account deletion")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, status)
+}
+
+// CancelDeletion cancels the scheduled deletion of the current account
+func (h *Handler) CancelDeletion(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	if err := h.service.CancelDeletion(c.Request.Context(), userIDValue.(string)); err != nil {
+		if err == ErrNoDeletionPending {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No account deletion scheduled"})
+			return
+		}
+		if err == ErrDeletionStarted {
+			c.JSON(http.StatusConflict, gin.H{"error": "Account deletion already started"})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to cancel account deletion")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel account deletion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+}
@@ -0,0 +1,353 @@
+package privacy
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"genie/internal/models"
+	"genie/internal/wishlist"
+)
+
+const (
+	// deletedUserName replaces the name of a deleted user in the records other users keep
+	deletedUserName = "Utilisateur supprimé"
+	// deletedMessageContent replaces the content of the messages of a deleted user, as in DeleteMessage
+	deletedMessageContent = "Ce message a été supprimé"
+)
+
+// purgeUser permanently deletes an account: its managed accounts, wishlists, events, stories and
+// friendships are deleted with their media, while the messages, transactions and gifts other users
+// still see are anonymized. Every step can run again, so a failed purge is simply retried.
+func (s *Service) purgeUser(ctx context.Context, user *models.User) error {
+	uid := user.ID
+	now := time.Now()
+
+	steps := []func(context.Context, primitive.ObjectID, time.Time) error{
+		s.purgeManagedAccounts,
+		s.purgeWishlists,
+		s.releaseReservations,
+		s.purgeEvents,
+		s.anonymizeMessages,
+		s.anonymizeTransactions,
+		s.purgeSocial,
+	}
+	for _, step := range steps {
+		if err := step(ctx, uid, now); err != nil {
+			return err
+		}
+	}
+
+	s.deleteMedia(ctx, user.AvatarURL, user.ProfilePictureURL)
+
+	// Pending sign-in links and codes sent to the account's email or phone
+	targets := []string{}
+	for _, target := range []string{user.Email, user.Phone} {
+		if target != "" {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) > 0 {
+		if _, err := s.db.Collection(passwordlessCollection).DeleteMany(ctx, bson.M{"target": bson.M{"$in": targets}}); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.db.Collection(usersCollection).DeleteOne(ctx, bson.M{"_id": uid})
+	return err
+}
+
+// purgeManagedAccounts deletes the accounts the user manages with their pictures.
+// Their wishlists belong to the user and are deleted with the user's.
+func (s *Service) purgeManagedAccounts(ctx context.Context, uid primitive.ObjectID, _ time.Time) error {
+	var accounts []models.ManagedAccount
+	if err := s.findAll(ctx, managedAccountsCollection, bson.M{"ownerId": uid}, &accounts); err != nil {
+		return err
+	}
+	for _, account := range accounts {
+		s.deleteMedia(ctx, account.AvatarURL, account.ProfilePictureURL)
+	}
+
+	_, err := s.db.Collection(managedAccountsCollection).DeleteMany(ctx, bson.M{"ownerId": uid})
+	return err
+}
+
+// purgeWishlists deletes the user's wishlists with their items, pictures, activity and guest
+// reservations, and removes the user from the wishlists shared with them
+func (s *Service) purgeWishlists(ctx context.Context, uid primitive.ObjectID, _ time.Time) error {
+	var wishlists []models.Wishlist
+	if err := s.findAll(ctx, wishlistsCollection, bson.M{"userId": uid}, &wishlists); err != nil {
+		return err
+	}
+
+	wishlistIDs := make([]primitive.ObjectID, 0, len(wishlists))
+	for _, wishlist := range wishlists {
+		wishlistIDs = append(wishlistIDs, wishlist.ID)
+		s.deleteMedia(ctx, wishlist.CoverImage)
+	}
+
+	if len(wishlistIDs) > 0 {
+		var items []models.WishItem
+		if err := s.findAll(ctx, wishItemsCollection, bson.M{"wishlistId": bson.M{"$in": wishlistIDs}}, &items); err != nil {
+			return err
+		}
+		itemIDs := make([]primitive.ObjectID, 0, len(items))
+		for _, item := range items {
+			itemIDs = append(itemIDs, item.ID)
+			s.deleteMedia(ctx, item.ImageURL)
+		}
+
+		byWishlist := bson.M{"wishlistId": bson.M{"$in": wishlistIDs}}
+		if len(itemIDs) > 0 {
+			if _, err := s.db.Collection(priceHistoryCollection).DeleteMany(ctx, bson.M{"itemId": bson.M{"$in": itemIDs}}); err != nil {
+				return err
+			}
+		}
+		for _, collection := range []string{wishItemsCollection, guestReservationsCollection, activitiesCollection} {
+			if _, err := s.db.Collection(collection).DeleteMany(ctx, byWishlist); err != nil {
+				return err
+			}
+		}
+		if _, err := s.db.Collection(wishlistsCollection).DeleteMany(ctx, bson.M{"_id": bson.M{"$in": wishlistIDs}}); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.db.Collection(wishlistsCollection).UpdateMany(ctx,
+		bson.M{"sharedWith.userId": uid},
+		bson.M{"$pull": bson.M{"sharedWith": bson.M{"userId": uid}}},
+	); err != nil {
+		return err
+	}
+
+	// The activity feed of other wishlists keeps the event, not who did it
+	_, err := s.db.Collection(activitiesCollection).UpdateMany(ctx,
+		bson.M{"actorId": uid},
+		bson.M{
+			"$set":   bson.M{"actorName": deletedUserName},
+			"$unset": bson.M{"actorId": ""},
+		},
+	)
+	return err
+}
+
+// releaseReservations frees the items of other users' wishlists the user reserved but did not buy
+// yet. Gifts already bought stay reserved so their recipient still gets them, without the name of
+// the giver.
+func (s *Service) releaseReservations(ctx context.Context, uid primitive.ObjectID, now time.Time) error {
+	bought := []string{wishlist.ReservationPurchased, wishlist.ReservationReceived}
+	items := s.db.Collection(wishItemsCollection)
+
+	if _, err := items.UpdateMany(ctx,
+		bson.M{"reservedBy": uid, "reservationStatus": bson.M{"$nin": bought}},
+		bson.M{
+			"$set":   bson.M{"isReserved": false, "updatedAt": now},
+			"$unset": bson.M{"reservedBy": "", "reservationStatus": "", "reservedAt": "", "reservedForEventId": ""},
+		},
+	); err != nil {
+		return err
+	}
+	if _, err := items.UpdateMany(ctx,
+		bson.M{"reservedBy": uid},
+		bson.M{"$unset": bson.M{"reservedBy": ""}},
+	); err != nil {
+		return err
+	}
+
+	// Partial reservations of items wished in several copies: the quantity reserved but not
+	// bought becomes available again
+	pending := bson.M{"$and": bson.A{
+		bson.M{"$eq": bson.A{"$$r.userId", uid}},
+		bson.M{"$eq": bson.A{"$$r.status", wishlist.ReservationReserved}},
+	}}
+	if _, err := items.UpdateMany(ctx,
+		bson.M{"reservations": bson.M{"$elemMatch": bson.M{"userId": uid, "status": wishlist.ReservationReserved}}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"reservedQuantity": bson.M{"$subtract": bson.A{
+					bson.M{"$ifNull": bson.A{"$reservedQuantity", 0}},
+					bson.M{"$sum": bson.M{"$map": bson.M{
+						"input": bson.M{"$filter": bson.M{"input": "$reservations", "as": "r", "cond": pending}},
+						"as":    "r",
+						"in":    "$$r.quantity",
+					}}},
+				}},
+				"reservations": bson.M{"$filter": bson.M{"input": "$reservations", "as": "r", "cond": bson.M{"$not": bson.A{pending}}}},
+				"updatedAt":    now,
+			}}},
+			{{Key: "$set", Value: bson.M{
+				"isReserved": bson.M{"$gte": bson.A{"$reservedQuantity", bson.M{"$ifNull": bson.A{"$quantity", 1}}}},
+			}}},
+		},
+	); err != nil {
+		return err
+	}
+
+	// Bought copies are kept under the name of a deleted user, like guest reservations
+	_, err := items.UpdateMany(ctx,
+		bson.M{"reservations.userId": uid},
+		bson.M{"$set": bson.M{
+			"reservations.$[r].userId":    primitive.NilObjectID,
+			"reservations.$[r].guestName": deletedUserName,
+		}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"r.userId": uid}}}),
+	)
+	return err
+}
+
+// purgeEvents deletes the events the user created with their gift pictures, and removes the user
+// from the events of others
+func (s *Service) purgeEvents(ctx context.Context, uid primitive.ObjectID, _ time.Time) error {
+	var events []models.Event
+	if err := s.findAll(ctx, eventsCollection, bson.M{"creatorId": uid}, &events); err != nil {
+		return err
+	}
+	for _, event := range events {
+		for _, gift := range event.Gifts {
+			s.deleteMedia(ctx, gift.ImageURL)
+		}
+	}
+
+	if _, err := s.db.Collection(eventsCollection).DeleteMany(ctx, bson.M{"creatorId": uid}); err != nil {
+		return err
+	}
+
+	if _, err := s.db.Collection(eventsCollection).UpdateMany(ctx,
+		bson.M{"participants.userId": uid},
+		bson.M{"$pull": bson.M{"participants": bson.M{"userId": uid}}},
+	); err != nil {
+		return err
+	}
+
+	// Gifts the user had taken on in the events of others no longer have anyone assigned
+	_, err := s.db.Collection(eventsCollection).UpdateMany(ctx,
+		bson.M{"gifts.assignedTo": uid},
+		bson.M{"$unset": bson.M{"gifts.$[g].assignedTo": ""}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"g.assignedTo": uid}}}),
+	)
+	return err
+}
+
+// anonymizeMessages blanks the messages the user sent, deletes their media and removes the
+// user from their chats. The messages stay in place so conversations keep their thread.
+func (s *Service) anonymizeMessages(ctx context.Context, uid primitive.ObjectID, now time.Time) error {
+	var withMedia []models.Message
+	if err := s.findAll(ctx, messagesCollection, bson.M{"senderId": uid, "mediaUrl": bson.M{"$nin": []interface{}{nil, ""}}}, &withMedia); err != nil {
+		return err
+	}
+	for _, message := range withMedia {
+		s.deleteMedia(ctx, message.MediaURL)
+	}
+
+	if _, err := s.db.Collection(messagesCollection).UpdateMany(ctx,
+		bson.M{"senderId": uid},
+		bson.M{
+			"$set": bson.M{
+				"senderId":  primitive.NilObjectID,
+				"content":   deletedMessageContent,
+				"type":      models.MessageTypeSystem,
+				"updatedAt": now,
+			},
+			"$unset": bson.M{"mediaUrl": ""},
+		},
+	); err != nil {
+		return err
+	}
+
+	if _, err := s.db.Collection(chatsCollection).UpdateMany(ctx,
+		bson.M{"lastMessage.senderId": uid},
+		bson.M{
+			"$set": bson.M{
+				"lastMessage.senderId": primitive.NilObjectID,
+				"lastMessage.content":  deletedMessageContent,
+				"lastMessage.type":     models.MessageTypeSystem,
+			},
+			"$unset": bson.M{"lastMessage.mediaUrl": ""},
+		},
+	); err != nil {
+		return err
+	}
+
+	_, err := s.db.Collection(chatsCollection).UpdateMany(ctx,
+		bson.M{"participants": uid},
+		bson.M{"$pull": bson.M{"participants": uid}},
+	)
+	return err
+}
+
+// anonymizeTransactions strips personal details from transactions. Amounts and dates are kept
+// for accounting, both in the user's ledger and in the ledgers of the users they paid or were paid by.
+func (s *Service) anonymizeTransactions(ctx context.Context, uid primitive.ObjectID, now time.Time) error {
+	if _, err := s.db.Collection(transactionsCollection).UpdateMany(ctx,
+		bson.M{"userId": uid},
+		bson.M{
+			"$set":   bson.M{"userId": primitive.NilObjectID, "description": "Transaction", "updatedAt": now},
+			"$unset": bson.M{"recipientId": "", "recipientName": "", "recipientAvatar": ""},
+		},
+	); err != nil {
+		return err
+	}
+
+	_, err := s.db.Collection(transactionsCollection).UpdateMany(ctx,
+		bson.M{"recipientId": uid},
+		bson.M{
+			"$set":   bson.M{"recipientName": deletedUserName, "description": "Transaction", "updatedAt": now},
+			"$unset": bson.M{"recipientId": "", "recipientAvatar": ""},
+		},
+	)
+	return err
+}
+
+// purgeSocial deletes the user's friendships and friend requests, stories and their media,
+// story views and reminders
+func (s *Service) purgeSocial(ctx context.Context, uid primitive.ObjectID, _ time.Time) error {
+	var stories []models.Story
+	if err := s.findAll(ctx, storiesCollection, bson.M{"userId": uid}, &stories); err != nil {
+		return err
+	}
+	storyIDs := make([]primitive.ObjectID, 0, len(stories))
+	for _, story := range stories {
+		storyIDs = append(storyIDs, story.ID)
+		for _, media := range story.Media {
+			s.deleteMedia(ctx, media.URL)
+		}
+	}
+
+	deletions := []struct {
+		collection string
+		filter     bson.M
+	}{
+		{friendshipsCollection, bson.M{"$or": []bson.M{{"userId": uid}, {"friendId": uid}}}},
+		{friendRequestsCollection, bson.M{"$or": []bson.M{{"requesterId": uid}, {"recipientId": uid}}}},
+		{storyViewsCollection, bson.M{"$or": []bson.M{{"userId": uid}, {"storyId": bson.M{"$in": storyIDs}}}}},
+		{storiesCollection, bson.M{"userId": uid}},
+		{remindersCollection, bson.M{"$or": []bson.M{{"userId": uid}, {"friendId": uid}}}},
+	}
+	for _, deletion := range deletions {
+		if _, err := s.db.Collection(deletion.collection).DeleteMany(ctx, deletion.filter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteMedia removes uploaded files from storage. A file that cannot be deleted is logged
+// rather than blocking the deletion of the account.
+func (s *Service) deleteMedia(ctx context.Context, urls ...string) {
+	if s.media == nil {
+		return
+	}
+	for _, url := range urls {
+		if url == "" {
+			continue
+		}
+		if err := s.media.Delete(ctx, url); err != nil {
+			log.Warn().Err(err).Str("url", url).Msg("Failed to delete media of deleted account")
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+
+	"genie/internal/config"
+	"genie/internal/models"
+	"genie/internal/utils"
+)
+
+const (
+	// Collection names
+	usersCollection             = "users"
+	managedAccountsCollection   = "managed_accounts"
+	wishlistsCollection         = "wishlists"
+	wishItemsCollection         = "wishItems"
+	guestReservationsCollection = "guestReservations"
+	activitiesCollection        = "wishlistActivities"
+	eventsCollection            = "events"
+	chatsCollection             = "chats"
+	messagesCollection          = "messages"
+	friendshipsCollection       = "friendships"
+	friendRequestsCollection    = "friendRequests"
+	transactionsCollection      = "transactions"
+	storiesCollection           = "stories"
+	storyViewsCollection        = "storyViews"
+	remindersCollection         = "reminderJobs"
+	priceHistoryCollection      = "priceHistory"
+	passwordlessCollection      = "passwordless_logins"
+
+	// purgeBatchSize bounds the accounts deleted per scheduler tick
+	purgeBatchSize = 20
+)
+
+var (
+	ErrInvalidUserID     = errors.New("invalid user ID")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrInvalidPassword   = errors.New("invalid password")
+	ErrDeletionPending   = errors.New("account deletion already scheduled")
+	ErrNoDeletionPending = errors.New("no account deletion scheduled")
+	ErrDeletionStarted   = errors.New("account deletion already started")
+)
+
+// Service exports a user's data and deletes accounts once their grace period is over
+type Service struct {
+	db    *mongo.Database
+	cfg   config.PrivacyConfig
+	media MediaStore
+	email *utils.EmailService
+}
+
+// NewService creates a new privacy service
+func NewService(db *mongo.Database, cfg config.PrivacyConfig, media MediaStore, email *utils.EmailService) *Service {
+	// Index used by the scheduler to find accounts due for deletion
+	dueIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "deletionScheduledAt", Value: 1}},
+		Options: options.Index().SetSparse(true).SetBackground(true),
+	}
+	if _, err := db.Collection(usersCollection).Indexes().CreateOne(context.Background(), dueIndex); err != nil {
+		log.Warn().Err(err).Msg("Failed to create account deletion index on users collection")
+	}
+
+	return &Service{
+		db:    db,
+		cfg:   cfg,
+		media: media,
+		email: email,
+	}
+}
+
+// Start runs the deletion scheduler until ctx is cancelled
+func (s *Service) Start(ctx context.Context) {
+	go func() {
+		log.Info().Dur("purgeInterval", s.cfg.PurgeInterval).Msg("Starting account deletion scheduler")
+
+		ticker := time.NewTicker(s.cfg.PurgeInterval)
+		defer ticker.Stop()
+
+		s.tick(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info().Msg("Account deletion scheduler stopped")
+				return
+			case <-ticker.C:
+				s.tick(ctx)
+			}
+		}
+	}()
+}
+
+// tick deletes the accounts whose grace period is over
+func (s *Service) tick(ctx context.Context) {
+	if err := s.PurgeDue(ctx, time.Now()); err != nil {
+		log.Error().Err(err).Msg("Failed to purge deleted accounts")
+	}
+}
+
+// RequestDeletion schedules the deletion of an account at the end of the grace period.
+// Accounts with a password must confirm it; the deletion can be cancelled until then.
+func (s *Service) RequestDeletion(ctx context.Context, userID string, password string) (*models.AccountDeletionStatus, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletionScheduledAt != nil {
+		return nil, ErrDeletionPending
+	}
+	if user.PasswordHash != "" && bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	now := time.Now()
+	scheduledAt := now.Add(s.cfg.DeletionGracePeriod)
+	result, err := s.db.Collection(usersCollection).UpdateOne(ctx,
+		bson.M{"_id": user.ID, "deletionScheduledAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{
+			"deletionRequestedAt": now,
+			"deletionScheduledAt": scheduledAt,
+			"updatedAt":           now,
+		}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.ModifiedCount == 0 {
+		return nil, ErrDeletionPending
+	}
+
+	log.Info().Str("userID", userID).Time("scheduledAt", scheduledAt).Msg("Account deletion scheduled")
+	if user.Email != "" && s.email != nil {
+		message := fmt.Sprintf("La suppression de votre compte est programmée pour le %s. Toutes vos données seront alors définitivement effacées. Vous pouvez annuler cette demande d'ici là depuis l'application.",
+			scheduledAt.Format("02/01/2006"))
+		if err := s.email.SendAccountNotification(user.Email, "Suppression de votre compte", message); err != nil {
+			log.Warn().Err(err).Str("userID", userID).Msg("Failed to send account deletion notice")
+		}
+	}
+
+	return &models.AccountDeletionStatus{Scheduled: true, RequestedAt: &now, ScheduledAt: &scheduledAt}, nil
+}
+
+// CancelDeletion cancels a scheduled account deletion, unless the purge already started
+func (s *Service) CancelDeletion(ctx context.Context, userID string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	result, err := s.db.Collection(usersCollection).UpdateOne(ctx,
+		bson.M{
+			"_id":                 id,
+			"deletionScheduledAt": bson.M{"$exists": true},
+			"purgingAt":           bson.M{"$exists": false},
+		},
+		bson.M{
+			"$unset": bson.M{"deletionRequestedAt": "", "deletionScheduledAt": ""},
+			"$set":   bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		started, err := s.db.Collection(usersCollection).CountDocuments(ctx,
+			bson.M{"_id": id, "purgingAt": bson.M{"$exists": true}})
+		if err != nil {
+			return err
+		}
+		if started > 0 {
+			return ErrDeletionStarted
+		}
+		return ErrNoDeletionPending
+	}
+
+	log.Info().Str("userID", userID).Msg("Account deletion cancelled")
+	return nil
+}
+
+// GetDeletionStatus returns the pending deletion of an account, if any
+func (s *Service) GetDeletionStatus(ctx context.Context, userID string) (*models.AccountDeletionStatus, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AccountDeletionStatus{
+		Scheduled:   user.DeletionScheduledAt != nil,
+		RequestedAt: user.DeletionRequestedAt,
+		ScheduledAt: user.DeletionScheduledAt,
+	}, nil
+}
+
+// PurgeDue permanently deletes the accounts whose grace period ended before now
+func (s *Service) PurgeDue(ctx context.Context, now time.Time) error {
+	opts := options.Find().
+		SetLimit(purgeBatchSize).
+		SetSort(bson.D{{Key: "deletionScheduledAt", Value: 1}}).
+		SetProjection(bson.M{"_id": 1})
+	cursor, err := s.db.Collection(usersCollection).Find(ctx, bson.M{"deletionScheduledAt": bson.M{"$lte": now}}, opts)
+	if err != nil {
+		return err
+	}
+	var due []models.User
+	if err := cursor.All(ctx, &due); err != nil {
+		return err
+	}
+
+	for _, candidate := range due {
+		user, err := s.claimPurge(ctx, candidate.ID, now)
+		if err != nil {
+			log.Error().Err(err).Str("userID", candidate.ID.Hex()).Msg("Failed to claim account for deletion")
+			continue
+		}
+		if user == nil {
+			continue // The deletion was cancelled in the meantime
+		}
+
+		if err := s.purgeUser(ctx, user); err != nil {
+			// Retried on the next tick: every step of the purge can run again
+			log.Error().Err(err).Str("userID", user.ID.Hex()).Msg("Failed to delete account")
+			continue
+		}
+		log.Info().Str("userID", user.ID.Hex()).Msg("Account permanently deleted")
+	}
+	return nil
+}
+
+// claimPurge marks an account whose deletion is still due as being purged, so that the
+// deletion can no longer be cancelled. It returns nil when the deletion was cancelled.
+func (s *Service) claimPurge(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.User, error) {
+	var user models.User
+	err := s.db.Collection(usersCollection).FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "deletionScheduledAt": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"purgingAt": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// getUser loads a user by ID
+func (s *Service) getUser(ctx context.Context, userID string) (*models.User, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	var user models.User
+	if err := s.db.Collection(usersCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
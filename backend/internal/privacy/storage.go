@@ -0,0 +1,97 @@
+package privacy
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"genie/internal/config"
+)
+
+// MediaStore deletes uploaded media (avatars, item pictures, story and message media)
+type MediaStore interface {
+	// Delete removes the object behind url. URLs that do not point to our storage are ignored.
+	Delete(ctx context.Context, url string) error
+}
+
+// S3MediaStore deletes media from the S3 bucket uploads are written to
+type S3MediaStore struct {
+	cfg    config.StorageConfig
+	client *s3.S3
+}
+
+// NewS3MediaStore creates a media store for the configured bucket.
+// Without a bucket, there is nothing to delete and every call is a no-op.
+func NewS3MediaStore(cfg config.StorageConfig) (*S3MediaStore, error) {
+	store := &S3MediaStore{cfg: cfg}
+	if cfg.S3Bucket == "" {
+		return store, nil
+	}
+
+	awsConfig := &aws.Config{
+		Region:      aws.String(cfg.S3Region),
+		Credentials: credentials.NewStaticCredentials(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+	}
+	// Custom endpoint (MinIO...)
+	if cfg.S3Endpoint != "" {
+		awsConfig.Endpoint = aws.String(cfg.S3Endpoint)
+		awsConfig.DisableSSL = aws.Bool(strings.HasPrefix(cfg.S3Endpoint, "http://"))
+		awsConfig.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+	store.client = s3.New(sess)
+	return store, nil
+}
+
+// Delete removes the object behind url
+func (s *S3MediaStore) Delete(ctx context.Context, rawURL string) error {
+	if s.client == nil {
+		return nil
+	}
+	key, ok := s.objectKey(rawURL)
+	if !ok {
+		return nil
+	}
+
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.cfg.S3Bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// objectKey returns the key of an object of our bucket from its public URL, in the formats
+// built at upload time: {endpoint}/{bucket}/{key} or https://{bucket}.s3[.{region}].amazonaws.com/{key}
+func (s *S3MediaStore) objectKey(rawURL string) (string, bool) {
+	if rawURL == "" {
+		return "", false
+	}
+
+	if s.cfg.S3Endpoint != "" {
+		prefix := strings.TrimRight(s.cfg.S3Endpoint, "/") + "/" + s.cfg.S3Bucket + "/"
+		if key := strings.TrimPrefix(rawURL, prefix); key != rawURL && key != "" {
+			return key, true
+		}
+		return "", false
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	host := parsed.Hostname()
+	if host != s.cfg.S3Bucket+".s3.amazonaws.com" && host != s.cfg.S3Bucket+".s3."+s.cfg.S3Region+".amazonaws.com" {
+		return "", false
+	}
+	key := strings.TrimPrefix(parsed.Path, "/")
+	return key, key != ""
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// FailurePolicy règle la réponse aux échecs répétés sur une même clé (identifiant de compte)
+type FailurePolicy struct {
+	FreeAttempts     int           // échecs tolérés avant d'imposer un délai
+	BaseDelay        time.Duration // délai après le premier échec au-delà de FreeAttempts, doublé à chaque échec suivant
+	MaxDelay         time.Duration // délai maximal entre deux tentatives
+	LockoutThreshold int           // échecs entraînant le verrouillage
+	LockoutDuration  time.Duration // durée du verrouillage, et de la fenêtre de comptage des échecs
+}
+
+// Guard impose des délais croissants puis un verrouillage temporaire après des échecs répétés
+type Guard struct {
+	store  Store
+	name   string
+	policy FailurePolicy
+}
+
+// NewGuard crée une protection contre les échecs répétés
+func NewGuard(store Store, name string, policy FailurePolicy) *Guard {
+	return &Guard{store: store, name: name, policy: policy}
+}
+
+// Check renvoie une *LimitError si une tentative pour key doit être refusée :
+// clé verrouillée, ou délai imposé depuis le dernier échec pas encore écoulé
+func (g *Guard) Check(ctx context.Context, key string) error {
+	lock, err := g.store.Get(ctx, g.lockKey(key))
+	if err != nil {
+		return err
+	}
+	if lock.Count > 0 {
+		return &LimitError{RetryAfter: time.Until(lock.ExpiresAt), Locked: true}
+	}
+
+	failures, err := g.store.Get(ctx, g.failureKey(key))
+	if err != nil {
+		return err
+	}
+	if wait := time.Until(failures.LastAt.Add(g.delay(failures.Count))); failures.Count > 0 && wait > 0 {
+		return &LimitError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Fail enregistre un échec pour key et verrouille la clé au-delà du seuil
+func (g *Guard) Fail(ctx context.Context, key string) error {
+	failures, err := g.store.Incr(ctx, g.failureKey(key), g.policy.LockoutDuration)
+	if err != nil {
+		return err
+	}
+	if g.policy.LockoutThreshold > 0 && failures.Count >= g.policy.LockoutThreshold {
+		if _, err := g.store.Incr(ctx, g.lockKey(key), g.policy.LockoutDuration); err != nil {
+			return err
+		}
+		return g.store.Reset(ctx, g.failureKey(key))
+	}
+	return nil
+}
+
+// Succeed efface les échecs de key
+func (g *Guard) Succeed(ctx context.Context, key string) error {
+	return g.store.Reset(ctx, g.failureKey(key))
+}
+
+// delay renvoie le délai imposé après count échecs
+func (g *Guard) delay(count int) time.Duration {
+	excess := count - g.policy.FreeAttempts
+	if excess <= 0 || g.policy.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := g.policy.BaseDelay
+	for i := 1; i < excess && delay < g.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if g.policy.MaxDelay > 0 && delay > g.policy.MaxDelay {
+		delay = g.policy.MaxDelay
+	}
+	return delay
+}
+
+func (g *Guard) failureKey(key string) string {
+	return g.name + ":fail:" + key
+}
+
+func (g *Guard) lockKey(key string) string {
+	return g.name + ":lock:" + key
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Rule autorise Limit requêtes par fenêtre de durée Window
+type Rule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// LimitError est renvoyée lorsqu'une action est refusée par un limiteur
+type LimitError struct {
+	RetryAfter time.Duration
+	Locked     bool // compte temporairement verrouillé après trop d'échecs
+}
+
+func (e *LimitError) Error() string {
+	wait := retryAfterSeconds(e.RetryAfter)
+	if e.Locked {
+		return fmt.Sprintf("trop de tentatives échouées, compte temporairement verrouillé, réessayez dans %d secondes", wait)
+	}
+	return fmt.Sprintf("trop de tentatives, réessayez dans %d secondes", wait)
+}
+
+// RetryAfterSeconds renvoie le délai d'attente arrondi à la seconde supérieure, pour l'en-tête Retry-After
+func (e *LimitError) RetryAfterSeconds() int {
+	return retryAfterSeconds(e.RetryAfter)
+}
+
+func retryAfterSeconds(d time.Duration) int {
+	return int(math.Max(1, math.Ceil(d.Seconds())))
+}
+
+// Limiter limite le nombre de requêtes par clé (adresse IP, identifiant de compte...)
+type Limiter struct {
+	store Store
+	name  string
+	rule  Rule
+}
+
+// NewLimiter crée un limiteur. name sépare ses compteurs de ceux des autres limiteurs du même stockage.
+func NewLimiter(store Store, name string, rule Rule) *Limiter {
+	return &Limiter{store: store, name: name, rule: rule}
+}
+
+// Allow compte une requête pour key et renvoie une *LimitError si la limite est dépassée.
+// Les erreurs du stockage sont renvoyées telles quelles.
+func (l *Limiter) Allow(ctx context.Context, key string) (Entry, error) {
+	if l.rule.Limit <= 0 {
+		return Entry{}, nil
+	}
+
+	entry, err := l.store.Incr(ctx, l.name+":"+key, l.rule.Window)
+	if err != nil {
+		return Entry{}, err
+	}
+	if entry.Count > l.rule.Limit {
+		return entry, &LimitError{RetryAfter: time.Until(entry.ExpiresAt)}
+	}
+	return entry, nil
+}
+
+// Remaining renvoie le nombre de requêtes encore autorisées sur la fenêtre d'une entrée
+func (l *Limiter) Remaining(entry Entry) int {
+	if remaining := l.rule.Limit - entry.Count; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
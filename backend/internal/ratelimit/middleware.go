@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// KeyFunc renvoie la clé à limiter pour une requête ; une clé vide n'est pas limitée
+type KeyFunc func(c *gin.Context) string
+
+// ByIP limite par adresse IP du client
+func ByIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// ByUser limite par utilisateur authentifié (après AuthRequired), ou par adresse IP à défaut
+func ByUser(c *gin.Context) string {
+	if userID := c.GetString("userID"); userID != "" {
+		return "user:" + userID
+	}
+	return c.ClientIP()
+}
+
+// Middleware limite les requêtes d'une route ou d'un groupe de routes.
+// Les réponses portent les en-têtes X-RateLimit-* ; au-delà de la limite, la requête reçoit un 429.
+// Si le stockage est indisponible, la requête est laissée passer.
+func Middleware(limiter *Limiter, key KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := key(c)
+		if k == "" {
+			c.Next()
+			return
+		}
+
+		entry, err := limiter.Allow(c.Request.Context(), k)
+		var limitErr *LimitError
+		if errors.As(err, &limitErr) {
+			Abort(c, limitErr)
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Str("limiter", limiter.name).Msg("Limiteur de requêtes indisponible")
+			c.Next()
+			return
+		}
+
+		if limiter.rule.Limit > 0 {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(limiter.rule.Limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(limiter.Remaining(entry)))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(entry.ExpiresAt.Unix(), 10))
+		}
+		c.Next()
+	}
+}
+
+// Abort interrompt une requête refusée par un limiteur (429 avec l'en-tête Retry-After)
+func Abort(c *gin.Context, err *LimitError) {
+	c.Header("Retry-After", strconv.Itoa(err.RetryAfterSeconds()))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":      err.Error(),
+		"retryAfter": err.RetryAfterSeconds(),
+	})
+}
@@ -0,0 +1,167 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Entry est l'état d'un compteur sur sa fenêtre courante
+type Entry struct {
+	Count     int       // événements depuis le début de la fenêtre
+	LastAt    time.Time // dernier événement
+	ExpiresAt time.Time // fin de la fenêtre
+}
+
+// Store conserve les compteurs des limiteurs. Une fenêtre expirée repart de zéro.
+type Store interface {
+	// Incr compte un événement pour key ; la fenêtre de durée window démarre au premier événement
+	Incr(ctx context.Context, key string, window time.Duration) (Entry, error)
+	// Get renvoie l'état courant de key, vide si le compteur est absent ou expiré
+	Get(ctx context.Context, key string) (Entry, error)
+	// Reset supprime le compteur de key
+	Reset(ctx context.Context, key string) error
+}
+
+// memorySweepInterval est la fréquence de purge des compteurs expirés du stockage en mémoire
+const memorySweepInterval = time.Minute
+
+// MemoryStore garde les compteurs en mémoire. Ils ne sont pas partagés entre instances du serveur.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]Entry
+	lastSweep time.Time
+}
+
+// NewMemoryStore crée un stockage en mémoire
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]Entry), lastSweep: time.Now()}
+}
+
+// Incr compte un événement pour key
+func (m *MemoryStore) Incr(ctx context.Context, key string, window time.Duration) (Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+
+	entry, found := m.entries[key]
+	if !found || !now.Before(entry.ExpiresAt) {
+		entry = Entry{ExpiresAt: now.Add(window)}
+	}
+	entry.Count++
+	entry.LastAt = now
+	m.entries[key] = entry
+	return entry, nil
+}
+
+// Get renvoie l'état courant de key
+func (m *MemoryStore) Get(ctx context.Context, key string) (Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, found := m.entries[key]
+	if !found || !time.Now().Before(entry.ExpiresAt) {
+		return Entry{}, nil
+	}
+	return entry, nil
+}
+
+// Reset supprime le compteur de key
+func (m *MemoryStore) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// sweep purge les compteurs expirés. Appelé verrou pris.
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < memorySweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, entry := range m.entries {
+		if !now.Before(entry.ExpiresAt) {
+			delete(m.entries, key)
+		}
+	}
+}
+
+// rateLimitsCollection est la collection des compteurs partagés
+const rateLimitsCollection = "rate_limits"
+
+// MongoStore partage les compteurs entre instances du serveur via MongoDB.
+// Les compteurs expirés sont supprimés par un index TTL.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore crée un stockage MongoDB et son index d'expiration
+func NewMongoStore(db *mongo.Database) *MongoStore {
+	collection := db.Collection(rateLimitsCollection)
+
+	ttlIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	if _, err := collection.Indexes().CreateOne(context.Background(), ttlIndex); err != nil {
+		log.Warn().Err(err).Msg("Impossible de créer l'index d'expiration des limites de requêtes")
+	}
+
+	return &MongoStore{collection: collection}
+}
+
+type mongoEntry struct {
+	Count     int       `bson:"count"`
+	LastAt    time.Time `bson:"lastAt"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// Incr compte un événement pour key, en une seule opération atomique :
+// une fenêtre expirée (pas encore purgée par l'index TTL) repart de zéro
+func (m *MongoStore) Incr(ctx context.Context, key string, window time.Duration) (Entry, error) {
+	now := time.Now()
+	active := bson.M{"$gt": bson.A{"$expiresAt", now}}
+
+	var entry mongoEntry
+	err := m.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": key},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"count":     bson.M{"$cond": bson.A{active, bson.M{"$add": bson.A{"$count", 1}}, 1}},
+			"expiresAt": bson.M{"$cond": bson.A{active, "$expiresAt", now.Add(window)}},
+			"lastAt":    now,
+		}}}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry(entry), nil
+}
+
+// Get renvoie l'état courant de key
+func (m *MongoStore) Get(ctx context.Context, key string) (Entry, error) {
+	var entry mongoEntry
+	err := m.collection.FindOne(ctx, bson.M{"_id": key, "expiresAt": bson.M{"$gt": time.Now()}}).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return Entry{}, nil
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry(entry), nil
+}
+
+// Reset supprime le compteur de key
+func (m *MongoStore) Reset(ctx context.Context, key string) error {
+	_, err := m.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
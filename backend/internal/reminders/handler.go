@@ -0,0 +1,83 @@
+package reminders
+
+import (
+	"net/http"
+
+	"genie/internal/middleware"
+	"genie/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Handler is the reminders API handler
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new reminders handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the reminder routes
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/preferences", h.GetPreferences)
+	router.PUT("/preferences", h.UpdatePreferences)
+}
+
+// GetPreferences returns the notification opt-outs of the current user
+func (h *Handler) GetPreferences(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	prefs, err := h.service.GetPreferences(c.Request.Context(), userIDValue.(string))
+	if err != nil {
+		if err == ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to get notification preferences")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences replaces the notification opt-outs of the current user
+func (h *Handler) UpdatePreferences(c *gin.Context) {
+	userIDValue, exists := c.Get(middleware.UserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	var prefs models.NotificationPreferences
+	if err := c.ShouldBindJSON(&prefs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	updated, err := h.service.UpdatePreferences(c.Request.Context(), userIDValue.(string), &prefs)
+	if err != nil {
+		if err == ErrInvalidPreferences {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown notification channel or reminder kind"})
+			return
+		}
+		if err == ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Error().Err(err).Msg("Failed to update notification preferences")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,719 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"genie/internal/config"
+	"genie/internal/messaging"
+	"genie/internal/models"
+	"genie/internal/utils"
+)
+
+const (
+	// Collection names
+	remindersCollection   = "reminderJobs"
+	eventsCollection      = "events"
+	friendshipsCollection = "friendships"
+	usersCollection       = "users"
+
+	// How long a claimed job stays locked before another worker may retry it
+	jobLockDuration = 5 * time.Minute
+)
+
+var (
+	ErrInvalidUserID      = errors.New("invalid user ID")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidPreferences = errors.New("invalid notification preferences")
+
+	// errSkipReminder is returned by deliver when a job is no longer relevant
+	// (event cancelled, invitation answered, user opted out, ...)
+	errSkipReminder = errors.New("reminder no longer relevant")
+)
+
+// Service plans and delivers event and birthday reminders
+type Service struct {
+	db       *mongo.Database
+	cfg      config.RemindersConfig
+	email    *utils.EmailService
+	sms      *utils.SMSService
+	pusher   messaging.Pusher
+	lastPlan time.Time
+}
+
+// NewService creates a new reminders service
+func NewService(db *mongo.Database, cfg config.RemindersConfig, email *utils.EmailService, sms *utils.SMSService, pusher messaging.Pusher) *Service {
+	ctx := context.Background()
+
+	// Unique dedupe key so a reminder is only ever planned once
+	dedupeIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "dedupeKey", Value: 1}},
+		Options: options.Index().SetUnique(true).SetBackground(true),
+	}
+
+	// Index used by the dispatcher to find due jobs
+	dueIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "status", Value: 1}, {Key: "runAt", Value: 1}},
+		Options: options.Index().SetBackground(true),
+	}
+
+	if _, err := db.Collection(remindersCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{dedupeIndex, dueIndex}); err != nil {
+		log.Warn().Err(err).Msg("Failed to create indexes on reminderJobs collection")
+	}
+
+	return &Service{
+		db:     db,
+		cfg:    cfg,
+		email:  email,
+		sms:    sms,
+		pusher: pusher,
+	}
+}
+
+// Start runs the scheduler loop until ctx is cancelled
+func (s *Service) Start(ctx context.Context) {
+	go func() {
+		log.Info().Dur("pollInterval", s.cfg.PollInterval).Msg("Starting reminder scheduler")
+
+		ticker := time.NewTicker(s.cfg.PollInterval)
+		defer ticker.Stop()
+
+		s.tick(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info().Msg("Reminder scheduler stopped")
+				return
+			case <-ticker.C:
+				s.tick(ctx)
+			}
+		}
+	}()
+}
+
+// tick plans new reminders when needed and delivers the due ones
+func (s *Service) tick(ctx context.Context) {
+	now := time.Now()
+
+	if now.Sub(s.lastPlan) >= s.cfg.PlanInterval {
+		if err := s.Plan(ctx, now); err != nil {
+			log.Error().Err(err).Msg("Failed to plan reminders")
+		} else {
+			s.lastPlan = now
+		}
+	}
+
+	if err := s.Dispatch(ctx, now); err != nil {
+		log.Error().Err(err).Msg("Failed to dispatch reminders")
+	}
+}
+
+// Plan persists every reminder that should exist at the given time.
+// Planning is idempotent: existing jobs are left untouched.
+func (s *Service) Plan(ctx context.Context, now time.Time) error {
+	if err := s.planInvitationNudges(ctx, now); err != nil {
+		return fmt.Errorf("invitation nudges: %w", err)
+	}
+	if err := s.planEventReminders(ctx, now); err != nil {
+		return fmt.Errorf("event reminders: %w", err)
+	}
+	if err := s.planBirthdayReminders(ctx, now); err != nil {
+		return fmt.Errorf("birthday reminders: %w", err)
+	}
+	return nil
+}
+
+// planInvitationNudges plans a nudge for every invitation left unanswered too long
+func (s *Service) planInvitationNudges(ctx context.Context, now time.Time) error {
+	threshold := now.Add(-s.cfg.InvitationNudgeAfter)
+
+	query := bson.M{
+		"deletedAt": nil,
+		"startDate": bson.M{"$gt": now},
+		"participants": bson.M{
+			"$elemMatch": bson.M{
+				"status":    models.EventStatusInvited,
+				"invitedAt": bson.M{"$lte": threshold},
+			},
+		},
+	}
+
+	cursor, err := s.db.Collection(eventsCollection).Find(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var event models.Event
+		if err := cursor.Decode(&event); err != nil {
+			return err
+		}
+
+		eventID := event.ID
+		for _, p := range event.Participants {
+			// Participants added since the backfill may still lack an invitation date
+			invitedAt := p.InvitedAt
+			if invitedAt.IsZero() {
+				invitedAt = event.CreatedAt
+			}
+			if p.Status != models.EventStatusInvited || invitedAt.After(threshold) {
+				continue
+			}
+			job := &models.ReminderJob{
+				Kind:      models.ReminderInvitationNudge,
+				DedupeKey: fmt.Sprintf("%s:%s:%s", models.ReminderInvitationNudge, eventID.Hex(), p.UserID.Hex()),
+				UserID:    p.UserID,
+				EventID:   &eventID,
+				RunAt:     now,
+			}
+			if err := s.enqueue(ctx, job); err != nil {
+				return err
+			}
+		}
+	}
+
+	return cursor.Err()
+}
+
+// planEventReminders plans the "in 7 days" and "tomorrow" reminders of upcoming events
+func (s *Service) planEventReminders(ctx context.Context, now time.Time) error {
+	offsets := []struct {
+		kind   models.ReminderKind
+		before time.Duration
+	}{
+		{models.ReminderEventInOneWeek, 7 * 24 * time.Hour},
+		{models.ReminderEventTomorrow, 24 * time.Hour},
+	}
+
+	// Reminders whose time passed more than one planning round ago are stale
+	staleBefore := now.Add(-s.cfg.PlanInterval)
+
+	query := bson.M{
+		"deletedAt": nil,
+		"startDate": bson.M{
+			"$gt":  now,
+			"$lte": now.Add(7*24*time.Hour + s.cfg.PlanInterval),
+		},
+	}
+
+	cursor, err := s.db.Collection(eventsCollection).Find(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var event models.Event
+		if err := cursor.Decode(&event); err != nil {
+			return err
+		}
+
+		eventID := event.ID
+		for _, offset := range offsets {
+			runAt := event.StartDate.Add(-offset.before)
+			if runAt.Before(staleBefore) {
+				continue
+			}
+			for _, p := range event.Participants {
+				if !expectsEventReminders(p.Status) {
+					continue
+				}
+				job := &models.ReminderJob{
+					Kind:      offset.kind,
+					DedupeKey: fmt.Sprintf("%s:%s:%s:%d", offset.kind, eventID.Hex(), p.UserID.Hex(), event.StartDate.Unix()),
+					UserID:    p.UserID,
+					EventID:   &eventID,
+					RunAt:     runAt,
+				}
+				if err := s.enqueue(ctx, job); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return cursor.Err()
+}
+
+// expectsEventReminders reports whether a participant with the given RSVP status
+// should be reminded of the event: those who declined or wait for a seat are not
+func expectsEventReminders(status string) bool {
+	return status != models.EventStatusDeclined && status != models.EventStatusWaitlisted
+}
+
+// planBirthdayReminders alerts the friends of every user whose birthday is near.
+// Only the users born on one of the upcoming days are read, and their friends are
+// loaded with a single query.
+func (s *Service) planBirthdayReminders(ctx context.Context, now time.Time) error {
+	query := bson.M{
+		"birthDate": bson.M{"$gt": time.Time{}},
+		"$expr":     bson.M{"$or": birthdayWindow(now, s.cfg.BirthdayLeadTime)},
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "birthDate": 1})
+
+	cursor, err := s.db.Collection(usersCollection).Find(ctx, query, opts)
+	if err != nil {
+		return err
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return err
+	}
+
+	birthdays := make(map[primitive.ObjectID]time.Time, len(users))
+	userIDs := make([]primitive.ObjectID, 0, len(users))
+	for _, user := range users {
+		birthday := nextBirthday(user.BirthDate, now)
+		if birthday.Sub(now) > s.cfg.BirthdayLeadTime {
+			continue
+		}
+		birthdays[user.ID] = birthday
+		userIDs = append(userIDs, user.ID)
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	friends, err := s.friendsOf(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		birthdayUserID := userID
+		birthday := birthdays[userID]
+		for _, friendID := range friends[userID] {
+			job := &models.ReminderJob{
+				Kind:      models.ReminderFriendBirthday,
+				DedupeKey: fmt.Sprintf("%s:%s:%s:%d", models.ReminderFriendBirthday, birthdayUserID.Hex(), friendID.Hex(), birthday.Year()),
+				UserID:    friendID,
+				FriendID:  &birthdayUserID,
+				RunAt:     now,
+			}
+			if err := s.enqueue(ctx, job); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// birthdayWindow returns one $expr condition per day from today until the lead time,
+// matching the birth dates whose month and day fall on it
+func birthdayWindow(now time.Time, lead time.Duration) bson.A {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	days := bson.A{}
+	matchDay := func(month time.Month, day int) {
+		days = append(days, bson.M{"$and": bson.A{
+			bson.M{"$eq": bson.A{bson.M{"$month": "$birthDate"}, int(month)}},
+			bson.M{"$eq": bson.A{bson.M{"$dayOfMonth": "$birthDate"}, day}},
+		}})
+	}
+	for day := today; day.Sub(now) <= lead; day = day.AddDate(0, 0, 1) {
+		matchDay(day.Month(), day.Day())
+		// Outside leap years, a 29 February birthday falls on 1 March (see nextBirthday)
+		if day.Month() == time.March && day.Day() == 1 && day.AddDate(0, 0, -1).Day() != 29 {
+			matchDay(time.February, 29)
+		}
+	}
+	return days
+}
+
+// friendsOf returns the IDs of the accepted friends of each of the given users
+func (s *Service) friendsOf(ctx context.Context, userIDs []primitive.ObjectID) (map[primitive.ObjectID][]primitive.ObjectID, error) {
+	cursor, err := s.db.Collection(friendshipsCollection).Find(ctx, bson.M{
+		"status": "accepted",
+		"$or": []bson.M{
+			{"userId": bson.M{"$in": userIDs}},
+			{"friendId": bson.M{"$in": userIDs}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var friendships []struct {
+		UserID   primitive.ObjectID `bson:"userId"`
+		FriendID primitive.ObjectID `bson:"friendId"`
+	}
+	if err := cursor.All(ctx, &friendships); err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[primitive.ObjectID]bool, len(userIDs))
+	for _, id := range userIDs {
+		wanted[id] = true
+	}
+
+	friends := make(map[primitive.ObjectID][]primitive.ObjectID)
+	for _, f := range friendships {
+		if wanted[f.UserID] {
+			friends[f.UserID] = append(friends[f.UserID], f.FriendID)
+		}
+		if wanted[f.FriendID] {
+			friends[f.FriendID] = append(friends[f.FriendID], f.UserID)
+		}
+	}
+	return friends, nil
+}
+
+// enqueue persists a job unless one with the same dedupe key already exists
+func (s *Service) enqueue(ctx context.Context, job *models.ReminderJob) error {
+	now := time.Now()
+	job.ID = primitive.NewObjectID()
+	job.Status = models.ReminderStatusPending
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	_, err := s.db.Collection(remindersCollection).UpdateOne(ctx,
+		bson.M{"dedupeKey": job.DedupeKey},
+		bson.M{"$setOnInsert": job},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Dispatch delivers up to BatchSize due reminders
+func (s *Service) Dispatch(ctx context.Context, now time.Time) error {
+	for i := 0; i < s.cfg.BatchSize; i++ {
+		job, err := s.claimNext(ctx, now)
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			return nil
+		}
+
+		err = s.deliver(ctx, job)
+		if err := s.complete(ctx, job, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// claimNext atomically locks the next due job, including jobs whose lock expired
+// because the process stopped while handling them
+func (s *Service) claimNext(ctx context.Context, now time.Time) (*models.ReminderJob, error) {
+	query := bson.M{
+		"$or": []bson.M{
+			{"status": models.ReminderStatusPending, "runAt": bson.M{"$lte": now}},
+			{"status": models.ReminderStatusProcessing, "lockedUntil": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      models.ReminderStatusProcessing,
+			"lockedUntil": now.Add(jobLockDuration),
+			"updatedAt":   now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "runAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job models.ReminderJob
+	err := s.db.Collection(remindersCollection).FindOneAndUpdate(ctx, query, update, opts).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// complete records the outcome of a delivery attempt
+func (s *Service) complete(ctx context.Context, job *models.ReminderJob, deliveryErr error) error {
+	now := time.Now()
+	set := bson.M{"updatedAt": now}
+
+	switch {
+	case deliveryErr == nil:
+		set["status"] = models.ReminderStatusSent
+		set["sentAt"] = now
+	case errors.Is(deliveryErr, errSkipReminder):
+		set["status"] = models.ReminderStatusSkipped
+	case job.Attempts >= s.cfg.MaxAttempts:
+		set["status"] = models.ReminderStatusFailed
+		set["lastError"] = deliveryErr.Error()
+		log.Error().Err(deliveryErr).Str("jobID", job.ID.Hex()).Msg("Reminder permanently failed")
+	default:
+		// Retry later with a linear backoff
+		set["status"] = models.ReminderStatusPending
+		set["runAt"] = now.Add(time.Duration(job.Attempts) * s.cfg.PollInterval)
+		set["lastError"] = deliveryErr.Error()
+		log.Warn().Err(deliveryErr).Str("jobID", job.ID.Hex()).Int("attempts", job.Attempts).Msg("Reminder delivery failed, will retry")
+	}
+
+	_, err := s.db.Collection(remindersCollection).UpdateOne(ctx,
+		bson.M{"_id": job.ID},
+		bson.M{"$set": set, "$unset": bson.M{"lockedUntil": ""}},
+	)
+	return err
+}
+
+// notification is the channel-independent content of a reminder
+type notification struct {
+	title   string
+	message string
+	payload map[string]interface{}
+}
+
+// deliver sends a job to its recipient over every enabled channel
+func (s *Service) deliver(ctx context.Context, job *models.ReminderJob) error {
+	var user models.User
+	err := s.db.Collection(usersCollection).FindOne(ctx, bson.M{"_id": job.UserID}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errSkipReminder
+		}
+		return err
+	}
+
+	prefs := user.NotificationPreferences
+	if !prefs.KindEnabled(job.Kind) {
+		return errSkipReminder
+	}
+
+	n, err := s.buildNotification(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	attempted, delivered := 0, 0
+	var lastErr error
+
+	if user.Email != "" && s.email != nil && prefs.ChannelEnabled(models.NotificationChannelEmail) {
+		attempted++
+		if err := s.email.SendAccountNotification(user.Email, n.title, n.message); err != nil {
+			lastErr = err
+		} else {
+			delivered++
+		}
+	}
+
+	if user.Phone != "" && s.sms != nil && prefs.ChannelEnabled(models.NotificationChannelSMS) {
+		attempted++
+		if err := s.sms.SendSMS(user.Phone, n.message); err != nil {
+			lastErr = err
+		} else {
+			delivered++
+		}
+	}
+
+	if s.pusher != nil && prefs.ChannelEnabled(models.NotificationChannelPush) {
+		wsMsg := messaging.WebsocketMessage{
+			Type:    "reminder",
+			Payload: n.payload,
+		}
+		data, err := json.Marshal(wsMsg)
+		if err != nil {
+			return err
+		}
+		// Push is best effort: the user may simply not be connected
+		if s.pusher.SendToUser(user.ID.Hex(), data) > 0 {
+			delivered++
+		}
+	}
+
+	if delivered == 0 && attempted > 0 {
+		return lastErr
+	}
+	if delivered == 0 {
+		return errSkipReminder
+	}
+	return nil
+}
+
+// buildNotification renders a job, checking that it is still relevant
+func (s *Service) buildNotification(ctx context.Context, job *models.ReminderJob) (*notification, error) {
+	payload := map[string]interface{}{
+		"kind": job.Kind,
+	}
+
+	if job.Kind == models.ReminderFriendBirthday {
+		if job.FriendID == nil {
+			return nil, errSkipReminder
+		}
+		var friend models.User
+		err := s.db.Collection(usersCollection).FindOne(ctx, bson.M{"_id": *job.FriendID}).Decode(&friend)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, errSkipReminder
+			}
+			return nil, err
+		}
+
+		birthday := nextBirthday(friend.BirthDate, time.Now())
+		name := fmt.Sprintf("%s %s", friend.FirstName, friend.LastName)
+		payload["friendId"] = friend.ID.Hex()
+		payload["date"] = birthday
+
+		return &notification{
+			title:   "Anniversaire à venir",
+			message: fmt.Sprintf("L'anniversaire de %s approche : le %s !", name, birthday.Format("02/01")),
+			payload: payload,
+		}, nil
+	}
+
+	if job.EventID == nil {
+		return nil, errSkipReminder
+	}
+
+	var event models.Event
+	err := s.db.Collection(eventsCollection).FindOne(ctx, bson.M{"_id": *job.EventID, "deletedAt": nil}).Decode(&event)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errSkipReminder
+		}
+		return nil, err
+	}
+
+	if !event.StartDate.After(time.Now()) {
+		return nil, errSkipReminder
+	}
+
+	// The recipient must still be a participant with a relevant status
+	var participant *models.EventParticipant
+	for i := range event.Participants {
+		if event.Participants[i].UserID == job.UserID {
+			participant = &event.Participants[i]
+			break
+		}
+	}
+	if participant == nil || !expectsEventReminders(participant.Status) {
+		return nil, errSkipReminder
+	}
+
+	payload["eventId"] = event.ID.Hex()
+	payload["title"] = event.Title
+	payload["startDate"] = event.StartDate
+	date := event.StartDate.Format("02/01/2006")
+
+	switch job.Kind {
+	case models.ReminderInvitationNudge:
+		if participant.Status != models.EventStatusInvited {
+			return nil, errSkipReminder
+		}
+		return &notification{
+			title:   "Invitation en attente",
+			message: fmt.Sprintf("Vous n'avez pas encore répondu à l'invitation « %s » du %s.", event.Title, date),
+			payload: payload,
+		}, nil
+	case models.ReminderEventInOneWeek:
+		return &notification{
+			title:   "Plus qu'une semaine !",
+			message: fmt.Sprintf("Rappel : « %s » a lieu dans 7 jours, le %s.", event.Title, date),
+			payload: payload,
+		}, nil
+	case models.ReminderEventTomorrow:
+		return &notification{
+			title:   "C'est demain !",
+			message: fmt.Sprintf("Rappel : « %s » a lieu demain, le %s.", event.Title, date),
+			payload: payload,
+		}, nil
+	}
+
+	return nil, errSkipReminder
+}
+
+// GetPreferences returns the notification preferences of a user
+func (s *Service) GetPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	var user models.User
+	opts := options.FindOne().SetProjection(bson.M{"notificationPreferences": 1})
+	err = s.db.Collection(usersCollection).FindOne(ctx, bson.M{"_id": uid}, opts).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	prefs := user.NotificationPreferences
+	if prefs.DisabledChannels == nil {
+		prefs.DisabledChannels = []models.NotificationChannel{}
+	}
+	if prefs.DisabledKinds == nil {
+		prefs.DisabledKinds = []models.ReminderKind{}
+	}
+	return &prefs, nil
+}
+
+// UpdatePreferences replaces the notification preferences of a user
+func (s *Service) UpdatePreferences(ctx context.Context, userID string, prefs *models.NotificationPreferences) (*models.NotificationPreferences, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	validChannels := map[models.NotificationChannel]bool{
+		models.NotificationChannelEmail: true,
+		models.NotificationChannelSMS:   true,
+		models.NotificationChannelPush:  true,
+	}
+	for _, c := range prefs.DisabledChannels {
+		if !validChannels[c] {
+			return nil, ErrInvalidPreferences
+		}
+	}
+
+	validKinds := map[models.ReminderKind]bool{
+		models.ReminderInvitationNudge: true,
+		models.ReminderEventInOneWeek:  true,
+		models.ReminderEventTomorrow:   true,
+		models.ReminderFriendBirthday:  true,
+		models.ReminderPriceDrop:       true,
+		models.ReminderOutOfStock:      true,
+		models.ReminderBackInStock:     true,
+	}
+	for _, k := range prefs.DisabledKinds {
+		if !validKinds[k] {
+			return nil, ErrInvalidPreferences
+		}
+	}
+
+	result, err := s.db.Collection(usersCollection).UpdateOne(ctx,
+		bson.M{"_id": uid},
+		bson.M{"$set": bson.M{
+			"notificationPreferences": prefs,
+			"updatedAt":               time.Now(),
+		}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrUserNotFound
+	}
+
+	return s.GetPreferences(ctx, userID)
+}
+
+// nextBirthday returns the next occurrence (today included) of a birth date
+func nextBirthday(birthDate time.Time, now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	birthday := time.Date(now.Year(), birthDate.Month(), birthDate.Day(), 0, 0, 0, 0, now.Location())
+	if birthday.Before(today) {
+		birthday = birthday.AddDate(1, 0, 0)
+	}
+	return birthday
+}
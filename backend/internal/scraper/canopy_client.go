@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strings"
 	"time"
 
@@ -131,6 +133,86 @@ func (c *CanopyClient) GetAmazonProduct(asin string) (*Product, error) {
 	return &product, nil
 }
 
+// ProductOffer représente l'offre actuelle d'un produit Amazon
+type ProductOffer struct {
+	Product
+	ASIN    string `json:"asin"`
+	InStock bool   `json:"inStock"`
+}
+
+// GetAmazonProductOffer récupère le prix et la disponibilité actuels d'un produit Amazon par ASIN.
+// Canopy ne renvoie pas de prix lorsqu'aucune offre n'est disponible : le produit est alors considéré en rupture.
+func (c *CanopyClient) GetAmazonProductOffer(asin string) (*ProductOffer, error) {
+	query := `
+		query AmazonProduct($asin: String!) {
+			amazonProduct(input: {asinLookup: {asin: $asin}}) {
+				title
+				brand
+				mainImageUrl
+				url
+				price {
+					display
+					value
+					currency
+				}
+			}
+		}
+	`
+
+	response, err := c.ExecuteQuery(query, map[string]interface{}{"asin": asin})
+	if err != nil {
+		return nil, err
+	}
+
+	result, ok := response.Data["amazonProduct"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("aucun produit trouvé pour l'ASIN %s", asin)
+	}
+
+	offer := &ProductOffer{
+		Product: Product{
+			ID:       fmt.Sprintf("amazon_%s", asin),
+			Title:    getStringValue(result, "title"),
+			ImageURL: getStringValue(result, "mainImageUrl"),
+			Brand:    getStringValue(result, "brand"),
+			URL:      getStringValue(result, "url"),
+		},
+		ASIN: asin,
+	}
+
+	if priceData, ok := result["price"].(map[string]interface{}); ok {
+		if priceValue, ok := priceData["value"].(float64); ok && priceValue > 0 {
+			offer.Price = priceValue
+			offer.InStock = true
+		}
+		offer.Currency = getStringValue(priceData, "currency")
+	}
+
+	return offer, nil
+}
+
+// asinPattern reconnaît l'ASIN dans les différentes formes d'URL Amazon
+var asinPattern = regexp.MustCompile(`(?i)/(?:dp|gp/product|gp/aw/d|product|exec/obidos/asin)/([A-Z0-9]{10})(?:[/?#]|$)`)
+
+// ExtractASIN extrait l'ASIN d'une URL de produit Amazon
+func ExtractASIN(link string) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || parsed.Host == "" {
+		return "", false
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if !strings.Contains(host, "amazon.") {
+		return "", false
+	}
+
+	match := asinPattern.FindStringSubmatch(parsed.Path)
+	if match == nil {
+		return "", false
+	}
+	return strings.ToUpper(match[1]), true
+}
+
 // GetAmazonProductsByKeyword recherche des produits Amazon par mot-clé avec pagination
 func (c *CanopyClient) GetAmazonProductsByKeyword(keyword string, limit int) ([]Product, error) {
 	// Si limit est négatif ou non spécifié (0), utiliser 100 par défaut
@@ -0,0 +1,350 @@
+package unfurl
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// pageMetadata is what Parse collects from a page before merging it
+type pageMetadata struct {
+	meta      map[string]string // OpenGraph and product meta tags, first occurrence wins
+	title     string
+	canonical string
+	jsonLD    []string
+}
+
+// Parse extracts the product metadata of an HTML page.
+// JSON-LD Product data wins over OpenGraph tags, which win over the page title.
+func Parse(r io.Reader, base *url.URL) (*Preview, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &pageMetadata{meta: make(map[string]string)}
+	page.collect(doc)
+
+	preview := &Preview{URL: base.String()}
+	if page.canonical != "" {
+		preview.URL = resolve(base, page.canonical)
+	}
+
+	for _, raw := range page.jsonLD {
+		if product := findProduct(raw); product != nil {
+			applyJSONLD(preview, product, base)
+			preview.Source = SourceJSONLD
+			break
+		}
+	}
+
+	applyOpenGraph(preview, page.meta, base)
+	if preview.Name == "" {
+		preview.Name = strings.TrimSpace(page.title)
+	}
+	if preview.Source == "" {
+		preview.Source = SourceTitle
+	}
+
+	if preview.Name == "" {
+		return nil, ErrNoMetadata
+	}
+	return preview, nil
+}
+
+// collect walks the document and records the interesting nodes
+func (p *pageMetadata) collect(n *html.Node) {
+	if n.Type == html.ElementNode {
+		switch n.DataAtom {
+		case atom.Meta:
+			key := strings.ToLower(attr(n, "property"))
+			if key == "" {
+				key = strings.ToLower(attr(n, "name"))
+			}
+			if key == "" {
+				key = strings.ToLower(attr(n, "itemprop"))
+			}
+			if content := strings.TrimSpace(attr(n, "content")); key != "" && content != "" {
+				if _, seen := p.meta[key]; !seen {
+					p.meta[key] = content
+				}
+			}
+		case atom.Title:
+			if p.title == "" && n.FirstChild != nil {
+				p.title = n.FirstChild.Data
+			}
+		case atom.Link:
+			if strings.EqualFold(attr(n, "rel"), "canonical") && p.canonical == "" {
+				p.canonical = attr(n, "href")
+			}
+		case atom.Script:
+			if strings.EqualFold(strings.TrimSpace(attr(n, "type")), "application/ld+json") && n.FirstChild != nil {
+				p.jsonLD = append(p.jsonLD, n.FirstChild.Data)
+			}
+		}
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		p.collect(child)
+	}
+}
+
+// attr returns the value of an attribute of a node
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, name) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// findProduct returns the first Product object of a JSON-LD document,
+// looking into arrays and @graph containers
+func findProduct(raw string) map[string]interface{} {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		return nil
+	}
+	return searchProduct(doc, 0)
+}
+
+func searchProduct(v interface{}, depth int) map[string]interface{} {
+	if depth > 5 {
+		return nil
+	}
+
+	switch node := v.(type) {
+	case []interface{}:
+		for _, entry := range node {
+			if product := searchProduct(entry, depth+1); product != nil {
+				return product
+			}
+		}
+	case map[string]interface{}:
+		if hasType(node["@type"], "Product") {
+			return node
+		}
+		if graph, ok := node["@graph"]; ok {
+			return searchProduct(graph, depth+1)
+		}
+		// Some sites wrap the product in the page entity
+		if entity, ok := node["mainEntity"]; ok {
+			return searchProduct(entity, depth+1)
+		}
+	}
+	return nil
+}
+
+// hasType reports whether a JSON-LD @type, a string or a list, contains want
+func hasType(v interface{}, want string) bool {
+	switch t := v.(type) {
+	case string:
+		return strings.EqualFold(t, want) || strings.HasSuffix(t, "/"+want)
+	case []interface{}:
+		for _, entry := range t {
+			if hasType(entry, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyJSONLD copies the fields of a JSON-LD Product
+func applyJSONLD(preview *Preview, product map[string]interface{}, base *url.URL) {
+	preview.Name = stringValue(product["name"])
+	preview.Description = stringValue(product["description"])
+	if image := imageValue(product["image"]); image != "" {
+		preview.ImageURL = resolve(base, image)
+	}
+
+	offer := firstOffer(product["offers"])
+	if offer == nil {
+		return
+	}
+
+	price := offer["price"]
+	if price == nil {
+		price = offer["lowPrice"] // AggregateOffer
+	}
+	if value, ok := priceValue(price); ok {
+		preview.Price = value
+	}
+	preview.Currency = strings.ToUpper(stringValue(offer["priceCurrency"]))
+
+	// Some sites nest the price in a PriceSpecification
+	if preview.Price == 0 {
+		if spec, ok := offer["priceSpecification"].(map[string]interface{}); ok {
+			if value, ok := priceValue(spec["price"]); ok {
+				preview.Price = value
+			}
+			if preview.Currency == "" {
+				preview.Currency = strings.ToUpper(stringValue(spec["priceCurrency"]))
+			}
+		}
+	}
+}
+
+// firstOffer returns the first offer of an "offers" value
+func firstOffer(v interface{}) map[string]interface{} {
+	switch offers := v.(type) {
+	case map[string]interface{}:
+		return offers
+	case []interface{}:
+		for _, entry := range offers {
+			if offer, ok := entry.(map[string]interface{}); ok {
+				return offer
+			}
+		}
+	}
+	return nil
+}
+
+// applyOpenGraph fills the fields still missing from OpenGraph and product meta tags
+func applyOpenGraph(preview *Preview, meta map[string]string, base *url.URL) {
+	filled := false
+
+	if preview.Name == "" {
+		preview.Name = firstOf(meta, "og:title", "twitter:title", "name")
+		filled = filled || preview.Name != ""
+	}
+	if preview.Description == "" {
+		preview.Description = firstOf(meta, "og:description", "twitter:description", "description")
+	}
+	if preview.ImageURL == "" {
+		if image := firstOf(meta, "og:image:secure_url", "og:image", "twitter:image", "image"); image != "" {
+			preview.ImageURL = resolve(base, image)
+			filled = true
+		}
+	}
+	if preview.Price == 0 {
+		if value, ok := priceValue(firstOf(meta, "product:price:amount", "og:price:amount", "price")); ok {
+			preview.Price = value
+			filled = true
+		}
+	}
+	if preview.Currency == "" {
+		preview.Currency = strings.ToUpper(firstOf(meta, "product:price:currency", "og:price:currency", "pricecurrency"))
+	}
+
+	if filled && preview.Source == "" {
+		preview.Source = SourceOpenGraph
+	}
+}
+
+// firstOf returns the first non-empty meta value among keys
+func firstOf(meta map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value := meta[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// stringValue returns a JSON-LD text value
+func stringValue(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return strings.TrimSpace(html.UnescapeString(value))
+	case []interface{}:
+		if len(value) > 0 {
+			return stringValue(value[0])
+		}
+	case map[string]interface{}:
+		return stringValue(value["@value"])
+	}
+	return ""
+}
+
+// imageValue returns the URL of a JSON-LD image: a string, a list or an ImageObject
+func imageValue(v interface{}) string {
+	switch image := v.(type) {
+	case string:
+		return strings.TrimSpace(image)
+	case []interface{}:
+		for _, entry := range image {
+			if value := imageValue(entry); value != "" {
+				return value
+			}
+		}
+	case map[string]interface{}:
+		if value := imageValue(image["url"]); value != "" {
+			return value
+		}
+		return imageValue(image["contentUrl"])
+	}
+	return ""
+}
+
+// priceValue parses a price given as a number or as text such as "1 299,99 €"
+func priceValue(v interface{}) (float64, bool) {
+	switch price := v.(type) {
+	case float64:
+		return price, price > 0
+	case string:
+		return ParsePrice(price)
+	}
+	return 0, false
+}
+
+// ParsePrice parses a localized price such as "1 299,99 €" or "$24.99".
+// When both separators are present the last one is the decimal separator;
+// a lone comma followed by one or two digits is a decimal comma.
+func ParsePrice(text string) (float64, bool) {
+	var b strings.Builder
+	for _, r := range text {
+		if (r >= '0' && r <= '9') || r == '.' || r == ',' {
+			b.WriteRune(r)
+		}
+	}
+	cleaned := b.String()
+	if cleaned == "" {
+		return 0, false
+	}
+
+	lastDot := strings.LastIndex(cleaned, ".")
+	lastComma := strings.LastIndex(cleaned, ",")
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		if lastComma > lastDot {
+			cleaned = strings.ReplaceAll(cleaned, ".", "")
+			cleaned = strings.Replace(cleaned, ",", ".", 1)
+		} else {
+			cleaned = strings.ReplaceAll(cleaned, ",", "")
+		}
+	case lastComma >= 0:
+		if decimals := len(cleaned) - lastComma - 1; strings.Count(cleaned, ",") == 1 && decimals > 0 && decimals <= 2 {
+			cleaned = strings.Replace(cleaned, ",", ".", 1)
+		} else {
+			cleaned = strings.ReplaceAll(cleaned, ",", "")
+		}
+	case strings.Count(cleaned, ".") > 1:
+		cleaned = strings.ReplaceAll(cleaned, ".", "")
+	}
+
+	value, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+	return value, true
+}
+
+// resolve makes a possibly relative reference absolute
+func resolve(base *url.URL, ref string) string {
+	parsed, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ""
+	}
+	resolved := base.ResolveReference(parsed)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ""
+	}
+	return resolved.String()
+}
@@ -0,0 +1,150 @@
+package unfurl
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		pageURL string
+		want    Preview
+		wantErr error
+	}{
+		{
+			fixture: "opengraph.html",
+			pageURL: "https://www.boutique-son.example/p/123?ref=home",
+			want: Preview{
+				URL:         "https://www.boutique-son.example/produits/casque-sans-fil-noir",
+				Name:        "Casque audio sans fil à réduction de bruit",
+				Description: "Jusqu'à 30 heures d'autonomie, réduction de bruit active.",
+				ImageURL:    "https://www.boutique-son.example/media/casque-noir.jpg",
+				Price:       1299.99,
+				Currency:    "EUR",
+				Source:      SourceOpenGraph,
+			},
+		},
+		{
+			fixture: "jsonld.html",
+			pageURL: "https://www.toyshop.example/lego/bonsai",
+			want: Preview{
+				URL:         "https://www.toyshop.example/lego/bonsai",
+				Name:        "LEGO Botanical Bonsai Tree 10281 & Display Stand",
+				Description: "Build a bonsai tree with pink cherry blossoms.",
+				ImageURL:    "https://www.toyshop.example/images/bonsai-main.jpg",
+				Price:       49.99,
+				Currency:    "USD",
+				Source:      SourceJSONLD,
+			},
+		},
+		{
+			fixture: "jsonld_aggregate.html",
+			pageURL: "https://www.sport.example/sac-30l",
+			want: Preview{
+				URL:      "https://www.sport.example/sac-30l",
+				Name:     "Sac à dos randonnée 30 L",
+				ImageURL: "https://static.sport.example/sac-30l.jpg",
+				Price:    34.9,
+				Currency: "EUR",
+				Source:   SourceJSONLD,
+			},
+		},
+		{
+			fixture: "jsonld_price_specification.html",
+			pageURL: "https://shop.example/barista-pro",
+			want: Preview{
+				URL:      "https://shop.example/barista-pro",
+				Name:     "Kaffeemaschine Barista Pro",
+				ImageURL: "https://shop.example/img/barista.png",
+				Price:    1049,
+				Currency: "EUR",
+				Source:   SourceJSONLD,
+			},
+		},
+		{
+			fixture: "amazon.html",
+			pageURL: "https://www.amazon.fr/Nintendo-Switch-OLED-Blanche/dp/B098RJXBTY/ref=sr_1_1?keywords=switch",
+			want: Preview{
+				URL:         "https://www.amazon.fr/Nintendo-Switch-OLED-Blanche/dp/B098RJXBTY",
+				Name:        "Nintendo Switch – Modèle OLED avec station d'accueil et manettes Joy-Con Blanches : Amazon.fr: Jeux vidéo",
+				Description: "Nintendo Switch – Modèle OLED : Amazon.fr: Jeux vidéo",
+				Source:      SourceTitle,
+			},
+		},
+		{
+			fixture: "no_metadata.html",
+			pageURL: "https://example.com/",
+			wantErr: ErrNoMetadata,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			file, err := os.Open(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			base, err := url.Parse(tt.pageURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			preview, err := Parse(file, base)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *preview != tt.want {
+				t.Errorf("got  %+v\nwant %+v", *preview, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		text   string
+		want   float64
+		wantOK bool
+	}{
+		{"24.99", 24.99, true},
+		{"$24.99", 24.99, true},
+		{"24,99 €", 24.99, true},
+		{"1 299,99 €", 1299.99, true},
+		{"1.299,99", 1299.99, true},
+		{"1,299.99", 1299.99, true},
+		{"1,299", 1299, true},
+		{"1.234.567", 1234567, true},
+		{"EUR 5", 5, true},
+		{"0", 0, false},
+		{"gratuit", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParsePrice(tt.text)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("ParsePrice(%q) = %v, %v; want %v, %v", tt.text, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestResolveRejectsNonHTTPSchemes(t *testing.T) {
+	base, _ := url.Parse("https://shop.example/product")
+	for _, ref := range []string{"javascript:alert(1)", "data:image/png;base64,AAAA", "file:///etc/passwd"} {
+		if got := resolve(base, ref); got != "" {
+			t.Errorf("resolve(%q) = %q, want empty", ref, got)
+		}
+	}
+}
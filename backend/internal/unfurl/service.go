@@ -0,0 +1,232 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"genie/internal/config"
+	"genie/internal/scraper"
+)
+
+// Where the data of a preview comes from
+const (
+	SourceAmazon    = "amazon"
+	SourceJSONLD    = "json-ld"
+	SourceOpenGraph = "opengraph"
+	SourceTitle     = "title"
+)
+
+var (
+	ErrInvalidURL         = errors.New("invalid product URL")
+	ErrBlockedHost        = errors.New("host not allowed")
+	ErrRateLimited        = errors.New("too many requests to this host, try again later")
+	ErrUnsupportedContent = errors.New("URL does not point to a web page")
+	ErrNoMetadata         = errors.New("no product information found at this URL")
+)
+
+// Preview is the product information extracted from a URL
+type Preview struct {
+	URL         string  `json:"url"`
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	ImageURL    string  `json:"imageUrl,omitempty"`
+	Price       float64 `json:"price,omitempty"`
+	Currency    string  `json:"currency,omitempty"`
+	Source      string  `json:"source"`
+}
+
+// cacheEntry is a cached preview
+type cacheEntry struct {
+	preview   Preview
+	expiresAt time.Time
+}
+
+// Service turns product URLs into previews
+type Service struct {
+	cfg    config.UnfurlConfig
+	client *http.Client
+	canopy *scraper.CanopyClient
+
+	cacheMu sync.Mutex
+	cache   map[string]cacheEntry
+
+	hostsMu   sync.Mutex
+	nextSlots map[string]time.Time // next time each host may be contacted
+}
+
+// NewService creates a new unfurl service. canopy may be nil, in which case
+// Amazon pages are parsed like any other page.
+func NewService(cfg config.UnfurlConfig, canopy *scraper.CanopyClient) *Service {
+	return &Service{
+		cfg:       cfg,
+		client:    newHTTPClient(cfg.Timeout),
+		canopy:    canopy,
+		cache:     make(map[string]cacheEntry),
+		nextSlots: make(map[string]time.Time),
+	}
+}
+
+// Unfurl returns the product information behind a URL
+func (s *Service) Unfurl(ctx context.Context, rawURL string) (*Preview, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+	if err := validateURL(u); err != nil {
+		return nil, err
+	}
+	u.Fragment = ""
+	u.Host = strings.ToLower(u.Host)
+	key := u.String()
+
+	if preview, ok := s.cached(key); ok {
+		return preview, nil
+	}
+
+	preview, err := s.unfurl(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	s.store(key, preview)
+	return preview, nil
+}
+
+// unfurl resolves a URL without the cache: Amazon products through Canopy,
+// every other page by parsing its metadata
+func (s *Service) unfurl(ctx context.Context, u *url.URL) (*Preview, error) {
+	if asin, ok := scraper.ExtractASIN(u.String()); ok && s.canopy != nil {
+		preview, err := s.fromAmazon(asin, u)
+		if err == nil {
+			return preview, nil
+		}
+		log.Warn().Err(err).Str("asin", asin).Msg("Failed to resolve Amazon product, parsing the page instead")
+	}
+
+	if err := s.waitForHost(ctx, u.Hostname()); err != nil {
+		return nil, err
+	}
+	return s.fetchPage(ctx, u)
+}
+
+// fromAmazon resolves an Amazon product by ASIN
+func (s *Service) fromAmazon(asin string, u *url.URL) (*Preview, error) {
+	product, err := s.canopy.GetAmazonProduct(asin)
+	if err != nil {
+		return nil, err
+	}
+	// The keyword search behind GetAmazonProduct may fall back to another product
+	if product.ID != "amazon_"+asin {
+		return nil, fmt.Errorf("canopy returned %s instead of %s", product.ID, asin)
+	}
+
+	preview := &Preview{
+		URL:         u.String(),
+		Name:        product.Title,
+		Description: product.Description,
+		ImageURL:    product.ImageURL,
+		Price:       product.Price,
+		Currency:    product.Currency,
+		Source:      SourceAmazon,
+	}
+	if product.URL != "" {
+		preview.URL = product.URL
+	}
+	return preview, nil
+}
+
+// waitForHost spaces out the requests made to a host. It waits for the
+// host's next slot, or fails when that slot is too far away.
+func (s *Service) waitForHost(ctx context.Context, host string) error {
+	now := time.Now()
+
+	s.hostsMu.Lock()
+	slot := s.nextSlots[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	wait := slot.Sub(now)
+	if wait > s.cfg.MaxHostWait {
+		s.hostsMu.Unlock()
+		return ErrRateLimited
+	}
+	s.nextSlots[host] = slot.Add(s.cfg.HostInterval)
+
+	// Forget hosts that have been idle for a while
+	for h, next := range s.nextSlots {
+		if next.Before(now) {
+			delete(s.nextSlots, h)
+		}
+	}
+	s.hostsMu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cached returns a copy of a cached preview that has not expired
+func (s *Service) cached(key string) (*Preview, bool) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	entry, ok := s.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(s.cache, key)
+		return nil, false
+	}
+
+	preview := entry.preview
+	return &preview, true
+}
+
+// store caches a preview, making room by dropping expired entries first
+// and then the entry closest to expiry
+func (s *Service) store(key string, preview *Preview) {
+	if s.cfg.CacheSize <= 0 || s.cfg.CacheTTL <= 0 {
+		return
+	}
+
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	now := time.Now()
+	if len(s.cache) >= s.cfg.CacheSize {
+		var oldestKey string
+		var oldest time.Time
+		for k, entry := range s.cache {
+			if now.After(entry.expiresAt) {
+				delete(s.cache, k)
+				continue
+			}
+			if oldestKey == "" || entry.expiresAt.Before(oldest) {
+				oldestKey, oldest = k, entry.expiresAt
+			}
+		}
+		if len(s.cache) >= s.cfg.CacheSize {
+			delete(s.cache, oldestKey)
+		}
+	}
+
+	s.cache[key] = cacheEntry{preview: *preview, expiresAt: now.Add(s.cfg.CacheTTL)}
+}
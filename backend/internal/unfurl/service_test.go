@@ -0,0 +1,92 @@
+package unfurl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"genie/internal/scraper"
+)
+
+// canopyStub serves a recorded Canopy response
+func canopyStub(t *testing.T, fixture string) *scraper.CanopyClient {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	client := scraper.NewCanopyClient("test-key")
+	client.Endpoint = server.URL
+	client.HTTPClient = server.Client()
+	return client
+}
+
+func TestFromAmazon(t *testing.T) {
+	s := testService()
+	s.canopy = canopyStub(t, "canopy_product.json")
+	u, _ := url.Parse("https://www.amazon.fr/Nintendo-Switch-OLED-Blanche/dp/B098RJXBTY/ref=sr_1_1")
+
+	asin, ok := scraper.ExtractASIN(u.String())
+	if !ok || asin != "B098RJXBTY" {
+		t.Fatalf("ExtractASIN = %q, %v", asin, ok)
+	}
+
+	preview, err := s.fromAmazon(asin, u)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Preview{
+		URL:      "https://www.amazon.fr/dp/B098RJXBTY",
+		Name:     "Nintendo Switch – Modèle OLED avec station d'accueil et manettes Joy-Con Blanches",
+		ImageURL: "https://m.media-amazon.com/images/I/51yJ+nVfXOL.jpg",
+		Price:    319.99,
+		Currency: "EUR",
+		Source:   SourceAmazon,
+	}
+	if *preview != want {
+		t.Errorf("got  %+v\nwant %+v", *preview, want)
+	}
+}
+
+func TestFromAmazonRejectsAnotherProduct(t *testing.T) {
+	s := testService()
+	// The keyword search returned another product than the one asked for
+	s.canopy = canopyStub(t, "canopy_other_product.json")
+	u, _ := url.Parse("https://www.amazon.fr/dp/B098RJXBTY")
+
+	if _, err := s.fromAmazon("B098RJXBTY", u); err == nil {
+		t.Fatal("preview built from another product")
+	}
+}
+
+func TestUnfurlUsesCache(t *testing.T) {
+	s := testService()
+	preview := &Preview{URL: "https://shop.example/p", Name: "Cached product", Source: SourceOpenGraph}
+	s.store("https://shop.example/p", preview)
+
+	// Host case and fragment do not change the cache key
+	got, err := s.Unfurl(context.Background(), "https://SHOP.example/p#reviews")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != "Cached product" {
+		t.Errorf("got %+v, want the cached preview", got)
+	}
+
+	// Callers get a copy
+	got.Name = "changed"
+	again, _ := s.Unfurl(context.Background(), "https://shop.example/p")
+	if again.Name != "Cached product" {
+		t.Error("cached preview modified through a returned copy")
+	}
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"time"
 
 	"genie/internal/config"
 	"github.com/rs/zerolog/log"
@@ -122,6 +123,38 @@ func (s *EmailService) SendPasswordReset(to, code string) error {
 	return s.SendEmail(to, subject, plainContent, htmlContent)
 }
 
+// SendMagicLink envoie un lien de connexion sans mot de passe
+func (s *EmailService) SendMagicLink(to, link string, validity time.Duration) error {
+	minutes := int(validity.Minutes())
+	subject := "Votre lien de connexion"
+	plainContent := fmt.Sprintf("Bonjour,\n\nPour vous connecter, ouvrez ce lien sur l'appareil depuis lequel vous avez fait la demande :\n%s\n\nCe lien est valable pendant %d minutes et ne peut servir qu'une fois. Si vous n'avez pas demandé à vous connecter, ignorez ce message.\n\nL'équipe de l'application", link, minutes)
+
+	htmlContent := fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>Votre lien de connexion</title>
+	</head>
+	<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+		<div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+			<h1 style="color: #4a6ee0;">Connexion</h1>
+			<p>Bonjour,</p>
+			<p>Pour vous connecter, ouvrez ce lien sur l'appareil depuis lequel vous avez fait la demande :</p>
+			<div style="text-align: center; margin: 20px 0;">
+				<a href="%s" style="background-color: #4a6ee0; color: #fff; padding: 12px 24px; text-decoration: none; border-radius: 4px; font-weight: bold;">Se connecter</a>
+			</div>
+			<p>Ce lien est valable pendant <strong>%d minutes</strong> et ne peut servir qu'une fois.</p>
+			<p>Si vous n'avez pas demandé à vous connecter, ignorez ce message.</p>
+			<p>Cordialement,<br>L'équipe de l'application</p>
+		</div>
+	</body>
+	</html>
+	`, link, minutes)
+
+	return s.SendEmail(to, subject, plainContent, htmlContent)
+}
+
 // SendVerificationCode envoie un code de vérification par email
 func (s *EmailService) SendVerificationCode(to, code, purpose string) error {
 	var subject, plainContent, htmlContent string
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"time"
 
 	"genie/internal/config"
 	"github.com/rs/zerolog/log"
@@ -87,6 +88,12 @@ func (s *SMSService) SendPasswordReset(to string, code string) error {
 	return s.SendSMS(to, message)
 }
 
+// SendLoginCode envoie un code de connexion sans mot de passe
+func (s *SMSService) SendLoginCode(to string, code string, validity time.Duration) error {
+	message := fmt.Sprintf("Votre code de connexion est : %s. Valable pendant %d minutes. Ne le communiquez à personne.", code, int(validity.Minutes()))
+	return s.SendSMS(to, message)
+}
+
 // SendVerificationCode envoie un code de vérification par SMS
 func (s *SMSService) SendVerificationCode(to string, code string, purpose string) error {
 	var message string
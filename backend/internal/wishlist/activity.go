@@ -0,0 +1,120 @@
+package wishlist
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"genie/internal/models"
+)
+
+// recordActivity ajoute une entrée au fil d'activité d'une wishlist.
+// L'enregistrement ne fait jamais échouer l'opération qu'il décrit.
+func (s *Service) recordActivity(ctx context.Context, entry models.WishlistActivity) {
+	if s.activity == nil {
+		return
+	}
+	s.activity.Record(ctx, entry)
+}
+
+// ActivityAccess indique si un utilisateur peut suivre l'activité d'une wishlist et s'il en voit
+// les réservations. Renvoie mongo.ErrNoDocuments si la wishlist n'existe pas.
+func (s *Service) ActivityAccess(ctx context.Context, wishlistID, userID primitive.ObjectID) (bool, bool, error) {
+	wishlist, err := s.getWishlistByID(ctx, wishlistID)
+	if err != nil {
+		return false, false, err
+	}
+	if !s.can(ctx, wishlist, userID, ActionView) {
+		return false, false, nil
+	}
+	return true, seesReservations(wishlist, userID), nil
+}
+
+// ActivityRecipients renvoie les membres d'une wishlist à prévenir en direct de son activité :
+// le propriétaire et les collaborateurs qui ont accepté le partage. Une activité privée n'est
+// envoyée qu'à ceux qui voient les réservations.
+func (s *Service) ActivityRecipients(ctx context.Context, wishlistID primitive.ObjectID, private bool) ([]primitive.ObjectID, error) {
+	wishlist, err := s.getWishlistByID(ctx, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := []primitive.ObjectID{wishlist.UserID}
+	for _, share := range wishlist.SharedWith {
+		if share.Status == ShareStatusAccepted {
+			members = append(members, share.UserID)
+		}
+	}
+
+	recipients := []primitive.ObjectID{}
+	for _, member := range members {
+		if !Can(roleOf(wishlist, member), ActionView) {
+			continue
+		}
+		if private && !seesReservations(wishlist, member) {
+			continue
+		}
+		recipients = append(recipients, member)
+	}
+	return recipients, nil
+}
+
+// recordItemActivity enregistre une activité sur un item dont seul l'ID est connu
+func (s *Service) recordItemActivity(ctx context.Context, kind models.WishlistActivityType, itemID primitive.ObjectID, actorID primitive.ObjectID) {
+	if s.activity == nil {
+		return
+	}
+	item, err := s.getWishItemByID(ctx, itemID)
+	if err != nil {
+		log.Warn().Err(err).Str("itemID", itemID.Hex()).Msg("Impossible d'enregistrer l'activité de l'item")
+		return
+	}
+	s.recordActivity(ctx, itemActivity(kind, item, actorID))
+}
+
+// wishlistActivity prépare une activité portant sur la wishlist elle-même
+func wishlistActivity(kind models.WishlistActivityType, wishlistID primitive.ObjectID, actorID primitive.ObjectID) models.WishlistActivity {
+	return models.WishlistActivity{
+		WishlistID: wishlistID,
+		Type:       kind,
+		ActorID:    objectIDRef(actorID),
+		CreatedAt:  time.Now(),
+	}
+}
+
+// itemActivity prépare une activité portant sur un item.
+// Les réservations sont privées : le mode surprise décide si le propriétaire les voit.
+func itemActivity(kind models.WishlistActivityType, item *models.WishItem, actorID primitive.ObjectID) models.WishlistActivity {
+	entry := wishlistActivity(kind, item.WishlistID, actorID)
+	entry.ItemID = &item.ID
+	entry.ItemName = item.Name
+	switch kind {
+	case models.ActivityItemReserved, models.ActivityReservationCancelled, models.ActivityItemPurchased:
+		entry.Private = true
+	}
+	return entry
+}
+
+// changedFields liste les champs modifiés par une mise à jour, hors date de modification
+func changedFields(update bson.M) []string {
+	fields := []string{}
+	for field := range update {
+		if field != "updatedAt" {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// objectIDRef renvoie un pointeur vers l'ID, ou nil pour un ID vide (invité sans compte)
+func objectIDRef(id primitive.ObjectID) *primitive.ObjectID {
+	if id.IsZero() {
+		return nil
+	}
+	return &id
+}
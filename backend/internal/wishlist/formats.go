@@ -0,0 +1,475 @@
+package wishlist
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"genie/internal/models"
+	"genie/internal/unfurl"
+)
+
+// Formats d'import et d'export des wishlists
+const (
+	FormatJSON      = "json"
+	FormatCSV       = "csv"        // colonnes de l'export CSV de Genie
+	FormatAmazon    = "amazon"     // page HTML d'une liste d'envies Amazon enregistrée
+	FormatMappedCSV = "mapped_csv" // CSV quelconque, avec la correspondance des colonnes
+)
+
+// exportVersion est la version du format d'export JSON
+const exportVersion = 1
+
+// csvColumns est l'en-tête des exports CSV, relu par l'import csv
+var csvColumns = models.CSVColumnMapping{
+	Name:        "name",
+	Description: "description",
+	Price:       "price",
+	Currency:    "currency",
+	ImageURL:    "image_url",
+	Link:        "link",
+	Priority:    "priority",
+	Quantity:    "quantity",
+	Section:     "section",
+}
+
+// importedRow est une ligne lue dans un fichier importé, avant validation
+type importedRow struct {
+	item     models.TemplateItem
+	price    string // prix brut, validé avec la ligne
+	quantity string // quantité brute, validée avec la ligne
+}
+
+// importedList est le contenu lu dans un fichier importé
+type importedList struct {
+	title       string
+	description string
+	sections    []string
+	rows        []importedRow
+}
+
+// IsValidExportFormat indique si un format d'export est connu
+func IsValidExportFormat(format string) bool {
+	return format == FormatJSON || format == FormatCSV
+}
+
+// IsValidImportFormat indique si un format d'import est connu
+func IsValidImportFormat(format string) bool {
+	switch format {
+	case FormatJSON, FormatCSV, FormatAmazon, FormatMappedCSV:
+		return true
+	}
+	return false
+}
+
+// WriteExportCSV écrit les items d'un export au format CSV, avec l'en-tête de csvColumns
+func WriteExportCSV(w io.Writer, export *models.WishlistExport) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		csvColumns.Name, csvColumns.Description, csvColumns.Price, csvColumns.Currency,
+		csvColumns.ImageURL, csvColumns.Link, csvColumns.Priority, csvColumns.Quantity, csvColumns.Section,
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, item := range export.Items {
+		price := ""
+		if item.Price > 0 {
+			price = strconv.FormatFloat(item.Price, 'f', 2, 64)
+		}
+		quantity := ""
+		if item.Quantity > 0 {
+			quantity = strconv.Itoa(item.Quantity)
+		}
+		record := []string{
+			item.Name, item.Description, price, item.Currency,
+			item.ImageURL, item.Link, item.Priority, quantity, item.Section,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// parseImport lit un fichier importé selon son format
+func parseImport(format string, data []byte, mapping *models.CSVColumnMapping) (*importedList, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // BOM des exports Excel
+
+	switch format {
+	case FormatJSON:
+		return parseJSONImport(data)
+	case FormatCSV:
+		return parseCSVImport(data, csvColumns)
+	case FormatMappedCSV:
+		if mapping == nil || strings.TrimSpace(mapping.Name) == "" {
+			return nil, errors.New("correspondance des colonnes invalide")
+		}
+		return parseCSVImport(data, *mapping)
+	case FormatAmazon:
+		return parseAmazonImport(data)
+	}
+	return nil, errors.New("format d'import invalide")
+}
+
+// parseJSONImport relit un export JSON de Genie
+func parseJSONImport(data []byte) (*importedList, error) {
+	var export models.WishlistExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, errors.New("fichier illisible")
+	}
+
+	list := &importedList{
+		title:       export.Title,
+		description: export.Description,
+		sections:    export.Sections,
+		rows:        make([]importedRow, 0, len(export.Items)),
+	}
+	for _, item := range export.Items {
+		list.rows = append(list.rows, importedRow{item: item})
+	}
+	return list, nil
+}
+
+// parseCSVImport lit un CSV dont les colonnes sont désignées par leur en-tête.
+// Le séparateur (virgule, point-virgule ou tabulation) est déduit de l'en-tête.
+func parseCSVImport(data []byte, mapping models.CSVColumnMapping) (*importedList, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = csvSeparator(data)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("fichier illisible")
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	// Position de chaque champ demandé ; -1 pour un champ non importé
+	index := func(name string) (int, error) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return -1, nil
+		}
+		i, found := columns[name]
+		if !found {
+			return -1, fmt.Errorf("colonne introuvable : %s", name)
+		}
+		return i, nil
+	}
+	fields := []string{
+		mapping.Name, mapping.Description, mapping.Price, mapping.Currency,
+		mapping.ImageURL, mapping.Link, mapping.Priority, mapping.Quantity, mapping.Section,
+	}
+	positions := make([]int, len(fields))
+	for i, name := range fields {
+		if positions[i], err = index(name); err != nil {
+			return nil, err
+		}
+	}
+
+	list := &importedList{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("fichier illisible")
+		}
+		value := func(field int) string {
+			if positions[field] < 0 || positions[field] >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[positions[field]])
+		}
+		list.rows = append(list.rows, importedRow{
+			item: models.TemplateItem{
+				Name:        value(0),
+				Description: value(1),
+				Currency:    strings.ToUpper(value(3)),
+				ImageURL:    value(4),
+				Link:        value(5),
+				Priority:    strings.ToLower(value(6)),
+				Section:     value(8),
+			},
+			price:    value(2),
+			quantity: value(7),
+		})
+	}
+	return list, nil
+}
+
+// csvSeparator déduit le séparateur d'un CSV de sa première ligne
+func csvSeparator(data []byte) rune {
+	firstLine := data
+	if end := bytes.IndexByte(data, '\n'); end >= 0 {
+		firstLine = data[:end]
+	}
+	separator, count := ',', bytes.Count(firstLine, []byte(","))
+	for _, candidate := range []rune{';', '\t'} {
+		if n := bytes.Count(firstLine, []byte(string(candidate))); n > count {
+			separator, count = candidate, n
+		}
+	}
+	return separator
+}
+
+// amazonPriorities convertit les priorités d'une liste Amazon
+var amazonPriorities = map[string]string{
+	"highest": PriorityHigh,
+	"high":    PriorityHigh,
+	"medium":  PriorityMedium,
+	"low":     PriorityLow,
+	"lowest":  PriorityLow,
+}
+
+// amazonItem est un article lu dans une liste d'envies Amazon.
+// Les éléments d'un article portent un ID suffixé par celui de l'article (itemName_XXX, itemPrice_XXX...).
+type amazonItem struct {
+	name, href, price, image, comment, requested, priority string
+}
+
+// parseAmazonImport lit la page HTML d'une liste d'envies Amazon enregistrée depuis le navigateur
+func parseAmazonImport(data []byte) (*importedList, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("fichier illisible")
+	}
+
+	page := &amazonPage{items: make(map[string]*amazonItem)}
+	page.collect(doc)
+	if len(page.order) == 0 {
+		return nil, errors.New("aucun article trouvé dans la liste Amazon")
+	}
+
+	base := &url.URL{Scheme: "https", Host: "www.amazon.com"}
+	if canonical, err := url.Parse(page.canonical); err == nil && strings.Contains(canonical.Host, "amazon.") {
+		base = &url.URL{Scheme: "https", Host: canonical.Host}
+	}
+
+	list := &importedList{title: strings.TrimSpace(page.title)}
+	for _, id := range page.order {
+		item := page.items[id]
+		row := importedRow{
+			item: models.TemplateItem{
+				Name:        strings.TrimSpace(item.name),
+				Description: strings.TrimSpace(item.comment),
+				Priority:    amazonPriorities[strings.ToLower(strings.TrimSpace(item.priority))],
+				Currency:    currencyOf(item.price),
+				ImageURL:    item.image,
+			},
+			price:    item.price,
+			quantity: strings.TrimSpace(item.requested),
+		}
+		if item.href != "" {
+			if ref, err := url.Parse(item.href); err == nil {
+				link := base.ResolveReference(ref)
+				if match := amazonProductPath.FindStringSubmatch(link.Path); match != nil {
+					link = &url.URL{Scheme: "https", Host: link.Host, Path: "/dp/" + match[1]}
+				}
+				row.item.Link = link.String()
+			}
+		}
+		list.rows = append(list.rows, row)
+	}
+	return list, nil
+}
+
+// amazonPage rassemble les éléments d'une liste d'envies Amazon
+type amazonPage struct {
+	title     string
+	canonical string
+	order     []string
+	items     map[string]*amazonItem
+}
+
+// item renvoie l'article d'un suffixe d'ID, créé à sa première rencontre
+func (p *amazonPage) item(id string) *amazonItem {
+	item, found := p.items[id]
+	if !found {
+		item = &amazonItem{}
+		p.items[id] = item
+	}
+	return item
+}
+
+// collect parcourt le document et relève les éléments des articles
+func (p *amazonPage) collect(n *html.Node) {
+	if n.Type == html.ElementNode {
+		id := nodeAttr(n, "id")
+		switch {
+		case id == "profile-list-name":
+			p.title = nodeText(n)
+		case n.DataAtom == atom.Link && nodeAttr(n, "rel") == "canonical":
+			p.canonical = nodeAttr(n, "href")
+		case n.DataAtom == atom.A && strings.HasPrefix(id, "itemName_"):
+			key := strings.TrimPrefix(id, "itemName_")
+			if _, found := p.items[key]; !found || p.items[key].name == "" {
+				p.order = append(p.order, key)
+			}
+			item := p.item(key)
+			item.name = nodeAttr(n, "title")
+			if item.name == "" {
+				item.name = nodeText(n)
+			}
+			item.href = nodeAttr(n, "href")
+		case strings.HasPrefix(id, "itemPrice_"):
+			// Le prix lisible est dans .a-offscreen, le reste est sa mise en forme
+			text := nodeText(n)
+			if offscreen := findByClass(n, "a-offscreen"); offscreen != nil {
+				text = nodeText(offscreen)
+			}
+			p.item(strings.TrimPrefix(id, "itemPrice_")).price = text
+		case strings.HasPrefix(id, "itemImage_"):
+			if img := findElement(n, atom.Img); img != nil {
+				p.item(strings.TrimPrefix(id, "itemImage_")).image = nodeAttr(img, "src")
+			}
+		case strings.HasPrefix(id, "itemComment_"):
+			p.item(strings.TrimPrefix(id, "itemComment_")).comment = nodeText(n)
+		case strings.HasPrefix(id, "itemRequested_"):
+			p.item(strings.TrimPrefix(id, "itemRequested_")).requested = nodeText(n)
+		case strings.HasPrefix(id, "itemPriorityLabel_"):
+			p.item(strings.TrimPrefix(id, "itemPriorityLabel_")).priority = nodeText(n)
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		p.collect(child)
+	}
+}
+
+// nodeAttr renvoie la valeur d'un attribut d'un élément
+func nodeAttr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// nodeText renvoie le texte d'un élément, espaces normalisés
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// findElement renvoie le premier descendant d'un type donné
+func findElement(n *html.Node, tag atom.Atom) *html.Node {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == tag {
+			return child
+		}
+		if found := findElement(child, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findByClass renvoie le premier descendant portant une classe
+func findByClass(n *html.Node, class string) *html.Node {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode {
+			for _, c := range strings.Fields(nodeAttr(child, "class")) {
+				if c == class {
+					return child
+				}
+			}
+		}
+		if found := findByClass(child, class); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// currencyOf déduit la devise d'un prix affiché, vide si elle n'est pas reconnue
+func currencyOf(price string) string {
+	switch {
+	case strings.Contains(price, "€"):
+		return "EUR"
+	case strings.Contains(price, "£"):
+		return "GBP"
+	case strings.Contains(price, "CHF"):
+		return "CHF"
+	case strings.Contains(price, "$"):
+		return "USD"
+	}
+	return ""
+}
+
+// validateImportedRow vérifie une ligne importée et complète son item
+func validateImportedRow(row *importedRow) (models.TemplateItem, error) {
+	item := row.item
+	item.Name = strings.TrimSpace(item.Name)
+	if item.Name == "" {
+		return item, errors.New("nom manquant")
+	}
+
+	if row.price != "" {
+		price, ok := unfurl.ParsePrice(row.price)
+		if !ok {
+			return item, errors.New("prix invalide")
+		}
+		item.Price = price
+	}
+	if item.Price < 0 {
+		return item, errors.New("prix invalide")
+	}
+
+	if row.quantity != "" {
+		quantity, err := strconv.Atoi(row.quantity)
+		if err != nil {
+			return item, ErrInvalidQuantity
+		}
+		item.Quantity = quantity
+	}
+	if item.Quantity != 0 && !isValidQuantity(item.Quantity) {
+		return item, ErrInvalidQuantity
+	}
+
+	if item.Priority != "" && !isValidPriority(item.Priority) {
+		return item, errors.New("priorité invalide")
+	}
+	if item.Link != "" && !isHTTPURL(item.Link) {
+		return item, errors.New("lien invalide")
+	}
+	if item.ImageURL != "" && !isHTTPURL(item.ImageURL) {
+		// Une image locale ou intégrée (data:) n'est pas reprise
+		item.ImageURL = ""
+	}
+	return item, nil
+}
+
+// isHTTPURL indique si un lien est une URL http(s) absolue
+func isHTTPURL(link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
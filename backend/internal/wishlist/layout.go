@@ -0,0 +1,407 @@
+package wishlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"genie/internal/models"
+)
+
+// Niveaux de priorité d'un item
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+)
+
+// Limites de l'organisation d'une wishlist
+const (
+	maxSections       = 20
+	maxSectionNameLen = 50
+	maxItemQuantity   = 99
+)
+
+// isValidPriority vérifie qu'une priorité est connue
+func isValidPriority(priority string) bool {
+	switch priority {
+	case PriorityLow, PriorityMedium, PriorityHigh:
+		return true
+	}
+	return false
+}
+
+// itemPriority renvoie la priorité d'un item, medium par défaut
+func itemPriority(item *models.WishItem) string {
+	if item.Priority == "" {
+		return PriorityMedium
+	}
+	return item.Priority
+}
+
+// itemQuantity renvoie la quantité souhaitée d'un item, 1 par défaut
+func itemQuantity(item *models.WishItem) int {
+	if item.Quantity < 1 {
+		return 1
+	}
+	return item.Quantity
+}
+
+// isValidQuantity vérifie une quantité souhaitée
+func isValidQuantity(quantity int) bool {
+	return quantity >= 1 && quantity <= maxItemQuantity
+}
+
+// findSection renvoie la section d'une wishlist à partir de son ID
+func findSection(wishlist *models.Wishlist, sectionID primitive.ObjectID) *models.WishlistSection {
+	for i := range wishlist.Sections {
+		if wishlist.Sections[i].ID == sectionID {
+			return &wishlist.Sections[i]
+		}
+	}
+	return nil
+}
+
+// parseSectionID vérifie qu'un ID de section appartient à la wishlist ; un ID vide signifie hors section
+func parseSectionID(wishlist *models.Wishlist, sectionID string) (*primitive.ObjectID, error) {
+	if sectionID == "" {
+		return nil, nil
+	}
+	id, err := primitive.ObjectIDFromHex(sectionID)
+	if err != nil || findSection(wishlist, id) == nil {
+		return nil, errors.New("section not found")
+	}
+	return &id, nil
+}
+
+// fillLayout renseigne la section, la position et la priorité d'un item dans la réponse
+func fillLayout(response *models.WishItemResponse, item *models.WishItem, wishlist *models.Wishlist) {
+	response.Priority = itemPriority(item)
+	if item.SectionID != nil {
+		response.SectionID = item.SectionID.Hex()
+	}
+
+	response.Position = -1
+	if wishlist == nil {
+		return
+	}
+	for i, id := range wishlist.Items {
+		if id == item.ID {
+			response.Position = i
+			return
+		}
+	}
+	// Les items absents de l'ordre enregistré sont placés à la fin
+	response.Position = len(wishlist.Items)
+}
+
+// sortByPosition trie les items d'une wishlist dans leur ordre d'affichage
+func sortByPosition(responses []*models.WishItemResponse) {
+	sort.SliceStable(responses, func(i, j int) bool {
+		if responses[i].Position != responses[j].Position {
+			return responses[i].Position < responses[j].Position
+		}
+		return responses[i].CreatedAt.Before(responses[j].CreatedAt)
+	})
+}
+
+// layoutVersionFilter sélectionne une wishlist si sa layoutVersion n'a pas changé.
+// Les wishlists créées avant l'introduction de la version n'ont pas le champ.
+func layoutVersionFilter(wishlistID primitive.ObjectID, version int64) bson.M {
+	if version == 0 {
+		return bson.M{
+			"_id": wishlistID,
+			"$or": []bson.M{
+				{"layoutVersion": 0},
+				{"layoutVersion": bson.M{"$exists": false}},
+			},
+		}
+	}
+	return bson.M{"_id": wishlistID, "layoutVersion": version}
+}
+
+// CreateSection ajoute une section à la fin d'une wishlist
+func (s *Service) CreateSection(ctx context.Context, wishlistID string, userID primitive.ObjectID, req models.SectionRequest) (*models.WishlistSection, error) {
+	id, err := primitive.ObjectIDFromHex(wishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist, err := s.getWishlistByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.can(ctx, wishlist, userID, ActionEditList) {
+		return nil, errors.New("access denied")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len([]rune(name)) > maxSectionNameLen {
+		return nil, errors.New("nom de section invalide")
+	}
+
+	section := models.WishlistSection{
+		ID:   primitive.NewObjectID(),
+		Name: name,
+	}
+
+	// Le nombre de sections est vérifié dans le filtre pour rester exact sous concurrence
+	result, err := s.wishlistCol.UpdateOne(
+		ctx,
+		bson.M{"_id": id, fmt.Sprintf("sections.%d", maxSections-1): bson.M{"$exists": false}},
+		bson.M{
+			"$push": bson.M{"sections": section},
+			"$inc":  bson.M{"layoutVersion": 1},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, errors.New("nombre maximum de sections atteint")
+	}
+
+	s.recordActivity(ctx, wishlistActivity(models.ActivityLayoutChanged, id, userID))
+
+	return &section, nil
+}
+
+// RenameSection renomme une section d'une wishlist
+func (s *Service) RenameSection(ctx context.Context, wishlistID string, sectionID string, userID primitive.ObjectID, req models.SectionRequest) (*models.WishlistSection, error) {
+	id, err := primitive.ObjectIDFromHex(wishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist, err := s.getWishlistByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.can(ctx, wishlist, userID, ActionEditList) {
+		return nil, errors.New("access denied")
+	}
+
+	sid, err := parseSectionID(wishlist, sectionID)
+	if err != nil || sid == nil {
+		return nil, errors.New("section not found")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len([]rune(name)) > maxSectionNameLen {
+		return nil, errors.New("nom de section invalide")
+	}
+
+	result, err := s.wishlistCol.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "sections._id": *sid},
+		bson.M{"$set": bson.M{
+			"sections.$.name": name,
+			"updatedAt":       time.Now(),
+		}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, errors.New("section not found")
+	}
+
+	s.recordActivity(ctx, wishlistActivity(models.ActivityLayoutChanged, id, userID))
+
+	return &models.WishlistSection{ID: *sid, Name: name}, nil
+}
+
+// DeleteSection supprime une section ; ses items restent dans la wishlist, hors section
+func (s *Service) DeleteSection(ctx context.Context, wishlistID string, sectionID string, userID primitive.ObjectID) error {
+	id, err := primitive.ObjectIDFromHex(wishlistID)
+	if err != nil {
+		return err
+	}
+
+	wishlist, err := s.getWishlistByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !s.can(ctx, wishlist, userID, ActionEditList) {
+		return errors.New("access denied")
+	}
+
+	sid, err := parseSectionID(wishlist, sectionID)
+	if err != nil || sid == nil {
+		return errors.New("section not found")
+	}
+
+	result, err := s.wishlistCol.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "sections._id": *sid},
+		bson.M{
+			"$pull": bson.M{"sections": bson.M{"_id": *sid}},
+			"$inc":  bson.M{"layoutVersion": 1},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("section not found")
+	}
+
+	_, err = s.wishItemCol.UpdateMany(
+		ctx,
+		bson.M{"wishlistId": id, "sectionId": *sid},
+		bson.M{
+			"$unset": bson.M{"sectionId": ""},
+			"$set":   bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	s.recordActivity(ctx, wishlistActivity(models.ActivityLayoutChanged, id, userID))
+	return nil
+}
+
+// ReorderWishlist applique d'un bloc un nouvel ordre des items et des sections,
+// ainsi que les déplacements d'items entre sections.
+// La demande échoue si la wishlist a été réorganisée, ou si des items ont été
+// ajoutés ou supprimés, depuis la version sur laquelle le client s'est basé.
+func (s *Service) ReorderWishlist(ctx context.Context, wishlistID string, userID primitive.ObjectID, req models.ReorderWishlistRequest) (*models.WishlistResponse, error) {
+	id, err := primitive.ObjectIDFromHex(wishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist, err := s.getWishlistByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.can(ctx, wishlist, userID, ActionEditList) {
+		return nil, errors.New("access denied")
+	}
+
+	if wishlist.LayoutVersion != *req.Version {
+		return nil, errors.New("la wishlist a été modifiée entre-temps")
+	}
+
+	// Nouvel ordre des sections : toutes les sections existantes, chacune une fois
+	sections := wishlist.Sections
+	if req.Sections != nil {
+		if len(req.Sections) != len(wishlist.Sections) {
+			return nil, errors.New("ordre des sections invalide")
+		}
+		sections = make([]models.WishlistSection, 0, len(req.Sections))
+		seen := make(map[primitive.ObjectID]bool, len(req.Sections))
+		for _, sectionID := range req.Sections {
+			sid, err := parseSectionID(wishlist, sectionID)
+			if err != nil || sid == nil || seen[*sid] {
+				return nil, errors.New("ordre des sections invalide")
+			}
+			seen[*sid] = true
+			sections = append(sections, *findSection(wishlist, *sid))
+		}
+	}
+
+	// Items actuels de la wishlist, y compris ceux absents de l'ordre enregistré
+	cursor, err := s.wishItemCol.Find(ctx, bson.M{"wishlistId": id})
+	if err != nil {
+		return nil, err
+	}
+	var items []models.WishItem
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	itemsByID := make(map[primitive.ObjectID]*models.WishItem, len(items))
+	for i := range items {
+		itemsByID[items[i].ID] = &items[i]
+	}
+
+	// Nouvel ordre des items : tous les items, chacun une fois
+	if len(req.Items) != len(items) {
+		return nil, errors.New("la wishlist a été modifiée entre-temps")
+	}
+	order := make([]primitive.ObjectID, 0, len(req.Items))
+	moves := []mongo.WriteModel{}
+	now := time.Now()
+	for _, placement := range req.Items {
+		itemID, err := primitive.ObjectIDFromHex(placement.ItemID)
+		if err != nil {
+			return nil, errors.New("ordre des items invalide")
+		}
+		item, found := itemsByID[itemID]
+		if !found {
+			return nil, errors.New("la wishlist a été modifiée entre-temps")
+		}
+		delete(itemsByID, itemID) // Un item listé deux fois n'est plus trouvé la seconde fois
+
+		sectionID, err := parseSectionID(wishlist, placement.SectionID)
+		if err != nil {
+			return nil, err
+		}
+		order = append(order, itemID)
+
+		if sameSection(item.SectionID, sectionID) {
+			continue
+		}
+		update := bson.M{"$set": bson.M{"updatedAt": now}}
+		if sectionID == nil {
+			update["$unset"] = bson.M{"sectionId": ""}
+		} else {
+			update["$set"] = bson.M{"sectionId": *sectionID, "updatedAt": now}
+		}
+		moves = append(moves, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": itemID, "wishlistId": id}).
+			SetUpdate(update))
+	}
+
+	// L'ordre n'est enregistré que si personne n'a modifié la wishlist entre-temps
+	result, err := s.wishlistCol.UpdateOne(
+		ctx,
+		layoutVersionFilter(id, wishlist.LayoutVersion),
+		bson.M{
+			"$set": bson.M{
+				"items":     order,
+				"sections":  sections,
+				"updatedAt": now,
+			},
+			"$inc": bson.M{"layoutVersion": 1},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, errors.New("la wishlist a été modifiée entre-temps")
+	}
+
+	if len(moves) > 0 {
+		if _, err := s.wishItemCol.BulkWrite(ctx, moves); err != nil {
+			return nil, err
+		}
+	}
+
+	s.recordActivity(ctx, wishlistActivity(models.ActivityLayoutChanged, id, userID))
+
+	return s.GetWishlist(ctx, wishlistID, userID)
+}
+
+// sameSection compare deux sections, nil signifiant hors section
+func sameSection(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,195 @@
+package wishlist
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"genie/internal/models"
+)
+
+// resolveManagedAccount vérifie que le compte géré appartient à l'utilisateur.
+// Un ID vide renvoie nil : la wishlist est alors celle de l'utilisateur lui-même.
+func (s *Service) resolveManagedAccount(ctx context.Context, userID primitive.ObjectID, managedAccountID string) (*primitive.ObjectID, error) {
+	if managedAccountID == "" {
+		return nil, nil
+	}
+
+	id, err := primitive.ObjectIDFromHex(managedAccountID)
+	if err != nil {
+		return nil, errors.New("compte géré introuvable")
+	}
+
+	count, err := s.db.ManagedAccounts.CountDocuments(ctx, bson.M{"_id": id, "ownerId": userID})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, errors.New("compte géré introuvable")
+	}
+	return &id, nil
+}
+
+// getRecipients charge les comptes gérés destinataires des wishlists, indexés par ID.
+// Une erreur de lecture est seulement journalisée : les réponses restent sans destinataire.
+func (s *Service) getRecipients(ctx context.Context, wishlists ...*models.Wishlist) map[primitive.ObjectID]*models.WishlistRecipient {
+	recipients := make(map[primitive.ObjectID]*models.WishlistRecipient)
+
+	ids := []primitive.ObjectID{}
+	for _, wishlist := range wishlists {
+		if wishlist != nil && wishlist.ManagedAccountID != nil {
+			ids = append(ids, *wishlist.ManagedAccountID)
+		}
+	}
+	if len(ids) == 0 {
+		return recipients
+	}
+
+	cursor, err := s.db.ManagedAccounts.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		log.Warn().Err(err).Msg("Impossible de récupérer les comptes gérés des wishlists")
+		return recipients
+	}
+	defer cursor.Close(ctx)
+
+	var accounts []models.ManagedAccount
+	if err := cursor.All(ctx, &accounts); err != nil {
+		log.Warn().Err(err).Msg("Impossible de récupérer les comptes gérés des wishlists")
+		return recipients
+	}
+
+	for i := range accounts {
+		recipients[accounts[i].ID] = recipientOf(&accounts[i])
+	}
+	return recipients
+}
+
+// recipientOf présente un compte géré comme destinataire d'une wishlist
+func recipientOf(account *models.ManagedAccount) *models.WishlistRecipient {
+	avatar := account.ProfilePictureURL
+	if avatar == "" {
+		avatar = account.AvatarURL
+	}
+	return &models.WishlistRecipient{
+		ManagedAccountID: account.ID.Hex(),
+		GuardianID:       account.OwnerID.Hex(),
+		FirstName:        account.FirstName,
+		LastName:         account.LastName,
+		AvatarURL:        avatar,
+	}
+}
+
+// wishlistRefs renvoie des pointeurs vers les wishlists d'une liste
+func wishlistRefs(wishlists []models.Wishlist) []*models.Wishlist {
+	refs := make([]*models.Wishlist, len(wishlists))
+	for i := range wishlists {
+		refs[i] = &wishlists[i]
+	}
+	return refs
+}
+
+// wishlistRefsByID renvoie les wishlists d'un index par ID
+func wishlistRefsByID(wishlists map[primitive.ObjectID]*models.Wishlist) []*models.Wishlist {
+	refs := make([]*models.Wishlist, 0, len(wishlists))
+	for _, wishlist := range wishlists {
+		refs = append(refs, wishlist)
+	}
+	return refs
+}
+
+// fillRecipient renseigne le compte géré à qui la wishlist est destinée
+func fillRecipient(response *models.WishlistResponse, wishlist *models.Wishlist, recipients map[primitive.ObjectID]*models.WishlistRecipient) {
+	if wishlist == nil || wishlist.ManagedAccountID == nil {
+		return
+	}
+	response.ManagedAccountID = wishlist.ManagedAccountID.Hex()
+	response.Recipient = recipients[*wishlist.ManagedAccountID]
+}
+
+// fillItemRecipient renseigne le compte géré à qui l'item est destiné
+func fillItemRecipient(response *models.WishItemResponse, wishlist *models.Wishlist, recipients map[primitive.ObjectID]*models.WishlistRecipient) {
+	if wishlist == nil || wishlist.ManagedAccountID == nil {
+		return
+	}
+	response.Recipient = recipients[*wishlist.ManagedAccountID]
+}
+
+// GetManagedWishlists récupère les wishlists des comptes gérés d'un utilisateur.
+// Elles sont visibles par le parent et par ses amis.
+func (s *Service) GetManagedWishlists(ctx context.Context, guardianID primitive.ObjectID, viewerID primitive.ObjectID) ([]models.ManagedAccountWishlists, error) {
+	if guardianID != viewerID && !s.areFriends(ctx, guardianID, viewerID) {
+		return nil, errors.New("access denied")
+	}
+
+	accountsCursor, err := s.db.ManagedAccounts.Find(ctx, bson.M{"ownerId": guardianID})
+	if err != nil {
+		return nil, err
+	}
+	defer accountsCursor.Close(ctx)
+
+	var accounts []models.ManagedAccount
+	if err = accountsCursor.All(ctx, &accounts); err != nil {
+		return nil, err
+	}
+
+	results := []models.ManagedAccountWishlists{}
+	if len(accounts) == 0 {
+		return results, nil
+	}
+
+	accountIDs := make([]primitive.ObjectID, 0, len(accounts))
+	for _, account := range accounts {
+		accountIDs = append(accountIDs, account.ID)
+	}
+
+	cursor, err := s.wishlistCol.Find(ctx, bson.M{
+		"userId":           guardianID,
+		"managedAccountId": bson.M{"$in": accountIDs},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var wishlists []models.Wishlist
+	if err = cursor.All(ctx, &wishlists); err != nil {
+		return nil, err
+	}
+
+	byAccount := make(map[primitive.ObjectID][]*models.WishlistResponse, len(accounts))
+	for i := range wishlists {
+		wishlist := &wishlists[i]
+		response := &models.WishlistResponse{
+			ID:               wishlist.ID.Hex(),
+			UserID:           wishlist.UserID.Hex(),
+			ManagedAccountID: wishlist.ManagedAccountID.Hex(),
+			Title:            wishlist.Title,
+			Description:      wishlist.Description,
+			CoverImage:       wishlist.CoverImage,
+			IsPublic:         wishlist.IsPublic,
+			IsFavorite:       wishlist.IsFavorite,
+			SurpriseMode:     surpriseMode(wishlist),
+			IsOwner:          guardianID == viewerID,
+			CreatedAt:        wishlist.CreatedAt,
+			UpdatedAt:        wishlist.UpdatedAt,
+		}
+		byAccount[*wishlist.ManagedAccountID] = append(byAccount[*wishlist.ManagedAccountID], response)
+	}
+
+	for i := range accounts {
+		recipient := recipientOf(&accounts[i])
+		wishlists := byAccount[accounts[i].ID]
+		if wishlists == nil {
+			wishlists = []*models.WishlistResponse{}
+		}
+		for _, response := range wishlists {
+			response.Recipient = recipient
+		}
+		results = append(results, models.ManagedAccountWishlists{Recipient: *recipient, Wishlists: wishlists})
+	}
+
+	return results, nil
+}
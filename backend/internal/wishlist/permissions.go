@@ -0,0 +1,231 @@
+package wishlist
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"genie/internal/models"
+)
+
+// Action est une opération soumise à autorisation sur une wishlist
+type Action string
+
+const (
+	ActionView         Action = "view"
+	ActionAddItem      Action = "add_item"
+	ActionEditItem     Action = "edit_item"
+	ActionDeleteItem   Action = "delete_item"
+	ActionReserve      Action = "reserve"
+	ActionShare        Action = "share"
+	ActionDeleteList   Action = "delete_list"
+	ActionEditList     Action = "edit_list"     // titre, couverture, sections et ordre des items
+	ActionMarkReceived Action = "mark_received" // confirmer la réception d'un cadeau
+)
+
+// Rôles d'un utilisateur sur une wishlist. Les rôles partagés sont ceux
+// enregistrés dans sharedWith.permission.
+const (
+	RoleOwner    = "owner"
+	RoleGuardian = "guardian" // parent qui gère la wishlist d'un compte géré
+	RoleFriend   = "friend"   // ami du parent, sur la wishlist d'un compte géré
+	RolePublic   = "public"   // visiteur d'une wishlist publique, sans partage
+)
+
+// Ancienne valeur de sharedWith.permission donnant le droit de modifier ;
+// "read" et les valeurs inconnues deviennent "view"
+const legacyPermissionWrite = "write"
+
+// rolePermissions est la matrice de référence rôle -> actions des wishlists
+var rolePermissions = map[string]map[Action]bool{
+	// Le propriétaire ne réserve pas ses propres cadeaux
+	RoleOwner: {
+		ActionView:         true,
+		ActionAddItem:      true,
+		ActionEditItem:     true,
+		ActionDeleteItem:   true,
+		ActionShare:        true,
+		ActionDeleteList:   true,
+		ActionEditList:     true,
+		ActionMarkReceived: true,
+	},
+	// Le parent gère la wishlist de l'enfant et peut aussi lui offrir ses cadeaux
+	RoleGuardian: {
+		ActionView:         true,
+		ActionAddItem:      true,
+		ActionEditItem:     true,
+		ActionDeleteItem:   true,
+		ActionReserve:      true,
+		ActionShare:        true,
+		ActionDeleteList:   true,
+		ActionEditList:     true,
+		ActionMarkReceived: true,
+	},
+	PermissionAdmin: {
+		ActionView:       true,
+		ActionAddItem:    true,
+		ActionEditItem:   true,
+		ActionDeleteItem: true,
+		ActionReserve:    true,
+		ActionShare:      true,
+		ActionEditList:   true,
+	},
+	PermissionEdit: {
+		ActionView:       true,
+		ActionAddItem:    true,
+		ActionEditItem:   true,
+		ActionDeleteItem: true,
+		ActionReserve:    true,
+		ActionEditList:   true,
+	},
+	PermissionView: {
+		ActionView:    true,
+		ActionReserve: true,
+	},
+	RoleFriend: {
+		ActionView:    true,
+		ActionReserve: true,
+	},
+	RolePublic: {
+		ActionView:    true,
+		ActionReserve: true,
+	},
+}
+
+// IsValidPermission indique si une permission peut être accordée lors d'un partage
+func IsValidPermission(permission string) bool {
+	switch permission {
+	case PermissionView, PermissionEdit, PermissionAdmin:
+		return true
+	}
+	return false
+}
+
+// Can indique si un rôle autorise une action
+func Can(role string, action Action) bool {
+	return rolePermissions[role][action]
+}
+
+// roleOf renvoie le rôle d'un utilisateur sur une wishlist, ou "" s'il n'y a pas accès.
+// Seuls les partages acceptés donnent un rôle. L'amitié avec le parent d'un compte
+// géré n'est pas connue ici : voir Service.can.
+func roleOf(wishlist *models.Wishlist, userID primitive.ObjectID) string {
+	if wishlist.UserID == userID {
+		if wishlist.ManagedAccountID != nil {
+			return RoleGuardian
+		}
+		return RoleOwner
+	}
+	for _, share := range wishlist.SharedWith {
+		if share.UserID == userID && share.Status == ShareStatusAccepted {
+			return normalizePermission(share.Permission)
+		}
+	}
+	if wishlist.IsPublic {
+		return RolePublic
+	}
+	return ""
+}
+
+// can indique si un utilisateur peut effectuer une action sur une wishlist.
+// Les amis du parent consultent les wishlists de ses comptes gérés.
+func (s *Service) can(ctx context.Context, wishlist *models.Wishlist, userID primitive.ObjectID, action Action) bool {
+	role := roleOf(wishlist, userID)
+	if Can(role, action) {
+		return true
+	}
+	if (role == "" || role == RolePublic) && wishlist.ManagedAccountID != nil && s.areFriends(ctx, wishlist.UserID, userID) {
+		return Can(RoleFriend, action)
+	}
+	return false
+}
+
+// isRecipient indique si l'utilisateur est celui à qui la wishlist est destinée.
+// Le parent d'un compte géré n'en est pas le destinataire : il voit les réservations.
+func isRecipient(wishlist *models.Wishlist, userID primitive.ObjectID) bool {
+	return wishlist.UserID == userID && wishlist.ManagedAccountID == nil
+}
+
+// areFriends indique si deux utilisateurs ont une amitié acceptée
+func (s *Service) areFriends(ctx context.Context, userID, otherID primitive.ObjectID) bool {
+	if userID.IsZero() || otherID.IsZero() {
+		return false
+	}
+	count, err := s.db.DB.Collection("friendships").CountDocuments(ctx, bson.M{
+		"status": "accepted",
+		"$or": bson.A{
+			bson.M{"userId": userID, "friendId": otherID},
+			bson.M{"userId": otherID, "friendId": userID},
+		},
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Impossible de vérifier l'amitié")
+		return false
+	}
+	return count > 0
+}
+
+// getAuthorizedWishlist récupère une wishlist sur laquelle l'utilisateur peut effectuer l'action
+func (s *Service) getAuthorizedWishlist(ctx context.Context, wishlistID string, userID primitive.ObjectID, action Action) (*models.Wishlist, error) {
+	id, err := primitive.ObjectIDFromHex(wishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist, err := s.getWishlistByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.can(ctx, wishlist, userID, action) {
+		return nil, errors.New("access denied")
+	}
+	return wishlist, nil
+}
+
+// normalizePermission convertit une permission enregistrée vers le modèle actuel.
+// Une valeur inconnue ne donne que le droit de consulter.
+func normalizePermission(permission string) string {
+	switch permission {
+	case PermissionAdmin:
+		return PermissionAdmin
+	case PermissionEdit, legacyPermissionWrite:
+		return PermissionEdit
+	}
+	return PermissionView
+}
+
+// migrateSharePermissions convertit les permissions de partage enregistrées avec
+// l'ancien modèle (read, write) ou invalides vers view, edit et admin
+func (s *Service) migrateSharePermissions(ctx context.Context) {
+	valid := []string{PermissionView, PermissionEdit, PermissionAdmin}
+	permission := bson.M{"$switch": bson.M{
+		"branches": bson.A{
+			bson.M{"case": bson.M{"$eq": bson.A{"$$share.permission", PermissionAdmin}}, "then": PermissionAdmin},
+			bson.M{"case": bson.M{"$in": bson.A{"$$share.permission", bson.A{PermissionEdit, legacyPermissionWrite}}}, "then": PermissionEdit},
+		},
+		"default": PermissionView,
+	}}
+
+	result, err := s.wishlistCol.UpdateMany(ctx,
+		bson.M{"sharedWith": bson.M{"$elemMatch": bson.M{"permission": bson.M{"$nin": valid}}}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"sharedWith": bson.M{"$map": bson.M{
+				"input": "$sharedWith",
+				"as":    "share",
+				"in":    bson.M{"$mergeObjects": bson.A{"$$share", bson.M{"permission": permission}}},
+			}},
+		}}}},
+	)
+	if err != nil {
+		log.Warn().Err(err).Msg("Impossible de migrer les permissions de partage des wishlists")
+		return
+	}
+	if result.ModifiedCount > 0 {
+		log.Info().Int64("wishlists", result.ModifiedCount).Msg("Permissions de partage des wishlists migrées")
+	}
+}
@@ -0,0 +1,26 @@
+package wishlist
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PriceAccess indique si un utilisateur peut consulter le suivi de prix des items d'une wishlist
+// et s'il peut le modifier. Renvoie mongo.ErrNoDocuments si la wishlist n'existe pas.
+func (s *Service) PriceAccess(ctx context.Context, wishlistID, userID primitive.ObjectID) (bool, bool, error) {
+	wishlist, err := s.getWishlistByID(ctx, wishlistID)
+	if err != nil {
+		return false, false, err
+	}
+	if !s.can(ctx, wishlist, userID, ActionView) {
+		return false, false, nil
+	}
+	return true, s.can(ctx, wishlist, userID, ActionEditItem), nil
+}
+
+// PriceAlertRecipients renvoie les membres d'une wishlist à prévenir d'un changement de prix :
+// ceux qui la voient, comme pour son activité. Les prix ne révèlent aucune réservation.
+func (s *Service) PriceAlertRecipients(ctx context.Context, wishlistID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return s.ActivityRecipients(ctx, wishlistID, false)
+}
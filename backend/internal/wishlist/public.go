@@ -0,0 +1,688 @@
+package wishlist
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+
+	"genie/internal/models"
+	"genie/internal/ratelimit"
+)
+
+// États d'une réservation d'invité
+const (
+	GuestReservationPending   = "pending"
+	GuestReservationConfirmed = "confirmed"
+	GuestReservationCancelled = "cancelled"
+)
+
+// Erreurs des pages publiques, traduites en réponses HTTP par le handler
+var (
+	ErrShareLinkNotFound    = errors.New("lien public introuvable")
+	ErrShareLinkExpired     = errors.New("lien public expiré")
+	ErrInvalidSharePassword = errors.New("mot de passe invalide")
+	ErrInvalidGuestName     = errors.New("nom invalide")
+	ErrTooManyPending       = errors.New("trop de réservations en attente")
+	ErrInvalidGuestToken    = errors.New("lien de confirmation invalide ou expiré")
+)
+
+// maxGuestNameLength limite la taille du nom laissé par un invité
+const maxGuestNameLength = 80
+
+// randomToken génère un jeton aléatoire encodé pour les URL
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken renvoie l'empreinte stockée d'un jeton de confirmation
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ensurePublicIndexes crée les index des liens publics et des réservations d'invités
+func (s *Service) ensurePublicIndexes(ctx context.Context) {
+	slugIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "shareLink.slug", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"shareLink.slug": bson.M{"$exists": true}}),
+	}
+	if _, err := s.wishlistCol.Indexes().CreateOne(ctx, slugIndex); err != nil {
+		log.Warn().Err(err).Msg("Impossible de créer l'index des liens publics de wishlist")
+	}
+
+	guestIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "email", Value: 1}, {Key: "status", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "itemId", Value: 1}, {Key: "status", Value: 1}},
+		},
+		{
+			// Les réservations jamais confirmées disparaissent à expiration
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	if _, err := s.guestCol.Indexes().CreateMany(ctx, guestIndexes); err != nil {
+		log.Warn().Err(err).Msg("Impossible de créer les index des réservations d'invités")
+	}
+}
+
+// shareLinkResponse décrit le lien public d'une wishlist à son propriétaire
+func (s *Service) shareLinkResponse(link *models.ShareLink) *models.ShareLinkResponse {
+	if link == nil {
+		return nil
+	}
+	return &models.ShareLinkResponse{
+		Slug:        link.Slug,
+		URL:         fmt.Sprintf("%s/w/%s", s.config.Sharing.PublicBaseURL, link.Slug),
+		HasPassword: link.PasswordHash != "",
+		ExpiresAt:   link.ExpiresAt,
+		CreatedAt:   link.CreatedAt,
+	}
+}
+
+// applyShareLinkSettings renseigne le mot de passe et l'expiration d'un lien public
+func (s *Service) applyShareLinkSettings(link *models.ShareLink, req models.ShareLinkRequest) error {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return errors.New("date d'expiration invalide")
+	}
+	link.ExpiresAt = req.ExpiresAt
+
+	link.PasswordHash = ""
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.config.Security.PasswordHashCost)
+		if err != nil {
+			return err
+		}
+		link.PasswordHash = string(hash)
+	}
+	return nil
+}
+
+// CreateShareLink crée le lien public d'une wishlist, ou le remplace par un nouveau :
+// l'ancien lien cesse alors immédiatement de fonctionner
+func (s *Service) CreateShareLink(ctx context.Context, wishlistID string, userID primitive.ObjectID, req models.ShareLinkRequest) (*models.ShareLinkResponse, error) {
+	wishlist, err := s.getAuthorizedWishlist(ctx, wishlistID, userID, ActionShare)
+	if err != nil {
+		return nil, err
+	}
+
+	slug, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	link := &models.ShareLink{
+		Slug:      slug,
+		CreatedAt: time.Now(),
+	}
+	if err := s.applyShareLinkSettings(link, req); err != nil {
+		return nil, err
+	}
+
+	_, err = s.wishlistCol.UpdateOne(
+		ctx,
+		bson.M{"_id": wishlist.ID},
+		bson.M{"$set": bson.M{"shareLink": link, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordActivity(ctx, wishlistActivity(models.ActivityShareLinkUpdated, wishlist.ID, userID))
+
+	return s.shareLinkResponse(link), nil
+}
+
+// UpdateShareLink modifie le mot de passe et l'expiration du lien public sans en changer l'adresse
+func (s *Service) UpdateShareLink(ctx context.Context, wishlistID string, userID primitive.ObjectID, req models.ShareLinkRequest) (*models.ShareLinkResponse, error) {
+	wishlist, err := s.getAuthorizedWishlist(ctx, wishlistID, userID, ActionShare)
+	if err != nil {
+		return nil, err
+	}
+
+	if wishlist.ShareLink == nil {
+		return nil, ErrShareLinkNotFound
+	}
+
+	link := *wishlist.ShareLink
+	if err := s.applyShareLinkSettings(&link, req); err != nil {
+		return nil, err
+	}
+
+	// Le slug dans le filtre évite d'écraser une rotation concurrente
+	result, err := s.wishlistCol.UpdateOne(
+		ctx,
+		bson.M{"_id": wishlist.ID, "shareLink.slug": link.Slug},
+		bson.M{"$set": bson.M{"shareLink": link, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrShareLinkNotFound
+	}
+
+	s.recordActivity(ctx, wishlistActivity(models.ActivityShareLinkUpdated, wishlist.ID, userID))
+
+	return s.shareLinkResponse(&link), nil
+}
+
+// RevokeShareLink supprime le lien public d'une wishlist
+func (s *Service) RevokeShareLink(ctx context.Context, wishlistID string, userID primitive.ObjectID) error {
+	wishlist, err := s.getAuthorizedWishlist(ctx, wishlistID, userID, ActionShare)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.wishlistCol.UpdateOne(
+		ctx,
+		bson.M{"_id": wishlist.ID},
+		bson.M{
+			"$unset": bson.M{"shareLink": ""},
+			"$set":   bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	s.recordActivity(ctx, wishlistActivity(models.ActivityShareLinkRevoked, wishlist.ID, userID))
+	return nil
+}
+
+// resolveShareLink récupère la wishlist d'un lien public encore valide
+func (s *Service) resolveShareLink(ctx context.Context, slug string, password string) (*models.Wishlist, error) {
+	if slug == "" {
+		return nil, ErrShareLinkNotFound
+	}
+
+	var wishlist models.Wishlist
+	err := s.wishlistCol.FindOne(ctx, bson.M{"shareLink.slug": slug}).Decode(&wishlist)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrShareLinkNotFound
+		}
+		return nil, err
+	}
+
+	link := wishlist.ShareLink
+	if link.ExpiresAt != nil && time.Now().After(*link.ExpiresAt) {
+		return nil, ErrShareLinkExpired
+	}
+	if link.PasswordHash != "" {
+		if password == "" {
+			return nil, ErrInvalidSharePassword
+		}
+		// Chaque essai coûte une comparaison bcrypt : les échecs sont comptés par lien
+		if err := limitOnly(s.passwordGuard.Check(ctx, slug)); err != nil {
+			return nil, err
+		}
+		if bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) != nil {
+			s.recordSharePassword(ctx, slug, false)
+			return nil, ErrInvalidSharePassword
+		}
+		s.recordSharePassword(ctx, slug, true)
+	}
+
+	return &wishlist, nil
+}
+
+// SetRateLimitStore remplace le stockage des échecs de mot de passe des liens publics (en mémoire par défaut).
+// Avec plusieurs instances du serveur, un stockage partagé (MongoDB) est nécessaire.
+func (s *Service) SetRateLimitStore(store ratelimit.Store) {
+	cfg := s.config.RateLimit
+	s.passwordGuard = ratelimit.NewGuard(store, "wishlist:share-password", ratelimit.FailurePolicy{
+		FreeAttempts:     cfg.FreeFailures,
+		BaseDelay:        cfg.FailureBaseDelay,
+		MaxDelay:         cfg.FailureMaxDelay,
+		LockoutThreshold: cfg.LockoutThreshold,
+		LockoutDuration:  cfg.LockoutDuration,
+	})
+}
+
+// recordSharePassword enregistre le résultat d'un essai de mot de passe sur un lien public
+func (s *Service) recordSharePassword(ctx context.Context, slug string, success bool) {
+	var err error
+	if success {
+		err = s.passwordGuard.Succeed(ctx, slug)
+	} else {
+		err = s.passwordGuard.Fail(ctx, slug)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Erreur lors de l'enregistrement d'un essai de mot de passe")
+	}
+}
+
+// limitOnly ne garde que les dépassements de limite ; les autres erreurs sont journalisées
+func limitOnly(err error) error {
+	var limitErr *ratelimit.LimitError
+	if errors.As(err, &limitErr) {
+		return err
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Limiteur anti-abus indisponible")
+	}
+	return nil
+}
+
+// GetPublicWishlist renvoie la vue en lecture seule d'une wishlist depuis son lien public
+func (s *Service) GetPublicWishlist(ctx context.Context, slug string, password string) (*models.PublicWishlistResponse, error) {
+	wishlist, err := s.resolveShareLink(ctx, slug, password)
+	if err != nil {
+		return nil, err
+	}
+
+	var owner models.User
+	if err := s.userCol.FindOne(ctx, bson.M{"_id": wishlist.UserID}).Decode(&owner); err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.wishItemCol.Find(ctx, bson.M{"wishlistId": wishlist.ID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var items []models.WishItem
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+
+	// Ordre d'affichage de la wishlist, les items absents en dernier
+	positions := make(map[primitive.ObjectID]int, len(wishlist.Items))
+	for i, id := range wishlist.Items {
+		positions[id] = i
+	}
+	position := func(id primitive.ObjectID) int {
+		if i, found := positions[id]; found {
+			return i
+		}
+		return len(wishlist.Items)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return position(items[i].ID) < position(items[j].ID)
+	})
+
+	// Le visiteur est anonyme et peut être le destinataire : seul le mode off dévoile les réservations,
+	// les autres modes se limitent à ce qui reste réservable
+	showReservations := surpriseMode(wishlist) == SurpriseModeOff
+
+	response := &models.PublicWishlistResponse{
+		Title:       wishlist.Title,
+		Description: wishlist.Description,
+		CoverImage:  wishlist.CoverImage,
+		OwnerName:   owner.FirstName,
+		Sections:    wishlist.Sections,
+		Items:       []models.PublicWishItemResponse{},
+	}
+
+	for _, item := range items {
+		entry := models.PublicWishItemResponse{
+			ID:          item.ID.Hex(),
+			Name:        item.Name,
+			Description: item.Description,
+			Price:       item.Price,
+			Currency:    item.Currency,
+			ImageURL:    item.ImageURL,
+			Link:        item.Link,
+			Priority:    itemPriority(&item),
+			Quantity:    itemQuantity(&item),
+		}
+		if item.SectionID != nil {
+			entry.SectionID = item.SectionID.Hex()
+		}
+		if !item.IsReserved {
+			entry.AvailableQuantity = itemQuantity(&item) - item.ReservedQuantity
+		}
+		entry.CanReserve = entry.AvailableQuantity > 0
+		if showReservations {
+			isReserved := item.IsReserved
+			entry.IsReserved = &isReserved
+		}
+		response.Items = append(response.Items, entry)
+	}
+
+	return response, nil
+}
+
+// RequestGuestReservation enregistre la réservation d'un invité depuis la page publique
+// et lui envoie le lien de confirmation. L'item n'est bloqué qu'une fois la réservation confirmée.
+func (s *Service) RequestGuestReservation(ctx context.Context, slug string, password string, itemID string, req models.GuestReserveRequest) error {
+	wishlist, err := s.resolveShareLink(ctx, slug, password)
+	if err != nil {
+		return err
+	}
+
+	id, err := primitive.ObjectIDFromHex(itemID)
+	if err != nil {
+		return ErrItemNotFound
+	}
+	item, err := s.getWishItemByID(ctx, id)
+	if err != nil || item.WishlistID != wishlist.ID {
+		return ErrItemNotFound
+	}
+	if item.IsReserved {
+		return ErrAlreadyReserved
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len([]rune(name)) > maxGuestNameLength {
+		return ErrInvalidGuestName
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	if quantity < 1 || quantity > itemQuantity(item)-item.ReservedQuantity {
+		return ErrInvalidQuantity
+	}
+
+	// Éviter qu'une adresse soit inondée de demandes de confirmation
+	now := time.Now()
+	pending, err := s.guestCol.CountDocuments(ctx, bson.M{
+		"email":     email,
+		"status":    GuestReservationPending,
+		"expiresAt": bson.M{"$gt": now},
+	})
+	if err != nil {
+		return err
+	}
+	if pending >= int64(s.config.Sharing.GuestMaxPending) {
+		return ErrTooManyPending
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := now.Add(s.config.Sharing.GuestConfirmationTTL)
+	reservation := models.GuestReservation{
+		ID:         primitive.NewObjectID(),
+		WishlistID: wishlist.ID,
+		ItemID:     item.ID,
+		Name:       name,
+		Email:      email,
+		Quantity:   quantity,
+		TokenHash:  hashToken(token),
+		Status:     GuestReservationPending,
+		ExpiresAt:  &expiresAt,
+		CreatedAt:  now,
+	}
+	if _, err := s.guestCol.InsertOne(ctx, reservation); err != nil {
+		return err
+	}
+
+	if s.email == nil {
+		return errors.New("envoi d'email indisponible")
+	}
+
+	base := s.config.Sharing.PublicBaseURL
+	title := "Confirmez votre réservation"
+	message := fmt.Sprintf(
+		"Vous avez demandé à réserver « %s » dans la liste « %s ».\n\n"+
+			"Confirmez votre réservation : %s/reservations/confirm?token=%s\n\n"+
+			"Ce lien expire le %s. Vous pourrez annuler votre réservation à tout moment : %s/reservations/cancel?token=%s",
+		item.Name, wishlist.Title, base, token, expiresAt.Format("02/01/2006 à 15h04"), base, token,
+	)
+	return s.email.SendAccountNotification(email, title, message)
+}
+
+// ConfirmGuestReservation confirme la réservation d'un invité et bloque l'item
+func (s *Service) ConfirmGuestReservation(ctx context.Context, token string) error {
+	now := time.Now()
+
+	// Réclamer la réservation avant de toucher à l'item : un double clic ne réserve qu'une fois
+	var reservation models.GuestReservation
+	err := s.guestCol.FindOneAndUpdate(ctx,
+		bson.M{
+			"tokenHash": hashToken(token),
+			"status":    GuestReservationPending,
+			"expiresAt": bson.M{"$gt": now},
+		},
+		bson.M{
+			"$set":   bson.M{"status": GuestReservationConfirmed, "confirmedAt": now},
+			"$unset": bson.M{"expiresAt": ""},
+		},
+	).Decode(&reservation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrInvalidGuestToken
+		}
+		return err
+	}
+
+	if err := s.reserveForGuest(ctx, &reservation, now); err != nil {
+		// L'item n'a pas pu être réservé, la demande est abandonnée
+		if _, updateErr := s.guestCol.UpdateOne(ctx,
+			bson.M{"_id": reservation.ID},
+			bson.M{"$set": bson.M{"status": GuestReservationCancelled}},
+		); updateErr != nil {
+			log.Warn().Err(updateErr).Str("reservationID", reservation.ID.Hex()).Msg("Impossible d'abandonner la réservation d'invité")
+		}
+		return err
+	}
+
+	s.recordGuestActivity(ctx, models.ActivityItemReserved, &reservation)
+	return nil
+}
+
+// reserveForGuest bloque un item pour une réservation d'invité confirmée
+func (s *Service) reserveForGuest(ctx context.Context, reservation *models.GuestReservation, now time.Time) error {
+	item, err := s.getWishItemByID(ctx, reservation.ItemID)
+	if err != nil {
+		return ErrItemNotFound
+	}
+
+	if itemQuantity(item) > 1 || item.ReservedQuantity > 0 {
+		return s.addPartialReservation(ctx, item.ID, models.ItemReservation{
+			GuestReservationID: &reservation.ID,
+			GuestName:          reservation.Name,
+			Quantity:           reservation.Quantity,
+			Status:             ReservationReserved,
+			ReservedAt:         now,
+		})
+	}
+
+	result, err := s.wishItemCol.UpdateOne(ctx,
+		bson.M{
+			"_id":              item.ID,
+			"isReserved":       bson.M{"$ne": true},
+			"quantity":         bson.M{"$not": bson.M{"$gt": 1}},
+			"reservedQuantity": bson.M{"$not": bson.M{"$gt": 0}},
+		},
+		bson.M{
+			"$set": bson.M{
+				"isReserved":         true,
+				"reservedBy":         primitive.NilObjectID,
+				"guestReservationId": reservation.ID,
+				"reservedByGuest":    reservation.Name,
+				"reservationStatus":  ReservationReserved,
+				"reservedAt":         now,
+				"updatedAt":          now,
+			},
+			"$unset": bson.M{"reservedForEventId": "", "purchasedAt": "", "receivedAt": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrAlreadyReserved
+	}
+	return nil
+}
+
+// CancelGuestReservation annule la réservation d'un invité avec le jeton reçu par email.
+// Un cadeau déjà reçu ne peut plus être libéré.
+func (s *Service) CancelGuestReservation(ctx context.Context, token string) error {
+	var reservation models.GuestReservation
+	err := s.guestCol.FindOne(ctx, bson.M{"tokenHash": hashToken(token)}).Decode(&reservation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrInvalidGuestToken
+		}
+		return err
+	}
+
+	now := time.Now()
+	switch reservation.Status {
+	case GuestReservationPending:
+		// Rien n'a encore été bloqué sur l'item
+	case GuestReservationConfirmed:
+		if err := s.releaseForGuest(ctx, &reservation, now); err != nil {
+			return err
+		}
+	default:
+		return ErrCannotCancel
+	}
+
+	result, err := s.guestCol.UpdateOne(ctx,
+		bson.M{"_id": reservation.ID, "status": reservation.Status},
+		bson.M{
+			"$set":   bson.M{"status": GuestReservationCancelled},
+			"$unset": bson.M{"expiresAt": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCannotCancel
+	}
+
+	if reservation.Status == GuestReservationConfirmed {
+		s.recordGuestActivity(ctx, models.ActivityReservationCancelled, &reservation)
+	}
+	return nil
+}
+
+// recordGuestActivity enregistre la réservation, ou son annulation, par un invité sans compte
+func (s *Service) recordGuestActivity(ctx context.Context, kind models.WishlistActivityType, reservation *models.GuestReservation) {
+	if s.activity == nil {
+		return
+	}
+	item, err := s.getWishItemByID(ctx, reservation.ItemID)
+	if err != nil {
+		log.Warn().Err(err).Str("itemID", reservation.ItemID.Hex()).Msg("Impossible d'enregistrer l'activité de l'invité")
+		return
+	}
+	entry := itemActivity(kind, item, primitive.NilObjectID)
+	entry.ActorName = reservation.Name
+	entry.Quantity = reservation.Quantity
+	s.recordActivity(ctx, entry)
+}
+
+// releaseForGuest libère l'item bloqué par une réservation d'invité
+func (s *Service) releaseForGuest(ctx context.Context, reservation *models.GuestReservation, now time.Time) error {
+	// Réservation d'un item en un seul exemplaire
+	result, err := s.wishItemCol.UpdateOne(ctx,
+		bson.M{
+			"_id":                reservation.ItemID,
+			"guestReservationId": reservation.ID,
+			"reservationStatus":  ReservationReserved,
+		},
+		bson.M{
+			"$set": bson.M{
+				"isReserved": false,
+				"reservedBy": primitive.NilObjectID,
+				"updatedAt":  now,
+			},
+			"$unset": bson.M{"guestReservationId": "", "reservedByGuest": "", "reservationStatus": "", "reservedAt": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	// Réservation partielle
+	result, err = s.wishItemCol.UpdateOne(ctx,
+		bson.M{
+			"_id": reservation.ItemID,
+			"reservations": bson.M{"$elemMatch": bson.M{
+				"guestReservationId": reservation.ID,
+				"status":             ReservationReserved,
+			}},
+			"reservationStatus": bson.M{"$ne": ReservationReceived},
+		},
+		bson.M{
+			"$pull":  bson.M{"reservations": bson.M{"guestReservationId": reservation.ID}},
+			"$inc":   bson.M{"reservedQuantity": -reservation.Quantity},
+			"$set":   bson.M{"isReserved": false, "updatedAt": now},
+			"$unset": bson.M{"reservationStatus": "", "reservedAt": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCannotCancel
+	}
+	return nil
+}
+
+// thankGuests remercie par email les invités qui ont offert un item
+func (s *Service) thankGuests(ctx context.Context, item *models.WishItem, ownerID primitive.ObjectID, message string) error {
+	if s.email == nil {
+		return nil
+	}
+
+	var owner models.User
+	if err := s.userCol.FindOne(ctx, bson.M{"_id": ownerID}).Decode(&owner); err != nil {
+		return err
+	}
+
+	cursor, err := s.guestCol.Find(ctx, bson.M{"itemId": item.ID, "status": GuestReservationConfirmed})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var reservations []models.GuestReservation
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return err
+	}
+
+	title := "Merci pour votre cadeau !"
+	text := fmt.Sprintf("%s %s a bien reçu « %s » et vous remercie.", owner.FirstName, owner.LastName, item.Name)
+	if message != "" {
+		text = fmt.Sprintf("%s\n\n%s", text, message)
+	}
+
+	for _, reservation := range reservations {
+		if err := s.email.SendAccountNotification(reservation.Email, title, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package wishlist
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"genie/internal/models"
+)
+
+// Les wishlists rattachées à un événement forment son registre de cadeaux.
+// L'événement décide qui y accède ; ce service reste seul juge de qui peut partager une
+// wishlist, de ce que son destinataire voit des réservations et de la façon de réserver.
+
+var ErrItemNotFound = errors.New("wish item not found")
+
+// ShareableWishlist renvoie une wishlist que l'utilisateur peut partager, par exemple en la
+// rattachant à un événement, ou nil si elle n'existe pas ou qu'il n'en a pas le droit
+func (s *Service) ShareableWishlist(ctx context.Context, wishlistID, userID primitive.ObjectID) (*models.Wishlist, error) {
+	wishlist, err := s.getWishlistByID(ctx, wishlistID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !s.can(ctx, wishlist, userID, ActionShare) {
+		return nil, nil
+	}
+	return wishlist, nil
+}
+
+// RegistryItems renvoie les wishlists d'un registre et leurs items tels qu'un participant de
+// l'événement les voit. L'accès vient de l'événement : seul le mode surprise s'applique.
+func (s *Service) RegistryItems(ctx context.Context, wishlistIDs []primitive.ObjectID, viewerID primitive.ObjectID) ([]models.Wishlist, []*models.WishItemResponse, error) {
+	cursor, err := s.wishlistCol.Find(ctx, bson.M{"_id": bson.M{"$in": wishlistIDs}})
+	if err != nil {
+		return nil, nil, err
+	}
+	var wishlists []models.Wishlist
+	if err := cursor.All(ctx, &wishlists); err != nil {
+		return nil, nil, err
+	}
+
+	wishlistsByID := make(map[primitive.ObjectID]*models.Wishlist, len(wishlists))
+	for i := range wishlists {
+		wishlistsByID[wishlists[i].ID] = &wishlists[i]
+	}
+
+	cursor, err = s.wishItemCol.Find(ctx, bson.M{"wishlistId": bson.M{"$in": wishlistIDs}})
+	if err != nil {
+		return nil, nil, err
+	}
+	var items []models.WishItem
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, nil, err
+	}
+
+	responses := []*models.WishItemResponse{}
+	for _, item := range items {
+		wishlist, found := wishlistsByID[item.WishlistID]
+		if !found {
+			continue
+		}
+		response := &models.WishItemResponse{
+			ID:          item.ID.Hex(),
+			WishlistID:  item.WishlistID.Hex(),
+			UserID:      item.UserID.Hex(),
+			Name:        item.Name,
+			Description: item.Description,
+			Price:       item.Price,
+			Currency:    item.Currency,
+			ImageURL:    item.ImageURL,
+			Link:        item.Link,
+			IsFavorite:  item.IsFavorite,
+			IsReserved:  item.IsReserved,
+			CreatedAt:   item.CreatedAt,
+			UpdatedAt:   item.UpdatedAt,
+		}
+
+		fillLayout(response, &item, wishlist)
+		fillReservation(response, &item, wishlist, viewerID)
+
+		responses = append(responses, response)
+	}
+
+	sortByPosition(responses)
+
+	return wishlists, responses, nil
+}
+
+// ReserveForEvent réserve, ou libère, un item du registre d'un événement pour l'un de ses participants.
+// wishlistIDs sont les wishlists rattachées à l'événement : l'item doit appartenir à l'une d'elles.
+// quantity ne sert qu'aux items souhaités en plusieurs exemplaires, 1 par défaut.
+func (s *Service) ReserveForEvent(ctx context.Context, itemID, userID, eventID primitive.ObjectID, wishlistIDs []primitive.ObjectID, reserve bool, quantity int) error {
+	item, err := s.getWishItemByID(ctx, itemID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrItemNotFound
+		}
+		return err
+	}
+
+	linked := false
+	for _, id := range wishlistIDs {
+		if id == item.WishlistID {
+			linked = true
+			break
+		}
+	}
+	if !linked {
+		return ErrItemNotFound
+	}
+
+	wishlist, err := s.getWishlistByID(ctx, item.WishlistID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrItemNotFound
+		}
+		return err
+	}
+
+	req := models.ReserveWishItemRequest{Reserve: reserve, Quantity: quantity}
+	return s.reserve(ctx, item, wishlist, userID, req, &eventID)
+}
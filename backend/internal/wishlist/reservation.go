@@ -0,0 +1,507 @@
+package wishlist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"genie/internal/messaging"
+	"genie/internal/models"
+)
+
+// Modes surprise : ce que le propriétaire voit des réservations de sa wishlist
+const (
+	SurpriseModeHidden = "hidden" // les items réservés apparaissent disponibles
+	SurpriseModeCount  = "count"  // seul le nombre d'items réservés est visible
+	SurpriseModeOff    = "off"    // tout est visible, y compris qui a réservé
+)
+
+// Étapes d'une réservation
+const (
+	ReservationReserved  = "reserved"
+	ReservationPurchased = "purchased"
+	ReservationReceived  = "received"
+)
+
+// Erreurs de réservation, distinguées aussi par le registre des événements
+var (
+	ErrOwnItem         = errors.New("vous ne pouvez pas réserver votre propre item")
+	ErrAlreadyReserved = errors.New("cet item est déjà réservé")
+	ErrCannotCancel    = errors.New("vous ne pouvez pas annuler cette réservation")
+	// Réservations partielles d'un item souhaité en plusieurs exemplaires
+	ErrAlreadyReservedByYou = errors.New("vous avez déjà réservé cet item")
+	ErrInvalidQuantity      = errors.New("quantité invalide")
+	ErrInsufficientQuantity = errors.New("quantité disponible insuffisante")
+)
+
+// maxThankYouLength limite la taille du message de remerciement
+const maxThankYouLength = 500
+
+// isValidSurpriseMode vérifie qu'un mode surprise est connu
+func isValidSurpriseMode(mode string) bool {
+	switch mode {
+	case SurpriseModeHidden, SurpriseModeCount, SurpriseModeOff:
+		return true
+	}
+	return false
+}
+
+// surpriseMode renvoie le mode surprise d'une wishlist, hidden par défaut
+func surpriseMode(wishlist *models.Wishlist) string {
+	if wishlist == nil || wishlist.SurpriseMode == "" {
+		return SurpriseModeHidden
+	}
+	return wishlist.SurpriseMode
+}
+
+// reservationStatus renvoie l'étape de réservation d'un item.
+// Les items réservés avant l'introduction des étapes sont considérés comme réservés.
+func reservationStatus(item *models.WishItem) string {
+	if !item.IsReserved {
+		return ""
+	}
+	if item.ReservationStatus == "" {
+		return ReservationReserved
+	}
+	return item.ReservationStatus
+}
+
+// seesReservations indique si un utilisateur voit les réservations d'une wishlist :
+// sauf en mode off, son destinataire ne les voit pas
+func seesReservations(wishlist *models.Wishlist, userID primitive.ObjectID) bool {
+	return !isRecipient(wishlist, userID) || surpriseMode(wishlist) == SurpriseModeOff
+}
+
+// fillReservation renseigne l'état de réservation d'un item dans la réponse.
+// Sauf en mode off, le destinataire de la wishlist voit l'item disponible tant qu'il ne l'a pas reçu.
+func fillReservation(response *models.WishItemResponse, item *models.WishItem, wishlist *models.Wishlist, viewerID primitive.ObjectID) {
+	response.Quantity = itemQuantity(item)
+
+	if wishlist != nil && !seesReservations(wishlist, viewerID) && item.ReservationStatus != ReservationReceived {
+		response.IsReserved = false
+		return
+	}
+
+	response.ReservedQuantity = item.ReservedQuantity
+	for _, reservation := range item.Reservations {
+		if reservation.UserID == viewerID {
+			response.MyReservedQuantity = reservation.Quantity
+		}
+	}
+
+	status := reservationStatus(item)
+	if status == "" {
+		return
+	}
+
+	response.IsReserved = true
+	response.ReservationStatus = status
+	response.PurchasedAt = item.PurchasedAt
+	response.ReceivedAt = item.ReceivedAt
+	if !item.ReservedBy.IsZero() {
+		response.ReservedBy = item.ReservedBy.Hex()
+	}
+	response.ReservedByGuest = item.ReservedByGuest
+	if item.ReservedForEventID != nil {
+		response.ReservedForEventID = item.ReservedForEventID.Hex()
+	}
+}
+
+// reservedCount renvoie le nombre d'items réservés d'une wishlist lorsque le lecteur peut le connaître
+func (s *Service) reservedCount(ctx context.Context, wishlist *models.Wishlist, viewerID primitive.ObjectID) (*int64, error) {
+	if isRecipient(wishlist, viewerID) && surpriseMode(wishlist) == SurpriseModeHidden {
+		return nil, nil
+	}
+
+	count, err := s.wishItemCol.CountDocuments(ctx, bson.M{
+		"wishlistId":        wishlist.ID,
+		"isReserved":        true,
+		"reservationStatus": bson.M{"$ne": ReservationReceived},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &count, nil
+}
+
+// getWishlistsByID récupère les wishlists auxquelles appartiennent des items
+func (s *Service) getWishlistsByID(ctx context.Context, items []models.WishItem) (map[primitive.ObjectID]*models.Wishlist, error) {
+	ids := []primitive.ObjectID{}
+	seen := make(map[primitive.ObjectID]bool)
+	for _, item := range items {
+		if !seen[item.WishlistID] {
+			seen[item.WishlistID] = true
+			ids = append(ids, item.WishlistID)
+		}
+	}
+
+	wishlistsByID := make(map[primitive.ObjectID]*models.Wishlist, len(ids))
+	if len(ids) == 0 {
+		return wishlistsByID, nil
+	}
+
+	cursor, err := s.wishlistCol.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var wishlists []models.Wishlist
+	if err := cursor.All(ctx, &wishlists); err != nil {
+		return nil, err
+	}
+	for i := range wishlists {
+		wishlistsByID[wishlists[i].ID] = &wishlists[i]
+	}
+	return wishlistsByID, nil
+}
+
+// reservePartial réserve, ou libère, une partie de la quantité souhaitée d'un item.
+// Chaque utilisateur a au plus une réservation par item ; eventID, optionnel, est l'événement
+// depuis lequel elle est faite.
+func (s *Service) reservePartial(ctx context.Context, item *models.WishItem, userID primitive.ObjectID, req models.ReserveWishItemRequest, eventID *primitive.ObjectID) error {
+	var mine *models.ItemReservation
+	for i := range item.Reservations {
+		if item.Reservations[i].UserID == userID {
+			mine = &item.Reservations[i]
+			break
+		}
+	}
+
+	now := time.Now()
+	if !req.Reserve {
+		// Un cadeau acheté ou reçu ne peut plus être libéré
+		if mine == nil || mine.Status != ReservationReserved || item.ReservationStatus == ReservationReceived {
+			return ErrCannotCancel
+		}
+
+		result, err := s.wishItemCol.UpdateOne(ctx,
+			bson.M{
+				"_id": item.ID,
+				"reservations": bson.M{"$elemMatch": bson.M{
+					"userId":   userID,
+					"quantity": mine.Quantity,
+					"status":   ReservationReserved,
+				}},
+			},
+			bson.M{
+				"$pull":  bson.M{"reservations": bson.M{"userId": userID}},
+				"$inc":   bson.M{"reservedQuantity": -mine.Quantity},
+				"$set":   bson.M{"isReserved": false, "updatedAt": now},
+				"$unset": bson.M{"reservationStatus": "", "reservedAt": ""},
+			},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrCannotCancel
+		}
+
+		entry := itemActivity(models.ActivityReservationCancelled, item, userID)
+		entry.Quantity = mine.Quantity
+		s.recordActivity(ctx, entry)
+		return nil
+	}
+
+	if mine != nil {
+		return ErrAlreadyReservedByYou
+	}
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	if quantity < 1 || quantity > itemQuantity(item) {
+		return ErrInvalidQuantity
+	}
+
+	err := s.addPartialReservation(ctx, item.ID, models.ItemReservation{
+		UserID:     userID,
+		EventID:    eventID,
+		Quantity:   quantity,
+		Status:     ReservationReserved,
+		ReservedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	entry := itemActivity(models.ActivityItemReserved, item, userID)
+	entry.Quantity = quantity
+	s.recordActivity(ctx, entry)
+	return nil
+}
+
+// addPartialReservation ajoute une réservation partielle à un item.
+// Le filtre garantit que la quantité souhaitée n'est jamais dépassée, même sous concurrence.
+func (s *Service) addPartialReservation(ctx context.Context, itemID primitive.ObjectID, reservation models.ItemReservation) error {
+	quantity := reservation.Quantity
+	reserved := bson.M{"$ifNull": bson.A{"$reservedQuantity", 0}}
+
+	filter := bson.M{
+		"_id":        itemID,
+		"isReserved": bson.M{"$ne": true},
+		"$expr": bson.M{"$lte": bson.A{
+			bson.M{"$add": bson.A{reserved, quantity}},
+			bson.M{"$ifNull": bson.A{"$quantity", 1}},
+		}},
+	}
+	if !reservation.UserID.IsZero() {
+		filter["reservations.userId"] = bson.M{"$ne": reservation.UserID}
+	}
+
+	result, err := s.wishItemCol.UpdateOne(ctx,
+		filter,
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"reservedQuantity": bson.M{"$add": bson.A{reserved, quantity}},
+				"reservations": bson.M{"$concatArrays": bson.A{
+					bson.M{"$ifNull": bson.A{"$reservations", bson.A{}}},
+					bson.A{bson.M{"$literal": reservation}},
+				}},
+				"updatedAt": time.Now(),
+			}}},
+			{{Key: "$set", Value: bson.M{
+				"isReserved": bson.M{"$gte": bson.A{"$reservedQuantity", "$quantity"}},
+			}}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrInsufficientQuantity
+	}
+	return nil
+}
+
+// updateQuantity change la quantité souhaitée d'un item sans descendre sous les réservations partielles.
+// La quantité d'un item déjà réservé en entier par une seule personne ne peut plus changer.
+func (s *Service) updateQuantity(ctx context.Context, item *models.WishItem, quantity int) error {
+	if !isValidQuantity(quantity) {
+		return ErrInvalidQuantity
+	}
+
+	reserved := bson.M{"$ifNull": bson.A{"$reservedQuantity", 0}}
+	filter := bson.M{
+		"_id": item.ID,
+		"$or": []bson.M{
+			{"isReserved": bson.M{"$ne": true}},
+			{"reservedQuantity": bson.M{"$gt": 0}},
+		},
+		"$expr": bson.M{"$lte": bson.A{reserved, quantity}},
+	}
+	if quantity == 1 {
+		// Un item souhaité en un seul exemplaire n'a pas de réservations partielles
+		filter["reservedQuantity"] = bson.M{"$not": bson.M{"$gt": 0}}
+	}
+
+	result, err := s.wishItemCol.UpdateOne(ctx, filter, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"quantity": quantity,
+			"isReserved": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{reserved, 0}},
+				bson.M{"$gte": bson.A{reserved, quantity}},
+				"$isReserved",
+			}},
+			"updatedAt": time.Now(),
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("la quantité ne peut pas être inférieure aux réservations")
+	}
+	return nil
+}
+
+// MarkPurchased indique que la personne qui a réservé un item l'a acheté
+func (s *Service) MarkPurchased(ctx context.Context, itemID string, userID primitive.ObjectID) error {
+	id, err := primitive.ObjectIDFromHex(itemID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result, err := s.wishItemCol.UpdateOne(ctx,
+		bson.M{
+			"_id":               id,
+			"isReserved":        true,
+			"reservedBy":        userID,
+			"reservationStatus": bson.M{"$nin": []string{ReservationPurchased, ReservationReceived}},
+		},
+		bson.M{"$set": bson.M{
+			"reservationStatus": ReservationPurchased,
+			"purchasedAt":       now,
+			"updatedAt":         now,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		s.recordItemActivity(ctx, models.ActivityItemPurchased, id, userID)
+		return nil
+	}
+
+	// Réservation partielle d'un item souhaité en plusieurs exemplaires
+	result, err = s.wishItemCol.UpdateOne(ctx,
+		bson.M{
+			"_id":          id,
+			"reservations": bson.M{"$elemMatch": bson.M{"userId": userID, "status": ReservationReserved}},
+		},
+		bson.M{"$set": bson.M{
+			"reservations.$.status":      ReservationPurchased,
+			"reservations.$.purchasedAt": now,
+			"updatedAt":                  now,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("aucune réservation à confirmer")
+	}
+
+	s.recordItemActivity(ctx, models.ActivityItemPurchased, id, userID)
+	return nil
+}
+
+// MarkReceived indique que le propriétaire de la wishlist a reçu un item réservé,
+// et remercie éventuellement la personne qui l'a offert
+func (s *Service) MarkReceived(ctx context.Context, itemID string, userID primitive.ObjectID, req models.MarkReceivedRequest) error {
+	id, err := primitive.ObjectIDFromHex(itemID)
+	if err != nil {
+		return err
+	}
+
+	item, err := s.getWishItemByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	wishlist, err := s.getWishlistByID(ctx, item.WishlistID)
+	if err != nil {
+		return err
+	}
+
+	if !s.can(ctx, wishlist, userID, ActionMarkReceived) {
+		return errors.New("access denied")
+	}
+
+	message := strings.TrimSpace(req.Message)
+	if len([]rune(message)) > maxThankYouLength {
+		return errors.New("message trop long")
+	}
+
+	// Un item souhaité en plusieurs exemplaires peut n'être réservé qu'en partie
+	now := time.Now()
+	result, err := s.wishItemCol.UpdateOne(ctx,
+		bson.M{
+			"_id": id,
+			"$or": []bson.M{
+				{"isReserved": true},
+				{"reservedQuantity": bson.M{"$gt": 0}},
+			},
+			"reservationStatus": bson.M{"$ne": ReservationReceived},
+		},
+		bson.M{"$set": bson.M{
+			"reservationStatus": ReservationReceived,
+			"receivedAt":        now,
+			"updatedAt":         now,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		// Sauf en mode off, le destinataire ne doit pas pouvoir distinguer un item
+		// libre d'un item réservé : la demande est ignorée comme si elle avait abouti
+		if !seesReservations(wishlist, userID) {
+			return nil
+		}
+		return errors.New("cet item n'est pas en attente de réception")
+	}
+
+	s.recordActivity(ctx, itemActivity(models.ActivityItemReceived, item, userID))
+
+	if !req.SendThankYou {
+		return nil
+	}
+
+	// Un item souhaité en plusieurs exemplaires peut avoir été offert par plusieurs personnes
+	givers := []primitive.ObjectID{}
+	if !item.ReservedBy.IsZero() {
+		givers = append(givers, item.ReservedBy)
+	}
+	for _, reservation := range item.Reservations {
+		if !reservation.UserID.IsZero() {
+			givers = append(givers, reservation.UserID)
+		}
+	}
+
+	for _, giverID := range givers {
+		if err := s.sendThankYou(ctx, item, userID, giverID, message); err != nil {
+			// La réception est enregistrée, le remerciement n'est qu'un bonus
+			log.Warn().Err(err).Str("itemID", item.ID.Hex()).Msg("Impossible d'envoyer le remerciement")
+		}
+	}
+
+	// Les invités sans compte sont remerciés par email
+	if err := s.thankGuests(ctx, item, userID, message); err != nil {
+		log.Warn().Err(err).Str("itemID", item.ID.Hex()).Msg("Impossible de remercier les invités")
+	}
+	return nil
+}
+
+// sendThankYou notifie une personne qui a offert un item
+func (s *Service) sendThankYou(ctx context.Context, item *models.WishItem, ownerID primitive.ObjectID, giverID primitive.ObjectID, message string) error {
+	var owner, giver models.User
+	if err := s.userCol.FindOne(ctx, bson.M{"_id": ownerID}).Decode(&owner); err != nil {
+		return err
+	}
+	if err := s.userCol.FindOne(ctx, bson.M{"_id": giverID}).Decode(&giver); err != nil {
+		return err
+	}
+
+	title := "Merci pour votre cadeau !"
+	text := fmt.Sprintf("%s %s a bien reçu « %s » et vous remercie.", owner.FirstName, owner.LastName, item.Name)
+	if message != "" {
+		text = fmt.Sprintf("%s\n\n%s", text, message)
+	}
+
+	prefs := giver.NotificationPreferences
+	if giver.Email != "" && s.email != nil && prefs.ChannelEnabled(models.NotificationChannelEmail) {
+		if err := s.email.SendAccountNotification(giver.Email, title, text); err != nil {
+			return err
+		}
+	}
+
+	if s.pusher != nil && prefs.ChannelEnabled(models.NotificationChannelPush) {
+		data, err := json.Marshal(messaging.WebsocketMessage{
+			Type: "thank_you",
+			Payload: map[string]interface{}{
+				"itemId":     item.ID.Hex(),
+				"wishlistId": item.WishlistID.Hex(),
+				"name":       item.Name,
+				"fromUserId": ownerID.Hex(),
+				"message":    message,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		s.pusher.SendToUser(giver.ID.Hex(), data)
+	}
+	return nil
+}
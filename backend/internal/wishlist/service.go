@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -22,17 +23,22 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"genie/internal/activity"
 	"genie/internal/config"
 	"genie/internal/db"
+	"genie/internal/messaging"
 	"genie/internal/models"
+	"genie/internal/ratelimit"
+	"genie/internal/unfurl"
+	"genie/internal/utils"
 )
 
 // Constants for permission types
 const (
 	ShareStatusAccepted = "accepted"
 	ShareStatusPending  = "pending"
-	PermissionRead      = "read"
-	PermissionWrite     = "write"
+	PermissionView      = "view"
+	PermissionEdit      = "edit"
 	PermissionAdmin     = "admin"
 )
 
@@ -42,18 +48,48 @@ type Service struct {
 	wishlistCol *mongo.Collection
 	wishItemCol *mongo.Collection
 	userCol     *mongo.Collection
+	guestCol    *mongo.Collection
+	templateCol *mongo.Collection
 	config      *config.Config
+	unfurler    *unfurl.Service
+	email       *utils.EmailService
+	pusher      messaging.Pusher
+	activity    *activity.Service
+
+	// Échecs de mot de passe par lien public
+	passwordGuard *ratelimit.Guard
+
+	// Modèles proposés à partir des inspirations du scraper
+	inspirations    InspirationSource
+	curatedMu       sync.Mutex
+	curatedSyncedAt time.Time
 }
 
-// NewService crée une nouvelle instance du service wishlist
-func NewService(mongodb *db.Database, cfg *config.Config) *Service {
-	return &Service{
-		db:          mongodb,
-		config:      cfg,
-		wishlistCol: mongodb.DB.Collection("wishlists"),
-		wishItemCol: mongodb.DB.Collection("wishItems"),
-		userCol:     mongodb.Users,
-	}
+// NewService crée une nouvelle instance du service wishlist.
+// unfurler sert à compléter les items créés à partir d'un simple lien ; il peut être nil.
+// email et pusher servent aux remerciements envoyés après réception d'un cadeau.
+// activities alimente le fil d'activité des wishlists ; il peut être nil.
+// inspirations sert à proposer des modèles de wishlist ; il peut être nil.
+func NewService(mongodb *db.Database, cfg *config.Config, unfurler *unfurl.Service, email *utils.EmailService, pusher messaging.Pusher, activities *activity.Service, inspirations InspirationSource) *Service {
+	s := &Service{
+		db:           mongodb,
+		config:       cfg,
+		unfurler:     unfurler,
+		email:        email,
+		pusher:       pusher,
+		activity:     activities,
+		inspirations: inspirations,
+		wishlistCol:  mongodb.DB.Collection("wishlists"),
+		wishItemCol:  mongodb.DB.Collection("wishItems"),
+		userCol:      mongodb.Users,
+		guestCol:     mongodb.DB.Collection("guestReservations"),
+		templateCol:  mongodb.DB.Collection("wishlistTemplates"),
+	}
+	s.SetRateLimitStore(ratelimit.NewMemoryStore())
+	s.migrateSharePermissions(context.Background())
+	s.ensurePublicIndexes(context.Background())
+	s.ensureTemplateIndexes(context.Background())
+	return s
 }
 
 // getWishlistByID récupère une wishlist par son ID
@@ -82,58 +118,58 @@ func (s *Service) getWishItemByID(ctx context.Context, itemID primitive.ObjectID
 	return &item, nil
 }
 
-// hasEditPermission vérifie si un utilisateur a la permission d'éditer une wishlist
-func (s *Service) hasEditPermission(wishlist *models.Wishlist, userID primitive.ObjectID) bool {
-	// Si l'utilisateur est le propriétaire, il a les droits d'édition
-	if wishlist.UserID == userID {
-		return true
+// CreateWishlist crée une nouvelle wishlist
+func (s *Service) CreateWishlist(ctx context.Context, userID primitive.ObjectID, req models.CreateWishlistRequest) (*models.WishlistResponse, error) {
+	if req.SurpriseMode == "" {
+		req.SurpriseMode = SurpriseModeHidden
 	}
-
-	// Vérifier les permissions partagées
-	for _, share := range wishlist.SharedWith {
-		if share.UserID == userID && share.Status == ShareStatusAccepted && share.Permission == PermissionWrite {
-			return true
-		}
+	if !isValidSurpriseMode(req.SurpriseMode) {
+		return nil, errors.New("mode surprise invalide")
 	}
 
-	return false
-}
-
-// CreateWishlist crée une nouvelle wishlist
-func (s *Service) CreateWishlist(ctx context.Context, userID primitive.ObjectID, req models.CreateWishlistRequest) (*models.WishlistResponse, error) {
-	wishlist := models.Wishlist{
-		ID:          primitive.NewObjectID(),
-		UserID:      userID,
-		Title:       req.Title,
-		Description: req.Description,
-		CoverImage:  req.CoverImage,
-		IsPublic:    req.IsPublic,
-		IsFavorite:  req.IsFavorite,
-		Items:       []primitive.ObjectID{},
-		SharedWith:  []models.SharedWith{},
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	// Un parent peut tenir la wishlist d'un de ses comptes gérés
+	managedAccountID, err := s.resolveManagedAccount(ctx, userID, req.ManagedAccountID)
+	if err != nil {
+		return nil, err
 	}
 
-	_, err := s.wishlistCol.InsertOne(ctx, wishlist)
+	wishlist := models.Wishlist{
+		ID:               primitive.NewObjectID(),
+		UserID:           userID,
+		ManagedAccountID: managedAccountID,
+		Title:            req.Title,
+		Description:      req.Description,
+		CoverImage:       req.CoverImage,
+		IsPublic:         req.IsPublic,
+		IsFavorite:       req.IsFavorite,
+		Items:            []primitive.ObjectID{},
+		SharedWith:       []models.SharedWith{},
+		SurpriseMode:     req.SurpriseMode,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+
+	_, err = s.wishlistCol.InsertOne(ctx, wishlist)
 	if err != nil {
 		log.Error().Err(err).Msg("Erreur lors de la création de la wishlist")
 		return nil, err
 	}
 
 	response := &models.WishlistResponse{
-		ID:          wishlist.ID.Hex(),
-		UserID:      wishlist.UserID.Hex(),
-		Title:       wishlist.Title,
-		Description: wishlist.Description,
-		CoverImage:  wishlist.CoverImage,
-		IsPublic:    wishlist.IsPublic,
-		IsFavorite:  wishlist.IsFavorite,
-		IsOwner:     true,
-		Items:       []models.WishItemResponse{},
-		CreatedAt:   wishlist.CreatedAt,
-		UpdatedAt:   wishlist.UpdatedAt,
-	}
+		ID:           wishlist.ID.Hex(),
+		UserID:       wishlist.UserID.Hex(),
+		Title:        wishlist.Title,
+		Description:  wishlist.Description,
+		CoverImage:   wishlist.CoverImage,
+		IsPublic:     wishlist.IsPublic,
+		IsFavorite:   wishlist.IsFavorite,
+		SurpriseMode: surpriseMode(&wishlist),
+		IsOwner:      true,
+		Items:        []models.WishItemResponse{},
+		CreatedAt:    wishlist.CreatedAt,
+		UpdatedAt:    wishlist.UpdatedAt,
+	}
+	fillRecipient(response, &wishlist, s.getRecipients(ctx, &wishlist))
 
 	return response, nil
 }
@@ -156,20 +192,7 @@ func (s *Service) GetWishlist(ctx context.Context, wishlistID string, requesting
 
 	// Vérifier les autorisations d'accès
 	isOwner := wishlist.UserID == requestingUserID
-	hasAccess := isOwner
-
-	if !hasAccess && wishlist.IsPublic {
-		hasAccess = true
-	}
-
-	if !hasAccess {
-		for _, sharedWith := range wishlist.SharedWith {
-			if sharedWith.UserID == requestingUserID && sharedWith.Status == "accepted" {
-				hasAccess = true
-				break
-			}
-		}
-	}
+	hasAccess := s.can(ctx, &wishlist, requestingUserID, ActionView)
 
 	if !hasAccess {
 		return nil, errors.New("access denied")
@@ -177,16 +200,29 @@ func (s *Service) GetWishlist(ctx context.Context, wishlistID string, requesting
 
 	// Build response...
 	response := &models.WishlistResponse{
-		ID:          wishlist.ID.Hex(),
-		UserID:      wishlist.UserID.Hex(),
-		Title:       wishlist.Title,
-		Description: wishlist.Description,
-		CoverImage:  wishlist.CoverImage,
-		IsPublic:    wishlist.IsPublic,
-		IsFavorite:  wishlist.IsFavorite,
-		IsOwner:     isOwner,
-		CreatedAt:   wishlist.CreatedAt,
-		UpdatedAt:   wishlist.UpdatedAt,
+		ID:            wishlist.ID.Hex(),
+		UserID:        wishlist.UserID.Hex(),
+		Title:         wishlist.Title,
+		Description:   wishlist.Description,
+		CoverImage:    wishlist.CoverImage,
+		IsPublic:      wishlist.IsPublic,
+		IsFavorite:    wishlist.IsFavorite,
+		SurpriseMode:  surpriseMode(&wishlist),
+		IsOwner:       isOwner,
+		Sections:      wishlist.Sections,
+		LayoutVersion: wishlist.LayoutVersion,
+		CreatedAt:     wishlist.CreatedAt,
+		UpdatedAt:     wishlist.UpdatedAt,
+	}
+	fillRecipient(response, &wishlist, s.getRecipients(ctx, &wishlist))
+
+	response.ReservedCount, err = s.reservedCount(ctx, &wishlist, requestingUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isOwner {
+		response.ShareLink = s.shareLinkResponse(wishlist.ShareLink)
 	}
 
 	return response, nil
@@ -206,21 +242,26 @@ func (s *Service) GetUserWishlists(ctx context.Context, userID primitive.ObjectI
 		return nil, err
 	}
 
+	// Les wishlists des comptes gérés de l'utilisateur portent leur destinataire
+	recipients := s.getRecipients(ctx, wishlistRefs(wishlists)...)
+
 	// Convertir en réponses
 	responses := []*models.WishlistResponse{}
 	for _, wishlist := range wishlists {
 		response := &models.WishlistResponse{
-			ID:          wishlist.ID.Hex(),
-			UserID:      wishlist.UserID.Hex(),
-			Title:       wishlist.Title,
-			Description: wishlist.Description,
-			CoverImage:  wishlist.CoverImage,
-			IsPublic:    wishlist.IsPublic,
-			IsFavorite:  wishlist.IsFavorite,
-			IsOwner:     true,
-			CreatedAt:   wishlist.CreatedAt,
-			UpdatedAt:   wishlist.UpdatedAt,
+			ID:           wishlist.ID.Hex(),
+			UserID:       wishlist.UserID.Hex(),
+			Title:        wishlist.Title,
+			Description:  wishlist.Description,
+			CoverImage:   wishlist.CoverImage,
+			IsPublic:     wishlist.IsPublic,
+			IsFavorite:   wishlist.IsFavorite,
+			SurpriseMode: surpriseMode(&wishlist),
+			IsOwner:      true,
+			CreatedAt:    wishlist.CreatedAt,
+			UpdatedAt:    wishlist.UpdatedAt,
 		}
+		fillRecipient(response, &wishlist, recipients)
 		responses = append(responses, response)
 	}
 
@@ -240,7 +281,7 @@ func (s *Service) UpdateWishlist(ctx context.Context, wishlistID string, userID
 		return nil, err
 	}
 
-	if !s.hasEditPermission(wishlist, userID) {
+	if !s.can(ctx, wishlist, userID, ActionEditList) {
 		return nil, errors.New("access denied")
 	}
 
@@ -264,6 +305,16 @@ func (s *Service) UpdateWishlist(ctx context.Context, wishlistID string, userID
 	if req.IsFavorite != nil {
 		update["isFavorite"] = *req.IsFavorite
 	}
+	if req.SurpriseMode != "" {
+		// Seul le propriétaire choisit ce qu'il voit de ses réservations
+		if wishlist.UserID != userID {
+			return nil, errors.New("access denied")
+		}
+		if !isValidSurpriseMode(req.SurpriseMode) {
+			return nil, errors.New("mode surprise invalide")
+		}
+		update["surpriseMode"] = req.SurpriseMode
+	}
 
 	_, err = s.wishlistCol.UpdateOne(
 		ctx,
@@ -274,6 +325,12 @@ func (s *Service) UpdateWishlist(ctx context.Context, wishlistID string, userID
 		return nil, err
 	}
 
+	if changes := changedFields(update); len(changes) > 0 {
+		entry := wishlistActivity(models.ActivityWishlistUpdated, id, userID)
+		entry.Changes = changes
+		s.recordActivity(ctx, entry)
+	}
+
 	// Récupérer la wishlist mise à jour
 	return s.GetWishlist(ctx, wishlistID, userID)
 }
@@ -285,13 +342,13 @@ func (s *Service) DeleteWishlist(ctx context.Context, wishlistID string, userID
 		return err
 	}
 
-	// Vérifier si l'utilisateur est le propriétaire
+	// Seul le propriétaire peut supprimer la wishlist
 	wishlist, err := s.getWishlistByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	if wishlist.UserID != userID {
+	if !s.can(ctx, wishlist, userID, ActionDeleteList) {
 		return errors.New("access denied")
 	}
 
@@ -303,7 +360,17 @@ func (s *Service) DeleteWishlist(ctx context.Context, wishlistID string, userID
 
 	// Supprimer la wishlist
 	_, err = s.wishlistCol.DeleteOne(ctx, bson.M{"_id": id})
-	return err
+	if err != nil {
+		return err
+	}
+
+	// Le fil d'activité n'a plus de destinataires
+	if s.activity != nil {
+		if err := s.activity.DeleteForWishlist(ctx, id); err != nil {
+			log.Warn().Err(err).Str("wishlistID", wishlistID).Msg("Impossible de supprimer l'activité de la wishlist")
+		}
+	}
+	return nil
 }
 
 // ShareWishlist partage une wishlist avec un autre utilisateur
@@ -318,15 +385,26 @@ func (s *Service) ShareWishlist(ctx context.Context, wishlistID string, userID p
 		return err
 	}
 
-	// Vérifier si l'utilisateur est le propriétaire
+	if !IsValidPermission(req.Permission) {
+		return errors.New("permission invalide")
+	}
+
+	// Vérifier si l'utilisateur peut partager la wishlist
 	wishlist, err := s.getWishlistByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	if wishlist.UserID != userID {
+	if !s.can(ctx, wishlist, userID, ActionShare) {
 		return errors.New("access denied")
 	}
+	// Seul le propriétaire nomme des administrateurs
+	if req.Permission == PermissionAdmin && wishlist.UserID != userID {
+		return errors.New("access denied")
+	}
+	if targetUserID == wishlist.UserID {
+		return errors.New("impossible de partager une wishlist avec son propriétaire")
+	}
 
 	sharedWith := models.SharedWith{
 		UserID:     targetUserID,
@@ -335,16 +413,26 @@ func (s *Service) ShareWishlist(ctx context.Context, wishlistID string, userID p
 		SharedAt:   time.Now(),
 	}
 
-	// Mettre à jour la wishlist
-	_, err = s.wishlistCol.UpdateOne(
+	// Mettre à jour la wishlist ; un utilisateur n'a qu'un seul partage par wishlist
+	result, err := s.wishlistCol.UpdateOne(
 		ctx,
-		bson.M{"_id": id},
+		bson.M{"_id": id, "sharedWith.userId": bson.M{"$ne": targetUserID}},
 		bson.M{
 			"$push": bson.M{"sharedWith": sharedWith},
 			"$set":  bson.M{"updatedAt": time.Now()},
 		},
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("wishlist déjà partagée avec cet utilisateur")
+	}
+
+	entry := wishlistActivity(models.ActivityShared, id, userID)
+	entry.TargetUserID = &targetUserID
+	s.recordActivity(ctx, entry)
+	return nil
 }
 
 // GetWishlistInvitations récupère les invitations à des wishlists pour un utilisateur
@@ -367,6 +455,8 @@ func (s *Service) GetWishlistInvitations(ctx context.Context, userID primitive.O
 		return nil, err
 	}
 
+	recipients := s.getRecipients(ctx, wishlistRefs(wishlists)...)
+
 	responses := []*models.WishlistResponse{}
 	for _, wishlist := range wishlists {
 		response := &models.WishlistResponse{
@@ -380,6 +470,7 @@ func (s *Service) GetWishlistInvitations(ctx context.Context, userID primitive.O
 			CreatedAt:   wishlist.CreatedAt,
 			UpdatedAt:   wishlist.UpdatedAt,
 		}
+		fillRecipient(response, &wishlist, recipients)
 		responses = append(responses, response)
 	}
 
@@ -396,7 +487,7 @@ func (s *Service) RespondToInvitation(ctx context.Context, wishlistID string, us
 	status := ShareStatusAccepted
 	if !accept {
 		// Si refusé, on supprime l'invitation
-		_, err = s.wishlistCol.UpdateOne(
+		result, err := s.wishlistCol.UpdateOne(
 			ctx,
 			bson.M{"_id": id},
 			bson.M{
@@ -409,11 +500,17 @@ func (s *Service) RespondToInvitation(ctx context.Context, wishlistID string, us
 				"$set": bson.M{"updatedAt": time.Now()},
 			},
 		)
-		return err
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount > 0 {
+			s.recordActivity(ctx, wishlistActivity(models.ActivityShareDeclined, id, userID))
+		}
+		return nil
 	}
 
 	// Si accepté, on met à jour le statut
-	_, err = s.wishlistCol.UpdateOne(
+	result, err := s.wishlistCol.UpdateOne(
 		ctx,
 		bson.M{
 			"_id": id,
@@ -431,11 +528,17 @@ func (s *Service) RespondToInvitation(ctx context.Context, wishlistID string, us
 			},
 		},
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount > 0 {
+		s.recordActivity(ctx, wishlistActivity(models.ActivityShareAccepted, id, userID))
+	}
+	return nil
 }
 
 // RemoveSharing supprime le partage d'une wishlist avec un utilisateur
-func (s *Service) RemoveSharing(ctx context.Context, wishlistID string, ownerID primitive.ObjectID, targetUserID string) error {
+func (s *Service) RemoveSharing(ctx context.Context, wishlistID string, userID primitive.ObjectID, targetUserID string) error {
 	id, err := primitive.ObjectIDFromHex(wishlistID)
 	if err != nil {
 		return err
@@ -446,15 +549,21 @@ func (s *Service) RemoveSharing(ctx context.Context, wishlistID string, ownerID
 		return err
 	}
 
-	// Vérifier si l'utilisateur est le propriétaire
+	// Vérifier si l'utilisateur peut gérer les partages
 	wishlist, err := s.getWishlistByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	if wishlist.UserID != ownerID {
+	if !s.can(ctx, wishlist, userID, ActionShare) {
 		return errors.New("access denied")
 	}
+	// Seul le propriétaire retire un administrateur
+	for _, share := range wishlist.SharedWith {
+		if share.UserID == targetID && normalizePermission(share.Permission) == PermissionAdmin && wishlist.UserID != userID {
+			return errors.New("access denied")
+		}
+	}
 
 	// Supprimer le partage
 	_, err = s.wishlistCol.UpdateOne(
@@ -469,7 +578,14 @@ func (s *Service) RemoveSharing(ctx context.Context, wishlistID string, ownerID
 			"$set": bson.M{"updatedAt": time.Now()},
 		},
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	entry := wishlistActivity(models.ActivityShareRemoved, id, userID)
+	entry.TargetUserID = &targetID
+	s.recordActivity(ctx, entry)
+	return nil
 }
 
 // CreateWishItem crée un nouvel item dans une wishlist
@@ -485,10 +601,26 @@ func (s *Service) CreateWishItem(ctx context.Context, userID primitive.ObjectID,
 		return nil, err
 	}
 
-	if !s.hasEditPermission(wishlist, userID) {
+	if !s.can(ctx, wishlist, userID, ActionAddItem) {
 		return nil, errors.New("access denied")
 	}
 
+	if req.Priority != "" && !isValidPriority(req.Priority) {
+		return nil, errors.New("priorité invalide")
+	}
+	if req.Quantity != 0 && !isValidQuantity(req.Quantity) {
+		return nil, ErrInvalidQuantity
+	}
+	sectionID, err := parseSectionID(wishlist, req.SectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compléter les informations manquantes à partir du lien
+	if err := s.fillFromLink(ctx, &req); err != nil {
+		return nil, err
+	}
+
 	// Créer le nouvel item
 	item := models.WishItem{
 		ID:          primitive.NewObjectID(),
@@ -501,6 +633,9 @@ func (s *Service) CreateWishItem(ctx context.Context, userID primitive.ObjectID,
 		ImageURL:    req.ImageURL,
 		Link:        req.Link,
 		IsFavorite:  req.IsFavorite,
+		SectionID:   sectionID,
+		Priority:    req.Priority,
+		Quantity:    req.Quantity,
 		IsReserved:  false,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
@@ -511,12 +646,13 @@ func (s *Service) CreateWishItem(ctx context.Context, userID primitive.ObjectID,
 		return nil, err
 	}
 
-	// Ajouter l'item à la wishlist
+	// Ajouter l'item à la fin de la wishlist
 	_, err = s.wishlistCol.UpdateOne(
 		ctx,
 		bson.M{"_id": wishlistID},
 		bson.M{
 			"$push": bson.M{"items": item.ID},
+			"$inc":  bson.M{"layoutVersion": 1},
 			"$set":  bson.M{"updatedAt": time.Now()},
 		},
 	)
@@ -524,26 +660,82 @@ func (s *Service) CreateWishItem(ctx context.Context, userID primitive.ObjectID,
 		return nil, err
 	}
 
+	s.recordActivity(ctx, itemActivity(models.ActivityItemAdded, &item, userID))
+
 	// Créer la réponse
 	response := &models.WishItemResponse{
-		ID:          item.ID.Hex(),
-		WishlistID:  item.WishlistID.Hex(),
-		UserID:      item.UserID.Hex(),
-		Name:        item.Name,
-		Description: item.Description,
-		Price:       item.Price,
-		Currency:    item.Currency,
-		ImageURL:    item.ImageURL,
-		Link:        item.Link,
-		IsFavorite:  item.IsFavorite,
-		IsReserved:  item.IsReserved,
-		CreatedAt:   item.CreatedAt,
-		UpdatedAt:   item.UpdatedAt,
-	}
+		ID:            item.ID.Hex(),
+		WishlistID:    item.WishlistID.Hex(),
+		UserID:        item.UserID.Hex(),
+		Name:          item.Name,
+		Description:   item.Description,
+		Price:         item.Price,
+		Currency:      item.Currency,
+		ImageURL:      item.ImageURL,
+		Link:          item.Link,
+		IsFavorite:    item.IsFavorite,
+		IsReserved:    item.IsReserved,
+		PriceTracking: item.PriceTracking,
+		CreatedAt:     item.CreatedAt,
+		UpdatedAt:     item.UpdatedAt,
+	}
+
+	wishlist.Items = append(wishlist.Items, item.ID)
+	fillLayout(response, &item, wishlist)
+	fillReservation(response, &item, wishlist, userID)
+	fillItemRecipient(response, wishlist, s.getRecipients(ctx, wishlist))
 
 	return response, nil
 }
 
+// fillFromLink complète le nom, l'image et le prix d'une demande de création à partir du lien produit.
+// Les valeurs fournies par l'utilisateur sont conservées.
+func (s *Service) fillFromLink(ctx context.Context, req *models.CreateWishItemRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	complete := req.Name != "" && req.ImageURL != "" && req.Price > 0
+
+	if req.Link != "" && !complete && s.unfurler != nil {
+		preview, err := s.unfurler.Unfurl(ctx, req.Link)
+		if err != nil {
+			if req.Name == "" {
+				return fmt.Errorf("impossible de récupérer les informations du lien: %w", err)
+			}
+			// Le nom suffit à créer l'item, l'aperçu n'est qu'un complément
+			log.Warn().Err(err).Str("link", req.Link).Msg("Impossible de récupérer l'aperçu du lien")
+			return nil
+		}
+
+		if req.Name == "" {
+			req.Name = preview.Name
+		}
+		if req.Description == "" {
+			req.Description = preview.Description
+		}
+		if req.ImageURL == "" {
+			req.ImageURL = preview.ImageURL
+		}
+		if req.Price == 0 && preview.Price > 0 {
+			req.Price = preview.Price
+			if req.Currency == "" {
+				req.Currency = preview.Currency
+			}
+		}
+	}
+
+	if req.Name == "" {
+		return errors.New("nom requis")
+	}
+	return nil
+}
+
+// UnfurlLink renvoie l'aperçu d'un produit à partir de son URL
+func (s *Service) UnfurlLink(ctx context.Context, link string) (*unfurl.Preview, error) {
+	if s.unfurler == nil {
+		return nil, errors.New("aperçu des liens indisponible")
+	}
+	return s.unfurler.Unfurl(ctx, link)
+}
+
 // GetWishItem récupère un item de wishlist par son ID
 func (s *Service) GetWishItem(ctx context.Context, itemID string, userID primitive.ObjectID) (*models.WishItemResponse, error) {
 	id, err := primitive.ObjectIDFromHex(itemID)
@@ -563,42 +755,31 @@ func (s *Service) GetWishItem(ctx context.Context, itemID string, userID primiti
 		return nil, err
 	}
 
-	isOwner := wishlist.UserID == userID
-	hasAccess := isOwner || wishlist.IsPublic
-
-	if !hasAccess {
-		for _, share := range wishlist.SharedWith {
-			if share.UserID == userID && share.Status == ShareStatusAccepted {
-				hasAccess = true
-				break
-			}
-		}
-	}
-
-	if !hasAccess {
+	if !s.can(ctx, wishlist, userID, ActionView) {
 		return nil, errors.New("access denied")
 	}
 
 	// Créer la réponse
 	response := &models.WishItemResponse{
-		ID:          item.ID.Hex(),
-		WishlistID:  item.WishlistID.Hex(),
-		UserID:      item.UserID.Hex(),
-		Name:        item.Name,
-		Description: item.Description,
-		Price:       item.Price,
-		Currency:    item.Currency,
-		ImageURL:    item.ImageURL,
-		Link:        item.Link,
-		IsFavorite:  item.IsFavorite,
-		IsReserved:  item.IsReserved,
-		CreatedAt:   item.CreatedAt,
-		UpdatedAt:   item.UpdatedAt,
-	}
-
-	if item.IsReserved && !item.ReservedBy.IsZero() {
-		response.ReservedBy = item.ReservedBy.Hex()
-	}
+		ID:            item.ID.Hex(),
+		WishlistID:    item.WishlistID.Hex(),
+		UserID:        item.UserID.Hex(),
+		Name:          item.Name,
+		Description:   item.Description,
+		Price:         item.Price,
+		Currency:      item.Currency,
+		ImageURL:      item.ImageURL,
+		Link:          item.Link,
+		IsFavorite:    item.IsFavorite,
+		IsReserved:    item.IsReserved,
+		PriceTracking: item.PriceTracking,
+		CreatedAt:     item.CreatedAt,
+		UpdatedAt:     item.UpdatedAt,
+	}
+
+	fillLayout(response, item, wishlist)
+	fillReservation(response, item, wishlist, userID)
+	fillItemRecipient(response, wishlist, s.getRecipients(ctx, wishlist))
 
 	return response, nil
 }
@@ -622,7 +803,7 @@ func (s *Service) UpdateWishItem(ctx context.Context, itemID string, userID prim
 		return nil, err
 	}
 
-	if !s.hasEditPermission(wishlist, userID) {
+	if !s.can(ctx, wishlist, userID, ActionEditItem) {
 		return nil, errors.New("access denied")
 	}
 
@@ -652,6 +833,18 @@ func (s *Service) UpdateWishItem(ctx context.Context, itemID string, userID prim
 	if req.IsFavorite != nil {
 		update["isFavorite"] = *req.IsFavorite
 	}
+	if req.Priority != "" {
+		if !isValidPriority(req.Priority) {
+			return nil, errors.New("priorité invalide")
+		}
+		update["priority"] = req.Priority
+	}
+	quantityChanged := req.Quantity != 0 && req.Quantity != itemQuantity(item)
+	if quantityChanged {
+		if err := s.updateQuantity(ctx, item, req.Quantity); err != nil {
+			return nil, err
+		}
+	}
 
 	_, err = s.wishItemCol.UpdateOne(
 		ctx,
@@ -662,6 +855,16 @@ func (s *Service) UpdateWishItem(ctx context.Context, itemID string, userID prim
 		return nil, err
 	}
 
+	changes := changedFields(update)
+	if quantityChanged {
+		changes = append(changes, "quantity")
+	}
+	if len(changes) > 0 {
+		entry := itemActivity(models.ActivityItemUpdated, item, userID)
+		entry.Changes = changes
+		s.recordActivity(ctx, entry)
+	}
+
 	// Récupérer l'item mis à jour
 	return s.GetWishItem(ctx, itemID, userID)
 }
@@ -685,7 +888,7 @@ func (s *Service) DeleteWishItem(ctx context.Context, itemID string, userID prim
 		return err
 	}
 
-	if !s.hasEditPermission(wishlist, userID) {
+	if !s.can(ctx, wishlist, userID, ActionDeleteItem) {
 		return errors.New("access denied")
 	}
 
@@ -695,6 +898,7 @@ func (s *Service) DeleteWishItem(ctx context.Context, itemID string, userID prim
 		bson.M{"_id": item.WishlistID},
 		bson.M{
 			"$pull": bson.M{"items": id},
+			"$inc":  bson.M{"layoutVersion": 1},
 			"$set":  bson.M{"updatedAt": time.Now()},
 		},
 	)
@@ -704,7 +908,12 @@ func (s *Service) DeleteWishItem(ctx context.Context, itemID string, userID prim
 
 	// Supprimer l'item
 	_, err = s.wishItemCol.DeleteOne(ctx, bson.M{"_id": id})
-	return err
+	if err != nil {
+		return err
+	}
+
+	s.recordActivity(ctx, itemActivity(models.ActivityItemRemoved, item, userID))
+	return nil
 }
 
 // ReserveWishItem réserve un item de wishlist
@@ -720,58 +929,94 @@ func (s *Service) ReserveWishItem(ctx context.Context, itemID string, userID pri
 		return err
 	}
 
-	// Ne pas permettre au propriétaire de l'item de le réserver
-	if item.UserID == userID {
-		return errors.New("vous ne pouvez pas réserver votre propre item")
+	// Vérifier l'accès à la wishlist associée avant de révéler quoi que ce soit de l'item
+	wishlist, err := s.getWishlistByID(ctx, item.WishlistID)
+	if err != nil {
+		return err
 	}
 
-	// Vérifier si l'item est déjà réservé
-	if item.IsReserved && req.Reserve {
-		return errors.New("cet item est déjà réservé")
+	if !s.can(ctx, wishlist, userID, ActionReserve) {
+		return errors.New("access denied")
 	}
 
-	// Vérifier l'accès à la wishlist associée
-	wishlist, err := s.getWishlistByID(ctx, item.WishlistID)
-	if err != nil {
-		return err
+	return s.reserve(ctx, item, wishlist, userID, req, nil)
+}
+
+// reserve réserve, ou libère, un item pour un utilisateur dont l'accès a déjà été vérifié.
+// eventID rattache la réservation à l'événement depuis lequel elle est faite, s'il y en a un.
+func (s *Service) reserve(ctx context.Context, item *models.WishItem, wishlist *models.Wishlist, userID primitive.ObjectID, req models.ReserveWishItemRequest, eventID *primitive.ObjectID) error {
+	// Ne pas permettre au propriétaire de la wishlist ou de l'item de le réserver ;
+	// le parent peut offrir les cadeaux de la wishlist d'un compte géré
+	if wishlist.ManagedAccountID == nil && (item.UserID == userID || wishlist.UserID == userID) {
+		return ErrOwnItem
 	}
 
-	hasAccess := wishlist.IsPublic || wishlist.UserID == userID
-	if !hasAccess {
-		for _, share := range wishlist.SharedWith {
-			if share.UserID == userID && share.Status == ShareStatusAccepted {
-				hasAccess = true
-				break
-			}
-		}
+	// Vérifier si l'item est déjà réservé
+	if item.IsReserved && req.Reserve {
+		return ErrAlreadyReserved
 	}
 
-	if !hasAccess {
-		return errors.New("access denied")
+	// Les items souhaités en plusieurs exemplaires se réservent en partie
+	if itemQuantity(item) > 1 || item.ReservedQuantity > 0 {
+		return s.reservePartial(ctx, item, userID, req, eventID)
 	}
 
 	// Mettre à jour l'état de réservation
+	now := time.Now()
 	update := bson.M{
-		"updatedAt": time.Now(),
+		"updatedAt": now,
 	}
+	unset := bson.M{
+		"reservedForEventId": "",
+		"guestReservationId": "",
+		"reservedByGuest":    "",
+		"purchasedAt":        "",
+		"receivedAt":         "",
+	}
+	filter := bson.M{"_id": item.ID}
 
 	if !req.Reserve {
-		if !item.IsReserved || item.ReservedBy != userID {
-			return errors.New("vous ne pouvez pas annuler cette réservation")
+		// Un cadeau acheté ou reçu ne peut plus être libéré
+		if !item.IsReserved || item.ReservedBy != userID || reservationStatus(item) != ReservationReserved {
+			return ErrCannotCancel
 		}
 		update["isReserved"] = false
 		update["reservedBy"] = primitive.NilObjectID
+		unset["reservationStatus"] = ""
+		unset["reservedAt"] = ""
+		filter["reservedBy"] = userID
 	} else {
 		update["isReserved"] = true
 		update["reservedBy"] = userID
+		update["reservationStatus"] = ReservationReserved
+		update["reservedAt"] = now
+		if eventID != nil {
+			update["reservedForEventId"] = *eventID
+			delete(unset, "reservedForEventId")
+		}
+		filter["isReserved"] = bson.M{"$ne": true}
 	}
 
-	_, err = s.wishItemCol.UpdateOne(
+	result, err := s.wishItemCol.UpdateOne(
 		ctx,
-		bson.M{"_id": id},
-		bson.M{"$set": update},
+		filter,
+		bson.M{"$set": update, "$unset": unset},
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrAlreadyReserved
+	}
+
+	kind := models.ActivityItemReserved
+	if !req.Reserve {
+		kind = models.ActivityReservationCancelled
+	}
+	entry := itemActivity(kind, item, userID)
+	entry.EventID = eventID
+	s.recordActivity(ctx, entry)
+	return nil
 }
 
 // GetUserWishItems récupère tous les items de wishlist créés par un utilisateur
@@ -787,28 +1032,37 @@ func (s *Service) GetUserWishItems(ctx context.Context, userID primitive.ObjectI
 		return nil, err
 	}
 
+	// Récupérer les wishlists des items pour appliquer leur mode surprise
+	wishlistsByID, err := s.getWishlistsByID(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := s.getRecipients(ctx, wishlistRefsByID(wishlistsByID)...)
+
 	// Convertir en réponses
 	responses := []*models.WishItemResponse{}
 	for _, item := range items {
 		response := &models.WishItemResponse{
-			ID:          item.ID.Hex(),
-			WishlistID:  item.WishlistID.Hex(),
-			UserID:      item.UserID.Hex(),
-			Name:        item.Name,
-			Description: item.Description,
-			Price:       item.Price,
-			Currency:    item.Currency,
-			ImageURL:    item.ImageURL,
-			Link:        item.Link,
-			IsFavorite:  item.IsFavorite,
-			IsReserved:  item.IsReserved,
-			CreatedAt:   item.CreatedAt,
-			UpdatedAt:   item.UpdatedAt,
+			ID:            item.ID.Hex(),
+			WishlistID:    item.WishlistID.Hex(),
+			UserID:        item.UserID.Hex(),
+			Name:          item.Name,
+			Description:   item.Description,
+			Price:         item.Price,
+			Currency:      item.Currency,
+			ImageURL:      item.ImageURL,
+			Link:          item.Link,
+			IsFavorite:    item.IsFavorite,
+			IsReserved:    item.IsReserved,
+			PriceTracking: item.PriceTracking,
+			CreatedAt:     item.CreatedAt,
+			UpdatedAt:     item.UpdatedAt,
 		}
 
-		if item.IsReserved && !item.ReservedBy.IsZero() {
-			response.ReservedBy = item.ReservedBy.Hex()
-		}
+		fillLayout(response, &item, wishlistsByID[item.WishlistID])
+		fillReservation(response, &item, wishlistsByID[item.WishlistID], userID)
+		fillItemRecipient(response, wishlistsByID[item.WishlistID], recipients)
 
 		responses = append(responses, response)
 	}
@@ -829,19 +1083,7 @@ func (s *Service) GetWishlistItems(ctx context.Context, wishlistID string, userI
 		return nil, err
 	}
 
-	isOwner := wishlist.UserID == userID
-	hasAccess := isOwner || wishlist.IsPublic
-
-	if !hasAccess {
-		for _, share := range wishlist.SharedWith {
-			if share.UserID == userID && share.Status == ShareStatusAccepted {
-				hasAccess = true
-				break
-			}
-		}
-	}
-
-	if !hasAccess {
+	if !s.can(ctx, wishlist, userID, ActionView) {
 		return nil, errors.New("access denied")
 	}
 
@@ -857,32 +1099,37 @@ func (s *Service) GetWishlistItems(ctx context.Context, wishlistID string, userI
 		return nil, err
 	}
 
+	recipients := s.getRecipients(ctx, wishlist)
+
 	// Convertir en réponses
 	responses := []*models.WishItemResponse{}
 	for _, item := range items {
 		response := &models.WishItemResponse{
-			ID:          item.ID.Hex(),
-			WishlistID:  item.WishlistID.Hex(),
-			UserID:      item.UserID.Hex(),
-			Name:        item.Name,
-			Description: item.Description,
-			Price:       item.Price,
-			Currency:    item.Currency,
-			ImageURL:    item.ImageURL,
-			Link:        item.Link,
-			IsFavorite:  item.IsFavorite,
-			IsReserved:  item.IsReserved,
-			CreatedAt:   item.CreatedAt,
-			UpdatedAt:   item.UpdatedAt,
+			ID:            item.ID.Hex(),
+			WishlistID:    item.WishlistID.Hex(),
+			UserID:        item.UserID.Hex(),
+			Name:          item.Name,
+			Description:   item.Description,
+			Price:         item.Price,
+			Currency:      item.Currency,
+			ImageURL:      item.ImageURL,
+			Link:          item.Link,
+			IsFavorite:    item.IsFavorite,
+			IsReserved:    item.IsReserved,
+			PriceTracking: item.PriceTracking,
+			CreatedAt:     item.CreatedAt,
+			UpdatedAt:     item.UpdatedAt,
 		}
 
-		if item.IsReserved && !item.ReservedBy.IsZero() {
-			response.ReservedBy = item.ReservedBy.Hex()
-		}
+		fillLayout(response, &item, wishlist)
+		fillReservation(response, &item, wishlist, userID)
+		fillItemRecipient(response, wishlist, recipients)
 
 		responses = append(responses, response)
 	}
 
+	sortByPosition(responses)
+
 	return responses, nil
 }
 
@@ -929,23 +1176,27 @@ func (s *Service) SearchWishlists(ctx context.Context, userID primitive.ObjectID
 		return nil, err
 	}
 
+	recipients := s.getRecipients(ctx, wishlistRefs(wishlists)...)
+
 	// Convertir en réponses
 	responses := []*models.WishlistResponse{}
 	for _, wishlist := range wishlists {
 		isOwner := wishlist.UserID == userID
 
 		response := &models.WishlistResponse{
-			ID:          wishlist.ID.Hex(),
-			UserID:      wishlist.UserID.Hex(),
-			Title:       wishlist.Title,
-			Description: wishlist.Description,
-			CoverImage:  wishlist.CoverImage,
-			IsPublic:    wishlist.IsPublic,
-			IsFavorite:  wishlist.IsFavorite,
-			IsOwner:     isOwner,
-			CreatedAt:   wishlist.CreatedAt,
-			UpdatedAt:   wishlist.UpdatedAt,
+			ID:           wishlist.ID.Hex(),
+			UserID:       wishlist.UserID.Hex(),
+			Title:        wishlist.Title,
+			Description:  wishlist.Description,
+			CoverImage:   wishlist.CoverImage,
+			IsPublic:     wishlist.IsPublic,
+			IsFavorite:   wishlist.IsFavorite,
+			SurpriseMode: surpriseMode(&wishlist),
+			IsOwner:      isOwner,
+			CreatedAt:    wishlist.CreatedAt,
+			UpdatedAt:    wishlist.UpdatedAt,
 		}
+		fillRecipient(response, &wishlist, recipients)
 
 		responses = append(responses, response)
 	}
@@ -1012,28 +1263,36 @@ func (s *Service) SearchWishItems(ctx context.Context, userID primitive.ObjectID
 		return nil, err
 	}
 
+	wishlistsByID := make(map[primitive.ObjectID]*models.Wishlist, len(wishlists))
+	for i := range wishlists {
+		wishlistsByID[wishlists[i].ID] = &wishlists[i]
+	}
+
+	recipients := s.getRecipients(ctx, wishlistRefsByID(wishlistsByID)...)
+
 	// Convertir en réponses
 	responses := []*models.WishItemResponse{}
 	for _, item := range items {
 		response := &models.WishItemResponse{
-			ID:          item.ID.Hex(),
-			WishlistID:  item.WishlistID.Hex(),
-			UserID:      item.UserID.Hex(),
-			Name:        item.Name,
-			Description: item.Description,
-			Price:       item.Price,
-			Currency:    item.Currency,
-			ImageURL:    item.ImageURL,
-			Link:        item.Link,
-			IsFavorite:  item.IsFavorite,
-			IsReserved:  item.IsReserved,
-			CreatedAt:   item.CreatedAt,
-			UpdatedAt:   item.UpdatedAt,
+			ID:            item.ID.Hex(),
+			WishlistID:    item.WishlistID.Hex(),
+			UserID:        item.UserID.Hex(),
+			Name:          item.Name,
+			Description:   item.Description,
+			Price:         item.Price,
+			Currency:      item.Currency,
+			ImageURL:      item.ImageURL,
+			Link:          item.Link,
+			IsFavorite:    item.IsFavorite,
+			IsReserved:    item.IsReserved,
+			PriceTracking: item.PriceTracking,
+			CreatedAt:     item.CreatedAt,
+			UpdatedAt:     item.UpdatedAt,
 		}
 
-		if item.IsReserved && !item.ReservedBy.IsZero() {
-			response.ReservedBy = item.ReservedBy.Hex()
-		}
+		fillLayout(response, &item, wishlistsByID[item.WishlistID])
+		fillReservation(response, &item, wishlistsByID[item.WishlistID], userID)
+		fillItemRecipient(response, wishlistsByID[item.WishlistID], recipients)
 
 		responses = append(responses, response)
 	}
@@ -1057,8 +1316,8 @@ func (s *Service) UploadWishlistCover(ctx context.Context, wishlistID string, us
 		return "", err
 	}
 
-	// Vérifier que l'utilisateur est le propriétaire ou a des droits d'édition
-	if wishlist.UserID != userID && !s.hasEditPermission(wishlist, userID) {
+	// Vérifier que l'utilisateur peut modifier la wishlist
+	if !s.can(ctx, wishlist, userID, ActionEditList) {
 		return "", errors.New("access denied")
 	}
 
@@ -1154,7 +1413,7 @@ func (s *Service) UploadWishItemImage(ctx context.Context, itemID string, userID
 		return "", err
 	}
 
-	if !s.hasEditPermission(wishlist, userID) {
+	if !s.can(ctx, wishlist, userID, ActionEditItem) {
 		return "", errors.New("access denied")
 	}
 
@@ -0,0 +1,584 @@
+package wishlist
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"genie/internal/models"
+	"genie/internal/scraper"
+)
+
+// Limites des modèles de wishlist
+const (
+	maxTemplateNameLen      = 80
+	maxCuratedTemplateItems = 30
+)
+
+// InspirationSource fournit les catégories d'inspiration du scraper,
+// à partir desquelles les modèles proposés par Genie sont créés
+type InspirationSource interface {
+	GetCachedInspirations() (map[string]scraper.Inspiration, time.Time, bool)
+}
+
+// amazonProductPath extrait l'identifiant ASIN d'un lien produit Amazon
+var amazonProductPath = regexp.MustCompile(`/(?:dp|gp/product|gp/aw/d)/([A-Z0-9]{10})`)
+
+// trackingParams sont les paramètres de suivi ignorés pour comparer deux liens produit
+var trackingParams = map[string]bool{
+	"fbclid": true,
+	"gclid":  true,
+	"ref":    true,
+	"ref_":   true,
+	"tag":    true,
+}
+
+// normalizeLink renvoie une forme comparable d'un lien produit, vide si le lien est vide ou invalide
+func normalizeLink(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if strings.Contains(host, "amazon.") {
+		if match := amazonProductPath.FindStringSubmatch(u.Path); match != nil {
+			return host + "/dp/" + match[1]
+		}
+	}
+
+	query := u.Query()
+	for key := range query {
+		if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+
+	normalized := host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if encoded := query.Encode(); encoded != "" {
+		normalized += "?" + encoded
+	}
+	return normalized
+}
+
+// ensureTemplateIndexes crée les index des modèles de wishlist
+func (s *Service) ensureTemplateIndexes(ctx context.Context) {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "name", Value: 1}},
+			Options: options.Index().SetBackground(true),
+		},
+		{
+			Keys:    bson.D{{Key: "curated", Value: 1}, {Key: "sourceId", Value: 1}},
+			Options: options.Index().SetBackground(true),
+		},
+	}
+	if _, err := s.templateCol.Indexes().CreateMany(ctx, indexes); err != nil {
+		log.Warn().Err(err).Msg("Impossible de créer les index des modèles de wishlist")
+	}
+}
+
+// getOrderedItems récupère les items d'une wishlist dans leur ordre d'affichage
+func (s *Service) getOrderedItems(ctx context.Context, wishlist *models.Wishlist) ([]models.WishItem, error) {
+	cursor, err := s.wishItemCol.Find(ctx, bson.M{"wishlistId": wishlist.ID})
+	if err != nil {
+		return nil, err
+	}
+	var items []models.WishItem
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+
+	positions := make(map[primitive.ObjectID]int, len(wishlist.Items))
+	for i, id := range wishlist.Items {
+		positions[id] = i
+	}
+	position := func(item *models.WishItem) int {
+		if i, found := positions[item.ID]; found {
+			return i
+		}
+		// Les items absents de l'ordre enregistré sont placés à la fin
+		return len(wishlist.Items)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if position(&items[i]) != position(&items[j]) {
+			return position(&items[i]) < position(&items[j])
+		}
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	return items, nil
+}
+
+// newItem prépare un nouvel item, sans réservation ni suivi de prix, à partir d'un item de modèle
+func newItem(source models.TemplateItem, wishlistID primitive.ObjectID, userID primitive.ObjectID, sectionID *primitive.ObjectID, now time.Time) models.WishItem {
+	return models.WishItem{
+		ID:          primitive.NewObjectID(),
+		WishlistID:  wishlistID,
+		UserID:      userID,
+		Name:        source.Name,
+		Description: source.Description,
+		Price:       source.Price,
+		Currency:    source.Currency,
+		ImageURL:    source.ImageURL,
+		Link:        source.Link,
+		SectionID:   sectionID,
+		Priority:    source.Priority,
+		Quantity:    source.Quantity,
+		IsReserved:  false,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// templateItem extrait d'un item ce qui peut être recopié dans une autre wishlist
+func templateItem(item *models.WishItem, wishlist *models.Wishlist, includeImages bool) models.TemplateItem {
+	copied := models.TemplateItem{
+		Name:        item.Name,
+		Description: item.Description,
+		Price:       item.Price,
+		Currency:    item.Currency,
+		Link:        item.Link,
+		Priority:    item.Priority,
+		Quantity:    item.Quantity,
+	}
+	if includeImages {
+		copied.ImageURL = item.ImageURL
+	}
+	if item.SectionID != nil {
+		if section := findSection(wishlist, *item.SectionID); section != nil {
+			copied.Section = section.Name
+		}
+	}
+	return copied
+}
+
+// templateFromWishlist construit un modèle à partir d'une wishlist et de ses items
+func templateFromWishlist(wishlist *models.Wishlist, items []models.WishItem, includeImages bool) models.WishlistTemplate {
+	template := models.WishlistTemplate{
+		Description: wishlist.Description,
+		Items:       make([]models.TemplateItem, 0, len(items)),
+	}
+	if includeImages {
+		template.CoverImage = wishlist.CoverImage
+	}
+	for _, section := range wishlist.Sections {
+		template.Sections = append(template.Sections, section.Name)
+	}
+	for i := range items {
+		template.Items = append(template.Items, templateItem(&items[i], wishlist, includeImages))
+	}
+	return template
+}
+
+// createFromTemplate crée une wishlist et ses items à partir d'un modèle.
+// managedAccountID désigne le compte géré destinataire ; il peut être vide.
+func (s *Service) createFromTemplate(ctx context.Context, userID primitive.ObjectID, managedAccountID string, title string, isPublic bool, template models.WishlistTemplate) (*models.WishlistResponse, error) {
+	recipientID, err := s.resolveManagedAccount(ctx, userID, managedAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	wishlist := models.Wishlist{
+		ID:               primitive.NewObjectID(),
+		UserID:           userID,
+		ManagedAccountID: recipientID,
+		Title:            title,
+		Description:      template.Description,
+		CoverImage:       template.CoverImage,
+		IsPublic:         isPublic,
+		Items:            []primitive.ObjectID{},
+		SharedWith:       []models.SharedWith{},
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	sectionsByName := make(map[string]primitive.ObjectID, len(template.Sections))
+	for _, name := range template.Sections {
+		if _, found := sectionsByName[name]; found || len(wishlist.Sections) >= maxSections {
+			continue
+		}
+		section := models.WishlistSection{ID: primitive.NewObjectID(), Name: name}
+		wishlist.Sections = append(wishlist.Sections, section)
+		sectionsByName[name] = section.ID
+	}
+
+	items := make([]interface{}, 0, len(template.Items))
+	for _, source := range template.Items {
+		var sectionID *primitive.ObjectID
+		if id, found := sectionsByName[source.Section]; found {
+			sectionID = &id
+		}
+		item := newItem(source, wishlist.ID, userID, sectionID, now)
+		wishlist.Items = append(wishlist.Items, item.ID)
+		items = append(items, item)
+	}
+
+	if len(items) > 0 {
+		if _, err := s.wishItemCol.InsertMany(ctx, items); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := s.wishlistCol.InsertOne(ctx, wishlist); err != nil {
+		// Ne pas laisser d'items sans wishlist
+		if _, deleteErr := s.wishItemCol.DeleteMany(ctx, bson.M{"wishlistId": wishlist.ID}); deleteErr != nil {
+			log.Warn().Err(deleteErr).Str("wishlistID", wishlist.ID.Hex()).Msg("Impossible de supprimer les items de la wishlist non créée")
+		}
+		return nil, err
+	}
+
+	return s.GetWishlist(ctx, wishlist.ID.Hex(), userID)
+}
+
+// CloneWishlist duplique une wishlist, ses sections et ses items, sans les réservations ni les partages.
+// Les images ne sont reprises que si includeImages est demandé.
+func (s *Service) CloneWishlist(ctx context.Context, wishlistID string, userID primitive.ObjectID, req models.CloneWishlistRequest) (*models.WishlistResponse, error) {
+	wishlist, err := s.getAuthorizedWishlist(ctx, wishlistID, userID, ActionView)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.getOrderedItems(ctx, wishlist)
+	if err != nil {
+		return nil, err
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = wishlist.Title + " (copie)"
+	}
+
+	return s.createFromTemplate(ctx, userID, req.ManagedAccountID, title, false, templateFromWishlist(wishlist, items, req.IncludeImages))
+}
+
+// SaveAsTemplate enregistre une wishlist comme modèle réutilisable par l'utilisateur
+func (s *Service) SaveAsTemplate(ctx context.Context, wishlistID string, userID primitive.ObjectID, req models.SaveTemplateRequest) (*models.WishlistTemplate, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len([]rune(name)) > maxTemplateNameLen {
+		return nil, errors.New("nom de modèle invalide")
+	}
+
+	wishlist, err := s.getAuthorizedWishlist(ctx, wishlistID, userID, ActionView)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.getOrderedItems(ctx, wishlist)
+	if err != nil {
+		return nil, err
+	}
+
+	template := templateFromWishlist(wishlist, items, req.IncludeImages)
+	template.ID = primitive.NewObjectID()
+	template.UserID = &userID
+	template.Name = name
+	if req.Description != "" {
+		template.Description = req.Description
+	}
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = template.CreatedAt
+
+	if _, err := s.templateCol.InsertOne(ctx, template); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetTemplates récupère les modèles de l'utilisateur puis ceux proposés par Genie
+func (s *Service) GetTemplates(ctx context.Context, userID primitive.ObjectID) ([]models.WishlistTemplate, error) {
+	s.syncCuratedTemplates(ctx)
+
+	opts := options.Find().SetSort(bson.D{{Key: "curated", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := s.templateCol.Find(ctx, bson.M{
+		"$or": []bson.M{
+			{"userId": userID},
+			{"curated": true},
+		},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := []models.WishlistTemplate{}
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// DeleteTemplate supprime un modèle enregistré par l'utilisateur
+func (s *Service) DeleteTemplate(ctx context.Context, templateID string, userID primitive.ObjectID) error {
+	id, err := primitive.ObjectIDFromHex(templateID)
+	if err != nil {
+		return errors.New("template not found")
+	}
+
+	result, err := s.templateCol.DeleteOne(ctx, bson.M{"_id": id, "userId": userID, "curated": false})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("template not found")
+	}
+	return nil
+}
+
+// UseTemplate crée une wishlist à partir d'un modèle de l'utilisateur ou proposé par Genie
+func (s *Service) UseTemplate(ctx context.Context, templateID string, userID primitive.ObjectID, req models.UseTemplateRequest) (*models.WishlistResponse, error) {
+	id, err := primitive.ObjectIDFromHex(templateID)
+	if err != nil {
+		return nil, errors.New("template not found")
+	}
+
+	var template models.WishlistTemplate
+	err = s.templateCol.FindOne(ctx, bson.M{
+		"_id": id,
+		"$or": []bson.M{
+			{"userId": userID},
+			{"curated": true},
+		},
+	}).Decode(&template)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("template not found")
+		}
+		return nil, err
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = template.Name
+	}
+
+	return s.createFromTemplate(ctx, userID, req.ManagedAccountID, title, req.IsPublic, template)
+}
+
+// syncCuratedTemplates met à jour les modèles proposés par Genie lorsque le cache
+// des inspirations du scraper a été renouvelé depuis la dernière synchronisation
+func (s *Service) syncCuratedTemplates(ctx context.Context) {
+	if s.inspirations == nil {
+		return
+	}
+
+	inspirations, lastUpdated, _ := s.inspirations.GetCachedInspirations()
+	if len(inspirations) == 0 {
+		return
+	}
+
+	s.curatedMu.Lock()
+	defer s.curatedMu.Unlock()
+	if !lastUpdated.After(s.curatedSyncedAt) {
+		return
+	}
+
+	now := time.Now()
+	sourceIDs := make([]string, 0, len(inspirations))
+	writes := make([]mongo.WriteModel, 0, len(inspirations))
+	for id, inspiration := range inspirations {
+		items := []models.TemplateItem{}
+		for _, product := range inspiration.Products {
+			if len(items) >= maxCuratedTemplateItems {
+				break
+			}
+			if product.Title == "" {
+				continue
+			}
+			items = append(items, models.TemplateItem{
+				Name:        product.Title,
+				Description: product.Description,
+				Price:       product.Price,
+				Currency:    product.Currency,
+				ImageURL:    product.ImageURL,
+				Link:        product.URL,
+			})
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		name := inspiration.Name
+		if name == "" {
+			name = id
+		}
+		sourceIDs = append(sourceIDs, id)
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"curated": true, "sourceId": id}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"name":       name,
+					"coverImage": inspiration.Image,
+					"items":      items,
+					"updatedAt":  now,
+				},
+				"$setOnInsert": bson.M{"createdAt": now},
+			}).
+			SetUpsert(true))
+	}
+	if len(writes) == 0 {
+		return
+	}
+
+	if _, err := s.templateCol.BulkWrite(ctx, writes); err != nil {
+		log.Warn().Err(err).Msg("Impossible de mettre à jour les modèles de wishlist proposés")
+		return
+	}
+	// Les catégories qui ont disparu du scraper ne sont plus proposées
+	if _, err := s.templateCol.DeleteMany(ctx, bson.M{"curated": true, "sourceId": bson.M{"$nin": sourceIDs}}); err != nil {
+		log.Warn().Err(err).Msg("Impossible de supprimer les anciens modèles de wishlist proposés")
+	}
+	s.curatedSyncedAt = lastUpdated
+}
+
+// MergeWishlists ajoute les items d'une wishlist source à la wishlist cible, en ignorant
+// ceux dont le lien produit y figure déjà. Les sections de la source sont reprises par leur nom.
+// Avec DeleteSource, les items sont déplacés avec leurs réservations et la source est supprimée ;
+// sinon ils sont copiés, sans réservation.
+func (s *Service) MergeWishlists(ctx context.Context, targetID string, userID primitive.ObjectID, req models.MergeWishlistsRequest) (*models.MergeWishlistsResponse, error) {
+	if targetID == req.SourceID {
+		return nil, errors.New("impossible de fusionner une wishlist avec elle-même")
+	}
+
+	target, err := s.getAuthorizedWishlist(ctx, targetID, userID, ActionAddItem)
+	if err != nil {
+		return nil, err
+	}
+	sourceAction := ActionView
+	if req.DeleteSource {
+		sourceAction = ActionDeleteList
+	}
+	source, err := s.getAuthorizedWishlist(ctx, req.SourceID, userID, sourceAction)
+	if err != nil {
+		return nil, err
+	}
+
+	targetItems, err := s.getOrderedItems(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	sourceItems, err := s.getOrderedItems(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(targetItems))
+	for _, item := range targetItems {
+		if link := normalizeLink(item.Link); link != "" {
+			seen[link] = true
+		}
+	}
+
+	// Sections de la cible par nom, complétées par celles de la source
+	sectionsByName := make(map[string]primitive.ObjectID, len(target.Sections))
+	for _, section := range target.Sections {
+		sectionsByName[strings.ToLower(section.Name)] = section.ID
+	}
+	newSections := []models.WishlistSection{}
+	sectionFor := func(item *models.WishItem) *primitive.ObjectID {
+		if item.SectionID == nil {
+			return nil
+		}
+		section := findSection(source, *item.SectionID)
+		if section == nil {
+			return nil
+		}
+		key := strings.ToLower(section.Name)
+		if id, found := sectionsByName[key]; found {
+			return &id
+		}
+		if len(target.Sections)+len(newSections) >= maxSections {
+			return nil
+		}
+		created := models.WishlistSection{ID: primitive.NewObjectID(), Name: section.Name}
+		newSections = append(newSections, created)
+		sectionsByName[key] = created.ID
+		return &created.ID
+	}
+
+	now := time.Now()
+	result := &models.MergeWishlistsResponse{}
+	added := []models.WishItem{}
+	writes := []mongo.WriteModel{}
+	for i := range sourceItems {
+		item := &sourceItems[i]
+		if link := normalizeLink(item.Link); link != "" {
+			if seen[link] {
+				result.Skipped++
+				continue
+			}
+			seen[link] = true
+		}
+
+		sectionID := sectionFor(item)
+		if req.DeleteSource {
+			update := bson.M{"$set": bson.M{"wishlistId": target.ID, "updatedAt": now}}
+			if sectionID == nil {
+				update["$unset"] = bson.M{"sectionId": ""}
+			} else {
+				update["$set"] = bson.M{"wishlistId": target.ID, "sectionId": *sectionID, "updatedAt": now}
+			}
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": item.ID, "wishlistId": source.ID}).
+				SetUpdate(update))
+			item.WishlistID = target.ID
+			added = append(added, *item)
+			continue
+		}
+
+		copied := newItem(templateItem(item, source, true), target.ID, userID, sectionID, now)
+		writes = append(writes, mongo.NewInsertOneModel().SetDocument(copied))
+		added = append(added, copied)
+	}
+
+	if len(writes) > 0 {
+		if _, err := s.wishItemCol.BulkWrite(ctx, writes); err != nil {
+			return nil, err
+		}
+	}
+
+	addedIDs := make([]primitive.ObjectID, 0, len(added))
+	for _, item := range added {
+		addedIDs = append(addedIDs, item.ID)
+	}
+	_, err = s.wishlistCol.UpdateOne(ctx,
+		bson.M{"_id": target.ID},
+		bson.M{
+			"$push": bson.M{
+				"items":    bson.M{"$each": addedIDs},
+				"sections": bson.M{"$each": newSections},
+			},
+			"$inc": bson.M{"layoutVersion": 1},
+			"$set": bson.M{"updatedAt": now},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range added {
+		s.recordActivity(ctx, itemActivity(models.ActivityItemAdded, &added[i], userID))
+	}
+
+	if req.DeleteSource {
+		// Les doublons restés dans la source sont supprimés avec elle
+		if err := s.DeleteWishlist(ctx, req.SourceID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Added = len(added)
+	result.Wishlist, err = s.GetWishlist(ctx, targetID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
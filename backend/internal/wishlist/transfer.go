@@ -0,0 +1,224 @@
+package wishlist
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"genie/internal/models"
+)
+
+// Résultats de l'import d'une ligne
+const (
+	ImportStatusImported  = "imported"
+	ImportStatusDuplicate = "duplicate"
+	ImportStatusInvalid   = "invalid"
+)
+
+// maxImportRows est le nombre maximal de lignes lues dans un fichier importé
+const maxImportRows = 500
+
+// ExportWishlist exporte une wishlist et ses items, sans les réservations ni les partages
+func (s *Service) ExportWishlist(ctx context.Context, wishlistID string, userID primitive.ObjectID) (*models.WishlistExport, error) {
+	wishlist, err := s.getAuthorizedWishlist(ctx, wishlistID, userID, ActionView)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.getOrderedItems(ctx, wishlist)
+	if err != nil {
+		return nil, err
+	}
+
+	template := templateFromWishlist(wishlist, items, true)
+	return &models.WishlistExport{
+		Version:     exportVersion,
+		Title:       wishlist.Title,
+		Description: wishlist.Description,
+		Sections:    template.Sections,
+		Items:       template.Items,
+		ExportedAt:  time.Now(),
+	}, nil
+}
+
+// ImportNewWishlist crée une wishlist à partir d'un fichier importé.
+// Un titre vide reprend celui du fichier.
+func (s *Service) ImportNewWishlist(ctx context.Context, userID primitive.ObjectID, req models.ImportWishlistRequest, data []byte) (*models.ImportWishlistResponse, error) {
+	list, err := parseImport(req.Format, data, req.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = strings.TrimSpace(list.title)
+	}
+	if title == "" {
+		title = "Wishlist importée"
+	}
+
+	created, err := s.createFromTemplate(ctx, userID, req.ManagedAccountID, title, false, models.WishlistTemplate{
+		Description: list.description,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := primitive.ObjectIDFromHex(created.ID)
+	if err != nil {
+		return nil, err
+	}
+	wishlist, err := s.getWishlistByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.importRows(ctx, wishlist, userID, list)
+}
+
+// ImportIntoWishlist ajoute à une wishlist les items d'un fichier importé
+func (s *Service) ImportIntoWishlist(ctx context.Context, wishlistID string, userID primitive.ObjectID, req models.ImportWishlistRequest, data []byte) (*models.ImportWishlistResponse, error) {
+	wishlist, err := s.getAuthorizedWishlist(ctx, wishlistID, userID, ActionAddItem)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := parseImport(req.Format, data, req.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	return s.importRows(ctx, wishlist, userID, list)
+}
+
+// importRows ajoute les lignes valides d'un import à une wishlist. Un item est un doublon si son
+// lien produit, ou à défaut son nom, figure déjà dans la wishlist ou plus haut dans le fichier.
+// Les sections sont reprises par leur nom.
+func (s *Service) importRows(ctx context.Context, wishlist *models.Wishlist, userID primitive.ObjectID, list *importedList) (*models.ImportWishlistResponse, error) {
+	existing, err := s.getOrderedItems(ctx, wishlist)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, 2*len(existing))
+	for i := range existing {
+		for _, key := range importKeys(existing[i].Link, existing[i].Name) {
+			seen[key] = true
+		}
+	}
+
+	sectionsByName := make(map[string]primitive.ObjectID, len(wishlist.Sections))
+	for _, section := range wishlist.Sections {
+		sectionsByName[strings.ToLower(section.Name)] = section.ID
+	}
+	newSections := []models.WishlistSection{}
+	sectionFor := func(name string) *primitive.ObjectID {
+		name = strings.TrimSpace(name)
+		if name == "" || len(name) > maxSectionNameLen {
+			return nil
+		}
+		key := strings.ToLower(name)
+		if id, found := sectionsByName[key]; found {
+			return &id
+		}
+		if len(wishlist.Sections)+len(newSections) >= maxSections {
+			return nil
+		}
+		created := models.WishlistSection{ID: primitive.NewObjectID(), Name: name}
+		newSections = append(newSections, created)
+		sectionsByName[key] = created.ID
+		return &created.ID
+	}
+
+	for _, name := range list.sections {
+		sectionFor(name)
+	}
+
+	rows := list.rows
+	if len(rows) > maxImportRows {
+		rows = rows[:maxImportRows]
+	}
+
+	now := time.Now()
+	result := &models.ImportWishlistResponse{Rows: make([]models.ImportRowResult, 0, len(rows))}
+	added := []models.WishItem{}
+	documents := []interface{}{}
+	for i := range rows {
+		row := models.ImportRowResult{Row: i + 1, Name: strings.TrimSpace(rows[i].item.Name)}
+
+		source, err := validateImportedRow(&rows[i])
+		if err != nil {
+			row.Status = ImportStatusInvalid
+			row.Error = err.Error()
+			result.Invalid++
+			result.Rows = append(result.Rows, row)
+			continue
+		}
+
+		keys := importKeys(source.Link, source.Name)
+		if seen[keys[0]] {
+			row.Status = ImportStatusDuplicate
+			result.Duplicates++
+			result.Rows = append(result.Rows, row)
+			continue
+		}
+		for _, key := range keys {
+			seen[key] = true
+		}
+
+		item := newItem(source, wishlist.ID, userID, sectionFor(source.Section), now)
+		added = append(added, item)
+		documents = append(documents, item)
+		row.Status = ImportStatusImported
+		result.Imported++
+		result.Rows = append(result.Rows, row)
+	}
+
+	if len(documents) > 0 {
+		if _, err := s.wishItemCol.InsertMany(ctx, documents); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(added) > 0 || len(newSections) > 0 {
+		addedIDs := make([]primitive.ObjectID, 0, len(added))
+		for _, item := range added {
+			addedIDs = append(addedIDs, item.ID)
+		}
+		_, err = s.wishlistCol.UpdateOne(ctx,
+			bson.M{"_id": wishlist.ID},
+			bson.M{
+				"$push": bson.M{
+					"items":    bson.M{"$each": addedIDs},
+					"sections": bson.M{"$each": newSections},
+				},
+				"$inc": bson.M{"layoutVersion": 1},
+				"$set": bson.M{"updatedAt": now},
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range added {
+			s.recordActivity(ctx, itemActivity(models.ActivityItemAdded, &added[i], userID))
+		}
+	}
+
+	result.Wishlist, err = s.GetWishlist(ctx, wishlist.ID.Hex(), userID)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// importKeys renvoie les clés de dédoublonnage d'un item : d'abord celle qui l'identifie,
+// son lien produit ou à défaut son nom, puis son nom lorsqu'il a un lien
+func importKeys(link string, name string) []string {
+	nameKey := "name:" + strings.ToLower(strings.Join(strings.Fields(name), " "))
+	if normalized := normalizeLink(link); normalized != "" {
+		return []string{"link:" + normalized, nameKey}
+	}
+	return []string{nameKey}
+}